@@ -77,13 +77,13 @@ Examples:
 			}
 
 			// Classes
-			classResp, err := c.ListClasses("")
+			classes, _, err := siteClasses(true)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: could not fetch classes: %v\n", err)
 			} else {
-				registry := convert.BuildRegistryFromClasses(classResp.Classes)
+				registry := convert.BuildRegistryFromClasses(classes.Classes)
 				var classInfos []agent.ClassInfo
-				for _, cls := range classResp.Classes {
+				for _, cls := range classes.Classes {
 					name, _ := cls["name"].(string)
 					id, _ := cls["id"].(string)
 					category := categorizeClass(name)
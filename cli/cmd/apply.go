@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nerveband/agent-to-bricks/internal/aipatch"
+	"github.com/nerveband/agent-to-bricks/internal/batch"
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var applyDryRun bool
+
+// applyResult is the outcome printed by `bricks apply`.
+type applyResult struct {
+	PageID      int      `json:"pageId"`
+	PatchMode   string   `json:"patchMode"`
+	DryRun      bool     `json:"dryRun,omitempty"`
+	SnapshotID  string   `json:"snapshotId,omitempty"`
+	Label       string   `json:"label"`
+	ContentHash string   `json:"contentHash"`
+	Count       int      `json:"count"`
+	Added       []string `json:"added"`
+	Removed     []string `json:"removed"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <page-id> [patch.json]",
+	Short: "Apply a Bricks AI Patch document to a page",
+	Long: `Apply a patch in the canonical Bricks AI Patch format
+(schema/ai-bricks.schema.json) to a page.
+
+The document is validated first; schema errors are reported with JSON Pointer
+paths. Each patchMode becomes one atomic batch against the current page:
+
+  insert   nodes at targetIndex under targetParent (at the end without an index)
+  append   nodes after the last child of targetParent
+  replace  targetElementIds and their children with nodes, in place
+  delete   targetElementIds and their children
+
+A snapshot labelled with meta.model and meta.instruction is taken before the
+write, so 'bricks undo' or 'bricks site rollback' reverts it. Class IDs listed in
+bindings.globalClasses are remapped by name to this site's classes.`,
+	Example: `  bricks apply 1234 patch.json
+  cat patch.json | bricks apply 1234 --dry-run --format json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		if len(args) >= 2 {
+			data, err = os.ReadFile(args[1])
+		} else {
+			data, err = io.ReadAll(os.Stdin)
+		}
+		if err != nil {
+			return clierrors.ValidationError("INVALID_INPUT", fmt.Sprintf("failed to read input: %v", err))
+		}
+
+		patch, err := aipatch.Parse(data)
+		if err != nil {
+			return patchSchemaError(err)
+		}
+
+		current, err := c.GetElements(pageID)
+		if err != nil {
+			return fmt.Errorf("failed to read page: %w", err)
+		}
+
+		result := applyResult{PageID: pageID, PatchMode: patch.PatchMode, DryRun: applyDryRun, Label: patch.SnapshotLabel()}
+		if patch.Bindings != nil && len(patch.Bindings.GlobalClasses) > 0 {
+			classes, err := c.ListClasses("")
+			if err != nil {
+				return fmt.Errorf("failed to load classes for bindings: %w", err)
+			}
+			registry := convert.BuildRegistryFromClasses(classes.Classes)
+			missing := patch.RemapClasses(func(name string) (string, bool) {
+				id, _, ok := registry.Lookup(name)
+				return id, ok
+			})
+			for _, name := range missing {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("class %q is not defined on this site", name))
+			}
+		}
+
+		plan, err := patch.Plan(current.Elements)
+		if err != nil {
+			return clierrors.ValidationError("INVALID_PATCH", err.Error())
+		}
+		result.Added, result.Removed = plan.Added, plan.Removed
+		if result.Added == nil {
+			result.Added = []string{}
+		}
+		if result.Removed == nil {
+			result.Removed = []string{}
+		}
+
+		if applyDryRun {
+			elements, _, err := batch.Apply(current.Elements, plan.Ops)
+			if err != nil {
+				return clierrors.ValidationError("INVALID_PATCH", err.Error())
+			}
+			result.ContentHash = current.ContentHash
+			result.Count = len(elements)
+			return printApplyResult(result)
+		}
+
+		guard, err := guardWrite(cmd, c, pageID, result.Label, false)
+		if err != nil {
+			return err
+		}
+		defer guard.release()
+		result.SnapshotID = guard.SnapshotID()
+
+		resp, err := c.BatchElements(pageID, plan.Ops, current.ContentHash)
+		if err != nil {
+			return fmt.Errorf("failed to apply patch: %w", err)
+		}
+		guard.done(resp.ContentHash)
+		result.ContentHash = resp.ContentHash
+		result.Count = resp.Count
+		return printApplyResult(result)
+	},
+}
+
+// patchSchemaError reports schema violations, as a JSON document when JSON
+// output is on, and returns the error for the exit code.
+func patchSchemaError(err error) error {
+	var errs aipatch.Errors
+	if !stderrors.As(err, &errs) {
+		return clierrors.ValidationError("INVALID_JSON", err.Error())
+	}
+	if output.IsJSON() {
+		output.JSON(map[string]interface{}{"valid": false, "errors": errs})
+	} else {
+		fmt.Fprintln(os.Stderr, "Patch does not match the Bricks AI Patch schema:")
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "  x %s\n", e)
+		}
+	}
+	return clierrors.ValidationError("PATCH_SCHEMA_INVALID", fmt.Sprintf("patch failed schema validation with %d error(s)", len(errs)))
+}
+
+func printApplyResult(r applyResult) error {
+	if output.IsJSON() {
+		return output.JSON(r)
+	}
+	for _, w := range r.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	if r.DryRun {
+		fmt.Printf("Dry run: %s would add %d and remove %d elements, leaving %d (nothing written)\n",
+			r.PatchMode, len(r.Added), len(r.Removed), r.Count)
+		return nil
+	}
+	fmt.Printf("Applied %s to page %d: %d added, %d removed, %d elements (new hash: %s)\n",
+		r.PatchMode, r.PageID, len(r.Added), len(r.Removed), r.Count, r.ContentHash)
+	if r.SnapshotID != "" {
+		fmt.Printf("Snapshot %s (%s) taken before the write\n", r.SnapshotID, r.Label)
+	}
+	return nil
+}
+
+func init() {
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "validate and simulate against the current page without writing")
+	output.AddFormatFlags(applyCmd)
+	rootCmd.AddCommand(applyCmd)
+}
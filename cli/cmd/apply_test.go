@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/config"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+)
+
+func TestApplyReplace_SnapshotsThenBatches(t *testing.T) {
+	var calls []string
+	var label string
+	var ops []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /wp-json/agent-bricks/v1/pages/42/elements":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"elements": []map[string]interface{}{
+					{"id": "sec001", "name": "section", "parent": "0", "children": []interface{}{"hd0001"}, "settings": map[string]interface{}{}},
+					{"id": "hd0001", "name": "heading", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{"text": "Old"}},
+				},
+				"contentHash": "h0",
+				"count":       2,
+			})
+		case "POST /wp-json/agent-bricks/v1/pages/42/snapshots":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			label = body["label"]
+			json.NewEncoder(w).Encode(map[string]interface{}{"snapshotId": "snap_1", "contentHash": "h0"})
+		case "POST /wp-json/agent-bricks/v1/pages/42/elements/batch":
+			if r.Header.Get("If-Match") != "h0" {
+				t.Errorf("expected If-Match h0, got %q", r.Header.Get("If-Match"))
+			}
+			var body struct {
+				Operations []map[string]interface{} `json:"operations"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			ops = body.Operations
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": "h1", "count": 2})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	t.Setenv("HOME", t.TempDir())
+
+	patchPath := filepath.Join(t.TempDir(), "patch.json")
+	os.WriteFile(patchPath, []byte(`{
+		"meta": {"version": "1.0", "source": "ai-transform", "model": "gpt-4o", "instruction": "Make it bold"},
+		"patchMode": "replace",
+		"targetElementIds": ["hd0001"],
+		"nodes": [{"id": "hd0002", "name": "heading", "settings": {"text": "New", "tag": "h1"}}]
+	}`), 0644)
+
+	if err := applyCmd.RunE(applyCmd, []string{"42", patchPath}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	want := []string{
+		"GET /wp-json/agent-bricks/v1/pages/42/elements",
+		"POST /wp-json/agent-bricks/v1/pages/42/snapshots",
+		"POST /wp-json/agent-bricks/v1/pages/42/elements/batch",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("expected calls %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: expected %q, got %q", i, want[i], calls[i])
+		}
+	}
+	if label != "gpt-4o: Make it bold" {
+		t.Errorf("unexpected snapshot label %q", label)
+	}
+	if len(ops) != 2 || ops[0]["op"] != "delete" || ops[1]["op"] != "insert" || ops[1]["parentId"] != "sec001" || ops[1]["index"] != float64(0) {
+		t.Errorf("unexpected operations %v", ops)
+	}
+}
+
+func TestApplyRejectsSchemaErrors(t *testing.T) {
+	cfg = &config.Config{Site: config.SiteConfig{URL: "http://127.0.0.1:1", APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+
+	patchPath := filepath.Join(t.TempDir(), "patch.json")
+	os.WriteFile(patchPath, []byte(`{"meta": {"version": "1.0", "source": "manual"}, "patchMode": "insert", "nodes": [{"id": "X"}]}`), 0644)
+
+	oldStderr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	err := applyCmd.RunE(applyCmd, []string{"42", patchPath})
+	os.Stderr = oldStderr
+
+	var cliErr *clierrors.CLIError
+	if !errors.As(err, &cliErr) || cliErr.Code != "PATCH_SCHEMA_INVALID" {
+		t.Fatalf("expected PATCH_SCHEMA_INVALID before any request, got %v", err)
+	}
+}
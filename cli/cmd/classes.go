@@ -102,6 +102,7 @@ var classesCreateCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to create class: %w", err)
 		}
+		classesChanged()
 
 		if output.IsJSON() {
 			return output.JSON(result)
@@ -170,6 +171,7 @@ var classesDeleteCmd = &cobra.Command{
 		if err := c.DeleteClass(args[0]); err != nil {
 			return fmt.Errorf("failed to delete class: %w", err)
 		}
+		classesChanged()
 
 		fmt.Printf("Deleted class %s\n", args[0])
 		return nil
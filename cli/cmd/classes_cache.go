@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/classcache"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var classesCacheClearAll bool
+
+// classCache returns the per-site class cache shared by convert, agent
+// context and discover.
+func classCache() *classcache.Cache {
+	return classcache.New(filepath.Join(configDir(), "cache", "classes"), cfg.Cache.ClassesTTL)
+}
+
+// siteClasses returns the active site's global classes through the class
+// cache. With revalidate the site is always asked whether the cached copy
+// still holds; otherwise a copy within the TTL is used as is. When the site
+// can't be reached the cached copy is used with a warning.
+func siteClasses(revalidate bool) (*classcache.Entry, classcache.Status, error) {
+	entry, status, err := classCache().Get(context.Background(), newSiteClient(), cfg.Site.URL, revalidate)
+	if err != nil && entry != nil {
+		if status == classcache.StatusStale {
+			fmt.Fprintf(os.Stderr, "Warning: could not refresh classes (%v); using cached copy from %s\n",
+				err, entry.ValidatedAt.Local().Format(time.RFC3339))
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: could not save class cache: %v\n", err)
+		}
+		err = nil
+	}
+	return entry, status, err
+}
+
+// classesChanged drops the active site's cached classes after the CLI
+// creates or deletes one, so the next read sees the change even within
+// the TTL.
+func classesChanged() {
+	if _, err := classCache().Clear(cfg.Site.URL); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not clear class cache: %v\n", err)
+	}
+}
+
+// classCacheStatus is the output of `classes cache status`.
+type classCacheStatus struct {
+	Site        string `json:"site"`
+	Path        string `json:"path"`
+	Cached      bool   `json:"cached"`
+	Count       int    `json:"count"`
+	Hash        string `json:"hash,omitempty"`
+	FetchedAt   string `json:"fetchedAt,omitempty"`
+	ValidatedAt string `json:"validatedAt,omitempty"`
+	Age         string `json:"age,omitempty"`
+	TTL         string `json:"ttl"`
+	Fresh       bool   `json:"fresh"`
+}
+
+var classesCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the local class cache",
+	Long: `Global classes are cached per site in ~/.agent-to-bricks/cache/classes.
+A copy younger than cache.classes_ttl (default 24h) is used as is; an older
+one is revalidated with the site and fetched again only if it changed.
+Creating or deleting classes with the CLI clears the site's cached copy.`,
+}
+
+var classesCacheStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the active site's cached classes",
+	Example: `  bricks classes cache status
+  bricks classes cache status --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+		cache := classCache()
+		ttl := cfg.Cache.ClassesTTL
+		if ttl <= 0 {
+			ttl = classcache.DefaultTTL
+		}
+		status := classCacheStatus{Site: cfg.Site.URL, Path: cache.Path(cfg.Site.URL), TTL: ttl.String()}
+		if entry, err := cache.Load(cfg.Site.URL); err == nil {
+			status.Cached = true
+			status.Count = len(entry.Classes)
+			status.Hash = entry.Hash
+			status.FetchedAt = entry.FetchedAt.Format(time.RFC3339)
+			status.ValidatedAt = entry.ValidatedAt.Format(time.RFC3339)
+			status.Age = entry.Age(time.Now()).Round(time.Second).String()
+			status.Fresh = cache.Fresh(entry)
+		}
+
+		if output.IsJSON() {
+			return output.JSON(status)
+		}
+		if !status.Cached {
+			fmt.Printf("No cached classes for %s\n", status.Site)
+			return nil
+		}
+		state := "fresh"
+		if !status.Fresh {
+			state = "stale, revalidated on next use"
+		}
+		fmt.Printf("Site:       %s\n", status.Site)
+		fmt.Printf("File:       %s\n", status.Path)
+		fmt.Printf("Classes:    %d\n", status.Count)
+		if status.Hash != "" {
+			fmt.Printf("Hash:       %s\n", status.Hash)
+		}
+		fmt.Printf("Fetched:    %s\n", status.FetchedAt)
+		fmt.Printf("Validated:  %s (%s ago)\n", status.ValidatedAt, status.Age)
+		fmt.Printf("TTL:        %s (%s)\n", status.TTL, state)
+		return nil
+	},
+}
+
+var classesCacheRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Fetch the active site's classes and replace the cached copy",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireConfig(); err != nil {
+			return err
+		}
+		entry, err := classCache().Refresh(context.Background(), newSiteClient(), cfg.Site.URL)
+		if err != nil {
+			if entry == nil {
+				return clierrors.APIError("API_ERROR", fmt.Sprintf("failed to fetch classes: %v", err))
+			}
+			return fmt.Errorf("failed to save class cache: %w", err)
+		}
+		fmt.Printf("Cached %d classes for %s\n", len(entry.Classes), cfg.Site.URL)
+		return nil
+	},
+}
+
+var classesCacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove cached classes for the active site, or every site with --all",
+	Example: `  bricks classes cache clear
+  bricks classes cache clear --all`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache := classCache()
+		// The single-file registry cache written by older versions.
+		_ = os.Remove(filepath.Join(configDir(), "class-registry.json"))
+		if classesCacheClearAll {
+			n, err := cache.ClearAll()
+			if err != nil {
+				return fmt.Errorf("failed to clear class cache: %w", err)
+			}
+			fmt.Printf("Cleared cached classes for %d sites\n", n)
+			return nil
+		}
+		if err := requireConfig(); err != nil {
+			return err
+		}
+		removed, err := cache.Clear(cfg.Site.URL)
+		if err != nil {
+			return fmt.Errorf("failed to clear class cache: %w", err)
+		}
+		if !removed {
+			fmt.Printf("No cached classes for %s\n", cfg.Site.URL)
+			return nil
+		}
+		fmt.Printf("Cleared cached classes for %s\n", cfg.Site.URL)
+		return nil
+	},
+}
+
+func init() {
+	output.AddFormatFlags(classesCacheStatusCmd)
+	classesCacheClearCmd.Flags().BoolVar(&classesCacheClearAll, "all", false, "clear every site's cached classes")
+
+	classesCacheCmd.AddCommand(classesCacheStatusCmd)
+	classesCacheCmd.AddCommand(classesCacheRefreshCmd)
+	classesCacheCmd.AddCommand(classesCacheClearCmd)
+	classesCmd.AddCommand(classesCacheCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/classcache"
+	"github.com/nerveband/agent-to-bricks/internal/config"
+)
+
+func TestSiteClasses_SharedCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var full, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"h1"`)
+		if r.Header.Get("If-None-Match") == `"h1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"classes": []interface{}{map[string]interface{}{"id": "c1", "name": "btn"}},
+			"count":   1,
+			"total":   1,
+			"hash":    "h1",
+		})
+	}))
+	defer ts.Close()
+	cfg = &config.Config{Site: config.SiteConfig{URL: ts.URL, APIKey: "test-key"}}
+
+	entry, status, err := siteClasses(true)
+	if err != nil || status != classcache.StatusFetched || len(entry.Classes) != 1 {
+		t.Fatalf("first call: status %q, err %v", status, err)
+	}
+	if _, status, _ = siteClasses(true); status != classcache.StatusRevalidated || notModified != 1 {
+		t.Errorf("expected a 304 revalidation, got %q (%d not modified)", status, notModified)
+	}
+	if _, status, _ = siteClasses(false); status != classcache.StatusFresh || full+notModified != 2 {
+		t.Errorf("expected the fresh copy without a request, got %q", status)
+	}
+
+	convertClassCache = true
+	defer func() { convertClassCache = false }()
+	if reg := loadClassRegistry(); reg == nil || reg.Stats().Total != 1 {
+		t.Errorf("expected convert to use the shared cache, got %v", reg)
+	}
+	if full+notModified != 2 {
+		t.Errorf("--class-cache asked the site within the TTL")
+	}
+
+	if err := classesCacheRefreshCmd.RunE(classesCacheRefreshCmd, nil); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if full != 2 {
+		t.Errorf("refresh should fetch in full, got %d full fetches", full)
+	}
+
+	path := classCache().Path(ts.URL)
+	if err := classesCacheClearCmd.RunE(classesCacheClearCmd, nil); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected %s removed, got %v", path, err)
+	}
+}
+
+func TestSiteClasses_StaleWhenOffline(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	cfg = &config.Config{Site: config.SiteConfig{URL: ts.URL, APIKey: "test-key"}}
+
+	if _, _, err := siteClasses(true); err == nil {
+		t.Fatal("expected an error without a cached copy")
+	}
+	classCache().Save(&classcache.Entry{SiteURL: ts.URL, Classes: []map[string]interface{}{{"name": "btn"}}})
+	entry, status, err := siteClasses(true)
+	if err != nil || status != classcache.StatusStale || len(entry.Classes) != 1 {
+		t.Errorf("expected the stale copy, got %q, %v", status, err)
+	}
+}
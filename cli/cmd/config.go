@@ -2,8 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/nerveband/agent-to-bricks/internal/config"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
 	"github.com/nerveband/agent-to-bricks/internal/wizard"
 	"github.com/spf13/cobra"
 )
@@ -33,6 +39,7 @@ var configInitCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		newCfg = mergeIntoProfiles(path, newCfg.Site)
 
 		if err := newCfg.Save(path); err != nil {
 			return fmt.Errorf("failed to save config: %w", err)
@@ -51,12 +58,10 @@ func configInitSimple(path string) error {
 	fmt.Print("API Key (from WP Admin > Agent to Bricks): ")
 	fmt.Scanln(&apiKey)
 
-	newCfg := &config.Config{
-		Site: config.SiteConfig{
-			URL:    url,
-			APIKey: apiKey,
-		},
-	}
+	newCfg := mergeIntoProfiles(path, config.SiteConfig{
+		URL:    url,
+		APIKey: apiKey,
+	})
 
 	if err := newCfg.Save(path); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
@@ -68,19 +73,20 @@ func configInitSimple(path string) error {
 
 var configSetCmd = &cobra.Command{
 	Use:   "set <key> <value>",
-	Short: "Set a config value (e.g. site.url, site.api_key)",
+	Short: "Set a config value (e.g. site.url, site.api_key, http.timeout)",
 	Example: `  bricks config set site.url https://example.com
-  bricks config set site.api_key atb_xxx`,
+  bricks config set site.api_key atb_xxx
+  bricks config set http.timeout 45s
+  bricks config set http.retries 5
+  bricks config set safety.snapshots false
+  bricks config set cache.classes_ttl 6h`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		path := cfgFile
-		if path == "" {
-			path = config.DefaultPath()
-		}
-
-		c, err := config.Load(path)
-		if err != nil {
-			c = &config.Config{}
+		path, c := loadConfigFile()
+		if siteName != "" {
+			if err := c.UseSite(siteName); err != nil {
+				return clierrors.ConfigError("CONFIG_UNKNOWN_SITE", err.Error(), "Run: bricks config sites list")
+			}
 		}
 
 		key, value := args[0], args[1]
@@ -89,8 +95,32 @@ var configSetCmd = &cobra.Command{
 			c.Site.URL = value
 		case "site.api_key":
 			c.Site.APIKey = value
+		case "http.timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid duration for http.timeout: %s (e.g. 30s, 2m)", value)
+			}
+			c.HTTP.Timeout = d
+		case "http.retries":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid value for http.retries: %s (expected a non-negative integer)", value)
+			}
+			c.HTTP.Retries = &n
+		case "cache.classes_ttl":
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid duration for cache.classes_ttl: %s (e.g. 6h, 30m)", value)
+			}
+			c.Cache.ClassesTTL = d
+		case "safety.snapshots":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid value for safety.snapshots: %s (expected true or false)", value)
+			}
+			c.Safety.Snapshots = &b
 		default:
-			return fmt.Errorf("unknown config key: %s\nValid keys: site.url, site.api_key", key)
+			return fmt.Errorf("unknown config key: %s\nValid keys: site.url, site.api_key, http.timeout, http.retries, cache.classes_ttl, safety.snapshots", key)
 		}
 
 		if err := c.Save(path); err != nil {
@@ -98,11 +128,10 @@ var configSetCmd = &cobra.Command{
 		}
 
 		if key == "site.api_key" {
-			masked := value
-			if len(value) > 8 {
-				masked = value[:8] + "..."
-			}
-			fmt.Printf("Set %s = %s\n", key, masked)
+			value = maskAPIKey(value)
+		}
+		if name := c.ActiveSite(); name != "" {
+			fmt.Printf("Set %s = %s (site %q)\n", key, value, name)
 		} else {
 			fmt.Printf("Set %s = %s\n", key, value)
 		}
@@ -114,21 +143,206 @@ var configListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Show current configuration",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if siteErr != nil {
+			return siteErr
+		}
+		if name := cfg.ActiveSite(); name != "" {
+			fmt.Printf("Site profile:  %s\n", name)
+		}
 		fmt.Printf("Site URL:      %s\n", cfg.Site.URL)
 		if cfg.Site.APIKey != "" {
 			fmt.Printf("API Key:       %s...\n", cfg.Site.APIKey[:min(12, len(cfg.Site.APIKey))])
 		} else {
 			fmt.Println("API Key:       (not set)")
 		}
+		if cfg.HTTP.Timeout > 0 {
+			fmt.Printf("HTTP Timeout:  %s\n", cfg.HTTP.Timeout)
+		}
+		if cfg.HTTP.Retries != nil {
+			fmt.Printf("HTTP Retries:  %d\n", *cfg.HTTP.Retries)
+		}
+		if cfg.Cache.ClassesTTL > 0 {
+			fmt.Printf("Classes TTL:   %s\n", cfg.Cache.ClassesTTL)
+		}
+		fmt.Printf("Snapshots:     %t\n", cfg.SafetySnapshots())
+		return nil
+	},
+}
+
+var configUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set the default site profile",
+	Example: `  bricks config use staging
+  bricks config use prod`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, c := loadConfigFile()
+		if err := c.UseSite(args[0]); err != nil {
+			return clierrors.ConfigError("CONFIG_UNKNOWN_SITE", err.Error(), "Run: bricks config sites list")
+		}
+		c.Default = args[0]
+		if err := c.Save(path); err != nil {
+			return fmt.Errorf("failed to save: %w", err)
+		}
+		fmt.Printf("Default site is now %q (%s)\n", args[0], c.Site.URL)
+		return nil
+	},
+}
+
+var configSitesCmd = &cobra.Command{
+	Use:   "sites",
+	Short: "Manage named site profiles",
+}
+
+var configSitesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List site profiles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		_, c := loadConfigFile()
+
+		type siteEntry struct {
+			Name    string `json:"name"`
+			URL     string `json:"url"`
+			Default bool   `json:"default"`
+		}
+		var sites []siteEntry
+		for _, name := range c.SiteNames() {
+			sites = append(sites, siteEntry{Name: name, URL: c.Sites[name].URL, Default: name == c.Default})
+		}
+		if len(sites) == 0 && c.Site.URL != "" {
+			sites = append(sites, siteEntry{Name: config.LegacyProfile, URL: c.Site.URL, Default: true})
+		}
+
+		if output.IsJSON() {
+			return output.JSON(map[string]interface{}{"sites": sites, "default": c.Default})
+		}
+
+		if len(sites) == 0 {
+			fmt.Println("No sites configured.")
+			fmt.Println("Run: bricks config sites add <name> --url <url> --api-key <key>")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\tNAME\tURL")
+		for _, s := range sites {
+			marker := ""
+			if s.Default {
+				marker = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", marker, s.Name, s.URL)
+		}
+		w.Flush()
 		return nil
 	},
 }
 
+var (
+	sitesAddURL    string
+	sitesAddAPIKey string
+)
+
+var configSitesAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or update a site profile",
+	Example: `  bricks config sites add staging --url https://staging.example.com --api-key atb_xxx
+  bricks config sites add prod --url https://example.com --api-key atb_yyy`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sitesAddURL == "" {
+			return clierrors.ValidationError("MISSING_URL", "--url is required")
+		}
+		path, c := loadConfigFile()
+		site := config.SiteConfig{URL: sitesAddURL, APIKey: sitesAddAPIKey}
+		if existing, ok := c.Sites[args[0]]; ok && site.APIKey == "" {
+			site.APIKey = existing.APIKey
+		}
+		c.AddSite(args[0], site)
+		if err := c.Save(path); err != nil {
+			return fmt.Errorf("failed to save: %w", err)
+		}
+		fmt.Printf("Saved site %q (%s)\n", args[0], site.URL)
+		if c.Default == args[0] {
+			fmt.Println("This is the default site.")
+		}
+		return nil
+	},
+}
+
+var configSitesRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a site profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, c := loadConfigFile()
+		if err := c.RemoveSite(args[0]); err != nil {
+			return clierrors.ConfigError("CONFIG_SITE_REMOVE", err.Error(), "")
+		}
+		if err := c.Save(path); err != nil {
+			return fmt.Errorf("failed to save: %w", err)
+		}
+		fmt.Printf("Removed site %q\n", args[0])
+		return nil
+	},
+}
+
+// mergeIntoProfiles keeps the existing config when re-running init: the new
+// site replaces the --site profile (or the default one) instead of the whole
+// file, and the http, safety and cache settings are left alone. A single-site
+// config just gets the new site, unless --site names a profile to add.
+func mergeIntoProfiles(path string, site config.SiteConfig) *config.Config {
+	existing, err := config.Load(path)
+	if err != nil {
+		return &config.Config{Site: site}
+	}
+	if len(existing.Sites) == 0 && siteName == "" {
+		existing.Site = site
+		return existing
+	}
+	name := siteName
+	if name == "" {
+		name = existing.ActiveSite()
+	}
+	existing.AddSite(name, site)
+	return existing
+}
+
+// loadConfigFile reads the config file for editing. A missing file yields an
+// empty config so commands can create it.
+func loadConfigFile() (string, *config.Config) {
+	path := cfgFile
+	if path == "" {
+		path = config.DefaultPath()
+	}
+	c, err := config.Load(path)
+	if err != nil {
+		c = &config.Config{}
+	}
+	return path, c
+}
+
+func maskAPIKey(key string) string {
+	if len(key) > 8 {
+		return key[:8] + "..."
+	}
+	return key
+}
+
 func init() {
 	configInitCmd.Flags().Bool("no-tui", false, "use simple text prompts instead of TUI")
+	configSitesAddCmd.Flags().StringVar(&sitesAddURL, "url", "", "site URL")
+	configSitesAddCmd.Flags().StringVar(&sitesAddAPIKey, "api-key", "", "API key (from WP Admin > Agent to Bricks)")
+	output.AddFormatFlags(configSitesListCmd)
+
+	configSitesCmd.AddCommand(configSitesListCmd)
+	configSitesCmd.AddCommand(configSitesAddCmd)
+	configSitesCmd.AddCommand(configSitesRemoveCmd)
 
 	configCmd.AddCommand(configInitCmd)
 	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configUseCmd)
+	configCmd.AddCommand(configSitesCmd)
 	rootCmd.AddCommand(configCmd)
 }
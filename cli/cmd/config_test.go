@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/config"
+)
+
+func TestMergeIntoProfiles_KeepsSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`
+site:
+  url: https://old.example.com
+  api_key: atb_old
+http:
+  timeout: 45s
+safety:
+  snapshots: false
+cache:
+  classes_ttl: 6h
+`), 0644)
+
+	oldSite := siteName
+	defer func() { siteName = oldSite }()
+	site := config.SiteConfig{URL: "https://new.example.com", APIKey: "atb_new"}
+
+	siteName = ""
+	c := mergeIntoProfiles(path, site)
+	if c.Site != site || len(c.Sites) != 0 {
+		t.Errorf("expected the single site replaced, got %+v", c)
+	}
+	if c.HTTP.Timeout != 45*time.Second || c.SafetySnapshots() || c.Cache.ClassesTTL != 6*time.Hour {
+		t.Errorf("expected http, safety and cache settings kept, got %+v", c)
+	}
+
+	siteName = "staging"
+	c = mergeIntoProfiles(path, site)
+	if c.Sites[config.LegacyProfile].URL != "https://old.example.com" || c.Sites["staging"] != site {
+		t.Errorf("expected the old site migrated next to the new profile, got %+v", c.Sites)
+	}
+	if c.HTTP.Timeout != 45*time.Second {
+		t.Errorf("expected http settings kept, got %+v", c.HTTP)
+	}
+}
@@ -2,22 +2,41 @@ package cmd
 
 import (
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/nerveband/agent-to-bricks/internal/classcache"
+	"github.com/nerveband/agent-to-bricks/internal/client"
 	"github.com/nerveband/agent-to-bricks/internal/convert"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/media"
+	"github.com/nerveband/agent-to-bricks/internal/tokens"
 	"github.com/spf13/cobra"
 )
 
 var (
 	convertOutput     string
-	convertPush       int
+	convertPush       string
+	convertPushID     int
 	convertStdin      bool
 	convertClassCache bool
 	convertSnapshot   bool
 	convertDryRun     bool
+	convertCSS        []string
+	convertStyles     string
+	convertUnmapped   string
+	convertRichText   int
+	convertKeepIDs    bool
+	convertIDs        string
+	convertAppend     bool
+	convertInfer      bool
+	convertCreate     bool
+	convertClassAllow []string
+	convertClassSkip  []string
 )
 
 func configDir() string {
@@ -39,8 +58,50 @@ When connected to a site, CSS classes are resolved against the global class
 registry: ACSS utility classes and Frames component classes become proper
 _cssGlobalClasses IDs. Unresolved classes go to _cssClasses.
 
+Styles come from style attributes, <style> blocks and --css files. Rules
+match by class, ID, tag, descendant and child selectors and are applied in
+cascade order (specificity, then source order). Rules inside
+@media (max-width: ...) map to the site's Bricks breakpoints (cached with
+--class-cache), falling to the nearest breakpoint with a warning when the
+width matches none. :hover, :focus, :active, ::before and ::after rules map
+to Bricks state settings (_background:hover); other pseudo selectors, and
+declarations a state setting can't hold, go to the element's _cssCustom
+under %root%. With --styles classes, rules
+for a single class (.card { ... }) become new global classes instead of
+element settings; they are created on the site when pushing. Selectors and
+declarations that can't be represented are reported as warnings.
+
+Tags map to Bricks elements through mapping rules: built-ins cover
+figure, picture, svg, iframe (YouTube, Vimeo, Google Maps), audio, table,
+details (accordion-nested), form and custom elements. --mapping adds rules
+from a YAML/JSON file (default ~/.agent-to-bricks/mappings.yaml) that match
+on tag, class or attribute; see "bricks convert mappings".
+
+Text blocks whose content is inline formatting (strong, em, a, br, span,
+...) are kept as rich text with their inner HTML rather than split into
+separate elements. --rich-text-threshold sets how many inline elements that
+takes (default 1; 0 turns it off). Bare text beside block children becomes
+its own text element.
+
+HTML from "bricks convert bricks-to-html" carries data-bricks-* attributes
+that restore element types and settings. With --preserve-ids, its
+data-bricks-id values are reused, so pushing the edited HTML updates the
+same elements instead of regenerating them.
+
+New element IDs are random by default. --ids hash derives each ID from the
+element's position and content, so converting the same HTML again gives
+the same IDs; --ids explicit also takes them from data-bricks-id and id
+attributes. With --push, --append adds the elements after the page's
+existing ones instead of replacing them, and new IDs avoid the IDs already
+on the page.
+
 Use --push to send converted elements directly to a Bricks page.
 Use --stdin to pipe HTML from another tool (e.g., an LLM).`,
+	Example: `  bricks convert html page.html --css styles.css
+  bricks convert html page.html --styles classes --push 1460
+  bricks convert html page.html --mapping rules.yaml
+  bricks convert html page.html --preserve-ids --push 1460
+  bricks convert html section.html --ids hash --push 1460 --append`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Read HTML from file or stdin
@@ -57,113 +118,397 @@ Use --stdin to pipe HTML from another tool (e.g., an LLM).`,
 		if len(htmlData) == 0 {
 			return fmt.Errorf("no HTML input provided")
 		}
+		return runHTMLConversion(cmd, string(htmlData), "", nil)
+	},
+}
 
-		// Build class registry (from cache or API)
-		var registry *convert.ClassRegistry
-		if cfg.Site.URL != "" && cfg.Site.APIKey != "" {
-			cachePath := filepath.Join(configDir(), "class-registry.json")
-
-			if convertClassCache {
-				if reg, loadErr := convert.LoadRegistryFromFile(cachePath); loadErr == nil {
-					registry = reg
-					stats := reg.Stats()
-					fmt.Fprintf(os.Stderr, "Using cached class registry (%d classes: %d ACSS, %d Frames)\n",
-						stats.Total, stats.ACSS, stats.Frames)
-				}
-			}
+// runHTMLConversion converts an HTML document with the convert html flags
+// and delivers the result. pageCSS is stylesheet source applied before the
+// --css files, and warnings are reported ahead of the conversion's own.
+func runHTMLConversion(cmd *cobra.Command, htmlData, pageCSS string, warnings []string) error {
+	mode := convert.StyleMode(convertStyles)
+	if mode != convert.StyleInline && mode != convert.StyleClasses {
+		return clierrors.ValidationError("INVALID_ARGS", fmt.Sprintf("--styles must be inline or classes, got %q", convertStyles))
+	}
+	unmapped := convert.UnmappedMode(convertUnmapped)
+	if unmapped != convert.UnmappedWarn && unmapped != convert.UnmappedCustom {
+		return clierrors.ValidationError("INVALID_ARGS", fmt.Sprintf("--unmapped must be warn or custom, got %q", convertUnmapped))
+	}
+	ids, err := idStrategy(convertIDs)
+	if err != nil {
+		return err
+	}
+	if convertAppend && convertPush == "" {
+		return clierrors.ValidationError("INVALID_ARGS", "--append needs --push")
+	}
+	if err := resolvePushTarget(); err != nil {
+		return err
+	}
+	mapper, err := loadMapper()
+	if err != nil {
+		return err
+	}
+	var css strings.Builder
+	css.WriteString(pageCSS)
+	for _, path := range convertCSS {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read CSS: %w", err)
+		}
+		css.Write(data)
+		css.WriteString("\n")
+	}
 
-			if registry == nil {
-				c := newSiteClient()
-				classResp, apiErr := c.ListClasses("")
-				if apiErr != nil {
-					fmt.Fprintf(os.Stderr, "Warning: could not fetch classes: %v\n", apiErr)
-				} else {
-					registry = convert.BuildRegistryFromClasses(classResp.Classes)
-					stats := registry.Stats()
-					fmt.Fprintf(os.Stderr, "Loaded %d classes (ACSS: %d, Frames: %d)\n",
-						stats.Total, stats.ACSS, stats.Frames)
-					// Save cache for next time
-					os.MkdirAll(configDir(), 0755)
-					_ = registry.SaveToFile(cachePath, cfg.Site.URL)
-				}
-			}
+	registry := loadClassRegistry()
+	var createClasses *convert.ClassFilter
+	if convertCreate {
+		// Without the registry every class would look missing
+		if err := requireConfig(); err != nil {
+			return err
 		}
+		if registry == nil {
+			return clierrors.APIError("API_ERROR", "--create-classes needs the site's global classes, which could not be fetched")
+		}
+		createClasses = &convert.ClassFilter{Allow: convertClassAllow, SkipPrefixes: convertClassSkip}
+	}
 
-		// Convert
-		var elements []map[string]interface{}
-		if registry != nil {
-			elements, err = convert.HTMLToBricksWithRegistry(string(htmlData), registry)
-		} else {
-			elements, err = convert.HTMLToBricks(string(htmlData))
+	// Breakpoints are only needed to map @media rules
+	var breakpoints []convert.Breakpoint
+	if strings.Contains(htmlData, "@media") || strings.Contains(css.String(), "@media") {
+		breakpoints = loadBreakpoints()
+	}
+
+	// Appended elements must not reuse the page's element IDs
+	reserved, appendHash, err := appendReservedIDs()
+	if err != nil {
+		return err
+	}
+
+	// Read the tokens before converting so a bad site fails early
+	var tokenSet *tokens.Set
+	if convertSnapTokens {
+		if tokenSet, err = loadTokenSet(); err != nil {
+			return err
 		}
-		if err != nil {
-			return fmt.Errorf("conversion failed: %w", err)
+	}
+
+	// Convert
+	result, err := convert.Convert(htmlData, convert.Options{
+		Registry:        registry,
+		CSS:             css.String(),
+		StyleMode:       mode,
+		Unmapped:        unmapped,
+		Breakpoints:     breakpoints,
+		Mapping:         mapper,
+		InlineThreshold: inlineThresholdOption(convertRichText),
+		PreserveIDs:     convertKeepIDs,
+		IDs:             ids,
+		ReservedIDs:     reserved,
+		CreateClasses:   createClasses,
+	})
+	if err != nil {
+		return conversionError(err)
+	}
+	result.Warnings = append(warnings, result.Warnings...)
+	var extra map[string]interface{}
+	if tokenSet != nil {
+		extra = map[string]interface{}{"tokens": snapTokens(tokenSet, result)}
+	}
+	// Inferring after snapping lets snapped values match the classes
+	if convertInfer && registry != nil {
+		n := registry.InferClasses(result.Elements)
+		fmt.Fprintf(os.Stderr, "Inferred %d global classes from element styles\n", n)
+	}
+	return deliverConversion(cmd, result, appendHash, extra)
+}
+
+// conversionError reports a Convert failure, as a validation error when
+// the input asked for an element ID Bricks can't use.
+func conversionError(err error) error {
+	var idErr *convert.InvalidIDError
+	if stderrors.As(err, &idErr) {
+		cliErr := clierrors.ValidationError("INVALID_ELEMENT_ID", idErr.Error())
+		cliErr.Hint = "Use 6 to 10 lower-case letters and digits, or drop --ids explicit to generate IDs."
+		return cliErr
+	}
+	return fmt.Errorf("conversion failed: %w", err)
+}
+
+// appendReservedIDs reads the --push page's element IDs when --append is
+// set, so appended elements don't reuse them, with the page's content
+// hash for the push.
+func appendReservedIDs() ([]string, string, error) {
+	if !convertAppend {
+		return nil, "", nil
+	}
+	if err := requireConfig(); err != nil {
+		return nil, "", err
+	}
+	return pageElementIDs(newSiteClient(), convertPushID)
+}
+
+// resolvePushTarget resolves --push, a page ID, slug, title, path or URL,
+// into convertPushID.
+func resolvePushTarget() error {
+	convertPushID = 0
+	if convertPush == "" {
+		return nil
+	}
+	if err := requireConfig(); err != nil {
+		return err
+	}
+	id, err := resolvePage(newSiteClient(), convertPush)
+	if err != nil {
+		return err
+	}
+	convertPushID = id
+	return nil
+}
+
+// deliverConversion reports a conversion, localizes its media with
+// --localize-media, pushes it with --push (creating
+// its generated classes first, as --create-classes also does without
+// --push) and writes the JSON output, adding extra's keys to it.
+func deliverConversion(cmd *cobra.Command, result *convert.Result, appendHash string, extra map[string]interface{}) error {
+	elements := result.Elements
+	var manifest *media.Manifest
+	if convertLocalize {
+		var err error
+		if manifest, err = localizeMedia(result); err != nil {
+			return err
 		}
+	}
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
 
-		fmt.Fprintf(os.Stderr, "Converted %d elements\n", len(elements))
+	fmt.Fprintf(os.Stderr, "Converted %d elements\n", len(elements))
+	if len(result.Classes) > 0 {
+		fmt.Fprintf(os.Stderr, "Generated %d global classes from stylesheet rules\n", len(result.Classes))
+	}
 
-		// Push to page
-		if convertPush > 0 && !convertDryRun {
+	// --create-classes creates the generated classes without --push too;
+	// with it, they're created after the snapshot, just before pushing
+	if convertCreate && len(result.Classes) > 0 {
+		if convertDryRun {
+			for _, cls := range result.Classes {
+				settings, _ := cls["settings"].(map[string]interface{})
+				fmt.Fprintf(os.Stderr, "[dry-run] Would create class %s (%d settings)\n", cls["name"], len(settings))
+			}
+		} else if convertPushID == 0 {
 			if err := requireConfig(); err != nil {
 				return err
 			}
-			c := newSiteClient()
-
-			// Optional snapshot before pushing
-			if convertSnapshot {
-				snap, snapErr := c.CreateSnapshot(convertPush, "Pre-convert backup")
-				if snapErr != nil {
-					fmt.Fprintf(os.Stderr, "Warning: snapshot failed: %v\n", snapErr)
-				} else {
-					fmt.Fprintf(os.Stderr, "Snapshot created: %s\n", snap.SnapshotID)
-				}
+			if err := createGeneratedClasses(newSiteClient(), result); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Push to page
+	if convertPushID > 0 && !convertDryRun {
+		if err := requireConfig(); err != nil {
+			return err
+		}
+		c := newSiteClient()
+
+		// Snapshot before pushing: always with --snapshot, otherwise
+		// unless safety snapshots are off
+		guard, err := guardWrite(cmd, c, convertPushID, "Pre-convert backup", convertSnapshot)
+		if err != nil {
+			return err
+		}
+		defer guard.release()
+		if id := guard.SnapshotID(); id != "" {
+			fmt.Fprintf(os.Stderr, "Snapshot created: %s\n", id)
+		}
+
+		// Generated classes must exist before elements reference them
+		if len(result.Classes) > 0 {
+			if err := createGeneratedClasses(c, result); err != nil {
+				return err
 			}
+		}
 
+		var pushed *client.MutationResponse
+		var pushErr error
+		if convertAppend {
+			// The hash read with the page's IDs guards against the page
+			// changing in between
+			pushed, pushErr = c.AppendElements(convertPushID, elements, appendHash)
+		} else {
 			// Fetch current contentHash for If-Match header
-			existing, getErr := c.GetElements(convertPush)
+			existing, getErr := c.GetElements(convertPushID)
 			ifMatch := ""
 			if getErr == nil {
 				ifMatch = existing.ContentHash
 			}
-
-			result, pushErr := c.ReplaceElements(convertPush, elements, ifMatch)
-			if pushErr != nil {
-				return fmt.Errorf("push failed: %w", pushErr)
-			}
+			pushed, pushErr = c.ReplaceElements(convertPushID, elements, ifMatch)
+		}
+		if pushErr != nil {
+			return fmt.Errorf("push failed: %w", pushErr)
+		}
+		guard.done(pushed.ContentHash)
+		if convertAppend {
+			fmt.Fprintf(os.Stderr, "Appended %d elements to page %d (hash: %s)\n",
+				len(elements), convertPushID, pushed.ContentHash)
+		} else {
 			fmt.Fprintf(os.Stderr, "Pushed %d elements to page %d (hash: %s)\n",
-				result.Count, convertPush, result.ContentHash)
+				pushed.Count, convertPushID, pushed.ContentHash)
 		}
+	}
 
-		// Output JSON
-		output := map[string]interface{}{
-			"elements": elements,
-			"count":    len(elements),
-		}
-		jsonData, err := json.MarshalIndent(output, "", "  ")
-		if err != nil {
+	// Output JSON
+	output := map[string]interface{}{
+		"elements": elements,
+		"count":    len(elements),
+	}
+	if len(result.Classes) > 0 {
+		output["classes"] = result.Classes
+	}
+	if len(result.Warnings) > 0 {
+		output["warnings"] = result.Warnings
+	}
+	if manifest != nil {
+		output["media"] = manifest.Assets
+	}
+	for k, v := range extra {
+		output[k] = v
+	}
+	jsonData, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if convertOutput != "" {
+		if err := os.WriteFile(convertOutput, jsonData, 0644); err != nil {
 			return err
 		}
+		fmt.Fprintf(os.Stderr, "Written to %s\n", convertOutput)
+	} else if convertPushID == 0 || convertDryRun {
+		fmt.Println(string(jsonData))
+	}
 
-		if convertOutput != "" {
-			if err := os.WriteFile(convertOutput, jsonData, 0644); err != nil {
-				return err
-			}
-			fmt.Fprintf(os.Stderr, "Written to %s\n", convertOutput)
-		} else if convertPush == 0 || convertDryRun {
-			fmt.Println(string(jsonData))
-		}
+	return nil
+}
 
+// inlineThresholdOption maps --rich-text-threshold onto
+// Options.InlineThreshold, where 0 means the default and negative means off.
+func inlineThresholdOption(n int) int {
+	if n <= 0 {
+		return -1
+	}
+	return n
+}
+
+// loadClassRegistry returns the site's global class registry from the class
+// cache: with --class-cache a copy within the TTL is used without asking the
+// site, otherwise the cached copy is revalidated first.
+// It is nil without a configured site or when the classes can't be fetched.
+func loadClassRegistry() *convert.ClassRegistry {
+	if cfg.Site.URL == "" || cfg.Site.APIKey == "" {
 		return nil
-	},
+	}
+	entry, status, err := siteClasses(!convertClassCache)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not fetch classes: %v\n", err)
+		return nil
+	}
+	registry := convert.BuildRegistryFromClasses(entry.Classes)
+	stats := registry.Stats()
+	if status == classcache.StatusFresh {
+		fmt.Fprintf(os.Stderr, "Using cached class registry (%d classes: %d ACSS, %d Frames)\n",
+			stats.Total, stats.ACSS, stats.Frames)
+	} else {
+		fmt.Fprintf(os.Stderr, "Loaded %d classes (ACSS: %d, Frames: %d)\n",
+			stats.Total, stats.ACSS, stats.Frames)
+	}
+	return registry
+}
+
+// loadBreakpoints returns the site's breakpoints for @media mapping: the
+// cached copy with --class-cache, otherwise the live list (refreshing the
+// cache), falling back to the cache and then the Bricks defaults.
+func loadBreakpoints() []convert.Breakpoint {
+	if cfg.Site.URL == "" || cfg.Site.APIKey == "" {
+		return nil
+	}
+	cachePath := filepath.Join(configDir(), "breakpoints.json")
+	if convertClassCache {
+		if bps, err := convert.LoadBreakpoints(cachePath, cfg.Site.URL); err == nil {
+			return bps
+		}
+	}
+	info, err := newSiteClient().GetSiteInfo()
+	if err != nil {
+		if bps, cacheErr := convert.LoadBreakpoints(cachePath, cfg.Site.URL); cacheErr == nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not fetch breakpoints (%v); using cached copy\n", err)
+			return bps
+		}
+		fmt.Fprintf(os.Stderr, "Warning: could not fetch breakpoints (%v); using Bricks defaults\n", err)
+		return nil
+	}
+	bps := convert.BreakpointsFromSite(info.Breakpoints)
+	os.MkdirAll(configDir(), 0755)
+	_ = convert.SaveBreakpoints(cachePath, cfg.Site.URL, bps)
+	return bps
+}
+
+// createGeneratedClasses creates the global classes a conversion generated
+// and points elements at the IDs the site assigned them.
+func createGeneratedClasses(c *client.Client, result *convert.Result) error {
+	if len(result.Classes) > 0 {
+		defer classesChanged()
+	}
+	ids := make(map[string]string, len(result.Classes))
+	for _, cls := range result.Classes {
+		name, _ := cls["name"].(string)
+		settings, _ := cls["settings"].(map[string]interface{})
+		created, err := c.CreateClass(name, settings)
+		if err != nil {
+			return fmt.Errorf("failed to create class %q: %w", name, err)
+		}
+		localID, _ := cls["id"].(string)
+		if id, _ := created["id"].(string); id != "" {
+			ids[localID] = id
+			cls["id"] = id
+		}
+		fmt.Fprintf(os.Stderr, "Created class %s (%s)\n", name, cls["id"])
+	}
+	convert.RemapClassIDs(result.Elements, ids)
+	return nil
+}
+
+// addHTMLConversionFlags registers the flags runHTMLConversion reads.
+func addHTMLConversionFlags(c *cobra.Command) {
+	c.Flags().StringVarP(&convertOutput, "output", "o", "", "output file path")
+	c.Flags().StringVar(&convertPush, "push", "", "push to a page (ID, slug, title, path or URL) after converting")
+	c.Flags().BoolVar(&convertClassCache, "class-cache", false, "use cached class registry")
+	c.Flags().BoolVar(&convertSnapshot, "snapshot", false, "create snapshot before pushing")
+	c.Flags().BoolVar(&convertDryRun, "dry-run", false, "show result without pushing")
+	c.Flags().StringArrayVar(&convertCSS, "css", nil, "stylesheet to apply (repeatable)")
+	c.Flags().StringVar(&convertMapping, "mapping", "", "YAML/JSON file of tag-to-element mapping rules")
+	c.Flags().IntVar(&convertRichText, "rich-text-threshold", convert.DefaultInlineThreshold, "inline formatting elements a text block needs to be kept as rich text (0 disables)")
+	c.Flags().StringVar(&convertIDs, "ids", "random", "how new element IDs are made: random, hash (stable across runs) or explicit (from data-bricks-id/id attributes)")
+	c.Flags().BoolVar(&convertAppend, "append", false, "with --push, append to the page's elements instead of replacing them")
+	c.Flags().BoolVar(&convertLocalize, "localize-media", false, "upload remote images and videos to the media library and reference the attachments")
+	c.Flags().StringVar(&convertMediaManifest, "media-manifest", "", "with --localize-media, write the old-to-new media URL manifest to this file")
+	c.Flags().IntVar(&convertMediaConcurrency, "media-concurrency", media.DefaultConcurrency, "with --localize-media, files to download and upload at once")
+	c.Flags().StringVar(&convertStyles, "styles", "inline", "where stylesheet rules go: inline (element settings) or classes (new global classes for single-class rules)")
+	c.Flags().BoolVar(&convertCreate, "create-classes", false, "create global classes for class names the site doesn't have, with settings from their stylesheet rules")
+	c.Flags().StringArrayVar(&convertClassAllow, "class-allow", nil, "with --create-classes, only create classes matching this glob pattern (repeatable)")
+	c.Flags().StringArrayVar(&convertClassSkip, "class-skip-prefix", convert.DefaultSkipPrefixes, "with --create-classes, never create classes with this prefix (repeatable)")
+	c.Flags().BoolVar(&convertInfer, "infer-classes", false, "attach global classes whose settings match an element's styles, dropping the duplicated settings")
+	c.Flags().BoolVar(&convertSnapTokens, "snap-tokens", false, "replace raw spacing, font sizes and colors with the nearest site variable")
+	c.Flags().Float64Var(&convertSnapLengthTol, "snap-length-tolerance", tokens.DefaultLengthTolerance, "with --snap-tokens, largest relative difference a length may have from a variable (0.1 = 10%, 0 = exact)")
+	c.Flags().Float64Var(&convertSnapColorTol, "snap-color-tolerance", tokens.DefaultColorTolerance, "with --snap-tokens, largest CIEDE2000 difference a color may have from a variable")
+	c.Flags().StringVar(&convertUnmapped, "unmapped", "warn", "declarations with no Bricks setting: warn (drop them) or custom (keep them as custom CSS)")
 }
 
 func init() {
-	convertHTMLCmd.Flags().StringVarP(&convertOutput, "output", "o", "", "output file path")
-	convertHTMLCmd.Flags().IntVar(&convertPush, "push", 0, "push to page ID after converting")
+	addHTMLConversionFlags(convertHTMLCmd)
 	convertHTMLCmd.Flags().BoolVar(&convertStdin, "stdin", false, "read HTML from stdin")
-	convertHTMLCmd.Flags().BoolVar(&convertClassCache, "class-cache", false, "use cached class registry")
-	convertHTMLCmd.Flags().BoolVar(&convertSnapshot, "snapshot", false, "create snapshot before pushing")
-	convertHTMLCmd.Flags().BoolVar(&convertDryRun, "dry-run", false, "show result without pushing")
+	convertHTMLCmd.Flags().BoolVar(&convertKeepIDs, "preserve-ids", false, "reuse data-bricks-id attributes as element IDs")
 
 	convertCmd.AddCommand(convertHTMLCmd)
 	rootCmd.AddCommand(convertCmd)
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/webpage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	urlSelector string
+	urlNoCSS    bool
+)
+
+var convertGutenbergCmd = &cobra.Command{
+	Use:   "gutenberg [file.html]",
+	Short: "Convert WordPress block markup to Bricks element JSON",
+	Long: `Convert Gutenberg block markup (post_content with <!-- wp:... --> comments)
+to Bricks elements, from a file or stdin.
+
+Columns become a row of blocks (stacking on mobile unless the columns
+block says otherwise) with the columns' widths, groups and covers become
+sections (with the cover image as background) or nested blocks, buttons
+become a row of button elements, images keep their URL (--localize-media
+attaches them on the target site) and heading alignment becomes
+typography. Other blocks convert from their saved HTML. Top-level blocks
+outside groups and covers are wrapped in sections, and WordPress's
+generated classes (wp-block-*, has-*, is-*) are dropped; classes added in
+the editor are kept.
+
+Dynamic blocks (latest posts, query loops, ...) have no saved HTML and are
+skipped with a warning. The result goes through the same pipeline as
+"bricks convert html", with the same flags.`,
+	Example: `  bricks convert gutenberg post-content.html -o post.json
+  wp post get 42 --field=post_content | bricks convert gutenberg --push 1460`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var data []byte
+		var err error
+		if len(args) == 0 {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		if len(data) == 0 {
+			return fmt.Errorf("no block markup provided")
+		}
+		htmlData, warnings := convert.GutenbergToHTML(string(data))
+		return runHTMLConversion(cmd, htmlData, "", warnings)
+	},
+}
+
+var convertURLCmd = &cobra.Command{
+	Use:   "url <url>",
+	Short: "Fetch a live page and convert it to Bricks element JSON",
+	Long: `Fetch a page over HTTP and convert its main content to Bricks elements.
+
+The content is the element matching --selector, or else the page's <main>
+(or role="main"), a single <article>, or the <body> without its header,
+footer, nav and aside. Scripts and other non-content elements are dropped,
+and image, link and background URLs are made absolute so they keep
+working on the new site.
+
+The page's linked stylesheets and <style> blocks are downloaded and applied
+as with --css, so class, tag and @media rules carry over (--no-css skips
+them; @import rules are not followed). The result goes through the same
+pipeline as "bricks convert html", with the same flags.`,
+	Example: `  bricks convert url https://example.com/about -o about.json
+  bricks convert url https://example.com/pricing --selector ".pricing" --push 1460
+  bricks convert url https://example.com --styles classes --dry-run --push 1460`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		page, err := webpage.Fetch(context.Background(), args[0], webpage.Options{
+			Client:  &http.Client{Timeout: requestTimeout()},
+			SkipCSS: urlNoCSS,
+		})
+		if errors.Is(err, webpage.ErrInvalidURL) {
+			return clierrors.ValidationError("INVALID_ARGS", err.Error())
+		}
+		if err != nil {
+			return clierrors.APIError("FETCH_FAILED", err.Error())
+		}
+		content, err := convert.MainContent(page.HTML, urlSelector)
+		if err != nil {
+			return clierrors.ValidationError("INVALID_ARGS", fmt.Sprintf("--selector: %v", err))
+		}
+		fmt.Fprintf(os.Stderr, "Fetched %s (%d stylesheets)\n", page.URL, len(page.Stylesheets))
+		return runHTMLConversion(cmd, content, page.CSS, page.Warnings)
+	},
+}
+
+func init() {
+	addHTMLConversionFlags(convertGutenbergCmd)
+	addHTMLConversionFlags(convertURLCmd)
+	convertURLCmd.Flags().StringVar(&urlSelector, "selector", "", "CSS selector of the content to convert (default: main, article or body)")
+	convertURLCmd.Flags().BoolVar(&urlNoCSS, "no-css", false, "don't download the page's stylesheets")
+	convertCmd.AddCommand(convertGutenbergCmd)
+	convertCmd.AddCommand(convertURLCmd)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var convertMapping string
+
+// loadMapper builds the converter's tag-to-element mapper from the --mapping
+// file, or ~/.agent-to-bricks/mappings.yaml when it exists.
+func loadMapper() (*convert.Mapper, error) {
+	path := convertMapping
+	if path == "" {
+		def := filepath.Join(configDir(), "mappings.yaml")
+		if _, err := os.Stat(def); err == nil {
+			path = def
+		}
+	}
+	var rules []convert.MappingRule
+	if path != "" {
+		var err error
+		if rules, err = convert.LoadMappingRules(path); err != nil {
+			return nil, clierrors.ValidationError("INVALID_MAPPING", fmt.Sprintf("invalid mapping rules: %v", err))
+		}
+	}
+	mapper, err := convert.NewMapper(rules)
+	if err != nil {
+		return nil, clierrors.ValidationError("INVALID_MAPPING", err.Error())
+	}
+	return mapper, nil
+}
+
+var convertMappingsCmd = &cobra.Command{
+	Use:   "mappings",
+	Short: "List the active tag-to-element mapping rules",
+	Long: `List the rules convert html uses to turn HTML elements into Bricks
+elements, in match order: user rules from --mapping (or
+~/.agent-to-bricks/mappings.yaml) first, then the built-in rules. The first
+matching rule wins.`,
+	Example: `  bricks convert mappings
+  bricks convert mappings --mapping rules.yaml --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		mapper, err := loadMapper()
+		if err != nil {
+			return err
+		}
+		rules := mapper.Rules()
+
+		if output.IsJSON() {
+			out := make([]map[string]interface{}, 0, len(rules))
+			for _, r := range rules {
+				out = append(out, map[string]interface{}{
+					"name":     r.Name,
+					"match":    r.Match(),
+					"element":  r.Element,
+					"settings": r.Settings,
+					"children": r.Children,
+					"source":   r.Source,
+				})
+			}
+			return output.JSON(map[string]interface{}{"rules": out})
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MATCH\tELEMENT\tNAME\tSOURCE")
+		for _, r := range rules {
+			name := r.Name
+			if name == "" {
+				name = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Match(), r.Element, name, r.Source)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	convertMappingsCmd.Flags().StringVar(&convertMapping, "mapping", "", "YAML/JSON file of mapping rules")
+	output.AddFormatFlags(convertMappingsCmd)
+	convertCmd.AddCommand(convertMappingsCmd)
+}
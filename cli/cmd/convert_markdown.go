@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/media"
+	"github.com/nerveband/agent-to-bricks/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+var convertMarkdownCmd = &cobra.Command{
+	Use:   "markdown [file.md]",
+	Short: "Convert a Markdown document to Bricks element JSON",
+	Long: `Convert a CommonMark document (with GitHub tables) to Bricks elements.
+
+Content is grouped into a section and container per h2; anything before the
+first h2 gets its own. Headings, paragraphs and images map to their
+elements (an image's title becomes its caption), fenced code blocks to code
+elements with their language, and lists, blockquotes and tables to rich
+text. Inline formatting stays as rich text as in "bricks convert html".
+
+YAML front matter between --- lines sets up the page:
+
+  title              h1 for the page when the document has none
+  template           local template placed before the content
+  classes            classes for every section
+  container_classes  classes for every container
+
+Classes resolve against the site's class registry like "bricks convert
+html". --push, --append, --snapshot, --dry-run and --ids work as they do
+there.`,
+	Example: `  bricks convert markdown post.md -o post.json
+  bricks convert markdown post.md --push 1460 --snapshot
+  cat post.md | bricks convert markdown --push 1460 --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var src []byte
+		var err error
+		if convertStdin || len(args) == 0 {
+			src, err = io.ReadAll(os.Stdin)
+		} else {
+			src, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		if len(src) == 0 {
+			return fmt.Errorf("no Markdown input provided")
+		}
+		ids, err := idStrategy(convertIDs)
+		if err != nil {
+			return err
+		}
+		if convertAppend && convertPush == "" {
+			return clierrors.ValidationError("INVALID_ARGS", "--append needs --push")
+		}
+		if err := resolvePushTarget(); err != nil {
+			return err
+		}
+
+		doc, err := convert.MarkdownToHTML(src)
+		if err != nil {
+			return clierrors.ValidationError("INVALID_FRONT_MATTER", err.Error())
+		}
+
+		// Appended elements must not reuse the page's element IDs
+		reserved, appendHash, err := appendReservedIDs()
+		if err != nil {
+			return err
+		}
+
+		// The front matter's template goes first; the content must not
+		// reuse its IDs either
+		var composed *templates.ComposeResult
+		if name := doc.FrontMatter.Template; name != "" {
+			cat, err := loadCatalog()
+			if err != nil {
+				return err
+			}
+			tmpl := cat.Get(name)
+			if tmpl == nil {
+				return clierrors.ValidationError("INVALID_FRONT_MATTER", fmt.Sprintf("template %q not found", name))
+			}
+			composed, err = templates.ComposeWithOptions([]*templates.Template{tmpl}, templates.ComposeOptions{
+				IDs:         ids,
+				ReservedIDs: reserved,
+			})
+			if err != nil {
+				return err
+			}
+			for _, el := range composed.Elements {
+				if id, _ := el["id"].(string); id != "" {
+					reserved = append(reserved, id)
+				}
+			}
+		}
+
+		result, err := convert.Convert(doc.HTML, convert.Options{
+			Registry:        loadClassRegistry(),
+			InlineThreshold: inlineThresholdOption(convertRichText),
+			IDs:             ids,
+			ReservedIDs:     reserved,
+		})
+		if err != nil {
+			return conversionError(err)
+		}
+
+		var extra map[string]interface{}
+		if composed != nil {
+			result.Elements = append(composed.Elements, result.Elements...)
+			if len(composed.GlobalClasses) > 0 {
+				extra = map[string]interface{}{"globalClasses": composed.GlobalClasses}
+			}
+		}
+		return deliverConversion(cmd, result, appendHash, extra)
+	},
+}
+
+func init() {
+	convertMarkdownCmd.Flags().StringVarP(&convertOutput, "output", "o", "", "output file path")
+	convertMarkdownCmd.Flags().StringVar(&convertPush, "push", "", "push to a page (ID, slug, title, path or URL) after converting")
+	convertMarkdownCmd.Flags().BoolVar(&convertStdin, "stdin", false, "read Markdown from stdin")
+	convertMarkdownCmd.Flags().BoolVar(&convertClassCache, "class-cache", false, "use cached class registry")
+	convertMarkdownCmd.Flags().BoolVar(&convertSnapshot, "snapshot", false, "create snapshot before pushing")
+	convertMarkdownCmd.Flags().BoolVar(&convertDryRun, "dry-run", false, "show result without pushing")
+	convertMarkdownCmd.Flags().IntVar(&convertRichText, "rich-text-threshold", convert.DefaultInlineThreshold, "inline formatting elements a text block needs to be kept as rich text (0 disables)")
+	convertMarkdownCmd.Flags().StringVar(&convertIDs, "ids", "random", "how new element IDs are made: random, hash (stable across runs) or explicit")
+	convertMarkdownCmd.Flags().BoolVar(&convertAppend, "append", false, "with --push, append to the page's elements instead of replacing them")
+	convertMarkdownCmd.Flags().BoolVar(&convertLocalize, "localize-media", false, "upload remote images and videos to the media library and reference the attachments")
+	convertMarkdownCmd.Flags().StringVar(&convertMediaManifest, "media-manifest", "", "with --localize-media, write the old-to-new media URL manifest to this file")
+	convertMarkdownCmd.Flags().IntVar(&convertMediaConcurrency, "media-concurrency", media.DefaultConcurrency, "with --localize-media, files to download and upload at once")
+	convertCmd.AddCommand(convertMarkdownCmd)
+}
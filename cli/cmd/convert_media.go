@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+	"github.com/nerveband/agent-to-bricks/internal/media"
+)
+
+var (
+	convertLocalize         bool
+	convertMediaManifest    string
+	convertMediaConcurrency int
+)
+
+// localizeMedia uploads the remote images and videos in a conversion with
+// --localize-media and rewrites them to attachments, returning the
+// manifest. With --dry-run nothing is uploaded.
+func localizeMedia(result *convert.Result) (*media.Manifest, error) {
+	if err := requireConfig(); err != nil {
+		return nil, err
+	}
+	manifest := media.Localize(context.Background(), newSiteClient(), result.Elements, media.Options{
+		Concurrency: convertMediaConcurrency,
+		DryRun:      convertDryRun,
+		SiteURL:     cfg.Site.URL,
+	})
+	for _, a := range manifest.Assets {
+		if a.Status == media.StatusFailed {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("media %s not localized: %s", a.Source, a.Error))
+		}
+	}
+	counts := manifest.Counts()
+	if convertDryRun {
+		fmt.Fprintf(os.Stderr, "Media: %d to upload, %d already in the library, %d failed\n",
+			counts[media.StatusPlanned], counts[media.StatusExisting], counts[media.StatusFailed])
+	} else {
+		fmt.Fprintf(os.Stderr, "Media: %d uploaded, %d already in the library, %d failed\n",
+			counts[media.StatusUploaded], counts[media.StatusExisting], counts[media.StatusFailed])
+	}
+
+	if convertMediaManifest != "" {
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(convertMediaManifest, data, 0644); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Media manifest written to %s\n", convertMediaManifest)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderOutput string
+	renderStyles string
+)
+
+var convertBricksToHTMLCmd = &cobra.Command{
+	Use:   "bricks-to-html [file.json|page]",
+	Short: "Render Bricks elements as semantic HTML",
+	Long: `Render a Bricks element tree as clean, semantic HTML, from a JSON file
+({"elements": [...]} or a bare array), a page, or stdin.
+
+Sections, headings, text, links, buttons, images, video, maps and lists get
+their HTML tags. Global class IDs are resolved back to class names through
+the site's class registry (cached with --class-cache). Style settings become
+inline styles, or with --styles block, rules in a <style> block; breakpoint
+and state settings (_padding:mobile_portrait, _background:hover) and
+_cssCustom always go to the <style> block.
+
+Every element carries its ID in data-bricks-id. Elements whose tag alone
+would convert to something else are marked with data-bricks-element, and
+settings HTML can't express are kept as JSON in data-bricks-settings. Edit
+the HTML and convert it back with "bricks convert html --preserve-ids" to
+update the same elements.`,
+	Example: `  bricks convert bricks-to-html 1460 -o page.html
+  bricks convert html page.html --preserve-ids --push 1460
+  bricks site pull 1460 -o page.json && bricks convert bricks-to-html page.json --styles block`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if renderStyles != "inline" && renderStyles != "block" {
+			return clierrors.ValidationError("INVALID_ARGS", fmt.Sprintf("--styles must be inline or block, got %q", renderStyles))
+		}
+
+		var elements []map[string]interface{}
+		var err error
+		switch {
+		case len(args) == 0:
+			data, readErr := io.ReadAll(os.Stdin)
+			if readErr != nil {
+				return fmt.Errorf("failed to read input: %w", readErr)
+			}
+			elements, err = parseElements(data, "stdin")
+		case fileExists(args[0]):
+			elements, err = readElementsFile(args[0])
+		default:
+			if err := requireConfig(); err != nil {
+				return err
+			}
+			c := newSiteClient()
+			pageID, resolveErr := resolvePage(c, args[0])
+			if resolveErr != nil {
+				return resolveErr
+			}
+			resp, getErr := c.GetElements(pageID)
+			if getErr != nil {
+				return fmt.Errorf("failed to read page: %w", getErr)
+			}
+			elements = resp.Elements
+		}
+		if err != nil {
+			return err
+		}
+
+		// Breakpoints are only needed for breakpoint-suffixed settings
+		var breakpoints []convert.Breakpoint
+		if hasSuffixedSettings(elements) {
+			breakpoints = loadBreakpoints()
+		}
+
+		result := convert.RenderHTML(elements, convert.RenderOptions{
+			Registry:    loadClassRegistry(),
+			StyleBlock:  renderStyles == "block",
+			Breakpoints: breakpoints,
+		})
+		for _, w := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+
+		if renderOutput != "" {
+			if err := os.WriteFile(renderOutput, []byte(result.HTML), 0644); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Rendered %d elements to %s\n", len(elements), renderOutput)
+			return nil
+		}
+		fmt.Print(result.HTML)
+		return nil
+	},
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// hasSuffixedSettings reports whether any element has a breakpoint or state
+// setting key such as "_padding:tablet_portrait".
+func hasSuffixedSettings(elements []map[string]interface{}) bool {
+	for _, el := range elements {
+		settings, _ := el["settings"].(map[string]interface{})
+		for k := range settings {
+			if strings.Contains(k, ":") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func init() {
+	convertBricksToHTMLCmd.Flags().StringVarP(&renderOutput, "output", "o", "", "output file path")
+	convertBricksToHTMLCmd.Flags().StringVar(&renderStyles, "styles", "inline", "where style settings go: inline (style attributes) or block (a <style> block)")
+	convertBricksToHTMLCmd.Flags().BoolVar(&convertClassCache, "class-cache", false, "use cached class registry")
+	convertCmd.AddCommand(convertBricksToHTMLCmd)
+}
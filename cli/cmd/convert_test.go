@@ -8,11 +8,14 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nerveband/agent-to-bricks/internal/config"
-	"github.com/nerveband/agent-to-bricks/internal/convert"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
 )
 
 // --- Unit tests for configDir() ---
@@ -39,6 +42,18 @@ func TestConvertHTMLCmd_FlagsRegistered(t *testing.T) {
 		{"class-cache", ""},
 		{"snapshot", ""},
 		{"dry-run", ""},
+		{"css", ""},
+		{"styles", ""},
+		{"mapping", ""},
+		{"rich-text-threshold", ""},
+		{"preserve-ids", ""},
+		{"ids", ""},
+		{"append", ""},
+		{"localize-media", ""},
+		{"snap-tokens", ""},
+		{"infer-classes", ""},
+		{"create-classes", ""},
+		{"unmapped", ""},
 	}
 	for _, f := range flags {
 		t.Run(f.name, func(t *testing.T) {
@@ -66,7 +81,7 @@ func TestConvertHTML_FileInput(t *testing.T) {
 
 	// Reset flags
 	convertOutput = ""
-	convertPush = 0
+	convertPush = ""
 	convertStdin = false
 	convertClassCache = false
 	convertSnapshot = false
@@ -124,7 +139,7 @@ func TestConvertHTML_OutputFlag(t *testing.T) {
 	cfg = &config.Config{}
 
 	convertOutput = outFile
-	convertPush = 0
+	convertPush = ""
 	convertStdin = false
 	convertClassCache = false
 	convertSnapshot = false
@@ -160,7 +175,7 @@ func TestConvertHTML_StdinInput(t *testing.T) {
 	cfg = &config.Config{}
 
 	convertOutput = ""
-	convertPush = 0
+	convertPush = ""
 	convertStdin = true
 	convertClassCache = false
 	convertSnapshot = false
@@ -211,7 +226,7 @@ func TestConvertHTML_EmptyInput(t *testing.T) {
 	cfg = &config.Config{}
 
 	convertOutput = ""
-	convertPush = 0
+	convertPush = ""
 	convertStdin = true
 	convertClassCache = false
 
@@ -242,7 +257,7 @@ func TestConvertHTML_NoArgsReadsStdin(t *testing.T) {
 	cfg = &config.Config{}
 
 	convertOutput = ""
-	convertPush = 0
+	convertPush = ""
 	convertStdin = false // not explicitly set, but no args either
 	convertClassCache = false
 	convertSnapshot = false
@@ -319,7 +334,7 @@ func TestConvertHTML_DryRunPreventsPush(t *testing.T) {
 	os.WriteFile(htmlFile, []byte("<div>Test</div>"), 0644)
 
 	convertOutput = ""
-	convertPush = 42
+	convertPush = "42"
 	convertStdin = false
 	convertClassCache = false
 	convertSnapshot = false
@@ -353,13 +368,14 @@ func TestConvertHTML_DryRunPreventsPush(t *testing.T) {
 	}
 
 	// Reset
-	convertPush = 0
+	convertPush = ""
 	convertDryRun = false
 }
 
 // --- Test --push with mock server ---
 
 func TestConvertHTML_PushToPage(t *testing.T) {
+	skipSafetySnapshots(t)
 	var pushCalled bool
 	var pushedElements []interface{}
 
@@ -400,7 +416,7 @@ func TestConvertHTML_PushToPage(t *testing.T) {
 	os.WriteFile(htmlFile, []byte("<div><p>Push test</p></div>"), 0644)
 
 	convertOutput = ""
-	convertPush = 99
+	convertPush = "99"
 	convertStdin = false
 	convertClassCache = false
 	convertSnapshot = false
@@ -424,7 +440,134 @@ func TestConvertHTML_PushToPage(t *testing.T) {
 	}
 
 	// Reset
-	convertPush = 0
+	convertPush = ""
+}
+
+func TestConvertHTML_PushBySlug(t *testing.T) {
+	skipSafetySnapshots(t)
+	t.Setenv("HOME", t.TempDir())
+	var pushedPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/wp-json/agent-bricks/v1/pages" && r.URL.Query().Get("slug") == "about":
+			json.NewEncoder(w).Encode([]map[string]interface{}{{"id": 73, "title": "About", "slug": "about"}})
+		case r.Method == "PUT":
+			pushedPath = r.URL.Path
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": "h2", "count": 1})
+		case strings.Contains(r.URL.Path, "/classes"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"classes": []interface{}{}})
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+	cfg = &config.Config{Site: config.SiteConfig{URL: ts.URL, APIKey: "test-key"}}
+
+	htmlFile := filepath.Join(t.TempDir(), "test.html")
+	os.WriteFile(htmlFile, []byte("<p>Slug push</p>"), 0644)
+	convertOutput, convertStdin, convertClassCache, convertSnapshot, convertDryRun = "", false, false, false, false
+	convertPush = "about"
+	defer func() { convertPush = "" }()
+
+	oldStdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = oldStdout }()
+
+	if err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile}); err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+	if pushedPath != "/wp-json/agent-bricks/v1/pages/73/elements" {
+		t.Errorf("expected a push to page 73, got %q", pushedPath)
+	}
+}
+
+func TestConvertHTML_AppendAvoidsPageIDs(t *testing.T) {
+	skipSafetySnapshots(t)
+	var appended []interface{}
+	var ifMatch string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/classes"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"classes": []interface{}{}})
+		case r.Method == "GET" && strings.Contains(r.URL.Path, "/elements"):
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"elements": []map[string]interface{}{
+					{"id": "hero01", "name": "section", "parent": "0", "children": []interface{}{}},
+				},
+				"contentHash": "page-hash",
+			})
+		case r.Method == "POST" && strings.Contains(r.URL.Path, "/elements"):
+			ifMatch = r.Header.Get("If-Match")
+			var payload map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&payload)
+			appended, _ = payload["elements"].([]interface{})
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": "new-hash"})
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+	cfg = &config.Config{Site: config.SiteConfig{URL: ts.URL, APIKey: "test-key"}}
+
+	htmlFile := filepath.Join(t.TempDir(), "section.html")
+	os.WriteFile(htmlFile, []byte(`<section id="hero01"><p id="intro1">Hi</p></section>`), 0644)
+
+	convertOutput = ""
+	convertPush = "7"
+	convertDryRun = false
+	convertSnapshot = false
+	convertIDs = "explicit"
+	convertAppend = true
+	defer func() { convertPush, convertIDs, convertAppend = "", "", false }()
+
+	oldStdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = oldStdout }()
+
+	if err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile}); err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+	if len(appended) != 2 {
+		t.Fatalf("expected 2 appended elements, got %v", appended)
+	}
+	if id := appended[0].(map[string]interface{})["id"]; id == "hero01" {
+		t.Error("appended element reused an ID already on the page")
+	}
+	if id := appended[1].(map[string]interface{})["id"]; id != "intro1" {
+		t.Errorf("expected the free explicit ID kept, got %v", id)
+	}
+	if ifMatch != "page-hash" {
+		t.Errorf("expected If-Match from the page read, got %q", ifMatch)
+	}
+}
+
+func TestConvertHTML_InvalidExplicitID(t *testing.T) {
+	convertOutput = ""
+	convertPush = ""
+	convertIDs = "explicit"
+	defer func() { convertIDs = "" }()
+
+	htmlFile := filepath.Join(t.TempDir(), "a.html")
+	os.WriteFile(htmlFile, []byte(`<section id="hero"><p>x</p></section>`), 0644)
+	err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile})
+	if ce, ok := err.(*clierrors.CLIError); !ok || ce.Code != "INVALID_ELEMENT_ID" || !strings.Contains(ce.Message, `"hero"`) {
+		t.Errorf("expected INVALID_ELEMENT_ID naming the ID, got %v", err)
+	}
+}
+
+func TestConvertHTML_AppendNeedsPush(t *testing.T) {
+	convertPush = ""
+	convertAppend = true
+	defer func() { convertAppend = false }()
+
+	htmlFile := filepath.Join(t.TempDir(), "a.html")
+	os.WriteFile(htmlFile, []byte("<p>x</p>"), 0644)
+	err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile})
+	if ce, ok := err.(*clierrors.CLIError); !ok || ce.Code != "INVALID_ARGS" {
+		t.Errorf("expected INVALID_ARGS, got %v", err)
+	}
 }
 
 // --- Test --snapshot before push ---
@@ -476,11 +619,12 @@ func TestConvertHTML_SnapshotBeforePush(t *testing.T) {
 	}
 
 	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
 	htmlFile := filepath.Join(tmpDir, "test.html")
 	os.WriteFile(htmlFile, []byte("<div>Snapshot test</div>"), 0644)
 
 	convertOutput = ""
-	convertPush = 55
+	convertPush = "55"
 	convertStdin = false
 	convertClassCache = false
 	convertSnapshot = true
@@ -503,40 +647,100 @@ func TestConvertHTML_SnapshotBeforePush(t *testing.T) {
 	}
 
 	// Reset
-	convertPush = 0
+	convertPush = ""
 	convertSnapshot = false
 }
 
 // --- Test --class-cache loads from file ---
 
 func TestConvertHTML_ClassCacheFlag(t *testing.T) {
-	// Create a cached registry file and verify round-trip
-	tmpDir := t.TempDir()
-
-	reg := convert.NewClassRegistry()
-	reg.Add("test-class", "cls-001", "acss")
-	reg.Add("frame-class", "cls-002", "frames")
+	t.Setenv("HOME", t.TempDir())
 
-	cachePath := filepath.Join(tmpDir, "class-registry.json")
-	if err := reg.SaveToFile(cachePath, "https://example.com"); err != nil {
-		t.Fatalf("failed to save registry: %v", err)
+	// Two sites with a "btn" class under different IDs, counting full
+	// fetches and 304 revalidations
+	type site struct {
+		ts                *httptest.Server
+		full, notModified int
 	}
-
-	// Load it back
-	loaded, err := convert.LoadRegistryFromFile(cachePath)
-	if err != nil {
-		t.Fatalf("failed to load registry: %v", err)
+	newSite := func(classID string) *site {
+		s := &site{}
+		s.ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.URL.Path, "/classes") {
+				w.WriteHeader(404)
+				return
+			}
+			w.Header().Set("ETag", `"`+classID+`"`)
+			if r.Header.Get("If-None-Match") == `"`+classID+`"` {
+				s.notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			s.full++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"classes": []interface{}{map[string]interface{}{"id": classID, "name": "btn"}},
+				"count":   1,
+				"total":   1,
+			})
+		}))
+		t.Cleanup(s.ts.Close)
+		return s
+	}
+	a, b := newSite("site_a_btn"), newSite("site_b_btn")
+
+	htmlFile := filepath.Join(t.TempDir(), "test.html")
+	os.WriteFile(htmlFile, []byte(`<div class="btn">Hello</div>`), 0644)
+	convertOutput, convertPush, convertStdin, convertSnapshot, convertDryRun = "", "", false, false, false
+	convertClassCache = true
+	defer func() { convertClassCache = false }()
+
+	// convertWith converts against s and returns the div's global classes
+	convertWith := func(s *site, ttl time.Duration) []interface{} {
+		t.Helper()
+		cfg = &config.Config{
+			Site:  config.SiteConfig{URL: s.ts.URL, APIKey: "test-key"},
+			Cache: config.CacheConfig{ClassesTTL: ttl},
+		}
+		oldStdout := os.Stdout
+		rOut, wOut, _ := os.Pipe()
+		os.Stdout = wOut
+		err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile})
+		wOut.Close()
+		os.Stdout = oldStdout
+		var buf bytes.Buffer
+		io.Copy(&buf, rOut)
+		if err != nil {
+			t.Fatalf("RunE returned error: %v\nOutput: %s", err, buf.String())
+		}
+		var result struct {
+			Elements []map[string]interface{} `json:"elements"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &result); err != nil || len(result.Elements) == 0 {
+			t.Fatalf("Failed to parse output: %v\nOutput: %s", err, buf.String())
+		}
+		settings, _ := result.Elements[0]["settings"].(map[string]interface{})
+		classes, _ := settings["_cssGlobalClasses"].([]interface{})
+		return classes
 	}
 
-	stats := loaded.Stats()
-	if stats.Total != 2 {
-		t.Errorf("loaded registry total = %d, want 2", stats.Total)
+	// Each site is fetched once and cached under its own key
+	if got := convertWith(a, time.Hour); !reflect.DeepEqual(got, []interface{}{"site_a_btn"}) || a.full != 1 {
+		t.Errorf("site A: expected its class from a full fetch, got %v (%d fetches)", got, a.full)
 	}
-	if stats.ACSS != 1 {
-		t.Errorf("loaded registry ACSS = %d, want 1", stats.ACSS)
+	if got := convertWith(b, time.Hour); !reflect.DeepEqual(got, []interface{}{"site_b_btn"}) || b.full != 1 {
+		t.Errorf("site B: expected its own class, not site A's cache, got %v (%d fetches)", got, b.full)
 	}
-	if stats.Frames != 1 {
-		t.Errorf("loaded registry Frames = %d, want 1", stats.Frames)
+	if classCache().Path(a.ts.URL) == classCache().Path(b.ts.URL) {
+		t.Error("expected per-site cache files")
+	}
+
+	// Within the TTL the cache is used without asking the site
+	if got := convertWith(a, time.Hour); !reflect.DeepEqual(got, []interface{}{"site_a_btn"}) || a.full+a.notModified != 1 {
+		t.Errorf("expected site A's cached class without a request, got %v (%d requests)", got, a.full+a.notModified)
+	}
+
+	// Past the TTL the cached hash is revalidated and a 304 keeps the copy
+	if got := convertWith(a, time.Nanosecond); !reflect.DeepEqual(got, []interface{}{"site_a_btn"}) || a.notModified != 1 || a.full != 1 {
+		t.Errorf("expected a 304 revalidation after the TTL, got %v (%d full, %d not modified)", got, a.full, a.notModified)
 	}
 }
 
@@ -572,7 +776,7 @@ func TestConvertHTML_WithClassRegistry(t *testing.T) {
 	os.WriteFile(htmlFile, []byte(`<div class="mt-m btn-primary unknown-class">Hello</div>`), 0644)
 
 	convertOutput = ""
-	convertPush = 0
+	convertPush = ""
 	convertStdin = false
 	convertClassCache = false
 	convertSnapshot = false
@@ -636,7 +840,7 @@ func TestConvertHTML_PushRequiresConfig(t *testing.T) {
 	os.WriteFile(htmlFile, []byte("<div>Test</div>"), 0644)
 
 	convertOutput = ""
-	convertPush = 42
+	convertPush = "42"
 	convertStdin = false
 	convertClassCache = false
 	convertSnapshot = false
@@ -652,7 +856,7 @@ func TestConvertHTML_PushRequiresConfig(t *testing.T) {
 	}
 
 	// Reset
-	convertPush = 0
+	convertPush = ""
 }
 
 // --- Test nonexistent file ---
@@ -661,7 +865,7 @@ func TestConvertHTML_NonexistentFile(t *testing.T) {
 	cfg = &config.Config{}
 
 	convertOutput = ""
-	convertPush = 0
+	convertPush = ""
 	convertStdin = false
 
 	err := convertHTMLCmd.RunE(convertHTMLCmd, []string{"/nonexistent/file.html"})
@@ -705,6 +909,7 @@ func TestConvertHTMLCmd_Metadata(t *testing.T) {
 // --- Test push does not output JSON to stdout (only stderr messages) ---
 
 func TestConvertHTML_PushSuppressesStdout(t *testing.T) {
+	skipSafetySnapshots(t)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case strings.Contains(r.URL.Path, "/classes"):
@@ -737,7 +942,7 @@ func TestConvertHTML_PushSuppressesStdout(t *testing.T) {
 	os.WriteFile(htmlFile, []byte("<div>Test</div>"), 0644)
 
 	convertOutput = ""
-	convertPush = 1
+	convertPush = "1"
 	convertStdin = false
 	convertClassCache = false
 	convertSnapshot = false
@@ -765,5 +970,645 @@ func TestConvertHTML_PushSuppressesStdout(t *testing.T) {
 	}
 
 	// Reset
-	convertPush = 0
+	convertPush = ""
+}
+
+// --- Test --styles classes creates generated classes before pushing ---
+
+func TestConvertHTML_StyleClassesCreatedOnPush(t *testing.T) {
+	skipSafetySnapshots(t)
+	var created []string
+	var pushed []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && strings.Contains(r.URL.Path, "/classes"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"classes": []interface{}{}, "count": 0, "total": 0})
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/classes"):
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			created = append(created, body["name"].(string))
+			w.WriteHeader(201)
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "site01", "name": body["name"]})
+		case r.Method == "GET" && strings.Contains(r.URL.Path, "/elements"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"elements": []interface{}{}, "contentHash": "h0"})
+		case r.Method == "PUT" && strings.Contains(r.URL.Path, "/elements"):
+			var body struct {
+				Elements []map[string]interface{} `json:"elements"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			pushed = body.Elements
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": "h1", "count": 1})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: ts.URL, APIKey: "test-key"}}
+
+	tmpDir := t.TempDir()
+	htmlFile := filepath.Join(tmpDir, "card.html")
+	os.WriteFile(htmlFile, []byte(`<div class="card">Hi</div>`), 0644)
+	cssFile := filepath.Join(tmpDir, "card.css")
+	os.WriteFile(cssFile, []byte(`.card { padding: 16px }`), 0644)
+
+	convertOutput, convertPush, convertStdin, convertClassCache, convertSnapshot, convertDryRun = "", "12", false, false, false, false
+	convertCSS, convertStyles = []string{cssFile}, "classes"
+	defer func() { convertPush, convertCSS, convertStyles = "", nil, "inline" }()
+
+	if err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile}); err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+	if len(created) != 1 || created[0] != "card" {
+		t.Fatalf("expected class card to be created, got %v", created)
+	}
+	settings, _ := pushed[0]["settings"].(map[string]interface{})
+	if refs, _ := settings["_cssGlobalClasses"].([]interface{}); len(refs) != 1 || refs[0] != "site01" {
+		t.Errorf("expected pushed element to reference the created class ID, got %v", settings)
+	}
+}
+
+func TestConvertMappings_ListsUserRulesFirst(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	rules := filepath.Join(t.TempDir(), "rules.yaml")
+	os.WriteFile(rules, []byte("rules:\n  - name: hero\n    class: hero\n    element: section\n"), 0644)
+
+	convertMapping = rules
+	defer func() { convertMapping = "" }()
+	output.Reset()
+	defer output.Reset()
+	_ = convertMappingsCmd.Flags().Set("format", "json")
+	defer convertMappingsCmd.Flags().Set("format", "")
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := convertMappingsCmd.RunE(convertMappingsCmd, nil)
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	if err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+	var result struct {
+		Rules []map[string]interface{} `json:"rules"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\noutput: %s", err, buf.String())
+	}
+	if len(result.Rules) < 2 {
+		t.Fatalf("expected user and builtin rules, got %v", result.Rules)
+	}
+	first := result.Rules[0]
+	if first["match"] != ".hero" || first["element"] != "section" || first["source"] != rules {
+		t.Errorf("expected user rule first, got %v", first)
+	}
+	if last := result.Rules[len(result.Rules)-1]; last["source"] != "builtin" {
+		t.Errorf("expected builtin rules last, got %v", last)
+	}
+
+	os.WriteFile(rules, []byte("rules:\n  - tag: div\n"), 0644)
+	err = convertMappingsCmd.RunE(convertMappingsCmd, nil)
+	if cliErr, ok := err.(*clierrors.CLIError); !ok || cliErr.Code != "INVALID_MAPPING" {
+		t.Errorf("expected INVALID_MAPPING error, got %v", err)
+	}
+}
+
+func TestConvertBricksToHTML_RoundTrip(t *testing.T) {
+	cfg = &config.Config{}
+	dir := t.TempDir()
+	pageFile := filepath.Join(dir, "page.json")
+	os.WriteFile(pageFile, []byte(`{"elements":[
+		{"id":"sec001","name":"section","parent":"0","children":["hed001"],"settings":{}},
+		{"id":"hed001","name":"heading","parent":"sec001","children":[],"settings":{"text":"Hi","tag":"h2","_cssClasses":"title"}}
+	]}`), 0644)
+	htmlFile := filepath.Join(dir, "page.html")
+
+	renderOutput = htmlFile
+	defer func() { renderOutput = "" }()
+	if err := convertBricksToHTMLCmd.RunE(convertBricksToHTMLCmd, []string{pageFile}); err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+	data, _ := os.ReadFile(htmlFile)
+	if !strings.Contains(string(data), `<h2 data-bricks-id="hed001" class="title">Hi</h2>`) {
+		t.Fatalf("unexpected HTML:\n%s", data)
+	}
+
+	convertOutput, convertPush, convertStdin, convertDryRun = "", "", false, false
+	convertKeepIDs = true
+	defer func() { convertKeepIDs = false }()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile})
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	if err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+
+	var result struct {
+		Elements []map[string]interface{} `json:"elements"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse output JSON: %v\n%s", err, buf.String())
+	}
+	if len(result.Elements) != 2 || result.Elements[0]["id"] != "sec001" || result.Elements[1]["id"] != "hed001" {
+		t.Errorf("expected preserved IDs, got %v", result.Elements)
+	}
+}
+
+func TestConvertBricksToHTML_InvalidStyles(t *testing.T) {
+	renderStyles = "classes"
+	defer func() { renderStyles = "inline" }()
+	err := convertBricksToHTMLCmd.RunE(convertBricksToHTMLCmd, []string{"page.json"})
+	if cliErr, ok := err.(*clierrors.CLIError); !ok || cliErr.Code != "INVALID_ARGS" {
+		t.Errorf("expected INVALID_ARGS, got %v", err)
+	}
+}
+
+func TestConvertMarkdown_FrontMatterTemplate(t *testing.T) {
+	cfg = &config.Config{}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(templateDir(), 0755)
+	os.WriteFile(filepath.Join(templateDir(), "hero.json"), []byte(`{"name":"hero","elements":[
+		{"id":"h1","name":"section","parent":"0","children":[],"settings":{}}
+	]}`), 0644)
+	mdFile := filepath.Join(home, "post.md")
+	os.WriteFile(mdFile, []byte("---\ntemplate: hero\n---\n## One\n\nText"), 0644)
+
+	convertOutput, convertPush, convertStdin, convertDryRun = "", "", false, false
+	convertIDs = "hash"
+	defer func() { convertIDs = "" }()
+
+	run := func() []map[string]interface{} {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := convertMarkdownCmd.RunE(convertMarkdownCmd, []string{mdFile})
+		w.Close()
+		os.Stdout = oldStdout
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		if err != nil {
+			t.Fatalf("RunE returned error: %v", err)
+		}
+		var result struct {
+			Elements []map[string]interface{} `json:"elements"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+			t.Fatalf("failed to parse output JSON: %v\n%s", err, buf.String())
+		}
+		return result.Elements
+	}
+
+	elements := run()
+	var names []interface{}
+	for _, el := range elements {
+		names = append(names, el["name"])
+	}
+	if len(elements) != 5 || elements[0]["name"] != "section" || elements[0]["id"] == "h1" || elements[1]["name"] != "section" {
+		t.Fatalf("expected the template section before the content, got %v", names)
+	}
+	if again := run(); !reflect.DeepEqual(again, elements) {
+		t.Error("expected --ids hash to give the same elements on every run")
+	}
+
+	os.WriteFile(mdFile, []byte("---\ntemplate: missing\n---\nText"), 0644)
+	err := convertMarkdownCmd.RunE(convertMarkdownCmd, []string{mdFile})
+	if cliErr, ok := err.(*clierrors.CLIError); !ok || cliErr.Code != "INVALID_FRONT_MATTER" {
+		t.Errorf("expected INVALID_FRONT_MATTER, got %v", err)
+	}
+}
+
+func TestConvertURL_AppliesPageCSS(t *testing.T) {
+	cfg = &config.Config{}
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Write([]byte(`<html><head><link rel="stylesheet" href="site.css"></head><body>
+<header>Menu</header><main><h2 class="title">Hello</h2><img src="a.png"></main></body></html>`))
+		case "/site.css":
+			w.Write([]byte(`.title { color: #ff0000; }`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	convertOutput, convertPush, convertDryRun, convertStyles, convertIDs = "", "", false, "inline", "random"
+	urlSelector, urlNoCSS = "", false
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := convertURLCmd.RunE(convertURLCmd, []string{ts.URL + "/page"})
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	if err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "Menu") {
+		t.Errorf("expected the page header left out, got %s", out)
+	}
+	if !strings.Contains(out, `"raw": "#ff0000"`) && !strings.Contains(out, `"hex": "#ff0000"`) {
+		t.Errorf("expected the linked stylesheet applied, got %s", out)
+	}
+	if !strings.Contains(out, ts.URL+"/a.png") {
+		t.Errorf("expected the image URL made absolute, got %s", out)
+	}
+
+	urlSelector = "["
+	err = convertURLCmd.RunE(convertURLCmd, []string{ts.URL + "/page"})
+	urlSelector = ""
+	if cliErr, ok := err.(*clierrors.CLIError); !ok || cliErr.Code != "INVALID_ARGS" {
+		t.Errorf("expected INVALID_ARGS for a bad selector, got %v", err)
+	}
+	err = convertURLCmd.RunE(convertURLCmd, []string{ts.URL + "/missing"})
+	if cliErr, ok := err.(*clierrors.CLIError); !ok || cliErr.Code != "FETCH_FAILED" {
+		t.Errorf("expected FETCH_FAILED for a missing page, got %v", err)
+	}
+	err = convertURLCmd.RunE(convertURLCmd, []string{"ftp://example.com"})
+	if cliErr, ok := err.(*clierrors.CLIError); !ok || cliErr.Code != "INVALID_ARGS" {
+		t.Errorf("expected INVALID_ARGS for a non-HTTP URL, got %v", err)
+	}
+}
+
+func TestConvertURL_UsesHTTPTimeout(t *testing.T) {
+	cfg = &config.Config{HTTP: config.HTTPConfig{Timeout: 50 * time.Millisecond}}
+	t.Setenv("HOME", t.TempDir())
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+	urlSelector, urlNoCSS = "", false
+
+	start := time.Now()
+	err := convertURLCmd.RunE(convertURLCmd, []string{ts.URL + "/page"})
+	if cliErr, ok := err.(*clierrors.CLIError); !ok || cliErr.Code != "FETCH_FAILED" {
+		t.Errorf("expected FETCH_FAILED after the timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected http.timeout to bound the fetch, took %s", elapsed)
+	}
+}
+
+func TestConvertGutenberg_FileInput(t *testing.T) {
+	cfg = &config.Config{}
+	t.Setenv("HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "post.html")
+	os.WriteFile(file, []byte(`<!-- wp:heading -->
+<h2 class="wp-block-heading">Hi</h2>
+<!-- /wp:heading -->
+<!-- wp:latest-posts /-->`), 0644)
+	convertOutput, convertPush, convertDryRun, convertStyles, convertIDs = "", "", false, "inline", "random"
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := convertGutenbergCmd.RunE(convertGutenbergCmd, []string{file})
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	if err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+
+	var result struct {
+		Elements []map[string]interface{} `json:"elements"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse output JSON: %v\n%s", err, buf.String())
+	}
+	var names []string
+	for _, el := range result.Elements {
+		names = append(names, el["name"].(string))
+	}
+	if strings.Join(names, ",") != "section,container,heading" {
+		t.Errorf("expected the heading wrapped in a section, got %v", names)
+	}
+}
+
+func TestConvertHTML_LocalizeMedia(t *testing.T) {
+	var uploads int
+	// Media already on the site is left alone, so the image comes from its
+	// own server and the site is addressed as localhost rather than
+	// 127.0.0.1.
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("jpeg"))
+	}))
+	defer origin.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/media/upload"):
+			uploads++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id": 42, "url": "https://site.test/uploads/hero.jpg", "filename": "hero.jpg",
+			})
+		case strings.HasSuffix(r.URL.Path, "/media"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"media": []interface{}{}, "count": 0})
+		case strings.Contains(r.URL.Path, "/classes"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"classes": []interface{}{}, "count": 0, "total": 0})
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+	cfg = &config.Config{Site: config.SiteConfig{URL: strings.Replace(ts.URL, "127.0.0.1", "localhost", 1), APIKey: "test-key"}}
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	htmlFile := filepath.Join(dir, "page.html")
+	os.WriteFile(htmlFile, []byte(`<img src="`+origin.URL+`/img/hero.jpg"><img src="`+origin.URL+`/img/hero.jpg">`), 0644)
+	manifestFile := filepath.Join(dir, "media.json")
+
+	convertOutput, convertPush, convertStdin, convertDryRun, convertStyles, convertIDs = "", "", false, false, "inline", "random"
+	convertLocalize, convertMediaManifest = true, manifestFile
+	defer func() { convertLocalize, convertMediaManifest = false, "" }()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile})
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	if err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+
+	var result struct {
+		Elements []map[string]interface{} `json:"elements"`
+		Media    []map[string]interface{} `json:"media"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse output JSON: %v\n%s", err, buf.String())
+	}
+	if uploads != 1 || len(result.Media) != 1 || result.Media[0]["status"] != "uploaded" {
+		t.Errorf("expected one upload for the repeated image, got %d uploads, media %v", uploads, result.Media)
+	}
+	for _, el := range result.Elements {
+		img := el["settings"].(map[string]interface{})["image"].(map[string]interface{})
+		if img["id"] != float64(42) || img["url"] != "https://site.test/uploads/hero.jpg" {
+			t.Errorf("expected the image rewritten to the attachment, got %v", img)
+		}
+	}
+	if data, err := os.ReadFile(manifestFile); err != nil || !strings.Contains(string(data), origin.URL+"/img/hero.jpg") {
+		t.Errorf("expected the manifest written, got %s (%v)", data, err)
+	}
+}
+
+func TestConvertHTML_SnapTokens(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/variables"):
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"variables": []map[string]interface{}{
+					{"name": "--space-m", "value": "1.5rem"},
+					{"name": "--primary", "value": "#3366ff"},
+				},
+				"extractedFromCss": []map[string]interface{}{},
+			})
+		case strings.Contains(r.URL.Path, "/classes"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"classes": []interface{}{}, "count": 0, "total": 0})
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+	cfg = &config.Config{Site: config.SiteConfig{URL: ts.URL, APIKey: "test-key"}}
+	t.Setenv("HOME", t.TempDir())
+
+	htmlFile := filepath.Join(t.TempDir(), "page.html")
+	os.WriteFile(htmlFile, []byte(`<section style="padding: 25px; margin-top: 28px; color: #3467fe">Hi</section>`), 0644)
+
+	convertOutput, convertPush, convertStdin, convertDryRun, convertStyles, convertIDs = "", "", false, false, "inline", "random"
+	convertSnapTokens, convertSnapLengthTol, convertSnapColorTol = true, 0.1, 3
+	defer func() { convertSnapTokens = false }()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile})
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	if err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+
+	var result struct {
+		Elements []map[string]interface{} `json:"elements"`
+		Tokens   struct {
+			Substitutions []map[string]interface{} `json:"substitutions"`
+			NearMisses    []map[string]interface{} `json:"nearMisses"`
+		} `json:"tokens"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse output JSON: %v\n%s", err, buf.String())
+	}
+	settings := result.Elements[0]["settings"].(map[string]interface{})
+	if pad := settings["_padding"].(map[string]interface{}); pad["top"] != "var(--space-m)" {
+		t.Errorf("expected padding snapped to --space-m, got %v", pad)
+	}
+	if !strings.Contains(buf.String(), `"raw": "var(--primary)"`) {
+		t.Errorf("expected color snapped to --primary, got %s", buf.String())
+	}
+	if len(result.Tokens.Substitutions) != 5 || len(result.Tokens.NearMisses) != 1 {
+		t.Errorf("expected 5 substitutions and a near miss for margin-top, got %+v", result.Tokens)
+	}
+}
+
+func TestConvertHTML_InferClasses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/variables"):
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"variables": []map[string]interface{}{{"name": "--space-m", "value": "1.5rem"}},
+			})
+		case strings.Contains(r.URL.Path, "/classes"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"classes": []interface{}{
+				map[string]interface{}{"id": "acss_import_gap--m", "name": "gap--m", "settings": map[string]interface{}{"_gap": "var(--space-m)"}},
+			}, "count": 1, "total": 1})
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+	cfg = &config.Config{Site: config.SiteConfig{URL: ts.URL, APIKey: "test-key"}}
+	t.Setenv("HOME", t.TempDir())
+
+	htmlFile := filepath.Join(t.TempDir(), "page.html")
+	os.WriteFile(htmlFile, []byte(`<div style="display: flex; gap: 24px">Hi</div>`), 0644)
+
+	convertOutput, convertPush, convertStdin, convertDryRun, convertStyles, convertIDs = "", "", false, false, "inline", "random"
+	convertInfer, convertSnapTokens, convertSnapLengthTol, convertSnapColorTol = true, true, 0.1, 3
+	defer func() { convertInfer, convertSnapTokens = false, false }()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile})
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	if err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+
+	var result struct {
+		Elements []map[string]interface{} `json:"elements"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse output JSON: %v\n%s", err, buf.String())
+	}
+	// 24px snaps to --space-m, which is .gap--m
+	settings := result.Elements[0]["settings"].(map[string]interface{})
+	if refs, _ := settings["_cssGlobalClasses"].([]interface{}); len(refs) != 1 || refs[0] != "acss_import_gap--m" {
+		t.Errorf("expected gap--m inferred, got %v", settings)
+	}
+	if _, ok := settings["_gap"]; ok || settings["_display"] != "flex" {
+		t.Errorf("expected the gap dropped and the display kept, got %v", settings)
+	}
+}
+
+func TestConvertHTML_CreateClasses(t *testing.T) {
+	var created []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/classes") && r.Method == http.MethodPost:
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			name, _ := body["name"].(string)
+			created = append(created, name)
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "site_" + name, "name": name})
+		case strings.Contains(r.URL.Path, "/classes"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"classes": []interface{}{
+				map[string]interface{}{"id": "acss_import_mt-l", "name": "mt-l"},
+			}, "count": 1, "total": 1})
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+	cfg = &config.Config{Site: config.SiteConfig{URL: ts.URL, APIKey: "test-key"}}
+	t.Setenv("HOME", t.TempDir())
+
+	htmlFile := filepath.Join(t.TempDir(), "page.html")
+	os.WriteFile(htmlFile, []byte(`<style>.card { padding: 10px }</style><div class="card mt-l fr-grid note">Hi</div>`), 0644)
+
+	convertOutput, convertPush, convertStdin, convertStyles, convertIDs = "", "", false, "inline", "random"
+	convertCreate, convertClassAllow, convertClassSkip = true, []string{"card*"}, []string{"fr-"}
+	defer func() { convertCreate, convertClassAllow, convertDryRun = false, nil, false }()
+
+	run := func() map[string]interface{} {
+		t.Helper()
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile})
+		w.Close()
+		os.Stdout = oldStdout
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		if err != nil {
+			t.Fatalf("RunE returned error: %v", err)
+		}
+		var result struct {
+			Elements []map[string]interface{} `json:"elements"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+			t.Fatalf("failed to parse output JSON: %v\n%s", err, buf.String())
+		}
+		return result.Elements[0]["settings"].(map[string]interface{})
+	}
+
+	// Dry run lists the classes but creates nothing
+	convertDryRun = true
+	run()
+	if len(created) != 0 {
+		t.Errorf("expected nothing created in a dry run, got %v", created)
+	}
+
+	convertDryRun = false
+	settings := run()
+	if len(created) != 1 || created[0] != "card" {
+		t.Errorf("expected only card created, got %v", created)
+	}
+	refs, _ := settings["_cssGlobalClasses"].([]interface{})
+	if len(refs) != 2 || refs[0] != "site_card" || refs[1] != "acss_import_mt-l" {
+		t.Errorf("expected elements to reference the new class ID, got %v", refs)
+	}
+	if settings["_cssClasses"] != "fr-grid note" {
+		t.Errorf("expected skipped and disallowed classes left unresolved, got %v", settings["_cssClasses"])
+	}
+}
+
+func TestConvertHTML_CreateClassesRefreshesCache(t *testing.T) {
+	// The site keeps the classes it is sent and rejects duplicate names
+	classes := []interface{}{map[string]interface{}{"id": "acss_import_mt-l", "name": "mt-l"}}
+	var creates int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/classes") && r.Method == http.MethodPost:
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			name, _ := body["name"].(string)
+			for _, c := range classes {
+				if c.(map[string]interface{})["name"] == name {
+					w.WriteHeader(http.StatusConflict)
+					json.NewEncoder(w).Encode(map[string]interface{}{"message": "class exists"})
+					return
+				}
+			}
+			creates++
+			cls := map[string]interface{}{"id": "site_" + name, "name": name}
+			classes = append(classes, cls)
+			json.NewEncoder(w).Encode(cls)
+		case strings.Contains(r.URL.Path, "/classes"):
+			json.NewEncoder(w).Encode(map[string]interface{}{"classes": classes, "count": len(classes), "total": len(classes)})
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer ts.Close()
+	cfg = &config.Config{Site: config.SiteConfig{URL: ts.URL, APIKey: "test-key"}}
+	t.Setenv("HOME", t.TempDir())
+
+	htmlFile := filepath.Join(t.TempDir(), "page.html")
+	os.WriteFile(htmlFile, []byte(`<style>.card { padding: 10px }</style><div class="card mt-l">Hi</div>`), 0644)
+
+	convertOutput, convertPush, convertStdin, convertStyles, convertIDs, convertDryRun = "", "", false, "inline", "random", false
+	convertCreate, convertClassCache = true, true
+	defer func() { convertCreate, convertClassCache = false, false }()
+
+	oldStdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = oldStdout }()
+
+	for i := 1; i <= 2; i++ {
+		if err := convertHTMLCmd.RunE(convertHTMLCmd, []string{htmlFile}); err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+	}
+	if creates != 1 {
+		t.Errorf("expected card created once, got %d creates", creates)
+	}
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/framework"
+	"github.com/nerveband/agent-to-bricks/internal/tokens"
+)
+
+var (
+	convertSnapTokens    bool
+	convertSnapLengthTol float64
+	convertSnapColorTol  float64
+)
+
+// loadTokenSet reads the site's variables into the token set --snap-tokens
+// snaps to, classifying them with the loaded framework configs.
+func loadTokenSet() (*tokens.Set, error) {
+	if convertSnapLengthTol < 0 || convertSnapColorTol < 0 {
+		return nil, clierrors.ValidationError("INVALID_ARGS", "snap tolerances must not be negative")
+	}
+	if err := requireConfig(); err != nil {
+		return nil, err
+	}
+	resp, err := newSiteClient().GetVariables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variables: %w", err)
+	}
+	var vars []tokens.Variable
+	for _, items := range [][]map[string]interface{}{resp.Variables, resp.ExtractedFromCSS} {
+		for _, v := range items {
+			name, _ := v["name"].(string)
+			value, _ := v["value"].(string)
+			if name != "" && value != "" {
+				vars = append(vars, tokens.Variable{Name: name, Value: value})
+			}
+		}
+	}
+
+	var fws []*framework.Framework
+	if reg, err := framework.NewRegistry(); err == nil {
+		for _, id := range reg.List() {
+			fws = append(fws, reg.Get(id))
+		}
+	}
+	return tokens.NewSet(vars, fws, tokens.Options{
+		LengthTolerance: convertSnapLengthTol,
+		ColorTolerance:  convertSnapColorTol,
+	}), nil
+}
+
+// snapTokens rewrites the raw lengths and colors in a conversion's elements
+// and generated classes to the site's variables with --snap-tokens, and
+// reports each substitution and near miss.
+func snapTokens(set *tokens.Set, result *convert.Result) *tokens.Report {
+	report := &tokens.Report{Substitutions: []tokens.Match{}, NearMisses: []tokens.Match{}}
+	set.Snap(result.Elements, report)
+	for _, cls := range result.Classes {
+		name, _ := cls["name"].(string)
+		settings, _ := cls["settings"].(map[string]interface{})
+		set.SnapSettings("."+name, settings, report)
+	}
+
+	for _, m := range report.Substitutions {
+		fmt.Fprintf(os.Stderr, "Snapped %s %s: %s -> var(%s)\n", m.Owner, m.Setting, m.Value, m.Token)
+	}
+	for _, m := range report.NearMisses {
+		fmt.Fprintf(os.Stderr, "Near miss %s %s: %s is %g from %s (%s)\n", m.Owner, m.Setting, m.Value, m.Distance, m.Token, m.TokenValue)
+	}
+	fmt.Fprintf(os.Stderr, "Tokens: %d values snapped, %d near misses\n", len(report.Substitutions), len(report.NearMisses))
+	return report
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nerveband/agent-to-bricks/internal/diff"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var diffSnapshot string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <a.json|page-id> [b.json]",
+	Short: "Compare two element trees",
+	Long: `Show what changed between two Bricks element lists.
+
+Elements are matched by ID and reported as added, removed, moved (new
+position under the same parent), reparented or modified, with per-key setting
+changes. Nested settings such as _typography and _padding are compared key by
+key, and _cssGlobalClasses ignores order.
+
+Output formats:
+  (default)       readable summary
+  --format json   changes plus a "patches" array usable with 'bricks site patch'
+  --format patch  unified diff of the canonical JSON`,
+	Example: `  bricks diff before.json after.json
+  bricks diff 1234 layout.json --format json > fixes.json
+  bricks diff 1234 --snapshot snap_abc123`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+
+		var (
+			a, b      []map[string]interface{}
+			fromLabel string
+			toLabel   string
+			baseHash  string
+			err       error
+		)
+
+		switch {
+		case diffSnapshot != "":
+			if len(args) != 1 {
+				return clierrors.ValidationError("INVALID_ARGS", "--snapshot takes a single page ID")
+			}
+			if err := requireConfig(); err != nil {
+				return err
+			}
+			c := newSiteClient()
+			pageID, err := resolvePage(c, args[0])
+			if err != nil {
+				return err
+			}
+			snap, err := c.GetSnapshot(pageID, diffSnapshot)
+			if err != nil {
+				return fmt.Errorf("failed to get snapshot: %w", err)
+			}
+			current, err := c.GetElements(pageID)
+			if err != nil {
+				return fmt.Errorf("failed to read page: %w", err)
+			}
+			a, b = snap.Elements, current.Elements
+			fromLabel, toLabel = "snapshot "+diffSnapshot, fmt.Sprintf("page %d", pageID)
+			baseHash = current.ContentHash
+
+		case len(args) == 2:
+			if _, statErr := os.Stat(args[0]); statErr == nil {
+				a, err = readElementsFile(args[0])
+				if err != nil {
+					return err
+				}
+				fromLabel = args[0]
+			} else {
+				if err := requireConfig(); err != nil {
+					return err
+				}
+				c := newSiteClient()
+				pageID, err := resolvePage(c, args[0])
+				if err != nil {
+					return err
+				}
+				current, err := c.GetElements(pageID)
+				if err != nil {
+					return fmt.Errorf("failed to read page: %w", err)
+				}
+				a = current.Elements
+				fromLabel = fmt.Sprintf("page %d", pageID)
+				baseHash = current.ContentHash
+			}
+			b, err = readElementsFile(args[1])
+			if err != nil {
+				return err
+			}
+			toLabel = args[1]
+
+		default:
+			return clierrors.ValidationError("INVALID_ARGS", "need two files, a page ID and a file, or a page ID with --snapshot")
+		}
+
+		return printDiff(a, b, fromLabel, toLabel, baseHash)
+	},
+}
+
+// printDiff compares two element lists and prints the result in the
+// selected output format. baseHash, when set, is included in JSON output.
+func printDiff(a, b []map[string]interface{}, fromLabel, toLabel, baseHash string) error {
+	res := diff.Compare(a, b)
+
+	switch output.GetFormat() {
+	case "json":
+		payload := map[string]interface{}{
+			"from":    fromLabel,
+			"to":      toLabel,
+			"summary": res.Summary,
+			"changes": res.Changes,
+			"patches": res.Patches(),
+		}
+		if baseHash != "" {
+			payload["contentHash"] = baseHash
+		}
+		return output.JSON(payload)
+	case "patch":
+		fmt.Print(diff.Unified(a, b, fromLabel, toLabel))
+		return nil
+	}
+
+	if res.Empty() {
+		fmt.Println("No differences.")
+		return nil
+	}
+	fmt.Printf("%s → %s: %s\n", fromLabel, toLabel, diffSummary(res.Summary))
+	printDiffChanges(res.Changes)
+	return nil
+}
+
+// readElementsFile reads an element list from a pulled page file
+// ({"elements": [...]}) or a bare JSON array.
+func readElementsFile(path string) ([]map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, clierrors.ValidationError("INVALID_INPUT", fmt.Sprintf("failed to read %s: %v", path, err))
+	}
+	return parseElements(data, path)
+}
+
+// parseElements parses an element list in either readElementsFile format;
+// label names the source in errors.
+func parseElements(data []byte, label string) ([]map[string]interface{}, error) {
+	var wrapped struct {
+		Elements []map[string]interface{} `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Elements != nil {
+		return wrapped.Elements, nil
+	}
+	var bare []map[string]interface{}
+	if err := json.Unmarshal(data, &bare); err != nil {
+		return nil, clierrors.ValidationError("INVALID_JSON", fmt.Sprintf("failed to parse %s", label))
+	}
+	return bare, nil
+}
+
+func diffSummary(s diff.Summary) string {
+	var parts []string
+	for _, p := range []struct {
+		n    int
+		what string
+	}{
+		{s.Added, "added"}, {s.Removed, "removed"}, {s.Moved, "moved"},
+		{s.Reparented, "reparented"}, {s.Modified, "modified"},
+	} {
+		if p.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", p.n, p.what))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func printDiffChanges(changes []diff.Change) {
+	marks := map[string]string{
+		diff.Added: "+", diff.Removed: "-", diff.Moved: ">", diff.Reparented: "^", diff.Modified: "~",
+	}
+	for _, ch := range changes {
+		line := fmt.Sprintf("  %s %s %s", marks[ch.Type], ch.ID, ch.Name)
+		switch ch.Type {
+		case diff.Reparented:
+			line += fmt.Sprintf("  (parent %s → %s)", ch.FromParent, ch.ToParent)
+		case diff.Moved:
+			line += "  (reordered)"
+		}
+		fmt.Println(line)
+		for _, k := range ch.Keys {
+			switch {
+			case k.Added != nil || k.Removed != nil:
+				var parts []string
+				for _, id := range k.Added {
+					parts = append(parts, "+"+id)
+				}
+				for _, id := range k.Removed {
+					parts = append(parts, "-"+id)
+				}
+				fmt.Printf("      %s: %s\n", k.Path, strings.Join(parts, " "))
+			case k.From == nil:
+				fmt.Printf("      %s: + %s\n", k.Path, compactJSON(k.To))
+			case k.To == nil:
+				fmt.Printf("      %s: - %s\n", k.Path, compactJSON(k.From))
+			default:
+				fmt.Printf("      %s: %s → %s\n", k.Path, compactJSON(k.From), compactJSON(k.To))
+			}
+		}
+	}
+}
+
+func compactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffSnapshot, "snapshot", "", "compare a page snapshot with the current page")
+	output.AddFormatFlags(diffCmd)
+	rootCmd.AddCommand(diffCmd)
+}
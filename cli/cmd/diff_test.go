@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/output"
+)
+
+func TestDiffFiles_JSONPatches(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.json")
+	b := filepath.Join(dir, "b.json")
+	os.WriteFile(a, []byte(`{"elements":[{"id":"h1","name":"heading","parent":"0","settings":{"text":"Hi","tag":"h2"}}]}`), 0644)
+	os.WriteFile(b, []byte(`[{"id":"h1","name":"heading","parent":"0","settings":{"text":"Hello"}}]`), 0644)
+
+	output.Reset()
+	defer output.Reset()
+	_ = diffCmd.Flags().Set("format", "json")
+	defer diffCmd.Flags().Set("format", "")
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := diffCmd.RunE(diffCmd, []string{a, b})
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	if err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+
+	var result struct {
+		Patches []map[string]interface{} `json:"patches"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\noutput: %s", err, buf.String())
+	}
+	if len(result.Patches) != 1 {
+		t.Fatalf("expected 1 patch, got %v", result.Patches)
+	}
+	settings := result.Patches[0]["settings"].(map[string]interface{})
+	if settings["text"] != "Hello" {
+		t.Errorf("expected text Hello, got %v", settings["text"])
+	}
+	if v, ok := settings["tag"]; !ok || v != nil {
+		t.Errorf("expected removed tag as null, got %v", settings)
+	}
+}
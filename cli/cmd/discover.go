@@ -42,6 +42,7 @@ Use --json (recommended) for structured output that agents can parse.`,
 			return fmt.Errorf("failed to get site info: %w", err)
 		}
 		result["site"] = map[string]interface{}{
+			"profile":        cfg.ActiveSite(),
 			"url":            cfg.Site.URL,
 			"bricksVersion":  info.BricksVersion,
 			"wpVersion":      info.WPVersion,
@@ -74,7 +75,7 @@ Use --json (recommended) for structured output that agents can parse.`,
 		}
 
 		// Global classes
-		classes, err := c.ListClasses("")
+		classes, _, err := siteClasses(true)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: could not fetch classes: %v\n", err)
 		} else {
@@ -89,7 +90,7 @@ Use --json (recommended) for structured output that agents can parse.`,
 				grouped[fw] = append(grouped[fw], name)
 			}
 			result["classes"] = map[string]interface{}{
-				"total":   classes.Count,
+				"total":   len(classes.Classes),
 				"grouped": grouped,
 			}
 		}
@@ -113,7 +114,7 @@ Use --json (recommended) for structured output that agents can parse.`,
 		}
 
 		// Human-readable summary
-		fmt.Printf("Site: %s\n", cfg.Site.URL)
+		fmt.Printf("Site: %s%s\n", cfg.Site.URL, siteLabel())
 		fmt.Printf("Bricks: %s | WordPress: %s | Plugin: %s\n",
 			info.BricksVersion, info.WPVersion, info.PluginVersion)
 		fmt.Printf("Element types: %d\n", len(info.ElementTypes))
@@ -124,7 +125,7 @@ Use --json (recommended) for structured output that agents can parse.`,
 		}
 
 		if classes != nil {
-			fmt.Printf("\nGlobal classes: %d total\n", classes.Count)
+			fmt.Printf("\nGlobal classes: %d total\n", len(classes.Classes))
 			grouped := map[string]int{}
 			for _, cls := range classes.Classes {
 				fw, _ := cls["framework"].(string)
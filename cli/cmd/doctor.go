@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/nerveband/agent-to-bricks/internal/doctor"
 	"github.com/nerveband/agent-to-bricks/internal/output"
@@ -19,24 +18,25 @@ var doctorCmd = &cobra.Command{
 			return err
 		}
 
-		pageID, err := strconv.Atoi(args[0])
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
 		if err != nil {
-			return fmt.Errorf("invalid page ID: %s", args[0])
+			return err
 		}
 
-		c := newSiteClient()
 		resp, err := c.GetElements(pageID)
 		if err != nil {
 			return fmt.Errorf("failed to pull elements: %w", err)
 		}
 
 		report := doctor.Check(resp.Elements)
+		report.Site = cfg.ActiveSite()
 
 		if output.IsJSON() {
 			return output.JSON(report)
 		}
 
-		fmt.Printf("Checking page %d (%d elements)...\n\n", pageID, resp.Count)
+		fmt.Printf("Checking page %d on %s%s (%d elements)...\n\n", pageID, cfg.Site.URL, siteLabel(), resp.Count)
 
 		if len(report.Issues) == 0 {
 			fmt.Println("No issues found. Page is healthy!")
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	"github.com/nerveband/agent-to-bricks/internal/elementid"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+)
+
+// idStrategy parses an --ids flag value.
+func idStrategy(value string) (elementid.Strategy, error) {
+	s, err := elementid.ParseStrategy(value)
+	if err != nil {
+		return "", clierrors.ValidationError("INVALID_ARGS", fmt.Sprintf("--ids: %v", err))
+	}
+	return s, nil
+}
+
+// pageElementIDs returns the IDs of a page's elements, which elements
+// appended to it must not reuse, and the page's content hash.
+func pageElementIDs(c *client.Client, pageID int) ([]string, string, error) {
+	resp, err := c.GetElements(pageID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read page %d: %w", pageID, err)
+	}
+	ids := make([]string, 0, len(resp.Elements))
+	for _, el := range resp.Elements {
+		if id, _ := el["id"].(string); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, resp.ContentHash, nil
+}
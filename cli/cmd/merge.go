@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/merge"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/nerveband/agent-to-bricks/internal/workspace"
+)
+
+// mergedResponse is printed instead of a plain MutationResponse when a write
+// only succeeded after merging with remote changes.
+type mergedResponse struct {
+	*client.MutationResponse
+	Merged bool `json:"merged"`
+}
+
+// baseCache records pulled pages so --merge can find the version an edited
+// file started from.
+func baseCache() workspace.BaseCache {
+	return workspace.BaseCache{Dir: filepath.Join(configDir(), "bases")}
+}
+
+// rememberBase stores a pulled page version. Failures are not fatal; they only
+// mean a later --merge needs --base.
+func rememberBase(pageID int, hash string, elements []map[string]interface{}) {
+	_ = baseCache().Save(pageID, hash, elements)
+}
+
+// loadMergeBase finds the elements a local edit was based on: an explicit
+// --base file, the pull cache, or a working copy tracking the page.
+func loadMergeBase(pageID int, hash, baseFile string) ([]map[string]interface{}, error) {
+	if baseFile != "" {
+		return readElementsFile(baseFile)
+	}
+
+	if elements, ok := baseCache().Load(pageID, hash); ok {
+		return elements, nil
+	}
+	if ws, err := workspace.Find("."); err == nil {
+		if p, err := ws.Page(pageID); err == nil && p.BaseHash == hash {
+			return p.BaseElements, nil
+		}
+	}
+	return nil, clierrors.ValidationError("MERGE_BASE_MISSING",
+		fmt.Sprintf("no base version of page %d with hash %q is recorded; pass --base with the originally pulled file", pageID, hash))
+}
+
+func isConflict(err error) bool {
+	var cliErr *clierrors.CLIError
+	return stderrors.As(err, &cliErr) && cliErr.Code == "CONTENT_CONFLICT"
+}
+
+// mergeWithRemote fetches the current page and merges local edits into it.
+// Overlapping edits are returned as a conflict error carrying them as
+// details.
+func mergeWithRemote(c *client.Client, pageID int, base, local []map[string]interface{}) (*merge.Result, *client.ElementsResponse, error) {
+	remote, err := c.GetElements(pageID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read page for merge: %w", err)
+	}
+	res := merge.Merge(base, local, remote.Elements)
+	if !res.Clean() {
+		return nil, nil, conflictError(pageID, res.Conflicts)
+	}
+	return res, remote, nil
+}
+
+// conflictError returns CONTENT_CONFLICT for overlapping edits. With JSON
+// output the conflicts go out once, in the error's details; otherwise they
+// are listed on stderr.
+func conflictError(pageID int, conflicts []merge.Conflict) error {
+	err := clierrors.ConflictError(fmt.Sprintf("%d merge conflict(s) on page %d; resolve them and pull again", len(conflicts), pageID))
+	err.Details = map[string]interface{}{
+		"merged":    false,
+		"pageId":    pageID,
+		"conflicts": conflicts,
+	}
+	if !output.IsJSON() {
+		fmt.Fprintf(os.Stderr, "Merge conflicts on page %d:\n", pageID)
+		for _, c := range conflicts {
+			fmt.Fprintf(os.Stderr, "  %s %s %s: %s\n", c.ID, c.Name, c.Field, c.Kind)
+		}
+	}
+	return err
+}
+
+// mergePatches replays patches on top of the current page after a conflict
+// and pushes only the resulting delta.
+func mergePatches(c *client.Client, pageID int, base, patches []map[string]interface{}) (*client.MutationResponse, error) {
+	local, err := merge.ApplyPatches(base, patches)
+	if err != nil {
+		return nil, clierrors.ValidationError("INVALID_PATCH", err.Error())
+	}
+	res, remote, err := mergeWithRemote(c, pageID, base, local)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := pushDelta(c, pageID, workspace.ComputeDelta(remote.Elements, res.Elements), remote.ContentHash)
+	if err != nil {
+		return nil, err
+	}
+	rememberBase(pageID, hash, res.Elements)
+	return &client.MutationResponse{Success: true, ContentHash: hash, Count: len(res.Elements)}, nil
+}
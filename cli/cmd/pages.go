@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/nerveband/agent-to-bricks/internal/pageref"
+	"github.com/spf13/cobra"
+)
+
+var pagesCmd = &cobra.Command{
+	Use:   "pages",
+	Short: "List pages and resolve page references",
+}
+
+var (
+	pagesSearch string
+	pagesLimit  int
+	pagesPage   int
+	pagesAll    bool
+)
+
+var pagesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pages on the connected site",
+	Example: `  bricks pages list
+  bricks pages list --search pricing
+  bricks pages list --all --format json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		params := client.PageParams{Search: pagesSearch, PerPage: pagesLimit, Page: pagesPage}
+		var resp *client.PagesResponse
+		if pagesAll {
+			all, err := c.AllPages(params)
+			if err != nil {
+				return fmt.Errorf("failed to list pages: %w", err)
+			}
+			resp = &client.PagesResponse{Pages: all, Total: len(all), Page: 1, PerPage: len(all), TotalPages: 1}
+		} else {
+			var err error
+			resp, err = c.ListPages(params)
+			if err != nil {
+				return fmt.Errorf("failed to list pages: %w", err)
+			}
+		}
+		if resp.Pages == nil {
+			resp.Pages = []client.Page{}
+		}
+
+		if output.IsJSON() {
+			return output.JSON(resp)
+		}
+		if len(resp.Pages) == 0 {
+			fmt.Println("No pages found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTITLE\tSLUG\tSTATUS\tMODIFIED")
+		for _, p := range resp.Pages {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", p.ID, p.Title, p.Slug, p.Status, p.Modified)
+		}
+		w.Flush()
+		if resp.TotalPages > 1 {
+			fmt.Printf("\n%d pages (page %d of %d)\n", resp.Total, resp.Page, resp.TotalPages)
+		}
+		return nil
+	},
+}
+
+var pagesFindCmd = &cobra.Command{
+	Use:   "find <page-ref>",
+	Short: "Resolve a page ID, slug, title, path or URL to a page",
+	Long: `Show which page a reference resolves to. Every command that takes a page
+accepts the same references:
+
+  1460                               page ID
+  about-us                           slug
+  "About Us"                         title (case-insensitive)
+  /services/web-design/              path
+  https://example.com/pricing/       URL (also ?page_id=1460 links)
+
+A reference that matches several pages fails with PAGE_AMBIGUOUS and lists the
+candidates; use the page ID to pick one.`,
+	Example: `  bricks pages find about-us
+  bricks pages find "https://example.com/pricing/" --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		m, err := pageref.Resolve(context.Background(), newSiteClient(), args[0])
+		if err != nil {
+			return err
+		}
+		if output.IsJSON() {
+			return output.JSON(m)
+		}
+		if m.Page == nil {
+			fmt.Printf("%d\n", m.ID)
+			return nil
+		}
+		fmt.Printf("ID:      %d\n", m.Page.ID)
+		fmt.Printf("Title:   %s\n", m.Page.Title)
+		fmt.Printf("Slug:    %s\n", m.Page.Slug)
+		fmt.Printf("Status:  %s\n", m.Page.Status)
+		if m.Page.Link != "" {
+			fmt.Printf("Link:    %s\n", m.Page.Link)
+		}
+		fmt.Printf("Matched: by %s\n", m.By)
+		return nil
+	},
+}
+
+// resolvePage turns a page reference argument into a page ID. Numeric IDs
+// resolve without a request.
+func resolvePage(c *client.Client, ref string) (int, error) {
+	m, err := pageref.Resolve(context.Background(), c, ref)
+	if err != nil {
+		return 0, err
+	}
+	return m.ID, nil
+}
+
+func init() {
+	output.AddFormatFlags(pagesListCmd)
+	output.AddFormatFlags(pagesFindCmd)
+
+	pagesListCmd.Flags().StringVar(&pagesSearch, "search", "", "filter pages by title or content")
+	pagesListCmd.Flags().IntVar(&pagesLimit, "limit", 20, "max results per page (up to 50)")
+	pagesListCmd.Flags().IntVar(&pagesPage, "page", 1, "result page")
+	pagesListCmd.Flags().BoolVar(&pagesAll, "all", false, "fetch every page of results")
+
+	pagesCmd.AddCommand(pagesListCmd)
+	pagesCmd.AddCommand(pagesFindCmd)
+	rootCmd.AddCommand(pagesCmd)
+}
@@ -19,6 +19,7 @@ var (
 	patchRemoves []string
 	patchStdin   bool
 	patchDryRun  bool
+	patchMerge   bool
 )
 
 var patchCmd = &cobra.Command{
@@ -39,6 +40,10 @@ Patch classes or settings by element ID:
 Patch from JSON stdin (for complex or multi-element patches):
   echo '{"patches":[{"id":"abc123","settings":{"_cssClasses":"new"}}]}' | bricks patch 1338 --stdin
 
+If the page changes between reading its hash and writing, the patch fails
+with a conflict. Pass --merge to replay it on the current page instead when
+the remote changes touch different settings.
+
 This is faster and cheaper than regenerating full page JSON — only the
 changed settings are sent. Perfect for class swaps, text edits, and
 style tweaks.`,
@@ -49,12 +54,11 @@ style tweaks.`,
 			return err
 		}
 
-		pageID := 0
-		if _, err := fmt.Sscanf(args[0], "%d", &pageID); err != nil || pageID == 0 {
-			return fmt.Errorf("invalid page ID: %s", args[0])
-		}
-
 		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
+		if err != nil {
+			return err
+		}
 
 		// --list mode: show elements with IDs
 		if patchList {
@@ -157,10 +161,22 @@ style tweaks.`,
 			return fmt.Errorf("failed to read page: %w", err)
 		}
 
+		guard, err := guardWrite(cmd, c, pageID, "", false)
+		if err != nil {
+			return err
+		}
+		defer guard.release()
 		result, err := c.PatchElements(pageID, patches, existing.ContentHash)
+		if err != nil && patchMerge && isConflict(err) {
+			result, err = mergePatches(c, pageID, existing.Elements, patches)
+			if err != nil {
+				return err
+			}
+		}
 		if err != nil {
 			return fmt.Errorf("patch failed: %w", err)
 		}
+		guard.done(result.ContentHash)
 
 		if output.IsJSON() {
 			return output.JSON(result)
@@ -182,6 +198,7 @@ func init() {
 	patchCmd.Flags().StringArrayVar(&patchRemoves, "rm", nil, "remove a setting key (repeatable)")
 	patchCmd.Flags().BoolVar(&patchStdin, "stdin", false, "read JSON patches from stdin")
 	patchCmd.Flags().BoolVar(&patchDryRun, "dry-run", false, "show patch payload without sending")
+	patchCmd.Flags().BoolVar(&patchMerge, "merge", false, "three-way merge with remote changes on conflict")
 	output.AddFormatFlags(patchCmd)
 	rootCmd.AddCommand(patchCmd)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/nerveband/agent-to-bricks/internal/client"
 	"github.com/nerveband/agent-to-bricks/internal/config"
@@ -16,6 +17,11 @@ import (
 
 var (
 	cfgFile    string
+	siteName   string
+	siteErr    error
+	reqTimeout time.Duration
+	reqRetries int
+	noSnapshot bool
 	cfg        *config.Config
 	cliVersion string
 	cliCommit  string
@@ -51,6 +57,10 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ~/.agent-to-bricks/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&siteName, "site", "", "site profile to use (default: the config's default profile)")
+	rootCmd.PersistentFlags().DurationVar(&reqTimeout, "timeout", 0, "per-request timeout, e.g. 45s (default: config http.timeout or 30s)")
+	rootCmd.PersistentFlags().IntVar(&reqRetries, "retries", 0, "retries for idempotent requests (default: config http.retries or 3)")
+	rootCmd.PersistentFlags().BoolVar(&noSnapshot, "no-snapshot", false, "skip the safety snapshot and undo journal entry before page writes")
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
@@ -97,23 +107,64 @@ func initConfig() {
 	if err != nil {
 		// Config not found is OK for init/help commands
 		cfg = &config.Config{}
-		return
+	} else {
+		cfg = loaded
+	}
+
+	siteErr = nil
+	if siteName != "" {
+		if err := cfg.UseSite(siteName); err != nil {
+			siteErr = clierrors.ConfigError("CONFIG_UNKNOWN_SITE", err.Error(), "Run: bricks config sites list")
+		}
 	}
-	cfg = loaded
+}
+
+// siteLabel describes the active site profile for messages, e.g. ` (site "staging")`.
+func siteLabel() string {
+	if cfg == nil || cfg.ActiveSite() == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (site %q)", cfg.ActiveSite())
 }
 
 func newSiteClient() *client.Client {
 	c := client.New(cfg.Site.URL, cfg.Site.APIKey)
 	c.SetCLIVersion(cliVersion)
+
+	// Flags beat config.yaml, which beats the client defaults.
+	c.SetTimeout(requestTimeout())
+	policy := client.DefaultRetryPolicy()
+	if rootCmd.PersistentFlags().Changed("retries") {
+		policy.MaxRetries = reqRetries
+	} else if cfg.HTTP.Retries != nil {
+		policy.MaxRetries = *cfg.HTTP.Retries
+	}
+	c.SetRetryPolicy(policy)
 	return c
 }
 
+// requestTimeout is the per-request timeout from --timeout, else
+// http.timeout in config.yaml, else the client default. Commands fetching
+// from other hosts use it too.
+func requestTimeout() time.Duration {
+	if rootCmd.PersistentFlags().Changed("timeout") {
+		return reqTimeout
+	}
+	if cfg.HTTP.Timeout > 0 {
+		return cfg.HTTP.Timeout
+	}
+	return client.DefaultTimeout
+}
+
 func requireConfig() error {
+	if siteErr != nil {
+		return siteErr
+	}
 	if cfg.Site.URL == "" {
-		return clierrors.ConfigError("CONFIG_MISSING_URL", "site URL not configured", "Run: bricks config init")
+		return clierrors.ConfigError("CONFIG_MISSING_URL", "site URL not configured"+siteLabel(), "Run: bricks config init")
 	}
 	if cfg.Site.APIKey == "" {
-		return clierrors.ConfigError("CONFIG_MISSING_KEY", "API key not configured", "Run: bricks config set site.api_key <key>")
+		return clierrors.ConfigError("CONFIG_MISSING_KEY", "API key not configured"+siteLabel(), "Run: bricks config set site.api_key <key>")
 	}
 	return nil
 }
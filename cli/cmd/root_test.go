@@ -1,9 +1,48 @@
 package cmd
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestRootHasPersistentPreRun(t *testing.T) {
 	if rootCmd.PersistentPreRun == nil {
 		t.Error("expected PersistentPreRun to be set for update check")
 	}
 }
+
+func TestInitConfig_SiteFlag(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(path, []byte(`
+default: prod
+sites:
+  prod:
+    url: https://example.com
+    api_key: atb_prod
+  staging:
+    url: https://staging.example.com
+`), 0644)
+
+	oldCfgFile, oldSite := cfgFile, siteName
+	defer func() { cfgFile, siteName = oldCfgFile, oldSite; siteErr = nil }()
+	cfgFile = path
+
+	siteName = "staging"
+	initConfig()
+	if cfg.Site.URL != "https://staging.example.com" {
+		t.Fatalf("expected staging URL, got %s", cfg.Site.URL)
+	}
+	err := requireConfig()
+	if err == nil || !strings.Contains(err.Error(), `"staging"`) {
+		t.Errorf("expected missing key error naming the staging profile, got %v", err)
+	}
+
+	siteName = "nope"
+	initConfig()
+	if err := requireConfig(); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("expected unknown profile error, got %v", err)
+	}
+}
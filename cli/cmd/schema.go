@@ -110,10 +110,12 @@ func walkCommands(cmd *cobra.Command, prefix string, result map[string]bool) {
 			continue
 		}
 		name := strings.TrimSpace(prefix + " " + sub.Name())
+		// A group that also runs on its own (site snapshots) is a command too.
+		if !sub.HasSubCommands() || sub.Runnable() {
+			result[name] = true
+		}
 		if sub.HasSubCommands() {
 			walkCommands(sub, name, result)
-		} else {
-			result[name] = true
 		}
 	}
 }
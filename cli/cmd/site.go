@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strconv"
 	"strings"
 
+	"github.com/nerveband/agent-to-bricks/internal/client"
 	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
 	"github.com/nerveband/agent-to-bricks/internal/output"
 	"github.com/spf13/cobra"
@@ -111,17 +111,17 @@ var sitePullCmd = &cobra.Command{
 			return err
 		}
 
-		pageID, err := strconv.Atoi(args[0])
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
 		if err != nil {
-			return fmt.Errorf("invalid page ID: %s", args[0])
+			return err
 		}
 
-		c := newSiteClient()
-
 		resp, err := c.GetElements(pageID)
 		if err != nil {
 			return fmt.Errorf("failed to pull elements: %w", err)
 		}
+		rememberBase(pageID, resp.ContentHash, resp.Elements)
 
 		data, err := json.MarshalIndent(resp, "", "  ")
 		if err != nil {
@@ -141,11 +141,22 @@ var sitePullCmd = &cobra.Command{
 	},
 }
 
+var (
+	sitePushMerge bool
+	sitePushBase  string
+)
+
 var sitePushCmd = &cobra.Command{
 	Use:   "push <page-id> [file.json]",
 	Short: "Push elements from a JSON file or stdin (full replace)",
+	Long: `Replace all elements on a page with the given JSON.
+
+The file's contentHash is sent as If-Match. With --merge, a conflict does not
+abort the push: the edit is three-way merged with the current page, using the
+pulled version as the base, and pushed again if no edits overlap.`,
 	Example: `  bricks site push 1234 layout.json
-  cat layout.json | bricks site push 1234`,
+  cat layout.json | bricks site push 1234
+  bricks site push 1234 layout.json --merge`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		output.ResolveFormat(cmd)
@@ -153,9 +164,10 @@ var sitePushCmd = &cobra.Command{
 			return err
 		}
 
-		pageID, err := strconv.Atoi(args[0])
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
 		if err != nil {
-			return clierrors.ValidationError("INVALID_PAGE_ID", fmt.Sprintf("invalid page ID: %s", args[0]))
+			return err
 		}
 
 		var data []byte
@@ -176,12 +188,37 @@ var sitePushCmd = &cobra.Command{
 			return clierrors.ValidationError("INVALID_JSON", "failed to parse JSON input")
 		}
 
-		c := newSiteClient()
-
+		guard, err := guardWrite(cmd, c, pageID, "", false)
+		if err != nil {
+			return err
+		}
+		defer guard.release()
 		resp, err := c.ReplaceElements(pageID, payload.Elements, payload.ContentHash)
+		if err != nil && sitePushMerge && isConflict(err) {
+			base, berr := loadMergeBase(pageID, payload.ContentHash, sitePushBase)
+			if berr != nil {
+				return berr
+			}
+			res, remote, merr := mergeWithRemote(c, pageID, base, payload.Elements)
+			if merr != nil {
+				return merr
+			}
+			resp, err = c.ReplaceElements(pageID, res.Elements, remote.ContentHash)
+			if err != nil {
+				return fmt.Errorf("failed to push merged elements: %w", err)
+			}
+			guard.done(resp.ContentHash)
+			rememberBase(pageID, resp.ContentHash, res.Elements)
+			if output.IsJSON() {
+				return output.JSON(mergedResponse{resp, true})
+			}
+			fmt.Printf("Merged with remote changes and pushed %d elements (new hash: %s)\n", resp.Count, resp.ContentHash)
+			return nil
+		}
 		if err != nil {
 			return fmt.Errorf("failed to push elements: %w", err)
 		}
+		guard.done(resp.ContentHash)
 
 		if output.IsJSON() {
 			return output.JSON(resp)
@@ -191,14 +228,22 @@ var sitePushCmd = &cobra.Command{
 	},
 }
 
-var patchFile string
+var (
+	patchFile      string
+	sitePatchMerge bool
+	sitePatchBase  string
+)
 
 var sitePatchCmd = &cobra.Command{
 	Use:   "patch <page-id>",
 	Short: "Patch specific elements on a page",
-	Long:  "Patch elements using --file patches.json or stdin",
+	Long: `Patch elements using --file patches.json or stdin.
+
+With --merge, a content conflict is resolved by replaying the patches on the
+current page when they do not overlap with the remote changes.`,
 	Example: `  bricks site patch 1234 --file fixes.json
-  echo '{"patches":[...]}' | bricks site patch 1234`,
+  echo '{"patches":[...]}' | bricks site patch 1234
+  bricks site patch 1234 --file fixes.json --merge`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		output.ResolveFormat(cmd)
@@ -206,9 +251,10 @@ var sitePatchCmd = &cobra.Command{
 			return err
 		}
 
-		pageID, err := strconv.Atoi(args[0])
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
 		if err != nil {
-			return clierrors.ValidationError("INVALID_PAGE_ID", fmt.Sprintf("invalid page ID: %s", args[0]))
+			return err
 		}
 
 		var data []byte
@@ -229,12 +275,32 @@ var sitePatchCmd = &cobra.Command{
 			return clierrors.ValidationError("INVALID_JSON", "failed to parse patch JSON input")
 		}
 
-		c := newSiteClient()
-
+		guard, err := guardWrite(cmd, c, pageID, "", false)
+		if err != nil {
+			return err
+		}
+		defer guard.release()
 		resp, err := c.PatchElements(pageID, patches.Patches, patches.ContentHash)
+		if err != nil && sitePatchMerge && isConflict(err) {
+			base, berr := loadMergeBase(pageID, patches.ContentHash, sitePatchBase)
+			if berr != nil {
+				return berr
+			}
+			merged, merr := mergePatches(c, pageID, base, patches.Patches)
+			if merr != nil {
+				return merr
+			}
+			guard.done(merged.ContentHash)
+			if output.IsJSON() {
+				return output.JSON(mergedResponse{merged, true})
+			}
+			fmt.Printf("Merged with remote changes and patched elements (new hash: %s)\n", merged.ContentHash)
+			return nil
+		}
 		if err != nil {
 			return fmt.Errorf("failed to patch elements: %w", err)
 		}
+		guard.done(resp.ContentHash)
 
 		if output.IsJSON() {
 			return output.JSON(resp)
@@ -255,13 +321,12 @@ var siteSnapshotCmd = &cobra.Command{
 			return err
 		}
 
-		pageID, err := strconv.Atoi(args[0])
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
 		if err != nil {
-			return fmt.Errorf("invalid page ID: %s", args[0])
+			return err
 		}
 
-		c := newSiteClient()
-
 		resp, err := c.CreateSnapshot(pageID, snapshotLabel)
 		if err != nil {
 			return fmt.Errorf("failed to create snapshot: %w", err)
@@ -275,31 +340,42 @@ var siteSnapshotCmd = &cobra.Command{
 var siteSnapshotsListCmd = &cobra.Command{
 	Use:   "snapshots <page-id>",
 	Short: "List snapshots for a page",
-	Args:  cobra.ExactArgs(1),
+	Long: `List a page's snapshots, oldest first. The subcommands show, compare,
+export, prune and restore snapshots.`,
+	Example: `  bricks site snapshots 1234
+  bricks site snapshots 1234 --format json`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
 		if err := requireConfig(); err != nil {
 			return err
 		}
 
-		pageID, err := strconv.Atoi(args[0])
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
 		if err != nil {
-			return fmt.Errorf("invalid page ID: %s", args[0])
+			return err
 		}
 
-		c := newSiteClient()
-
 		resp, err := c.ListSnapshots(pageID)
 		if err != nil {
 			return fmt.Errorf("failed to list snapshots: %w", err)
 		}
 
+		if output.IsJSON() {
+			if resp.Snapshots == nil {
+				resp.Snapshots = []client.Snapshot{}
+			}
+			return output.JSON(resp)
+		}
+
 		if len(resp.Snapshots) == 0 {
 			fmt.Println("No snapshots found.")
 			return nil
 		}
 
 		for _, s := range resp.Snapshots {
-			fmt.Printf("  %s  %s  (%d elements, hash: %s)\n", s.ID, s.Label, s.Count, s.ContentHash)
+			fmt.Printf("  %s  %s  %s  (%d elements, hash: %s)\n", s.SnapshotID, s.Timestamp, s.Label, s.ElementCount, s.ContentHash)
 		}
 		return nil
 	},
@@ -314,13 +390,12 @@ var siteRollbackCmd = &cobra.Command{
 			return err
 		}
 
-		pageID, err := strconv.Atoi(args[0])
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
 		if err != nil {
-			return fmt.Errorf("invalid page ID: %s", args[0])
+			return err
 		}
 
-		c := newSiteClient()
-
 		snapshotID := ""
 		if len(args) > 1 {
 			snapshotID = args[1]
@@ -333,15 +408,15 @@ var siteRollbackCmd = &cobra.Command{
 			if len(list.Snapshots) == 0 {
 				return fmt.Errorf("no snapshots found for page %d", pageID)
 			}
-			snapshotID = list.Snapshots[0].ID
+			snapshotID = latestSnapshot(list.Snapshots).SnapshotID
 		}
 
-		resp, err := c.Rollback(pageID, snapshotID)
+		resp, err := restoreSnapshot(cmd, c, pageID, snapshotID, pageID)
 		if err != nil {
-			return fmt.Errorf("failed to rollback: %w", err)
+			return err
 		}
 
-		fmt.Printf("Rolled back to %s (new hash: %s)\n", resp.Restored, resp.ContentHash)
+		fmt.Printf("Rolled back to %s (new hash: %s)\n", snapshotID, resp.ContentHash)
 		return nil
 	},
 }
@@ -351,8 +426,13 @@ func init() {
 	output.AddFormatFlags(siteFrameworksCmd)
 	output.AddFormatFlags(sitePushCmd)
 	output.AddFormatFlags(sitePatchCmd)
+	output.AddFormatFlags(siteSnapshotsListCmd)
 	sitePullCmd.Flags().StringVarP(&pullOutput, "output", "o", "", "output file path (default: stdout)")
 	sitePatchCmd.Flags().StringVarP(&patchFile, "file", "f", "", "patch file (JSON)")
+	sitePushCmd.Flags().BoolVar(&sitePushMerge, "merge", false, "three-way merge with remote changes on conflict")
+	sitePushCmd.Flags().StringVar(&sitePushBase, "base", "", "originally pulled file to merge against (default: pull cache)")
+	sitePatchCmd.Flags().BoolVar(&sitePatchMerge, "merge", false, "three-way merge with remote changes on conflict")
+	sitePatchCmd.Flags().StringVar(&sitePatchBase, "base", "", "originally pulled file to merge against (default: pull cache)")
 	siteSnapshotCmd.Flags().StringVarP(&snapshotLabel, "label", "l", "", "snapshot label")
 
 	siteCmd.AddCommand(siteInfoCmd)
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nerveband/agent-to-bricks/internal/batch"
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	batchFile   string
+	batchDryRun bool
+)
+
+// batchReport is the per-operation outcome printed by `site batch`.
+type batchReport struct {
+	PageID      int                 `json:"pageId"`
+	DryRun      bool                `json:"dryRun,omitempty"`
+	Success     bool                `json:"success"`
+	ContentHash string              `json:"contentHash,omitempty"`
+	Count       int                 `json:"count"`
+	Operations  []batchOpReport     `json:"operations"`
+	Error       *clierrors.CLIError `json:"error,omitempty"`
+}
+
+type batchOpReport struct {
+	Index int    `json:"index"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	client.BatchOpResult
+}
+
+var siteBatchCmd = &cobra.Command{
+	Use:   "batch <page-id>",
+	Short: "Apply several element operations in one atomic write",
+	Long: `Run append, insert, patch, move and delete operations against a page in a
+single request. The whole batch is guarded by one content hash and either
+every operation is applied or none is.
+
+Input is {"operations":[...], "contentHash":"..."} or a bare array of
+operations, from --file or stdin. Without contentHash the current hash is
+fetched first.
+
+Operations:
+  {"op":"append", "elements":[...], "parentId":"abc123"}
+  {"op":"insert", "elements":[...], "parentId":"abc123", "index":0}
+  {"op":"patch",  "patches":[{"id":"abc123","settings":{...}}]}
+  {"op":"move",   "id":"abc123", "parentId":"def456", "index":2}
+  {"op":"delete", "ids":["abc123"]}
+
+An empty parentId means the page root. --dry-run applies the operations to a
+local copy of the page and reports the result without writing.`,
+	Example: `  bricks site batch 1234 --file ops.json
+  cat ops.json | bricks site batch 1234 --dry-run --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		if batchFile != "" {
+			data, err = os.ReadFile(batchFile)
+		} else {
+			data, err = io.ReadAll(os.Stdin)
+		}
+		if err != nil {
+			return clierrors.ValidationError("INVALID_INPUT", fmt.Sprintf("failed to read input: %v", err))
+		}
+		ops, hash, err := parseBatchInput(data)
+		if err != nil {
+			return err
+		}
+
+		report := batchReport{PageID: pageID, DryRun: batchDryRun}
+
+		if batchDryRun || hash == "" {
+			current, err := c.GetElements(pageID)
+			if err != nil {
+				return fmt.Errorf("failed to read page: %w", err)
+			}
+			if hash == "" {
+				hash = current.ContentHash
+			}
+			if batchDryRun {
+				result, results, err := batch.Apply(current.Elements, ops)
+				report.ContentHash = current.ContentHash
+				report.Operations = opReports(ops, results, err)
+				if err != nil {
+					report.Error = clierrors.ValidationError("BATCH_OP_FAILED", err.Error())
+					if perr := printBatchReport(report); perr != nil {
+						return perr
+					}
+					return report.Error
+				}
+				report.Success = true
+				report.Count = len(result)
+				return printBatchReport(report)
+			}
+		}
+
+		guard, err := guardWrite(cmd, c, pageID, "", false)
+		if err != nil {
+			return err
+		}
+		defer guard.release()
+		resp, err := c.BatchElements(pageID, ops, hash)
+		var opErr *client.BatchOpError
+		if stderrors.As(err, &opErr) && opErr.Index >= 0 && opErr.Index < len(ops) {
+			report.Operations = opReports(ops, nil, &batch.OpError{Index: opErr.Index, Op: ops[opErr.Index].Op, Err: stderrors.New(opErr.Message)})
+			report.Error = clierrors.ValidationError("BATCH_OP_FAILED", opErr.Error())
+			if perr := printBatchReport(report); perr != nil {
+				return perr
+			}
+			return report.Error
+		}
+		if err != nil {
+			return fmt.Errorf("batch failed: %w", err)
+		}
+		guard.done(resp.ContentHash)
+		report.Success = resp.Success
+		report.ContentHash = resp.ContentHash
+		report.Count = resp.Count
+		report.Operations = opReports(ops, resp.Operations, nil)
+		return printBatchReport(report)
+	},
+}
+
+func parseBatchInput(data []byte) ([]client.BatchOp, string, error) {
+	var wrapped struct {
+		Operations  []client.BatchOp `json:"operations"`
+		ContentHash string           `json:"contentHash"`
+	}
+	var ops []client.BatchOp
+	hash := ""
+	if err := json.Unmarshal(data, &wrapped); err == nil {
+		ops, hash = wrapped.Operations, wrapped.ContentHash
+	} else if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, "", clierrors.ValidationError("INVALID_JSON", "failed to parse batch operations")
+	}
+	if len(ops) == 0 {
+		return nil, "", clierrors.ValidationError("INVALID_INPUT", "no operations provided")
+	}
+	return ops, hash, nil
+}
+
+// opReports lines up operations with their results. Operations after a
+// failure are reported as not applied.
+func opReports(ops []client.BatchOp, results []client.BatchOpResult, err error) []batchOpReport {
+	failed := -1
+	var opErr *batch.OpError
+	if stderrors.As(err, &opErr) {
+		failed = opErr.Index
+	}
+	reports := make([]batchOpReport, len(ops))
+	for i, op := range ops {
+		r := batchOpReport{Index: i, BatchOpResult: client.BatchOpResult{Op: op.Op}}
+		switch {
+		case failed == i:
+			r.Error = opErr.Err.Error()
+		case failed >= 0:
+			r.Error = "not applied"
+		case i < len(results):
+			r.OK = true
+			r.BatchOpResult = results[i]
+		default:
+			r.OK = err == nil
+		}
+		reports[i] = r
+	}
+	return reports
+}
+
+func printBatchReport(r batchReport) error {
+	if output.IsJSON() {
+		return output.JSON(r)
+	}
+	for _, op := range r.Operations {
+		status := "ok"
+		if !op.OK {
+			status = "FAILED: " + op.Error
+		}
+		detail := ""
+		switch {
+		case op.Added > 0:
+			detail = fmt.Sprintf("%d added", op.Added)
+		case op.Patched > 0:
+			detail = fmt.Sprintf("%d patched", op.Patched)
+		case op.Moved > 0:
+			detail = fmt.Sprintf("%d moved", op.Moved)
+		case op.Deleted > 0:
+			detail = fmt.Sprintf("%d deleted", op.Deleted)
+		}
+		fmt.Printf("  #%d %-7s %s %s\n", op.Index, op.Op, status, detail)
+	}
+	switch {
+	case !r.Success:
+	case r.DryRun:
+		fmt.Printf("Dry run: %d operations would leave %d elements (nothing written)\n", len(r.Operations), r.Count)
+	default:
+		fmt.Printf("Applied %d operations: %d elements (new hash: %s)\n", len(r.Operations), r.Count, r.ContentHash)
+	}
+	return nil
+}
+
+func init() {
+	siteBatchCmd.Flags().StringVarP(&batchFile, "file", "f", "", "operations file (JSON)")
+	siteBatchCmd.Flags().BoolVar(&batchDryRun, "dry-run", false, "simulate against the current page without writing")
+	output.AddFormatFlags(siteBatchCmd)
+	siteCmd.AddCommand(siteBatchCmd)
+}
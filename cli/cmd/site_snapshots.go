@@ -0,0 +1,410 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// snapshotArchiveFormat identifies files written by `site snapshots export`.
+const snapshotArchiveFormat = "agent-to-bricks/snapshots"
+
+var (
+	snapExportOutput string
+	pruneKeep        int
+	pruneOlderThan   string
+	pruneDryRun      bool
+	restoreToPage    string
+)
+
+// snapshotArchive is the portable JSON written by `site snapshots export`.
+type snapshotArchive struct {
+	Format     string                  `json:"format"`
+	Version    int                     `json:"version"`
+	Site       string                  `json:"site"`
+	PageID     int                     `json:"pageId"`
+	ExportedAt string                  `json:"exportedAt"`
+	Snapshots  []client.SnapshotDetail `json:"snapshots"`
+}
+
+var siteSnapshotsShowCmd = &cobra.Command{
+	Use:   "show <page-id> <snapshot-id>",
+	Short: "Show a snapshot and its elements",
+	Example: `  bricks site snapshots show 1234 snap_abc123
+  bricks site snapshots show 1234 snap_abc123 --format json > before.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
+		if err != nil {
+			return err
+		}
+
+		snap, err := c.GetSnapshot(pageID, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to get snapshot: %w", err)
+		}
+		if output.IsJSON() {
+			return output.JSON(snap)
+		}
+
+		fmt.Printf("Snapshot: %s\n", snap.SnapshotID)
+		if snap.Label != "" {
+			fmt.Printf("Label:    %s\n", snap.Label)
+		}
+		fmt.Printf("Taken:    %s\n", snap.Timestamp)
+		fmt.Printf("Hash:     %s\n\n", snap.ContentHash)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTYPE\tLABEL\tPARENT")
+		for _, el := range snap.Elements {
+			id, _ := el["id"].(string)
+			name, _ := el["name"].(string)
+			label, _ := el["label"].(string)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%v\n", id, name, label, el["parent"])
+		}
+		w.Flush()
+		fmt.Fprintf(os.Stderr, "\n%d elements\n", len(snap.Elements))
+		return nil
+	},
+}
+
+var siteSnapshotsDiffCmd = &cobra.Command{
+	Use:   "diff <page-id> <snapshot-id> [other-snapshot-id]",
+	Short: "Compare a snapshot with another snapshot or the live page",
+	Long: `Compare a snapshot with the current page, or with a second snapshot of the
+same page. Output formats match 'bricks diff': a readable summary, --format
+json with a "patches" array, or --format patch.`,
+	Example: `  bricks site snapshots diff 1234 snap_abc123
+  bricks site snapshots diff 1234 snap_abc123 snap_def456 --format json`,
+	Args: cobra.RangeArgs(2, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
+		if err != nil {
+			return err
+		}
+
+		from, err := c.GetSnapshot(pageID, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to get snapshot %s: %w", args[1], err)
+		}
+
+		if len(args) == 3 {
+			to, err := c.GetSnapshot(pageID, args[2])
+			if err != nil {
+				return fmt.Errorf("failed to get snapshot %s: %w", args[2], err)
+			}
+			return printDiff(from.Elements, to.Elements, "snapshot "+args[1], "snapshot "+args[2], "")
+		}
+
+		current, err := c.GetElements(pageID)
+		if err != nil {
+			return fmt.Errorf("failed to read page: %w", err)
+		}
+		return printDiff(from.Elements, current.Elements, "snapshot "+args[1], fmt.Sprintf("page %d", pageID), current.ContentHash)
+	},
+}
+
+var siteSnapshotsExportCmd = &cobra.Command{
+	Use:   "export <page-id> [snapshot-id...]",
+	Short: "Export snapshots with their elements to a JSON archive",
+	Long: `Write snapshots, including their elements, to a portable JSON archive.
+Without snapshot IDs every snapshot of the page is exported.`,
+	Example: `  bricks site snapshots export 1234 -o page-1234-snapshots.json
+  bricks site snapshots export 1234 snap_abc123 > snap.json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
+		if err != nil {
+			return err
+		}
+
+		ids := args[1:]
+		if len(ids) == 0 {
+			list, err := c.ListSnapshots(pageID)
+			if err != nil {
+				return fmt.Errorf("failed to list snapshots: %w", err)
+			}
+			for _, s := range list.Snapshots {
+				ids = append(ids, s.SnapshotID)
+			}
+		}
+
+		archive := snapshotArchive{
+			Format:     snapshotArchiveFormat,
+			Version:    1,
+			Site:       cfg.Site.URL,
+			PageID:     pageID,
+			ExportedAt: time.Now().UTC().Format(time.RFC3339),
+			Snapshots:  []client.SnapshotDetail{},
+		}
+		for _, id := range ids {
+			snap, err := c.GetSnapshot(pageID, id)
+			if err != nil {
+				return fmt.Errorf("failed to get snapshot %s: %w", id, err)
+			}
+			archive.Snapshots = append(archive.Snapshots, *snap)
+		}
+
+		data, err := json.MarshalIndent(archive, "", "  ")
+		if err != nil {
+			return err
+		}
+		if snapExportOutput == "" {
+			fmt.Println(string(data))
+			return nil
+		}
+		if err := os.WriteFile(snapExportOutput, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d snapshots of page %d to %s\n", len(archive.Snapshots), pageID, snapExportOutput)
+		return nil
+	},
+}
+
+// pruneResult is the outcome printed by `site snapshots prune`.
+type pruneResult struct {
+	PageID  int      `json:"pageId"`
+	DryRun  bool     `json:"dryRun,omitempty"`
+	Deleted []string `json:"deleted"`
+	Kept    []string `json:"kept"`
+}
+
+var siteSnapshotsPruneCmd = &cobra.Command{
+	Use:   "prune <page-id>",
+	Short: "Delete old snapshots of a page",
+	Long: `Delete snapshots beyond the newest --keep, or older than --older-than.
+With both flags, a snapshot is deleted only when it is outside the newest
+--keep and older than --older-than. Ages accept Go durations plus d (days)
+and w (weeks), e.g. 36h, 30d, 2w.`,
+	Example: `  bricks site snapshots prune 1234 --keep 3
+  bricks site snapshots prune 1234 --older-than 30d --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+		keepSet := cmd.Flags().Changed("keep")
+		if !keepSet && pruneOlderThan == "" {
+			return clierrors.ValidationError("INVALID_ARGS", "prune needs --keep, --older-than or both")
+		}
+		if pruneKeep < 0 {
+			return clierrors.ValidationError("INVALID_ARGS", "--keep must be zero or more")
+		}
+		var cutoff time.Time
+		if pruneOlderThan != "" {
+			age, err := parseAge(pruneOlderThan)
+			if err != nil {
+				return clierrors.ValidationError("INVALID_ARGS", fmt.Sprintf("invalid --older-than %q: %v", pruneOlderThan, err))
+			}
+			cutoff = time.Now().Add(-age)
+		}
+
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
+		if err != nil {
+			return err
+		}
+
+		list, err := c.ListSnapshots(pageID)
+		if err != nil {
+			return fmt.Errorf("failed to list snapshots: %w", err)
+		}
+
+		keep := -1
+		if keepSet {
+			keep = pruneKeep
+		}
+		deleted, kept := selectPrunable(list.Snapshots, keep, cutoff)
+		result := pruneResult{PageID: pageID, DryRun: pruneDryRun, Deleted: []string{}, Kept: []string{}}
+		for _, s := range kept {
+			result.Kept = append(result.Kept, s.SnapshotID)
+		}
+		for _, s := range deleted {
+			if !pruneDryRun {
+				if _, err := c.DeleteSnapshot(pageID, s.SnapshotID); err != nil {
+					return fmt.Errorf("failed to delete snapshot %s: %w", s.SnapshotID, err)
+				}
+			}
+			result.Deleted = append(result.Deleted, s.SnapshotID)
+		}
+
+		if output.IsJSON() {
+			return output.JSON(result)
+		}
+		verb := "Deleted"
+		if pruneDryRun {
+			verb = "Would delete"
+		}
+		for _, s := range deleted {
+			fmt.Printf("  - %s  %s  %s\n", s.SnapshotID, s.Timestamp, s.Label)
+		}
+		fmt.Printf("%s %d snapshots of page %d, %d kept\n", verb, len(result.Deleted), pageID, len(result.Kept))
+		return nil
+	},
+}
+
+var siteSnapshotsRestoreCmd = &cobra.Command{
+	Use:   "restore <page-id> <snapshot-id>",
+	Short: "Restore a snapshot, optionally onto a different page",
+	Long: `Restore a snapshot's elements. Without --to-page this is 'site rollback'.
+With --to-page the elements replace the content of another page, so a page can
+be cloned from any snapshot. Either way the page written is snapshotted first,
+so 'bricks undo' reverts the restore.`,
+	Example: `  bricks site snapshots restore 1234 snap_abc123
+  bricks site snapshots restore 1234 snap_abc123 --to-page staging-home`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
+		if err != nil {
+			return err
+		}
+		targetID := pageID
+		if restoreToPage != "" {
+			if targetID, err = resolvePage(c, restoreToPage); err != nil {
+				return err
+			}
+		}
+
+		result := map[string]interface{}{"pageId": pageID, "snapshotId": args[1], "toPage": targetID}
+		resp, err := restoreSnapshot(cmd, c, pageID, args[1], targetID)
+		if err != nil {
+			return err
+		}
+		result["contentHash"], result["count"] = resp.ContentHash, resp.Count
+
+		if output.IsJSON() {
+			return output.JSON(result)
+		}
+		fmt.Printf("Restored %s from page %d onto page %d (%v elements, new hash: %v)\n",
+			args[1], pageID, targetID, result["count"], result["contentHash"])
+		return nil
+	},
+}
+
+// restoreSnapshot writes a snapshot of page fromID onto page toID through
+// guardWrite, so the restore is snapshotted and journaled for 'bricks undo'.
+// The snapshot is read before the safety snapshot is taken: the plugin keeps
+// only the newest snapshots per page, and the new one could push it out.
+func restoreSnapshot(cmd *cobra.Command, c *client.Client, fromID int, snapshotID string, toID int) (*client.MutationResponse, error) {
+	snap, err := c.GetSnapshot(fromID, snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	current, err := c.GetElements(toID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page %d: %w", toID, err)
+	}
+	guard, err := guardWrite(cmd, c, toID, "", false)
+	if err != nil {
+		return nil, err
+	}
+	defer guard.release()
+	resp, err := c.ReplaceElements(toID, snap.Elements, current.ContentHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore onto page %d: %w", toID, err)
+	}
+	guard.done(resp.ContentHash)
+	return resp, nil
+}
+
+// latestSnapshot returns the most recently taken snapshot. The plugin lists
+// snapshots oldest first, so ties go to the later entry.
+func latestSnapshot(snaps []client.Snapshot) client.Snapshot {
+	latest := snaps[len(snaps)-1]
+	latestAt, _ := latest.CreatedAt()
+	for _, s := range snaps {
+		if at, ok := s.CreatedAt(); ok && at.After(latestAt) {
+			latest, latestAt = s, at
+		}
+	}
+	return latest
+}
+
+// selectPrunable splits snapshots into those to delete and those to keep.
+// keep < 0 means no count limit; a zero cutoff means no age limit. The
+// newest keep snapshots always survive.
+func selectPrunable(snaps []client.Snapshot, keep int, cutoff time.Time) (deleted, kept []client.Snapshot) {
+	for i, s := range snaps {
+		newer := len(snaps) - 1 - i
+		protected := keep >= 0 && newer < keep
+		old := true
+		if !cutoff.IsZero() {
+			at, ok := s.CreatedAt()
+			old = ok && at.Before(cutoff)
+		} else if keep < 0 {
+			old = false
+		}
+		if !protected && old {
+			deleted = append(deleted, s)
+		} else {
+			kept = append(kept, s)
+		}
+	}
+	return deleted, kept
+}
+
+// parseAge parses a Go duration, or a whole number of days ("30d") or weeks
+// ("2w").
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("expected a number before %q", suffix)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+func init() {
+	output.AddFormatFlags(siteSnapshotsShowCmd)
+	output.AddFormatFlags(siteSnapshotsDiffCmd)
+	output.AddFormatFlags(siteSnapshotsPruneCmd)
+	output.AddFormatFlags(siteSnapshotsRestoreCmd)
+	siteSnapshotsExportCmd.Flags().StringVarP(&snapExportOutput, "output", "o", "", "archive file path (default: stdout)")
+	siteSnapshotsPruneCmd.Flags().IntVar(&pruneKeep, "keep", 0, "number of newest snapshots to keep")
+	siteSnapshotsPruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "", "only delete snapshots older than this age (e.g. 30d, 12h)")
+	siteSnapshotsPruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "list what would be deleted without deleting")
+	siteSnapshotsRestoreCmd.Flags().StringVar(&restoreToPage, "to-page", "", "page to restore onto (default: the snapshot's own page)")
+
+	siteSnapshotsListCmd.AddCommand(siteSnapshotsShowCmd)
+	siteSnapshotsListCmd.AddCommand(siteSnapshotsDiffCmd)
+	siteSnapshotsListCmd.AddCommand(siteSnapshotsExportCmd)
+	siteSnapshotsListCmd.AddCommand(siteSnapshotsPruneCmd)
+	siteSnapshotsListCmd.AddCommand(siteSnapshotsRestoreCmd)
+}
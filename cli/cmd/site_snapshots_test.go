@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	"github.com/nerveband/agent-to-bricks/internal/config"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+)
+
+func snapAt(id string, age time.Duration) client.Snapshot {
+	return client.Snapshot{SnapshotID: id, TimestampGMT: time.Now().UTC().Add(-age).Format("2006-01-02 15:04:05")}
+}
+
+func TestSelectPrunable(t *testing.T) {
+	day := 24 * time.Hour
+	snaps := []client.Snapshot{snapAt("s1", 40*day), snapAt("s2", 35*day), snapAt("s3", 10*day), snapAt("s4", time.Hour)}
+	ids := func(ss []client.Snapshot) []string {
+		var out []string
+		for _, s := range ss {
+			out = append(out, s.SnapshotID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		keep   int
+		cutoff time.Time
+		want   []string
+	}{
+		{"keep only", 2, time.Time{}, []string{"s1", "s2"}},
+		{"age only", -1, time.Now().Add(-30 * day), []string{"s1", "s2"}},
+		{"keep protects old", 3, time.Now().Add(-30 * day), []string{"s1"}},
+		{"keep zero", 0, time.Time{}, []string{"s1", "s2", "s3", "s4"}},
+	}
+	for _, tt := range tests {
+		deleted, kept := selectPrunable(snaps, tt.keep, tt.cutoff)
+		got := ids(deleted)
+		if len(got) != len(tt.want) || len(deleted)+len(kept) != len(snaps) {
+			t.Errorf("%s: expected %v deleted, got %v", tt.name, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: expected %v deleted, got %v", tt.name, tt.want, got)
+				break
+			}
+		}
+	}
+
+	if latest := latestSnapshot([]client.Snapshot{snapAt("new", time.Hour), snapAt("old", day)}); latest.SnapshotID != "new" {
+		t.Errorf("expected newest snapshot regardless of order, got %s", latest.SnapshotID)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{"30d": 30 * 24 * time.Hour, "2w": 14 * 24 * time.Hour, "36h": 36 * time.Hour} {
+		got, err := parseAge(in)
+		if err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseAge("xd"); err == nil {
+		t.Error("expected an error for xd")
+	}
+}
+
+func TestSnapshotsPrune_DeletesOutsideKeep(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/wp-json/agent-bricks/v1/pages/9/snapshots":
+			json.NewEncoder(w).Encode(map[string]interface{}{"snapshots": []client.Snapshot{
+				{SnapshotID: "snap_a", Timestamp: "2020-01-01 00:00:00"},
+				{SnapshotID: "snap_b", Timestamp: "2020-01-02 00:00:00"},
+				{SnapshotID: "snap_c", Timestamp: "2020-01-03 00:00:00"},
+			}})
+		case r.Method == "DELETE":
+			deleted = append(deleted, r.URL.Path)
+			json.NewEncoder(w).Encode(map[string]interface{}{"deleted": "x", "remaining": 2})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	siteSnapshotsPruneCmd.Flags().Set("keep", "1")
+	defer func() {
+		pruneKeep = 0
+		siteSnapshotsPruneCmd.Flags().Lookup("keep").Changed = false
+	}()
+
+	if err := siteSnapshotsPruneCmd.RunE(siteSnapshotsPruneCmd, []string{"9"}); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	want := []string{
+		"/wp-json/agent-bricks/v1/pages/9/snapshots/snap_a",
+		"/wp-json/agent-bricks/v1/pages/9/snapshots/snap_b",
+	}
+	if len(deleted) != len(want) || deleted[0] != want[0] || deleted[1] != want[1] {
+		t.Errorf("expected deletes %v, got %v", want, deleted)
+	}
+}
+
+func TestSnapshotsRestore_ToOtherPage(t *testing.T) {
+	var calls []string
+	var pushed []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /wp-json/agent-bricks/v1/pages/5/snapshots/snap_x":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"snapshotId": "snap_x",
+				"elements":   []map[string]interface{}{{"id": "abc123", "name": "section", "parent": "0"}},
+			})
+		case "GET /wp-json/agent-bricks/v1/pages/6/elements":
+			json.NewEncoder(w).Encode(map[string]interface{}{"elements": []interface{}{}, "contentHash": "h6"})
+		case "POST /wp-json/agent-bricks/v1/pages/6/snapshots":
+			json.NewEncoder(w).Encode(map[string]interface{}{"snapshotId": "snap_6", "contentHash": "h6"})
+		case "PUT /wp-json/agent-bricks/v1/pages/6/elements":
+			if r.Header.Get("If-Match") != "h6" {
+				t.Errorf("expected If-Match h6, got %q", r.Header.Get("If-Match"))
+			}
+			var body struct {
+				Elements []interface{} `json:"elements"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			pushed = body.Elements
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": "h7", "count": 1})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	t.Setenv("HOME", t.TempDir())
+	restoreToPage = "6"
+	defer func() { restoreToPage = "" }()
+
+	if err := siteSnapshotsRestoreCmd.RunE(siteSnapshotsRestoreCmd, []string{"5", "snap_x"}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if len(calls) != 4 || len(pushed) != 1 {
+		t.Errorf("expected snapshot read, target read, safety snapshot and replace; got %v with %d elements", calls, len(pushed))
+	}
+}
+
+func TestSiteRollbackRecordsUndo(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /wp-json/agent-bricks/v1/pages/5/snapshots":
+			json.NewEncoder(w).Encode(map[string]interface{}{"snapshots": []map[string]interface{}{{"snapshotId": "snap_x"}}})
+		case "GET /wp-json/agent-bricks/v1/pages/5/snapshots/snap_x":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"snapshotId": "snap_x",
+				"elements":   []map[string]interface{}{{"id": "abc123", "name": "section", "parent": "0"}},
+			})
+		case "GET /wp-json/agent-bricks/v1/pages/5/elements":
+			json.NewEncoder(w).Encode(map[string]interface{}{"elements": []interface{}{}, "contentHash": "h5"})
+		case "POST /wp-json/agent-bricks/v1/pages/5/snapshots":
+			json.NewEncoder(w).Encode(map[string]interface{}{"snapshotId": "snap_5", "contentHash": "h5"})
+		case "PUT /wp-json/agent-bricks/v1/pages/5/elements":
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": "h6", "count": 1})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	t.Setenv("HOME", t.TempDir())
+	devNull, _ := os.Open(os.DevNull)
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout; devNull.Close() }()
+
+	for _, run := range []func() error{
+		func() error { return siteRollbackCmd.RunE(siteRollbackCmd, []string{"5"}) },
+		func() error { return siteSnapshotsRestoreCmd.RunE(siteSnapshotsRestoreCmd, []string{"5", "snap_x"}) },
+	} {
+		if err := run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ := undoJournal().Entries()
+	if len(entries) != 2 || entries[0].SnapshotID != "snap_5" || entries[1].NewHash != "h6" {
+		t.Errorf("expected both rollbacks journaled, got %+v", entries)
+	}
+	for _, call := range calls {
+		if strings.HasSuffix(call, "/rollback") {
+			t.Errorf("expected the snapshot written through the guarded replace, got %s", call)
+		}
+	}
+}
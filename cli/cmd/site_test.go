@@ -3,6 +3,7 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -11,10 +12,13 @@ import (
 	"testing"
 
 	"github.com/nerveband/agent-to-bricks/internal/config"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/merge"
 	"github.com/nerveband/agent-to-bricks/internal/output"
 )
 
 func TestSitePush_JSONOutput(t *testing.T) {
+	skipSafetySnapshots(t)
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "PUT" {
 			t.Fatalf("expected PUT, got %s", r.Method)
@@ -84,6 +88,7 @@ func TestSitePush_JSONOutput(t *testing.T) {
 }
 
 func TestSitePatch_JSONOutput(t *testing.T) {
+	skipSafetySnapshots(t)
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "PATCH" {
 			t.Fatalf("expected PATCH, got %s", r.Method)
@@ -158,3 +163,170 @@ func TestSitePatch_JSONOutput(t *testing.T) {
 		t.Fatalf("expected contentHash=patchedhash, got %v", result["contentHash"])
 	}
 }
+
+func TestSitePush_MergeOnConflict(t *testing.T) {
+	skipSafetySnapshots(t)
+	var puts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"elements": []map[string]interface{}{
+					{"id": "e1", "name": "heading", "settings": map[string]interface{}{"text": "Title", "tag": "h2"}},
+				},
+				"contentHash": "remotehash",
+				"count":       1,
+			})
+		case "PUT":
+			puts++
+			if r.Header.Get("If-Match") == "basehash" {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"error":"Content has changed"}`))
+				return
+			}
+			if r.Header.Get("If-Match") != "remotehash" {
+				t.Fatalf("expected retry against remotehash, got %s", r.Header.Get("If-Match"))
+			}
+			var body struct {
+				Elements []map[string]interface{} `json:"elements"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			settings := body.Elements[0]["settings"].(map[string]interface{})
+			if settings["text"] != "New title" || settings["tag"] != "h2" {
+				t.Errorf("expected merged settings, got %v", settings)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": "mergedhash", "count": 1})
+		}
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	sitePushMerge = true
+	defer func() { sitePushMerge, sitePushBase = false, "" }()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	sitePushBase = filepath.Join(tmpDir, "base.json")
+	os.WriteFile(sitePushBase, []byte(`{"elements":[{"id":"e1","name":"heading","settings":{"text":"Title","tag":"h1"}}],"contentHash":"basehash"}`), 0644)
+	inputFile := filepath.Join(tmpDir, "edited.json")
+	os.WriteFile(inputFile, []byte(`{"elements":[{"id":"e1","name":"heading","settings":{"text":"New title","tag":"h1"}}],"contentHash":"basehash"}`), 0644)
+
+	oldStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	err := sitePushCmd.RunE(sitePushCmd, []string{"2005", inputFile})
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("RunE returned error: %v", err)
+	}
+	if puts != 2 {
+		t.Errorf("expected 2 PUT requests, got %d", puts)
+	}
+}
+
+func TestSitePush_MergeConflictJSON(t *testing.T) {
+	skipSafetySnapshots(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"elements": []map[string]interface{}{
+					{"id": "e1", "name": "heading", "settings": map[string]interface{}{"text": "Remote title"}},
+				},
+				"contentHash": "remotehash",
+				"count":       1,
+			})
+		case "PUT":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error":"Content has changed"}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	_ = sitePushCmd.Flags().Set("format", "json")
+	defer sitePushCmd.Flags().Set("format", "")
+	sitePushMerge = true
+	defer func() { sitePushMerge, sitePushBase = false, "" }()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	sitePushBase = filepath.Join(tmpDir, "base.json")
+	os.WriteFile(sitePushBase, []byte(`{"elements":[{"id":"e1","name":"heading","settings":{"text":"Title"}}],"contentHash":"basehash"}`), 0644)
+	inputFile := filepath.Join(tmpDir, "edited.json")
+	os.WriteFile(inputFile, []byte(`{"elements":[{"id":"e1","name":"heading","settings":{"text":"Local title"}}],"contentHash":"basehash"}`), 0644)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := sitePushCmd.RunE(sitePushCmd, []string{"2005", inputFile})
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	var cliErr *clierrors.CLIError
+	if !errors.As(err, &cliErr) || cliErr.Code != "CONTENT_CONFLICT" {
+		t.Fatalf("expected CONTENT_CONFLICT, got %v", err)
+	}
+	details, _ := cliErr.Details.(map[string]interface{})
+	if conflicts, ok := details["conflicts"].([]merge.Conflict); !ok || len(conflicts) != 1 {
+		t.Errorf("expected one conflict in the error details, got %v", cliErr.Details)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got %s", buf.String())
+	}
+}
+
+func TestSiteBatch_ReportsFailedOp(t *testing.T) {
+	skipSafetySnapshots(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Fatalf("expected only the batch request, got %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"Batch op 1: element 'gone01' not found.","failedOp":1}`))
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	_ = siteBatchCmd.Flags().Set("format", "json")
+	defer siteBatchCmd.Flags().Set("format", "")
+	batchFile = filepath.Join(t.TempDir(), "ops.json")
+	defer func() { batchFile = "" }()
+	os.WriteFile(batchFile, []byte(`{"contentHash":"h1","operations":[
+		{"op":"delete","ids":["a00001"]},
+		{"op":"move","id":"gone01","parentId":""},
+		{"op":"delete","ids":["b00001"]}
+	]}`), 0644)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := siteBatchCmd.RunE(siteBatchCmd, []string{"2005"})
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	var cliErr *clierrors.CLIError
+	if !errors.As(err, &cliErr) || cliErr.Code != "BATCH_OP_FAILED" {
+		t.Fatalf("expected BATCH_OP_FAILED, got %v", err)
+	}
+	var report batchReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("failed to parse report: %v\n%s", err, buf.String())
+	}
+	ops := report.Operations
+	if len(ops) != 3 || ops[0].Error != "not applied" || ops[1].Error != "element 'gone01' not found." || ops[2].Error != "not applied" {
+		t.Errorf("unexpected per-op report %+v", ops)
+	}
+}
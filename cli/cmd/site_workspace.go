@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	"github.com/nerveband/agent-to-bricks/internal/diff"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/nerveband/agent-to-bricks/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cloneDir     string
+	cloneForce   bool
+	commitDryRun bool
+)
+
+var siteCloneCmd = &cobra.Command{
+	Use:   "clone <page-id>...",
+	Short: "Clone pages into a local working copy",
+	Long: `Pull pages into a local workspace for editing.
+
+Each page is written to page-<id>.json. The base elements and content hash are
+kept under .bricks/ so 'site status', 'site diff' and 'site commit' can tell
+what changed locally and remotely.`,
+	Example: `  bricks site clone 1234
+  bricks site clone 1234 5678 --dir ./pages`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		pageIDs := make([]int, 0, len(args))
+		for _, ref := range args {
+			id, err := resolvePage(c, ref)
+			if err != nil {
+				return err
+			}
+			pageIDs = append(pageIDs, id)
+		}
+
+		dir := cloneDir
+		if dir == "" {
+			if ws, err := workspace.Find("."); err == nil {
+				dir = ws.Root
+			} else {
+				dir = "."
+			}
+		}
+		ws, err := workspace.Init(dir)
+		if err != nil {
+			return fmt.Errorf("failed to create workspace: %w", err)
+		}
+
+		for _, pageID := range pageIDs {
+			if existing, err := ws.Page(pageID); err == nil && !cloneForce {
+				local, err := ws.ReadLocal(existing)
+				if err == nil && !workspace.ComputeDelta(existing.BaseElements, local).Empty() {
+					return clierrors.ValidationError("WORKSPACE_DIRTY",
+						fmt.Sprintf("page %d has uncommitted changes in %s; commit them or re-run with --force to discard them", pageID, existing.File))
+				}
+			}
+
+			resp, err := c.GetElements(pageID)
+			if err != nil {
+				return fmt.Errorf("failed to pull page %d: %w", pageID, err)
+			}
+			state := &workspace.PageState{
+				PageID:  pageID,
+				SiteURL: cfg.Site.URL,
+				File:    workspace.DefaultFile(pageID),
+			}
+			if err := ws.Checkout(state, resp.Elements, resp.ContentHash); err != nil {
+				return fmt.Errorf("failed to write page %d: %w", pageID, err)
+			}
+			fmt.Printf("Cloned page %d: %d elements (hash: %s) → %s\n",
+				pageID, resp.Count, resp.ContentHash, filepath.Join(ws.Root, state.File))
+		}
+		return nil
+	},
+}
+
+type pageStatus struct {
+	PageID     int    `json:"pageId"`
+	File       string `json:"file"`
+	Local      int    `json:"localChanges"`
+	Remote     bool   `json:"remoteChanged"`
+	BaseHash   string `json:"baseHash"`
+	RemoteHash string `json:"remoteHash,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+var siteStatusCmd = &cobra.Command{
+	Use:   "status [page-id...]",
+	Short: "Show local and remote changes in the working copy",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		ws, pages, err := workspacePages(args)
+		if err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		var statuses []pageStatus
+		for _, p := range pages {
+			st := pageStatus{PageID: p.PageID, File: p.File, BaseHash: p.BaseHash}
+			local, err := ws.ReadLocal(p)
+			if err != nil {
+				st.Error = err.Error()
+				statuses = append(statuses, st)
+				continue
+			}
+			st.Local = len(workspace.ComputeDelta(p.BaseElements, local).Changes)
+			if remote, err := c.GetElements(p.PageID); err != nil {
+				st.Error = err.Error()
+			} else {
+				st.RemoteHash = remote.ContentHash
+				st.Remote = remote.ContentHash != p.BaseHash
+			}
+			statuses = append(statuses, st)
+		}
+
+		if output.IsJSON() {
+			return output.JSON(statuses)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PAGE\tFILE\tLOCAL\tREMOTE")
+		for _, st := range statuses {
+			local := "clean"
+			if st.Local > 0 {
+				local = fmt.Sprintf("%d changed", st.Local)
+			}
+			remote := "up to date"
+			switch {
+			case st.Error != "":
+				local, remote = "error", st.Error
+			case st.Remote:
+				remote = "changed"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", st.PageID, st.File, local, remote)
+		}
+		return w.Flush()
+	},
+}
+
+type pageDiff struct {
+	PageID  int           `json:"pageId"`
+	File    string        `json:"file"`
+	Summary diff.Summary  `json:"summary"`
+	Changes []diff.Change `json:"changes"`
+}
+
+var siteDiffCmd = &cobra.Command{
+	Use:   "diff [page-id...]",
+	Short: "Show element changes in the working copy",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+
+		ws, pages, err := workspacePages(args)
+		if err != nil {
+			return err
+		}
+
+		var diffs []pageDiff
+		for _, p := range pages {
+			local, err := ws.ReadLocal(p)
+			if err != nil {
+				return clierrors.ValidationError("INVALID_INPUT", fmt.Sprintf("failed to read %s: %v", p.File, err))
+			}
+			res := diff.Compare(p.BaseElements, local)
+			if res.Empty() {
+				continue
+			}
+			diffs = append(diffs, pageDiff{PageID: p.PageID, File: p.File, Summary: res.Summary, Changes: res.Changes})
+		}
+
+		if output.IsJSON() {
+			if diffs == nil {
+				diffs = []pageDiff{}
+			}
+			return output.JSON(diffs)
+		}
+
+		if len(diffs) == 0 {
+			fmt.Println("No local changes.")
+			return nil
+		}
+		for _, d := range diffs {
+			fmt.Printf("page %d (%s): %s\n", d.PageID, d.File, diffSummary(d.Summary))
+			printDiffChanges(d.Changes)
+		}
+		return nil
+	},
+}
+
+type commitResult struct {
+	PageID      int    `json:"pageId"`
+	Added       int    `json:"added"`
+	Modified    int    `json:"modified"`
+	Removed     int    `json:"removed"`
+	ContentHash string `json:"contentHash"`
+}
+
+var siteCommitCmd = &cobra.Command{
+	Use:   "commit [page-id...]",
+	Short: "Push local changes from the working copy",
+	Long: `Send only the changed elements of each page to the site.
+
+Additions, edits and deletions are pushed with the base content hash as
+If-Match. If the page was changed on the site since it was cloned, the commit
+is rejected with a conflict and nothing is written.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+
+		ws, pages, err := workspacePages(args)
+		if err != nil {
+			return err
+		}
+
+		c := newSiteClient()
+		var results []commitResult
+		for _, p := range pages {
+			if p.SiteURL != "" && strings.TrimRight(p.SiteURL, "/") != strings.TrimRight(cfg.Site.URL, "/") {
+				return clierrors.ConfigError("WORKSPACE_SITE_MISMATCH",
+					fmt.Sprintf("page %d was cloned from %s, not %s%s", p.PageID, p.SiteURL, cfg.Site.URL, siteLabel()),
+					"Select the matching site with --site")
+			}
+
+			local, err := ws.ReadLocal(p)
+			if err != nil {
+				return clierrors.ValidationError("INVALID_INPUT", fmt.Sprintf("failed to read %s: %v", p.File, err))
+			}
+			d := workspace.ComputeDelta(p.BaseElements, local)
+			if d.Empty() {
+				continue
+			}
+
+			res := commitResult{
+				PageID:      p.PageID,
+				Added:       len(d.Added),
+				Modified:    len(d.Patches),
+				Removed:     len(d.Removed),
+				ContentHash: p.BaseHash,
+			}
+			if !commitDryRun {
+				guard, err := guardWrite(cmd, c, p.PageID, "", false)
+				if err != nil {
+					return err
+				}
+				defer guard.release()
+				hash, err := pushDelta(c, p.PageID, d, p.BaseHash)
+				if err != nil {
+					return fmt.Errorf("failed to commit page %d: %w", p.PageID, err)
+				}
+				guard.done(hash)
+				if err := ws.Checkout(p, local, hash); err != nil {
+					return fmt.Errorf("page %d committed but local state could not be saved: %w", p.PageID, err)
+				}
+				res.ContentHash = hash
+			}
+			results = append(results, res)
+		}
+
+		if output.IsJSON() {
+			if results == nil {
+				results = []commitResult{}
+			}
+			return output.JSON(results)
+		}
+
+		if len(results) == 0 {
+			fmt.Println("Nothing to commit.")
+			return nil
+		}
+		verb := "Committed"
+		if commitDryRun {
+			verb = "Would commit"
+		}
+		for _, r := range results {
+			fmt.Printf("%s page %d: %d added, %d modified, %d removed (hash: %s)\n",
+				verb, r.PageID, r.Added, r.Modified, r.Removed, r.ContentHash)
+		}
+		return nil
+	},
+}
+
+// pushDelta sends a delta as one batch of append, patch and delete operations
+// guarded by a single If-Match, so either all of it lands or none does. New
+// elements go first so that patched children arrays only reference existing
+// IDs.
+func pushDelta(c *client.Client, pageID int, d *workspace.Delta, hash string) (string, error) {
+	var ops []client.BatchOp
+	if len(d.Added) > 0 {
+		ops = append(ops, client.AppendOp("", d.Added...))
+	}
+	if len(d.Patches) > 0 {
+		ops = append(ops, client.PatchOp(d.Patches...))
+	}
+	if len(d.Removed) > 0 {
+		ops = append(ops, client.DeleteOp(d.Removed...))
+	}
+	if len(ops) == 0 {
+		return hash, nil
+	}
+	resp, err := c.BatchElements(pageID, ops, hash)
+	if err != nil {
+		return "", err
+	}
+	return resp.ContentHash, nil
+}
+
+// workspacePages opens the workspace containing the current directory and
+// returns the requested pages, or all tracked pages when none are given.
+func workspacePages(args []string) (*workspace.Workspace, []*workspace.PageState, error) {
+	ws, err := workspace.Find(".")
+	if err != nil {
+		return nil, nil, clierrors.ConfigError("WORKSPACE_NOT_FOUND", err.Error(),
+			"Run 'bricks site clone <page-id>' to create one")
+	}
+	if len(args) == 0 {
+		pages, err := ws.Pages()
+		if err != nil {
+			return nil, nil, err
+		}
+		return ws, pages, nil
+	}
+
+	ids, err := parsePageIDs(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pages []*workspace.PageState
+	for _, id := range ids {
+		p, err := ws.Page(id)
+		if err != nil {
+			return nil, nil, clierrors.ValidationError("PAGE_NOT_TRACKED", err.Error())
+		}
+		pages = append(pages, p)
+	}
+	return ws, pages, nil
+}
+
+func parsePageIDs(args []string) ([]int, error) {
+	ids := make([]int, 0, len(args))
+	for _, a := range args {
+		id, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, clierrors.ValidationError("INVALID_PAGE_ID", fmt.Sprintf("invalid page ID: %s", a))
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func init() {
+	siteCloneCmd.Flags().StringVar(&cloneDir, "dir", "", "workspace directory (default: enclosing workspace or current directory)")
+	siteCloneCmd.Flags().BoolVar(&cloneForce, "force", false, "overwrite uncommitted local changes")
+	siteCommitCmd.Flags().BoolVar(&commitDryRun, "dry-run", false, "show what would be pushed without writing")
+	output.AddFormatFlags(siteStatusCmd)
+	output.AddFormatFlags(siteDiffCmd)
+	output.AddFormatFlags(siteCommitCmd)
+
+	siteCmd.AddCommand(siteCloneCmd)
+	siteCmd.AddCommand(siteStatusCmd)
+	siteCmd.AddCommand(siteDiffCmd)
+	siteCmd.AddCommand(siteCommitCmd)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/config"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+)
+
+func TestSiteCloneAndCommit(t *testing.T) {
+	skipSafetySnapshots(t)
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path+" "+r.Header.Get("If-Match"))
+		switch r.Method + " " + r.URL.Path {
+		case "GET /wp-json/agent-bricks/v1/pages/77/elements":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"elements": []map[string]interface{}{
+					{"id": "sec001", "name": "section", "parent": "0", "children": []interface{}{"hd0001"}, "settings": map[string]interface{}{}},
+					{"id": "hd0001", "name": "heading", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{"text": "Hello"}},
+				},
+				"contentHash": "h0",
+				"count":       2,
+			})
+		case "POST /wp-json/agent-bricks/v1/pages/77/elements/batch":
+			var body struct {
+				Operations []struct {
+					Op      string                   `json:"op"`
+					Patches []map[string]interface{} `json:"patches"`
+				} `json:"operations"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if len(body.Operations) != 1 || body.Operations[0].Op != "patch" ||
+				len(body.Operations[0].Patches) != 1 || body.Operations[0].Patches[0]["id"] != "hd0001" {
+				t.Errorf("expected a single heading patch op, got %+v", body.Operations)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": "h1"})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+
+	t.Chdir(t.TempDir())
+
+	if err := siteCloneCmd.RunE(siteCloneCmd, []string{"77"}); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+
+	data, err := os.ReadFile("page-77.json")
+	if err != nil {
+		t.Fatalf("working file not written: %v", err)
+	}
+	var working map[string]interface{}
+	json.Unmarshal(data, &working)
+	els := working["elements"].([]interface{})
+	els[1].(map[string]interface{})["settings"] = map[string]interface{}{"text": "Hello, world"}
+	data, _ = json.Marshal(working)
+	os.WriteFile("page-77.json", data, 0644)
+
+	if err := siteCommitCmd.RunE(siteCommitCmd, nil); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	want := []string{
+		"GET /wp-json/agent-bricks/v1/pages/77/elements ",
+		"POST /wp-json/agent-bricks/v1/pages/77/elements/batch h0",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("expected calls %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: expected %q, got %q", i, want[i], calls[i])
+		}
+	}
+
+	// A second commit has nothing to push and must not hit the API.
+	if err := siteCommitCmd.RunE(siteCommitCmd, nil); err != nil {
+		t.Fatalf("second commit: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("expected no further calls, got %v", calls[2:])
+	}
+}
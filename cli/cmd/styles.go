@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/nerveband/agent-to-bricks/internal/output"
 	"github.com/nerveband/agent-to-bricks/internal/styles"
@@ -30,9 +29,9 @@ var stylesLearnCmd = &cobra.Command{
 		c := newSiteClient()
 
 		for _, arg := range args {
-			pageID, err := strconv.Atoi(arg)
+			pageID, err := resolvePage(c, arg)
 			if err != nil {
-				fmt.Printf("Skipping invalid page ID: %s\n", arg)
+				fmt.Printf("Skipping %s: %v\n", arg, err)
 				continue
 			}
 
@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 
 	"github.com/nerveband/agent-to-bricks/internal/embeddings"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
 	"github.com/nerveband/agent-to-bricks/internal/templates"
 	"github.com/spf13/cobra"
 )
@@ -142,12 +142,12 @@ var templatesLearnCmd = &cobra.Command{
 			return err
 		}
 
-		pageID, err := strconv.Atoi(args[0])
+		c := newSiteClient()
+		pageID, err := resolvePage(c, args[0])
 		if err != nil {
-			return fmt.Errorf("invalid page ID: %s", args[0])
+			return err
 		}
 
-		c := newSiteClient()
 		resp, err := c.GetElements(pageID)
 		if err != nil {
 			return fmt.Errorf("failed to pull elements: %w", err)
@@ -175,13 +175,40 @@ var templatesLearnCmd = &cobra.Command{
 }
 
 var composeOutput string
-var composePush int
+var composePush string
+var composeIDs string
+var composeAppend bool
 
 var composeCmd = &cobra.Command{
 	Use:   "compose <template1> [template2] ...",
 	Short: "Compose multiple templates into a single page",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Compose templates into one element list, giving every element a new ID.
+
+IDs are random by default. --ids hash derives them from the template and
+the element's original ID, so composing the same templates again gives the
+same IDs; --ids explicit keeps the templates' own IDs where they are free.
+With --push, --append adds the elements after the page's existing ones
+instead of replacing them, and new IDs avoid the IDs already on the page.`,
+	Example: `  bricks compose hero-cali content-alpha -o page.json
+  bricks compose cta-bravo --ids hash --push 1460 --append`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ids, err := idStrategy(composeIDs)
+		if err != nil {
+			return err
+		}
+		if composeAppend && composePush == "" {
+			return clierrors.ValidationError("INVALID_ARGS", "--append needs --push")
+		}
+		pageID := 0
+		if composePush != "" {
+			if err := requireConfig(); err != nil {
+				return err
+			}
+			if pageID, err = resolvePage(newSiteClient(), composePush); err != nil {
+				return err
+			}
+		}
 		cat, err := loadCatalog()
 		if err != nil {
 			return err
@@ -196,7 +223,17 @@ var composeCmd = &cobra.Command{
 			tmpls = append(tmpls, tmpl)
 		}
 
-		result, err := templates.ComposeWithClasses(tmpls)
+		opts := templates.ComposeOptions{IDs: ids}
+		ifMatch := ""
+		if composeAppend {
+			// Appended elements must not reuse the page's element IDs
+			opts.ReservedIDs, ifMatch, err = pageElementIDs(newSiteClient(), pageID)
+			if err != nil {
+				return err
+			}
+		}
+
+		result, err := templates.ComposeWithOptions(tmpls, opts)
 		if err != nil {
 			return err
 		}
@@ -204,21 +241,33 @@ var composeCmd = &cobra.Command{
 		elements := result.Elements
 
 		// Push to a page if --push flag is set
-		if composePush > 0 {
-			if err := requireConfig(); err != nil {
+		if pageID > 0 {
+			c := newSiteClient()
+			if !composeAppend {
+				if existing, _ := c.GetElements(pageID); existing != nil {
+					ifMatch = existing.ContentHash
+				}
+			}
+			guard, err := guardWrite(cmd, c, pageID, "", false)
+			if err != nil {
 				return err
 			}
-			c := newSiteClient()
-			existing, _ := c.GetElements(composePush)
-			ifMatch := ""
-			if existing != nil {
-				ifMatch = existing.ContentHash
+			defer guard.release()
+			if composeAppend {
+				pushResult, err := c.AppendElements(pageID, elements, ifMatch)
+				if err != nil {
+					return fmt.Errorf("push failed: %w", err)
+				}
+				guard.done(pushResult.ContentHash)
+				fmt.Printf("Appended %d elements to page %d\n", len(elements), pageID)
+				return nil
 			}
-			pushResult, err := c.ReplaceElements(composePush, elements, ifMatch)
+			pushResult, err := c.ReplaceElements(pageID, elements, ifMatch)
 			if err != nil {
 				return fmt.Errorf("push failed: %w", err)
 			}
-			fmt.Printf("Pushed %d elements to page %d\n", pushResult.Count, composePush)
+			guard.done(pushResult.ContentHash)
+			fmt.Printf("Pushed %d elements to page %d\n", pushResult.Count, pageID)
 			return nil
 		}
 
@@ -284,7 +333,9 @@ var templatesSearchCmd = &cobra.Command{
 
 func init() {
 	composeCmd.Flags().StringVarP(&composeOutput, "output", "o", "", "output file path")
-	composeCmd.Flags().IntVar(&composePush, "push", 0, "push composed result to page ID")
+	composeCmd.Flags().StringVar(&composePush, "push", "", "push composed result to a page (ID, slug, title, path or URL)")
+	composeCmd.Flags().StringVar(&composeIDs, "ids", "random", "how element IDs are made: random, hash (stable across runs) or explicit (keep template IDs)")
+	composeCmd.Flags().BoolVar(&composeAppend, "append", false, "with --push, append to the page's elements instead of replacing them")
 
 	templatesCmd.AddCommand(templatesListCmd)
 	templatesCmd.AddCommand(templatesShowCmd)
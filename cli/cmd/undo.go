@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/journal"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	undoSteps  int
+	undoDryRun bool
+)
+
+// undoJournal is the local record of page writes that `bricks undo` reverts.
+func undoJournal() journal.Journal {
+	return journal.Journal{Path: filepath.Join(configDir(), "journal.jsonl")}
+}
+
+// writeGuard is the safety net around one page write: a server snapshot
+// taken before the write, and an undo journal entry recorded after it.
+type writeGuard struct {
+	client   *client.Client
+	command  string
+	pageID   int
+	snapshot *client.SnapshotResponse
+	recorded bool
+}
+
+// guardWrite snapshots a page before a mutating command writes to it. It
+// takes no snapshot when safety snapshots are off (safety.snapshots: false
+// or --no-snapshot) unless force is set. label defaults to
+// "Auto: before <command>". Callers defer release, so the snapshot of a
+// write that never happened doesn't crowd out the page's real restore
+// points.
+func guardWrite(cmd *cobra.Command, c *client.Client, pageID int, label string, force bool) (*writeGuard, error) {
+	g := &writeGuard{client: c, command: cmd.CommandPath(), pageID: pageID}
+	if !force && (noSnapshot || !cfg.SafetySnapshots()) {
+		return g, nil
+	}
+	if label == "" {
+		label = "Auto: before " + g.command
+	}
+	snap, err := c.CreateSnapshot(pageID, label)
+	if err != nil {
+		cliErr := clierrors.APIError("SNAPSHOT_FAILED", fmt.Sprintf("failed to snapshot page %d before writing: %v", pageID, err))
+		cliErr.Hint = "Nothing was written. Re-run with --no-snapshot to write without a safety snapshot."
+		return nil, cliErr
+	}
+	g.snapshot = snap
+	return g, nil
+}
+
+// SnapshotID returns the safety snapshot's ID, or "" when none was taken.
+func (g *writeGuard) SnapshotID() string {
+	if g.snapshot == nil {
+		return ""
+	}
+	return g.snapshot.SnapshotID
+}
+
+// done records a successful write in the undo journal. A journal that cannot
+// be written only costs the ability to undo, so it is a warning.
+func (g *writeGuard) done(newHash string) {
+	if g.snapshot == nil {
+		return
+	}
+	g.recorded = true
+	err := undoJournal().Append(journal.Entry{
+		Time:       time.Now().UTC(),
+		Site:       cfg.Site.URL,
+		PageID:     g.pageID,
+		PrevHash:   g.snapshot.ContentHash,
+		NewHash:    newHash,
+		SnapshotID: g.snapshot.SnapshotID,
+		Command:    g.command,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record undo journal entry: %v\n", err)
+	}
+}
+
+// release deletes the safety snapshot when the write failed: the plugin
+// keeps only the last 10 snapshots per page.
+func (g *writeGuard) release() {
+	if g.snapshot == nil || g.recorded {
+		return
+	}
+	if _, err := g.client.DeleteSnapshot(g.pageID, g.snapshot.SnapshotID); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to delete unused snapshot %s: %v\n", g.snapshot.SnapshotID, err)
+	}
+}
+
+// undoStep is one reverted (or, with --dry-run, revertible) journal entry.
+type undoStep struct {
+	journal.Entry
+	RestoredHash string `json:"restoredHash,omitempty"`
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last page writes made by this CLI",
+	Long: `Roll back the most recent page writes recorded in the undo journal
+(~/.agent-to-bricks/journal.jsonl) for the active site, newest first.
+
+Every mutating command (site push, site patch, site batch, site commit,
+patch, apply, convert html --push, templates compose --push, site snapshots
+restore, site rollback) snapshots the page before writing and records the
+write. Undo restores that snapshot, but only while the page still has the
+content hash the write produced: if anyone has changed the page since, undo
+refuses with UNDO_PAGE_CHANGED and leaves the page alone.
+
+Turn the snapshots off with 'bricks config set safety.snapshots false' or
+--no-snapshot on a single command.`,
+	Example: `  bricks undo
+  bricks undo --steps 3
+  bricks undo --steps 2 --dry-run --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output.ResolveFormat(cmd)
+		if err := requireConfig(); err != nil {
+			return err
+		}
+		if undoSteps < 1 {
+			return clierrors.ValidationError("INVALID_ARGS", "--steps must be at least 1")
+		}
+
+		j := undoJournal()
+		entries, err := j.Recent(cfg.Site.URL, undoSteps)
+		if err != nil {
+			return fmt.Errorf("failed to read undo journal: %w", err)
+		}
+		if len(entries) == 0 {
+			return clierrors.ValidationError("NOTHING_TO_UNDO", fmt.Sprintf("the undo journal has no writes for %s", cfg.Site.URL))
+		}
+
+		c := newSiteClient()
+		// hashes tracks each page's expected content as earlier steps restore it.
+		hashes := map[int]string{}
+		var done []undoStep
+		for _, e := range entries {
+			current, ok := hashes[e.PageID]
+			if !ok {
+				resp, err := c.GetElements(e.PageID)
+				if err != nil {
+					return fmt.Errorf("failed to read page %d: %w", e.PageID, err)
+				}
+				current = resp.ContentHash
+			}
+			if current != e.NewHash {
+				err := clierrors.ValidationError("UNDO_PAGE_CHANGED",
+					fmt.Sprintf("page %d changed after %q (hash %s, expected %s); refusing to undo", e.PageID, e.Command, current, e.NewHash))
+				err.Hint = fmt.Sprintf("Compare first: bricks site snapshots diff %d %s", e.PageID, e.SnapshotID)
+				if len(done) > 0 {
+					printUndo(done)
+				}
+				return err
+			}
+
+			step := undoStep{Entry: e}
+			if undoDryRun {
+				hashes[e.PageID] = e.PrevHash
+			} else {
+				resp, err := c.Rollback(e.PageID, e.SnapshotID)
+				if err != nil {
+					if len(done) > 0 {
+						printUndo(done)
+					}
+					return fmt.Errorf("failed to restore snapshot %s on page %d: %w", e.SnapshotID, e.PageID, err)
+				}
+				if err := j.Remove(e); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to update undo journal: %v\n", err)
+				}
+				hashes[e.PageID] = resp.ContentHash
+				step.RestoredHash = resp.ContentHash
+			}
+			done = append(done, step)
+		}
+		return printUndo(done)
+	},
+}
+
+func printUndo(steps []undoStep) error {
+	if output.IsJSON() {
+		return output.JSON(map[string]interface{}{"dryRun": undoDryRun, "undone": steps})
+	}
+	verb := "Undid"
+	if undoDryRun {
+		verb = "Would undo"
+	}
+	for _, s := range steps {
+		fmt.Printf("%s %s on page %d (%s), restored snapshot %s\n",
+			verb, s.Command, s.PageID, s.Time.Local().Format("2006-01-02 15:04:05"), s.SnapshotID)
+	}
+	return nil
+}
+
+func init() {
+	undoCmd.Flags().IntVar(&undoSteps, "steps", 1, "number of writes to undo, newest first")
+	undoCmd.Flags().BoolVar(&undoDryRun, "dry-run", false, "check and list what would be undone without writing")
+	output.AddFormatFlags(undoCmd)
+	rootCmd.AddCommand(undoCmd)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/config"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/journal"
+	"github.com/nerveband/agent-to-bricks/internal/output"
+)
+
+// skipSafetySnapshots runs a write test as if --no-snapshot were given, for
+// tests whose fake servers only model the write itself.
+func skipSafetySnapshots(t *testing.T) {
+	t.Helper()
+	noSnapshot = true
+	t.Cleanup(func() { noSnapshot = false })
+}
+
+func TestSitePushThenUndo(t *testing.T) {
+	var calls []string
+	hash := "oldhash"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /wp-json/agent-bricks/v1/pages/7/snapshots":
+			json.NewEncoder(w).Encode(map[string]interface{}{"snapshotId": "snap_1", "contentHash": hash})
+		case "PUT /wp-json/agent-bricks/v1/pages/7/elements":
+			hash = "newhash"
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": hash, "count": 1})
+		case "GET /wp-json/agent-bricks/v1/pages/7/elements":
+			json.NewEncoder(w).Encode(map[string]interface{}{"elements": []interface{}{}, "contentHash": hash})
+		case "POST /wp-json/agent-bricks/v1/pages/7/snapshots/snap_1/rollback":
+			hash = "oldhash"
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": hash, "restoredFrom": "snap_1"})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	inputFile := filepath.Join(home, "push.json")
+	os.WriteFile(inputFile, []byte(`{"elements":[{"id":"e1","name":"heading"}],"contentHash":"oldhash"}`), 0644)
+	if err := sitePushCmd.RunE(sitePushCmd, []string{"7", inputFile}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	entries, _ := undoJournal().Entries()
+	if len(entries) != 1 || entries[0].SnapshotID != "snap_1" || entries[0].NewHash != "newhash" || entries[0].PrevHash != "oldhash" {
+		t.Fatalf("unexpected journal after push: %+v", entries)
+	}
+
+	if err := undoCmd.RunE(undoCmd, nil); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if last := calls[len(calls)-1]; last != "POST /wp-json/agent-bricks/v1/pages/7/snapshots/snap_1/rollback" {
+		t.Errorf("expected undo to roll back, last call %q", last)
+	}
+	if entries, _ := undoJournal().Entries(); len(entries) != 0 {
+		t.Errorf("expected journal to be empty after undo, got %+v", entries)
+	}
+}
+
+func TestFailedWriteDeletesSnapshot(t *testing.T) {
+	var deleted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /wp-json/agent-bricks/v1/pages/7/snapshots":
+			json.NewEncoder(w).Encode(map[string]interface{}{"snapshotId": "snap_1", "contentHash": "oldhash"})
+		case "PUT /wp-json/agent-bricks/v1/pages/7/elements":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"write failed"}`))
+		case "DELETE /wp-json/agent-bricks/v1/pages/7/snapshots/snap_1":
+			deleted = "snap_1"
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	inputFile := filepath.Join(home, "push.json")
+	os.WriteFile(inputFile, []byte(`{"elements":[{"id":"e1","name":"heading"}],"contentHash":"oldhash"}`), 0644)
+	if err := sitePushCmd.RunE(sitePushCmd, []string{"7", inputFile}); err == nil {
+		t.Fatal("expected the push to fail")
+	}
+	if deleted != "snap_1" {
+		t.Error("expected the unused safety snapshot to be deleted")
+	}
+	if entries, _ := undoJournal().Entries(); len(entries) != 0 {
+		t.Errorf("expected no journal entry for a failed write, got %+v", entries)
+	}
+}
+
+func TestUndoRefusesChangedPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Fatalf("undo must not write to a changed page, got %s %s", r.Method, r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"elements": []interface{}{}, "contentHash": "edited"})
+	}))
+	defer server.Close()
+
+	cfg = &config.Config{Site: config.SiteConfig{URL: server.URL, APIKey: "atb_testkey"}}
+	output.Reset()
+	defer output.Reset()
+	t.Setenv("HOME", t.TempDir())
+
+	undoJournal().Append(journal.Entry{
+		Time: time.Now(), Site: server.URL, PageID: 7,
+		PrevHash: "oldhash", NewHash: "newhash", SnapshotID: "snap_1", Command: "bricks site push",
+	})
+
+	err := undoCmd.RunE(undoCmd, nil)
+	var cliErr *clierrors.CLIError
+	if !errors.As(err, &cliErr) || cliErr.Code != "UNDO_PAGE_CHANGED" {
+		t.Fatalf("expected UNDO_PAGE_CHANGED, got %v", err)
+	}
+	if entries, _ := undoJournal().Entries(); len(entries) != 1 {
+		t.Errorf("expected the journal entry to be kept, got %+v", entries)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/nerveband/agent-to-bricks/internal/aipatch"
 	"github.com/nerveband/agent-to-bricks/internal/output"
 	"github.com/nerveband/agent-to-bricks/internal/validator"
 	"github.com/spf13/cobra"
@@ -13,7 +14,12 @@ import (
 var validateCmd = &cobra.Command{
 	Use:   "validate <file.json>",
 	Short: "Validate Bricks element JSON",
-	Args:  cobra.ExactArgs(1),
+	Long: `Validate Bricks element JSON: an {"elements":[...]} file or a Bricks export.
+
+A Bricks AI Patch document (one with a patchMode) is checked against
+schema/ai-bricks.schema.json, with errors reported as JSON Pointer paths, and
+then the elements it would create are validated.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		output.ResolveFormat(cmd)
 		data, err := os.ReadFile(args[0])
@@ -26,7 +32,12 @@ var validateCmd = &cobra.Command{
 			return fmt.Errorf("invalid JSON: %w", err)
 		}
 
-		result := validator.ValidateFile(parsed)
+		var result *validator.Result
+		if _, ok := parsed["patchMode"]; ok {
+			result = validatePatchDocument(parsed)
+		} else {
+			result = validator.ValidateFile(parsed)
+		}
 
 		if output.IsJSON() {
 			return output.JSON(result)
@@ -55,6 +66,33 @@ var validateCmd = &cobra.Command{
 	},
 }
 
+// validatePatchDocument checks a Bricks AI Patch against its schema and then
+// validates the elements it would create.
+func validatePatchDocument(doc map[string]interface{}) *validator.Result {
+	if errs := aipatch.Validate(doc); len(errs) > 0 {
+		r := &validator.Result{Valid: false}
+		for _, e := range errs {
+			r.Errors = append(r.Errors, e.Error())
+		}
+		return r
+	}
+	data, _ := json.Marshal(doc)
+	patch, err := aipatch.Parse(data)
+	if err != nil {
+		return &validator.Result{Valid: false, Errors: []string{err.Error()}}
+	}
+	if len(patch.Nodes) == 0 {
+		return &validator.Result{Valid: true}
+	}
+	// The target parent lives on the page, not in the document, so the
+	// nodes are checked as a root-level tree.
+	var elements []validator.Element
+	for _, el := range patch.Flatten("", map[string]bool{}) {
+		elements = append(elements, validator.Element(el))
+	}
+	return validator.Validate(elements)
+}
+
 func init() {
 	output.AddFormatFlags(validateCmd)
 	rootCmd.AddCommand(validateCmd)
@@ -0,0 +1,168 @@
+package aipatch
+
+import (
+	"fmt"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+)
+
+// Plan is the batch that applies a patch to a page.
+type Plan struct {
+	Ops []client.BatchOp
+	// Added are the IDs of the elements the patch creates, in flat order.
+	Added []string
+	// Removed are the IDs the patch deletes, including descendants of the
+	// target elements.
+	Removed []string
+}
+
+// Plan translates the patch into batch operations against the page's current
+// elements:
+//
+//	insert   nodes at targetIndex under targetParent (at the end without an index)
+//	append   nodes after the last child of targetParent
+//	replace  the target elements and their subtrees with nodes, at the
+//	         position of the first target
+//	delete   the target elements and their subtrees
+func (p *Patch) Plan(current []map[string]interface{}) (*Plan, error) {
+	byID := map[string]map[string]interface{}{}
+	used := map[string]bool{}
+	for _, el := range current {
+		id, _ := el["id"].(string)
+		byID[id] = el
+		used[id] = true
+	}
+
+	plan := &Plan{}
+	var removed map[string]bool
+	if p.PatchMode == ModeReplace || p.PatchMode == ModeDelete {
+		for _, id := range p.TargetElementIDs {
+			if byID[id] == nil {
+				return nil, fmt.Errorf("target element %q not found on page", id)
+			}
+		}
+		removed = subtree(current, p.TargetElementIDs)
+		for _, el := range current {
+			if id, _ := el["id"].(string); removed[id] {
+				plan.Removed = append(plan.Removed, id)
+				delete(used, id)
+			}
+		}
+		plan.Ops = append(plan.Ops, client.DeleteOp(plan.Removed...))
+	}
+	if p.PatchMode == ModeDelete {
+		return plan, nil
+	}
+
+	parent := string(p.TargetParent)
+	var index *int
+	switch p.PatchMode {
+	case ModeInsert:
+		index = p.TargetIndex
+	case ModeReplace:
+		// Anchor on the first target that is not inside another target.
+		first := byID[p.TargetElementIDs[0]]
+		for _, id := range p.TargetElementIDs {
+			if !removed[parentOf(byID[id])] {
+				first = byID[id]
+				break
+			}
+		}
+		parent = parentOf(first)
+		i := siblingIndex(current, first, removed)
+		index = &i
+	}
+	if ElementID(parent).IsRoot() {
+		parent = ""
+	} else if byID[parent] == nil || removed[parent] {
+		return nil, fmt.Errorf("target parent %q not found on page", parent)
+	}
+
+	for _, n := range allNodes(p.Nodes) {
+		if n.ID != "" && used[n.ID] {
+			return nil, fmt.Errorf("node ID %q already exists on page", n.ID)
+		}
+	}
+	elements := p.Flatten(parent, used)
+	for _, el := range elements {
+		plan.Added = append(plan.Added, el["id"].(string))
+	}
+	if index != nil {
+		plan.Ops = append(plan.Ops, client.InsertOp(parent, *index, elements...))
+	} else {
+		plan.Ops = append(plan.Ops, client.AppendOp(parent, elements...))
+	}
+	return plan, nil
+}
+
+func allNodes(nodes []Node) []Node {
+	var out []Node
+	for _, n := range nodes {
+		out = append(out, n)
+		out = append(out, allNodes(n.Children)...)
+	}
+	return out
+}
+
+// subtree returns the given IDs and every element beneath them.
+func subtree(elements []map[string]interface{}, ids []string) map[string]bool {
+	in := map[string]bool{}
+	for _, id := range ids {
+		in[id] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, el := range elements {
+			id, _ := el["id"].(string)
+			if !in[id] && in[parentOf(el)] {
+				in[id] = true
+				changed = true
+			}
+		}
+	}
+	return in
+}
+
+func parentOf(el map[string]interface{}) string {
+	switch p := el["parent"].(type) {
+	case string:
+		return p
+	case float64:
+		return fmt.Sprintf("%.0f", p)
+	}
+	return "0"
+}
+
+// siblingIndex is el's position among its parent's children, not counting
+// siblings that are about to be removed. Root elements are ordered by their
+// position in the flat list.
+func siblingIndex(elements []map[string]interface{}, el map[string]interface{}, removed map[string]bool) int {
+	id, _ := el["id"].(string)
+	parent := parentOf(el)
+	var siblings []interface{}
+	if ElementID(parent).IsRoot() {
+		for _, e := range elements {
+			if ElementID(parentOf(e)).IsRoot() {
+				siblings = append(siblings, e["id"])
+			}
+		}
+	} else {
+		for _, e := range elements {
+			if eid, _ := e["id"].(string); eid == parent {
+				siblings, _ = e["children"].([]interface{})
+				break
+			}
+		}
+	}
+	i := 0
+	for _, s := range siblings {
+		sid, _ := s.(string)
+		if sid == id {
+			return i
+		}
+		if !removed[sid] {
+			i++
+		}
+	}
+	return i
+}
@@ -0,0 +1,265 @@
+// Package aipatch models the canonical Bricks AI Patch document described by
+// schema/ai-bricks.schema.json and turns it into element operations.
+package aipatch
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Version is the only meta.version the schema accepts.
+const Version = "1.0"
+
+// Patch modes.
+const (
+	ModeInsert  = "insert"
+	ModeReplace = "replace"
+	ModeAppend  = "append"
+	ModeDelete  = "delete"
+)
+
+// Modes lists the patchMode values in schema order.
+var Modes = []string{ModeInsert, ModeReplace, ModeAppend, ModeDelete}
+
+// Sources lists the meta.source values in schema order.
+var Sources = []string{"ai-extension", "ai-transform", "manual", "import"}
+
+// Patch is a Bricks AI Patch document.
+type Patch struct {
+	Meta             Meta      `json:"meta"`
+	PatchMode        string    `json:"patchMode"`
+	TargetParent     ElementID `json:"targetParent,omitempty"`
+	TargetIndex      *int      `json:"targetIndex,omitempty"`
+	TargetElementIDs []string  `json:"targetElementIds,omitempty"`
+	Nodes            []Node    `json:"nodes"`
+	Assets           *Assets   `json:"assets,omitempty"`
+	Bindings         *Bindings `json:"bindings,omitempty"`
+}
+
+// Meta describes where a patch came from.
+type Meta struct {
+	Version       string `json:"version"`
+	Source        string `json:"source"`
+	Timestamp     int64  `json:"timestamp,omitempty"`
+	Model         string `json:"model,omitempty"`
+	Instruction   string `json:"instruction,omitempty"`
+	BricksVersion string `json:"bricksVersion,omitempty"`
+}
+
+// Node is one element of the patch tree. Children are nested nodes, not IDs.
+type Node struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Label    string                 `json:"label,omitempty"`
+	Parent   ElementID              `json:"parent,omitempty"`
+	Children []Node                 `json:"children,omitempty"`
+	Settings map[string]interface{} `json:"settings,omitempty"`
+}
+
+// Assets lists media referenced by nodes.
+type Assets struct {
+	Media []MediaAsset `json:"media,omitempty"`
+}
+
+// MediaAsset is one media file referenced by a node.
+type MediaAsset struct {
+	RefID        string `json:"refId,omitempty"`
+	URL          string `json:"url,omitempty"`
+	AttachmentID int    `json:"attachmentId,omitempty"`
+	Alt          string `json:"alt,omitempty"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+}
+
+// Bindings carries global classes and variables by name so a patch can move
+// between sites.
+type Bindings struct {
+	GlobalClasses []ClassBinding    `json:"globalClasses,omitempty"`
+	CSSVariables  []VariableBinding `json:"cssVariables,omitempty"`
+}
+
+// ClassBinding ties a class ID used in the nodes to its portable name.
+type ClassBinding struct {
+	ID       string                 `json:"id,omitempty"`
+	Name     string                 `json:"name"`
+	Category string                 `json:"category,omitempty"`
+	Settings map[string]interface{} `json:"settings,omitempty"`
+}
+
+// VariableBinding is a CSS variable referenced in node settings.
+type VariableBinding struct {
+	Name   string `json:"name,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Source string `json:"source,omitempty"`
+}
+
+// ElementID is an element reference the schema allows as a string or an
+// integer; integers are kept in their decimal form.
+type ElementID string
+
+// UnmarshalJSON accepts both "abc123" and 0.
+func (id *ElementID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*id = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = ElementID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("element ID must be a string or an integer")
+	}
+	*id = ElementID(n.String())
+	return nil
+}
+
+// IsRoot reports whether the ID refers to the page root.
+func (id ElementID) IsRoot() bool {
+	return id == "" || id == "0"
+}
+
+// Parse validates a document against the schema and decodes it. Schema
+// violations are returned as Errors.
+func Parse(data []byte) (*Patch, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if errs := Validate(doc); len(errs) > 0 {
+		return nil, errs
+	}
+	var p Patch
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SnapshotLabel describes the patch for the snapshot taken before it is
+// applied: the model and instruction when the patch records them, otherwise
+// its source and mode.
+func (p *Patch) SnapshotLabel() string {
+	instruction := strings.Join(strings.Fields(p.Meta.Instruction), " ")
+	if r := []rune(instruction); len(r) > 160 {
+		instruction = string(r[:157]) + "..."
+	}
+	switch {
+	case p.Meta.Model != "" && instruction != "":
+		return p.Meta.Model + ": " + instruction
+	case p.Meta.Model != "":
+		return p.Meta.Model + ": " + p.PatchMode
+	case instruction != "":
+		return instruction
+	}
+	return fmt.Sprintf("ai-patch %s (%s)", p.PatchMode, p.Meta.Source)
+}
+
+// Flatten converts the node tree into Bricks' flat element list, depth first.
+// Top-level nodes get parentID ("0" for the page root); nodes without an ID get
+// a fresh one that is not in used. used is updated with every ID assigned.
+func (p *Patch) Flatten(parentID string, used map[string]bool) []map[string]interface{} {
+	if parentID == "" {
+		parentID = "0"
+	}
+	var out []map[string]interface{}
+	for _, n := range p.Nodes {
+		out = flattenNode(out, n, parentID, used)
+	}
+	return out
+}
+
+func flattenNode(out []map[string]interface{}, n Node, parentID string, used map[string]bool) []map[string]interface{} {
+	id := n.ID
+	if id == "" {
+		id = newID(used)
+	}
+	used[id] = true
+
+	settings := n.Settings
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	el := map[string]interface{}{
+		"id":       id,
+		"name":     n.Name,
+		"parent":   parentID,
+		"children": []interface{}{},
+		"settings": settings,
+	}
+	if n.Label != "" {
+		el["label"] = n.Label
+	}
+	out = append(out, el)
+
+	children := make([]interface{}, 0, len(n.Children))
+	for _, c := range n.Children {
+		childIdx := len(out)
+		out = flattenNode(out, c, id, used)
+		children = append(children, out[childIdx]["id"])
+	}
+	el["children"] = children
+	return out
+}
+
+// RemapClasses rewrites global class IDs in the nodes to the target site's IDs,
+// matching bindings by class name. lookup returns the site's ID for a name. It
+// returns the names of bound classes the site does not have; those IDs are
+// left unchanged.
+func (p *Patch) RemapClasses(lookup func(name string) (string, bool)) []string {
+	if p.Bindings == nil {
+		return nil
+	}
+	remap := map[string]string{}
+	var missing []string
+	for _, b := range p.Bindings.GlobalClasses {
+		siteID, ok := lookup(b.Name)
+		if !ok {
+			missing = append(missing, b.Name)
+			continue
+		}
+		if b.ID != "" && b.ID != siteID {
+			remap[b.ID] = siteID
+		}
+	}
+	if len(remap) > 0 {
+		for i := range p.Nodes {
+			remapNode(&p.Nodes[i], remap)
+		}
+	}
+	return missing
+}
+
+func remapNode(n *Node, remap map[string]string) {
+	if classes, ok := n.Settings["_cssGlobalClasses"].([]interface{}); ok {
+		for i, c := range classes {
+			if s, ok := c.(string); ok && remap[s] != "" {
+				classes[i] = remap[s]
+			}
+		}
+	}
+	for i := range n.Children {
+		remapNode(&n.Children[i], remap)
+	}
+}
+
+func newID(used map[string]bool) string {
+	for {
+		b := make([]byte, 3)
+		io.ReadFull(rand.Reader, b)
+		id := hex.EncodeToString(b)
+		if !used[id] {
+			return id
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package aipatch_test
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/aipatch"
+	"github.com/nerveband/agent-to-bricks/internal/batch"
+)
+
+// The Go model hand-codes the schema's enums; keep them in step with the file.
+func TestSchemaConstantsMatchFile(t *testing.T) {
+	data, err := os.ReadFile("../../../schema/ai-bricks.schema.json")
+	if err != nil {
+		t.Skipf("schema file not available: %v", err)
+	}
+	var schema struct {
+		Properties struct {
+			Meta struct {
+				Properties struct {
+					Version struct{ Const string }
+					Source  struct{ Enum []string }
+				}
+			}
+			PatchMode struct{ Enum []string }
+		}
+		Defs struct {
+			BricksNode struct {
+				Properties struct {
+					ID struct{ Pattern string }
+				}
+			}
+			ElementSettings struct {
+				Properties struct {
+					Tag  struct{ Enum []string }
+					Link struct {
+						Properties struct {
+							Type struct{ Enum []string }
+						}
+					}
+				}
+			}
+			Bindings struct {
+				Properties struct {
+					CSSVariables struct {
+						Items struct {
+							Properties struct {
+								Source struct{ Enum []string }
+							}
+						}
+					} `json:"cssVariables"`
+				}
+			}
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"meta.version", aipatch.Version, schema.Properties.Meta.Properties.Version.Const},
+		{"meta.source", aipatch.Sources, schema.Properties.Meta.Properties.Source.Enum},
+		{"patchMode", aipatch.Modes, schema.Properties.PatchMode.Enum},
+		{"node id pattern", aipatch.NodeIDPattern.String(), schema.Defs.BricksNode.Properties.ID.Pattern},
+		{"settings.tag", aipatch.Tags, schema.Defs.ElementSettings.Properties.Tag.Enum},
+		{"settings.link.type", aipatch.LinkTypes, schema.Defs.ElementSettings.Properties.Link.Properties.Type.Enum},
+		{"cssVariables.source", aipatch.VariableSources, schema.Defs.Bindings.Properties.CSSVariables.Items.Properties.Source.Enum},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s: Go has %v, schema has %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestValidate(t *testing.T) {
+	valid := `{
+		"meta": {"version": "1.0", "source": "ai-transform", "model": "gpt-4o", "timestamp": 1771205000},
+		"patchMode": "insert",
+		"targetParent": 0,
+		"targetIndex": 1,
+		"nodes": [{"name": "section", "children": [{"id": "hd0001", "name": "heading", "settings": {"text": "Hi", "tag": "h2"}}]}],
+		"bindings": {"globalClasses": [{"id": "abc", "name": "hero"}]}
+	}`
+	if errs := aipatch.Validate(decode(t, valid)); len(errs) > 0 {
+		t.Fatalf("expected valid document, got %v", errs)
+	}
+
+	invalid := `{
+		"meta": {"version": "2.0"},
+		"patchMode": "upsert",
+		"targetIndex": -1,
+		"nodes": [
+			{"id": "hd0001", "name": "heading", "settings": {"tag": "h7", "_cssGlobalClasses": ["a", 3]}},
+			{"id": "hd0001", "children": [{"id": "BAD", "name": "text"}]}
+		],
+		"assets": {"media": [{"url": "not a url", "width": 1.5}]},
+		"bindings": {"globalClasses": [{"id": "x"}]}
+	}`
+	errs := aipatch.Validate(decode(t, invalid))
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Path)
+	}
+	sort.Strings(got)
+	want := []string{
+		"/assets/media/0/url",
+		"/assets/media/0/width",
+		"/bindings/globalClasses/0/name",
+		"/meta/source",
+		"/meta/version",
+		"/nodes/0/settings/_cssGlobalClasses/1",
+		"/nodes/0/settings/tag",
+		"/nodes/1/children/0/id",
+		"/nodes/1/id",
+		"/nodes/1/name",
+		"/patchMode",
+		"/targetIndex",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("error paths:\n got  %v\n want %v", got, want)
+	}
+
+	errs = aipatch.Validate(decode(t, `{"meta": {"version": "1.0", "source": "manual"}, "patchMode": "delete", "nodes": []}`))
+	if len(errs) != 1 || errs[0].Path != "/targetElementIds" {
+		t.Errorf("expected delete without targets to fail at /targetElementIds, got %v", errs)
+	}
+}
+
+func page() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"id": "sec001", "name": "section", "parent": "0", "children": []interface{}{"a00001", "b00001", "c00001"}, "settings": map[string]interface{}{}},
+		{"id": "a00001", "name": "heading", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{}},
+		{"id": "b00001", "name": "block", "parent": "sec001", "children": []interface{}{"b00002"}, "settings": map[string]interface{}{}},
+		{"id": "b00002", "name": "text-basic", "parent": "b00001", "children": []interface{}{}, "settings": map[string]interface{}{}},
+		{"id": "c00001", "name": "button", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{}},
+	}
+}
+
+func apply(t *testing.T, doc string) ([]map[string]interface{}, *aipatch.Plan) {
+	t.Helper()
+	p, err := aipatch.Parse([]byte(doc))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	plan, err := p.Plan(page())
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	out, _, err := batch.Apply(page(), plan.Ops)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	return out, plan
+}
+
+func children(elements []map[string]interface{}, id string) []interface{} {
+	for _, el := range elements {
+		if el["id"] == id {
+			c, _ := el["children"].([]interface{})
+			return c
+		}
+	}
+	return nil
+}
+
+func TestPlanModes(t *testing.T) {
+	meta := `"meta": {"version": "1.0", "source": "manual"}`
+
+	out, plan := apply(t, `{`+meta+`, "patchMode": "insert", "targetParent": "sec001", "targetIndex": 1,
+		"nodes": [{"id": "n00001", "name": "block", "children": [{"name": "heading"}]}]}`)
+	if got := children(out, "sec001"); !reflect.DeepEqual(got, []interface{}{"a00001", "n00001", "b00001", "c00001"}) {
+		t.Errorf("insert: sec001 children = %v", got)
+	}
+	if len(plan.Added) != 2 || len(children(out, "n00001")) != 1 {
+		t.Errorf("insert: nested node not attached, added %v", plan.Added)
+	}
+
+	out, _ = apply(t, `{`+meta+`, "patchMode": "append", "targetParent": "sec001", "nodes": [{"id": "n00001", "name": "image"}]}`)
+	if got := children(out, "sec001"); len(got) != 4 || got[3] != "n00001" {
+		t.Errorf("append: sec001 children = %v", got)
+	}
+
+	out, plan = apply(t, `{`+meta+`, "patchMode": "replace", "targetElementIds": ["b00001"], "nodes": [{"id": "b00001", "name": "div"}]}`)
+	if got := children(out, "sec001"); !reflect.DeepEqual(got, []interface{}{"a00001", "b00001", "c00001"}) {
+		t.Errorf("replace: sec001 children = %v", got)
+	}
+	if !reflect.DeepEqual(plan.Removed, []string{"b00001", "b00002"}) || len(out) != 4 {
+		t.Errorf("replace: expected subtree removed, removed %v, %d elements left", plan.Removed, len(out))
+	}
+
+	out, plan = apply(t, `{`+meta+`, "patchMode": "delete", "targetElementIds": ["b00001"], "nodes": []}`)
+	if len(out) != 3 || len(plan.Removed) != 2 {
+		t.Errorf("delete: expected subtree removed, got %d elements", len(out))
+	}
+
+	p, _ := aipatch.Parse([]byte(`{` + meta + `, "patchMode": "append", "targetParent": "nope00", "nodes": [{"name": "image"}]}`))
+	if _, err := p.Plan(page()); err == nil {
+		t.Error("expected missing target parent to fail")
+	}
+	p, _ = aipatch.Parse([]byte(`{` + meta + `, "patchMode": "append", "nodes": [{"id": "a00001", "name": "image"}]}`))
+	if _, err := p.Plan(page()); err == nil {
+		t.Error("expected a node ID already on the page to fail")
+	}
+}
+
+func TestSnapshotLabelAndBindings(t *testing.T) {
+	p, err := aipatch.Parse([]byte(`{
+		"meta": {"version": "1.0", "source": "ai-transform", "model": "gpt-4o", "instruction": "Make the\n heading larger"},
+		"patchMode": "append",
+		"nodes": [{"name": "heading", "settings": {"_cssGlobalClasses": ["old1", "keep"]}}],
+		"bindings": {"globalClasses": [{"id": "old1", "name": "hero"}, {"id": "gone", "name": "missing"}]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.SnapshotLabel(); got != "gpt-4o: Make the heading larger" {
+		t.Errorf("label = %q", got)
+	}
+
+	missing := p.RemapClasses(func(name string) (string, bool) {
+		if name == "hero" {
+			return "new1", true
+		}
+		return "", false
+	})
+	if !reflect.DeepEqual(missing, []string{"missing"}) {
+		t.Errorf("missing = %v", missing)
+	}
+	classes := p.Nodes[0].Settings["_cssGlobalClasses"]
+	if !reflect.DeepEqual(classes, []interface{}{"new1", "keep"}) {
+		t.Errorf("classes = %v", classes)
+	}
+}
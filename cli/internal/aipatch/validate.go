@@ -0,0 +1,399 @@
+package aipatch
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+
+	"github.com/nerveband/agent-to-bricks/internal/elementid"
+)
+
+// Error is one schema violation. Path is a JSON Pointer (RFC 6901) to the
+// offending value; for a missing property it points at where the property
+// should be.
+type Error struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "(document)"
+	}
+	return path + ": " + e.Message
+}
+
+// Errors collects every violation found in a document.
+type Errors []Error
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// NodeIDPattern is the pattern the schema requires for node IDs.
+var NodeIDPattern = elementid.Pattern
+
+// Tags lists the settings.tag values the schema accepts.
+var Tags = []string{"div", "span", "p", "a", "h1", "h2", "h3", "h4", "h5", "h6", "header", "footer", "main", "section", "article", "aside", "nav", "ul", "ol", "li", "figure", "figcaption", "blockquote", "address"}
+
+// LinkTypes lists the settings.link.type values the schema accepts.
+var LinkTypes = []string{"external", "internal", "popup", "action"}
+
+// VariableSources lists the bindings.cssVariables[].source values the schema
+// accepts.
+var VariableSources = []string{"acss", "bricks", "custom"}
+
+// Validate checks a decoded JSON document against the Bricks AI Patch schema,
+// plus the per-mode requirements the schema describes in prose: insert and
+// append need nodes, replace needs targets and nodes, delete needs targets.
+func Validate(doc interface{}) Errors {
+	v := &validator{}
+	root, ok := v.object("", doc)
+	if !ok {
+		return v.errs
+	}
+
+	v.required("", root, "meta", "patchMode", "nodes")
+	if meta, ok := root["meta"]; ok {
+		v.meta("/meta", meta)
+	}
+	mode, _ := root["patchMode"].(string)
+	if raw, ok := root["patchMode"]; ok {
+		v.enum("/patchMode", raw, Modes)
+	}
+	if raw, ok := root["targetParent"]; ok {
+		v.stringOrInteger("/targetParent", raw)
+	}
+	if raw, ok := root["targetIndex"]; ok {
+		if n, ok := v.integer("/targetIndex", raw); ok && n < 0 {
+			v.fail("/targetIndex", "must be >= 0")
+		}
+	}
+	targets := 0
+	if raw, ok := root["targetElementIds"]; ok {
+		if items, ok := v.array("/targetElementIds", raw); ok {
+			targets = len(items)
+			for i, item := range items {
+				v.str(ptr("/targetElementIds", i), item)
+			}
+		}
+	}
+	nodes := 0
+	ids := map[string]string{}
+	if raw, ok := root["nodes"]; ok {
+		if items, ok := v.array("/nodes", raw); ok {
+			nodes = len(items)
+			for i, item := range items {
+				v.node(ptr("/nodes", i), item, ids)
+			}
+		}
+	}
+	if raw, ok := root["assets"]; ok {
+		v.assets("/assets", raw)
+	}
+	if raw, ok := root["bindings"]; ok {
+		v.bindings("/bindings", raw)
+	}
+
+	switch mode {
+	case ModeInsert, ModeAppend:
+		if _, ok := root["nodes"]; ok && nodes == 0 {
+			v.fail("/nodes", "%s needs at least one node", mode)
+		}
+	case ModeReplace:
+		if targets == 0 {
+			v.fail("/targetElementIds", "replace needs at least one target element ID")
+		}
+		if _, ok := root["nodes"]; ok && nodes == 0 {
+			v.fail("/nodes", "replace needs at least one node")
+		}
+	case ModeDelete:
+		if targets == 0 {
+			v.fail("/targetElementIds", "delete needs at least one target element ID")
+		}
+	}
+	return v.errs
+}
+
+type validator struct {
+	errs Errors
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) meta(path string, raw interface{}) {
+	m, ok := v.object(path, raw)
+	if !ok {
+		return
+	}
+	v.required(path, m, "version", "source")
+	if raw, ok := m["version"]; ok {
+		if s, ok := v.str(path+"/version", raw); ok && s != Version {
+			v.fail(path+"/version", "must be %q", Version)
+		}
+	}
+	if raw, ok := m["source"]; ok {
+		v.enum(path+"/source", raw, Sources)
+	}
+	if raw, ok := m["timestamp"]; ok {
+		v.integer(path+"/timestamp", raw)
+	}
+	for _, key := range []string{"model", "instruction", "bricksVersion"} {
+		if raw, ok := m[key]; ok {
+			v.str(path+"/"+key, raw)
+		}
+	}
+}
+
+func (v *validator) node(path string, raw interface{}, ids map[string]string) {
+	n, ok := v.object(path, raw)
+	if !ok {
+		return
+	}
+	v.required(path, n, "name")
+	if raw, ok := n["id"]; ok {
+		if id, ok := v.str(path+"/id", raw); ok {
+			if !NodeIDPattern.MatchString(id) {
+				v.fail(path+"/id", "must match %s", NodeIDPattern)
+			} else if first, dup := ids[id]; dup {
+				v.fail(path+"/id", "duplicate node ID %q (first used at %s)", id, first)
+			} else {
+				ids[id] = path + "/id"
+			}
+		}
+	}
+	if raw, ok := n["name"]; ok {
+		if name, ok := v.str(path+"/name", raw); ok && name == "" {
+			v.fail(path+"/name", "must not be empty")
+		}
+	}
+	if raw, ok := n["label"]; ok {
+		v.str(path+"/label", raw)
+	}
+	if raw, ok := n["parent"]; ok {
+		v.stringOrInteger(path+"/parent", raw)
+	}
+	if raw, ok := n["children"]; ok {
+		if items, ok := v.array(path+"/children", raw); ok {
+			for i, item := range items {
+				v.node(ptr(path+"/children", i), item, ids)
+			}
+		}
+	}
+	if raw, ok := n["settings"]; ok {
+		v.settings(path+"/settings", raw)
+	}
+}
+
+func (v *validator) settings(path string, raw interface{}) {
+	s, ok := v.object(path, raw)
+	if !ok {
+		return
+	}
+	for _, key := range []string{"text", "_cssClasses", "_cssId", "style", "size"} {
+		if raw, ok := s[key]; ok {
+			v.str(path+"/"+key, raw)
+		}
+	}
+	for _, key := range []string{"_hidden", "_typography", "_background", "_border", "_gradient"} {
+		if raw, ok := s[key]; ok {
+			v.object(path+"/"+key, raw)
+		}
+	}
+	if raw, ok := s["tag"]; ok {
+		v.enum(path+"/tag", raw, Tags)
+	}
+	if raw, ok := s["_cssGlobalClasses"]; ok {
+		if items, ok := v.array(path+"/_cssGlobalClasses", raw); ok {
+			for i, item := range items {
+				v.str(ptr(path+"/_cssGlobalClasses", i), item)
+			}
+		}
+	}
+	if raw, ok := s["_attributes"]; ok {
+		if items, ok := v.array(path+"/_attributes", raw); ok {
+			for i, item := range items {
+				p := ptr(path+"/_attributes", i)
+				if attr, ok := v.object(p, item); ok {
+					v.strings(p, attr, "name", "value")
+				}
+			}
+		}
+	}
+	if raw, ok := s["link"]; ok {
+		p := path + "/link"
+		if link, ok := v.object(p, raw); ok {
+			if raw, ok := link["type"]; ok {
+				v.enum(p+"/type", raw, LinkTypes)
+			}
+			v.strings(p, link, "url")
+			if raw, ok := link["newTab"]; ok {
+				if _, ok := raw.(bool); !ok {
+					v.fail(p+"/newTab", "must be a boolean")
+				}
+			}
+			if raw, ok := link["postId"]; ok {
+				v.stringOrInteger(p+"/postId", raw)
+			}
+		}
+	}
+}
+
+func (v *validator) assets(path string, raw interface{}) {
+	a, ok := v.object(path, raw)
+	if !ok {
+		return
+	}
+	raw, ok = a["media"]
+	if !ok {
+		return
+	}
+	items, ok := v.array(path+"/media", raw)
+	if !ok {
+		return
+	}
+	for i, item := range items {
+		p := ptr(path+"/media", i)
+		m, ok := v.object(p, item)
+		if !ok {
+			continue
+		}
+		v.strings(p, m, "refId", "alt")
+		if raw, ok := m["url"]; ok {
+			if s, ok := v.str(p+"/url", raw); ok {
+				if u, err := url.Parse(s); err != nil || u.Scheme == "" {
+					v.fail(p+"/url", "must be an absolute URI")
+				}
+			}
+		}
+		for _, key := range []string{"attachmentId", "width", "height"} {
+			if raw, ok := m[key]; ok {
+				v.integer(p+"/"+key, raw)
+			}
+		}
+	}
+}
+
+func (v *validator) bindings(path string, raw interface{}) {
+	b, ok := v.object(path, raw)
+	if !ok {
+		return
+	}
+	if raw, ok := b["globalClasses"]; ok {
+		if items, ok := v.array(path+"/globalClasses", raw); ok {
+			for i, item := range items {
+				p := ptr(path+"/globalClasses", i)
+				if c, ok := v.object(p, item); ok {
+					v.required(p, c, "name")
+					v.strings(p, c, "id", "name", "category")
+					if raw, ok := c["settings"]; ok {
+						v.object(p+"/settings", raw)
+					}
+				}
+			}
+		}
+	}
+	if raw, ok := b["cssVariables"]; ok {
+		if items, ok := v.array(path+"/cssVariables", raw); ok {
+			for i, item := range items {
+				p := ptr(path+"/cssVariables", i)
+				if c, ok := v.object(p, item); ok {
+					v.strings(p, c, "name", "value")
+					if raw, ok := c["source"]; ok {
+						v.enum(p+"/source", raw, VariableSources)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) required(path string, m map[string]interface{}, keys ...string) {
+	for _, k := range keys {
+		if _, ok := m[k]; !ok {
+			v.fail(path+"/"+escape(k), "is required")
+		}
+	}
+}
+
+// strings checks that the given keys, where present, hold strings.
+func (v *validator) strings(path string, m map[string]interface{}, keys ...string) {
+	for _, k := range keys {
+		if raw, ok := m[k]; ok {
+			v.str(path+"/"+escape(k), raw)
+		}
+	}
+}
+
+func (v *validator) object(path string, raw interface{}) (map[string]interface{}, bool) {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		v.fail(path, "must be an object")
+	}
+	return m, ok
+}
+
+func (v *validator) array(path string, raw interface{}) ([]interface{}, bool) {
+	a, ok := raw.([]interface{})
+	if !ok {
+		v.fail(path, "must be an array")
+	}
+	return a, ok
+}
+
+func (v *validator) str(path string, raw interface{}) (string, bool) {
+	s, ok := raw.(string)
+	if !ok {
+		v.fail(path, "must be a string")
+	}
+	return s, ok
+}
+
+func (v *validator) integer(path string, raw interface{}) (int64, bool) {
+	f, ok := raw.(float64)
+	if !ok || f != math.Trunc(f) {
+		v.fail(path, "must be an integer")
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func (v *validator) stringOrInteger(path string, raw interface{}) {
+	if _, ok := raw.(string); ok {
+		return
+	}
+	if f, ok := raw.(float64); ok && f == math.Trunc(f) {
+		return
+	}
+	v.fail(path, "must be a string or an integer")
+}
+
+func (v *validator) enum(path string, raw interface{}, allowed []string) {
+	s, ok := v.str(path, raw)
+	if !ok {
+		return
+	}
+	for _, a := range allowed {
+		if s == a {
+			return
+		}
+	}
+	v.fail(path, "must be one of %s", strings.Join(allowed, ", "))
+}
+
+func ptr(path string, index int) string {
+	return fmt.Sprintf("%s/%d", path, index)
+}
+
+// escape encodes a reference token per RFC 6901.
+func escape(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
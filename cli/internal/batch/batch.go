@@ -0,0 +1,241 @@
+// Package batch simulates batch element operations locally, mirroring the
+// plugin's POST /pages/{id}/elements/batch endpoint.
+package batch
+
+import (
+	"fmt"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	"github.com/nerveband/agent-to-bricks/internal/merge"
+)
+
+// OpError reports which operation stopped a batch.
+type OpError struct {
+	Index int
+	Op    string
+	Err   error
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("batch op %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+func (e *OpError) Unwrap() error { return e.Err }
+
+// Apply runs ops against a copy of elements. It returns the resulting list and
+// one result per operation. The batch is atomic: on the first failing
+// operation it returns an *OpError and no elements.
+func Apply(elements []map[string]interface{}, ops []client.BatchOp) ([]map[string]interface{}, []client.BatchOpResult, error) {
+	out, err := merge.ApplyPatches(elements, nil) // deep copy
+	if err != nil {
+		return nil, nil, err
+	}
+	var results []client.BatchOpResult
+
+	for i, op := range ops {
+		var res client.BatchOpResult
+		var err error
+		out, res, err = applyOne(out, op)
+		if err != nil {
+			return nil, nil, &OpError{Index: i, Op: op.Op, Err: err}
+		}
+		results = append(results, res)
+	}
+	return out, results, nil
+}
+
+func applyOne(elements []map[string]interface{}, op client.BatchOp) ([]map[string]interface{}, client.BatchOpResult, error) {
+	res := client.BatchOpResult{Op: op.Op}
+	switch op.Op {
+	case client.BatchAppend, client.BatchInsert:
+		if len(op.Elements) == 0 {
+			return nil, res, fmt.Errorf("no elements")
+		}
+		existing := ids(elements)
+		for _, el := range op.Elements {
+			id, _ := el["id"].(string)
+			name, _ := el["name"].(string)
+			if id == "" || name == "" {
+				return nil, res, fmt.Errorf("element needs an id and a name")
+			}
+			if existing[id] {
+				return nil, res, fmt.Errorf("element %q already exists", id)
+			}
+			existing[id] = true
+		}
+		newEls, _ := merge.ApplyPatches(op.Elements, nil)
+		if op.Op == client.BatchAppend && op.ParentID == "" {
+			elements = append(elements, newEls...)
+		} else {
+			index := -1
+			if op.Op == client.BatchInsert && op.Index != nil {
+				index = *op.Index
+			}
+			placed, err := place(elements, newEls, op.ParentID, index)
+			if err != nil {
+				return nil, res, err
+			}
+			elements = placed
+		}
+		res.Added = len(newEls)
+
+	case client.BatchPatch:
+		patched, err := merge.ApplyPatches(elements, op.Patches)
+		if err != nil {
+			return nil, res, err
+		}
+		elements = patched
+		res.Patched = len(op.Patches)
+
+	case client.BatchMove:
+		pos := -1
+		for i, el := range elements {
+			if id, _ := el["id"].(string); id == op.ID {
+				pos = i
+				break
+			}
+		}
+		if pos < 0 {
+			return nil, res, fmt.Errorf("element %q not found", op.ID)
+		}
+		if isDescendant(elements, op.ParentID, op.ID) {
+			return nil, res, fmt.Errorf("cannot move %q into itself", op.ID)
+		}
+		moving := elements[pos]
+		elements = append(elements[:pos:pos], elements[pos+1:]...)
+		for _, el := range elements {
+			without(el, map[string]bool{op.ID: true})
+		}
+		index := -1
+		if op.Index != nil {
+			index = *op.Index
+		}
+		placed, err := place(elements, []map[string]interface{}{moving}, op.ParentID, index)
+		if err != nil {
+			return nil, res, err
+		}
+		elements = placed
+		res.Moved = 1
+
+	case client.BatchDelete:
+		drop := map[string]bool{}
+		for _, id := range op.IDs {
+			drop[id] = true
+		}
+		kept := elements[:0:0]
+		for _, el := range elements {
+			if id, _ := el["id"].(string); !drop[id] {
+				without(el, drop)
+				kept = append(kept, el)
+			}
+		}
+		elements = kept
+		res.Deleted = len(op.IDs)
+
+	default:
+		return nil, res, fmt.Errorf("unknown operation %q", op.Op)
+	}
+	return elements, res, nil
+}
+
+// place puts newEls under parentID at a child index (-1 for the end). Only
+// the top of the inserted subtree is attached; for the page root the flat
+// order decides position, as it does in Bricks.
+func place(elements, newEls []map[string]interface{}, parentID string, index int) ([]map[string]interface{}, error) {
+	if parentID == "" {
+		parentID = "0"
+	}
+	newIDs := ids(newEls)
+	var top []interface{}
+	for _, el := range newEls {
+		if p, _ := el["parent"].(string); !newIDs[p] {
+			el["parent"] = parentID
+			top = append(top, el["id"])
+		}
+	}
+
+	if parentID == "0" {
+		pos, roots := len(elements), 0
+		for i, el := range elements {
+			if isRoot(el) {
+				if roots == index {
+					pos = i
+					break
+				}
+				roots++
+			}
+		}
+		out := append([]map[string]interface{}{}, elements[:pos]...)
+		out = append(out, newEls...)
+		return append(out, elements[pos:]...), nil
+	}
+
+	for _, el := range elements {
+		if id, _ := el["id"].(string); id != parentID {
+			continue
+		}
+		children, _ := el["children"].([]interface{})
+		at := len(children)
+		if index >= 0 && index < at {
+			at = index
+		}
+		merged := append([]interface{}{}, children[:at]...)
+		merged = append(merged, top...)
+		el["children"] = append(merged, children[at:]...)
+		return append(elements, newEls...), nil
+	}
+	return nil, fmt.Errorf("parent element %q not found", parentID)
+}
+
+func isDescendant(elements []map[string]interface{}, id, ancestor string) bool {
+	parents := map[string]string{}
+	for _, el := range elements {
+		eid, _ := el["id"].(string)
+		p, _ := el["parent"].(string)
+		parents[eid] = p
+	}
+	seen := map[string]bool{}
+	for id != "" && id != "0" && !seen[id] {
+		if id == ancestor {
+			return true
+		}
+		seen[id] = true
+		id = parents[id]
+	}
+	return false
+}
+
+func isRoot(el map[string]interface{}) bool {
+	switch p := el["parent"].(type) {
+	case string:
+		return p == "" || p == "0"
+	case float64:
+		return p == 0
+	}
+	return true
+}
+
+// without removes dropped IDs from an element's children.
+func without(el map[string]interface{}, drop map[string]bool) {
+	list, ok := el["children"].([]interface{})
+	if !ok {
+		return
+	}
+	out := make([]interface{}, 0, len(list))
+	for _, c := range list {
+		if s, _ := c.(string); !drop[s] {
+			out = append(out, c)
+		}
+	}
+	el["children"] = out
+}
+
+func ids(elements []map[string]interface{}) map[string]bool {
+	m := make(map[string]bool, len(elements))
+	for _, el := range elements {
+		if id, _ := el["id"].(string); id != "" {
+			m[id] = true
+		}
+	}
+	return m
+}
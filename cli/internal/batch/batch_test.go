@@ -0,0 +1,109 @@
+package batch_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/batch"
+	"github.com/nerveband/agent-to-bricks/internal/client"
+)
+
+func page() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"id": "sec001", "name": "section", "parent": "0", "children": []interface{}{"a00001", "b00001"}, "settings": map[string]interface{}{}},
+		{"id": "a00001", "name": "heading", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{"text": "Hello"}},
+		{"id": "b00001", "name": "text-basic", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{}},
+		{"id": "sec002", "name": "section", "parent": "0", "children": []interface{}{}, "settings": map[string]interface{}{}},
+	}
+}
+
+func find(elements []map[string]interface{}, id string) map[string]interface{} {
+	for _, el := range elements {
+		if el["id"] == id {
+			return el
+		}
+	}
+	return nil
+}
+
+func TestApply(t *testing.T) {
+	in := page()
+	ops := []client.BatchOp{
+		client.InsertOp("sec001", 0, map[string]interface{}{"id": "n00001", "name": "image"}),
+		client.PatchOp(map[string]interface{}{"id": "a00001", "settings": map[string]interface{}{"text": "Hi"}}),
+		client.MoveOp("b00001", "sec002", 0),
+		client.DeleteOp("sec002"),
+	}
+	out, results, err := batch.Apply(in, ops)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 4 || results[0].Added != 1 || results[1].Patched != 1 || results[2].Moved != 1 || results[3].Deleted != 1 {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	sec := find(out, "sec001")
+	if want := []interface{}{"n00001", "a00001"}; !reflect.DeepEqual(sec["children"], want) {
+		t.Errorf("sec001 children = %v, want %v", sec["children"], want)
+	}
+	if find(out, "n00001")["parent"] != "sec001" {
+		t.Error("inserted element not attached to its parent")
+	}
+	if find(out, "b00001")["parent"] != "sec002" {
+		t.Error("moved element not reparented")
+	}
+	if find(out, "sec002") != nil {
+		t.Error("deleted element still present")
+	}
+	if find(out, "a00001")["settings"].(map[string]interface{})["text"] != "Hi" {
+		t.Error("patch not applied")
+	}
+	if in[1]["settings"].(map[string]interface{})["text"] != "Hello" || len(in) != 4 {
+		t.Error("input was modified")
+	}
+}
+
+func TestApplyRootInsert(t *testing.T) {
+	out, _, err := batch.Apply(page(), []client.BatchOp{
+		client.InsertOp("", 1, map[string]interface{}{"id": "sec003", "name": "section"}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var roots []interface{}
+	for _, el := range out {
+		if el["parent"] == "0" {
+			roots = append(roots, el["id"])
+		}
+	}
+	if want := []interface{}{"sec001", "sec003", "sec002"}; !reflect.DeepEqual(roots, want) {
+		t.Errorf("root order = %v, want %v", roots, want)
+	}
+}
+
+func TestApplyFailingOp(t *testing.T) {
+	ops := []client.BatchOp{
+		client.DeleteOp("b00001"),
+		client.MoveOp("sec001", "a00001", 0),
+		client.PatchOp(map[string]interface{}{"id": "a00001"}),
+	}
+	out, _, err := batch.Apply(page(), ops)
+	var opErr *batch.OpError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("expected *OpError, got %v", err)
+	}
+	if opErr.Index != 1 || opErr.Op != client.BatchMove {
+		t.Errorf("expected move at index 1 to fail, got %+v", opErr)
+	}
+	if out != nil {
+		t.Error("failed batch returned elements")
+	}
+
+	_, _, err = batch.Apply(page(), []client.BatchOp{
+		client.AppendOp("", map[string]interface{}{"id": "a00001", "name": "heading"}),
+	})
+	if !errors.As(err, &opErr) || opErr.Index != 0 {
+		t.Errorf("expected duplicate ID to fail op 0, got %v", err)
+	}
+}
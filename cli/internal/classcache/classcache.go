@@ -0,0 +1,211 @@
+// Package classcache keeps a copy of each site's global classes on disk,
+// shared by the commands that need them. A copy younger than the TTL is
+// used as is; an older one is revalidated with a conditional request and
+// only fetched again in full when the site's classes have changed.
+package classcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+)
+
+// Lister fetches the site's classes, or nothing when they still match a
+// hash. *client.Client implements it.
+type Lister interface {
+	ListClassesIfChangedContext(ctx context.Context, hash string) (*client.ClassesResponse, bool, error)
+}
+
+// DefaultTTL is how long a cached copy is used without asking the site.
+const DefaultTTL = 24 * time.Hour
+
+// Status says where Get's classes came from.
+type Status string
+
+const (
+	// StatusFresh is a cached copy within the TTL; the site wasn't asked.
+	StatusFresh Status = "fresh"
+	// StatusRevalidated is a cached copy the site confirmed unchanged.
+	StatusRevalidated Status = "revalidated"
+	// StatusFetched is a full list just fetched from the site.
+	StatusFetched Status = "fetched"
+	// StatusStale is a cached copy used because the site couldn't be
+	// reached.
+	StatusStale Status = "stale"
+)
+
+// Entry is one site's cached classes.
+type Entry struct {
+	SiteURL string `json:"siteUrl"`
+	// FetchedAt is when the classes were last fetched in full.
+	FetchedAt time.Time `json:"fetchedAt"`
+	// ValidatedAt is when the site last confirmed them; the TTL runs from
+	// here.
+	ValidatedAt time.Time `json:"validatedAt"`
+	// Hash is the site's hash of the classes, empty with older plugins.
+	Hash    string                   `json:"hash,omitempty"`
+	Classes []map[string]interface{} `json:"classes"`
+}
+
+// Age returns how long ago the site last confirmed the classes.
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.ValidatedAt)
+}
+
+// Cache is a directory of per-site class caches.
+type Cache struct {
+	Dir string
+	// TTL defaults to DefaultTTL.
+	TTL time.Duration
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// New returns a cache in dir.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+func (c *Cache) ttl() time.Duration {
+	if c.TTL <= 0 {
+		return DefaultTTL
+	}
+	return c.TTL
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+var unsafeName = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// Path returns the cache file for siteURL: the site's host, readable, and
+// a hash of the full URL so sites on one host don't share a file.
+func (c *Cache) Path(siteURL string) string {
+	host := "site"
+	if u, err := url.Parse(siteURL); err == nil && u.Host != "" {
+		host = strings.Trim(unsafeName.ReplaceAllString(strings.ToLower(u.Host), "-"), "-")
+	}
+	sum := sha256.Sum256([]byte(strings.TrimRight(siteURL, "/")))
+	return filepath.Join(c.Dir, host+"-"+hex.EncodeToString(sum[:4])+".json")
+}
+
+// Fresh reports whether an entry is within the TTL.
+func (c *Cache) Fresh(e *Entry) bool {
+	return e.Age(c.now()) < c.ttl()
+}
+
+// Load reads the cached classes for siteURL. A missing cache is an error
+// satisfying errors.Is(err, os.ErrNotExist).
+func (c *Cache) Load(siteURL string) (*Entry, error) {
+	data, err := os.ReadFile(c.Path(siteURL))
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("unreadable class cache: %w", err)
+	}
+	if strings.TrimRight(e.SiteURL, "/") != strings.TrimRight(siteURL, "/") {
+		return nil, fmt.Errorf("class cache is for %s", e.SiteURL)
+	}
+	return &e, nil
+}
+
+// Save writes an entry to its site's cache file.
+func (c *Cache) Save(e *Entry) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.Path(e.SiteURL), data, 0644)
+}
+
+// Get returns siteURL's classes. A cached copy within the TTL is used
+// without asking the site unless revalidate is set; otherwise the site is
+// asked whether the cached hash still holds, and the classes are fetched in
+// full only if not. When the site can't be reached and a cached copy
+// exists, Get returns it with StatusStale along with the error.
+func (c *Cache) Get(ctx context.Context, l Lister, siteURL string, revalidate bool) (*Entry, Status, error) {
+	cached, _ := c.Load(siteURL)
+	if cached != nil && !revalidate && c.Fresh(cached) {
+		return cached, StatusFresh, nil
+	}
+	hash := ""
+	if cached != nil {
+		hash = cached.Hash
+	}
+	resp, changed, err := l.ListClassesIfChangedContext(ctx, hash)
+	if err != nil {
+		if cached != nil {
+			return cached, StatusStale, err
+		}
+		return nil, "", err
+	}
+	now := c.now().UTC()
+	if !changed && cached != nil {
+		cached.ValidatedAt = now
+		return cached, StatusRevalidated, c.Save(cached)
+	}
+	if resp == nil {
+		return nil, "", errors.New("site sent no classes")
+	}
+	e := &Entry{SiteURL: siteURL, FetchedAt: now, ValidatedAt: now, Hash: resp.Hash, Classes: resp.Classes}
+	return e, StatusFetched, c.Save(e)
+}
+
+// Refresh fetches siteURL's classes in full and replaces the cached copy.
+func (c *Cache) Refresh(ctx context.Context, l Lister, siteURL string) (*Entry, error) {
+	resp, _, err := l.ListClassesIfChangedContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errors.New("site sent no classes")
+	}
+	now := c.now().UTC()
+	e := &Entry{SiteURL: siteURL, FetchedAt: now, ValidatedAt: now, Hash: resp.Hash, Classes: resp.Classes}
+	return e, c.Save(e)
+}
+
+// Clear removes siteURL's cached classes, reporting whether there were
+// any.
+func (c *Cache) Clear(siteURL string) (bool, error) {
+	err := os.Remove(c.Path(siteURL))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ClearAll removes every site's cached classes and returns how many files
+// it removed.
+func (c *Cache) ClearAll() (int, error) {
+	files, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	for i, f := range files {
+		if err := os.Remove(f); err != nil {
+			return i, err
+		}
+	}
+	return len(files), nil
+}
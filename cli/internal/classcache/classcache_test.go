@@ -0,0 +1,112 @@
+package classcache_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/classcache"
+	"github.com/nerveband/agent-to-bricks/internal/client"
+)
+
+// fakeSite serves classes under a hash, counting full and conditional
+// requests.
+type fakeSite struct {
+	hash        string
+	classes     []map[string]interface{}
+	err         error
+	full, fresh int
+}
+
+func (s *fakeSite) ListClassesIfChangedContext(ctx context.Context, hash string) (*client.ClassesResponse, bool, error) {
+	if s.err != nil {
+		return nil, false, s.err
+	}
+	if hash != "" && hash == s.hash {
+		s.fresh++
+		return nil, false, nil
+	}
+	s.full++
+	return &client.ClassesResponse{Classes: s.classes, Count: len(s.classes), Hash: s.hash}, true, nil
+}
+
+func newCache(t *testing.T, now *time.Time) *classcache.Cache {
+	c := classcache.New(t.TempDir(), time.Hour)
+	c.Now = func() time.Time { return *now }
+	return c
+}
+
+func TestGet_TTLAndRevalidation(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newCache(t, &now)
+	site := &fakeSite{hash: "h1", classes: []map[string]interface{}{{"id": "c1", "name": "card"}}}
+	ctx := context.Background()
+
+	e, status, err := c.Get(ctx, site, "https://a.test", false)
+	if err != nil || status != classcache.StatusFetched || len(e.Classes) != 1 {
+		t.Fatalf("expected a full fetch, got %v %v %v", e, status, err)
+	}
+
+	// Within the TTL the site isn't asked
+	now = now.Add(30 * time.Minute)
+	if _, status, _ = c.Get(ctx, site, "https://a.test", false); status != classcache.StatusFresh || site.full+site.fresh != 1 {
+		t.Errorf("expected the cached copy used, got %v after %d requests", status, site.full+site.fresh)
+	}
+
+	// Past it, or when asked to, a conditional request revalidates
+	now = now.Add(time.Hour)
+	if _, status, _ = c.Get(ctx, site, "https://a.test", false); status != classcache.StatusRevalidated || site.full != 1 {
+		t.Errorf("expected revalidation without a full fetch, got %v", status)
+	}
+	if e, _ := c.Load("https://a.test"); !e.ValidatedAt.Equal(now) || e.FetchedAt.Equal(now) {
+		t.Errorf("expected only the validation time moved, got %+v", e)
+	}
+
+	site.hash, site.classes = "h2", append(site.classes, map[string]interface{}{"id": "c2", "name": "hero"})
+	e, status, _ = c.Get(ctx, site, "https://a.test", true)
+	if status != classcache.StatusFetched || len(e.Classes) != 2 || e.Hash != "h2" {
+		t.Errorf("expected changed classes fetched, got %v %+v", status, e)
+	}
+
+	// An unreachable site falls back to the cached copy
+	site.err = errors.New("offline")
+	e, status, err = c.Get(ctx, site, "https://a.test", true)
+	if err == nil || status != classcache.StatusStale || len(e.Classes) != 2 {
+		t.Errorf("expected the stale copy with the error, got %v %v", status, err)
+	}
+	if _, _, err = c.Get(ctx, site, "https://b.test", false); err == nil {
+		t.Error("expected an error without a cached copy")
+	}
+}
+
+func TestCache_SiteScoping(t *testing.T) {
+	now := time.Now()
+	c := newCache(t, &now)
+	site := &fakeSite{hash: "h", classes: []map[string]interface{}{{"id": "c1", "name": "card"}}}
+	if _, err := c.Refresh(context.Background(), site, "https://a.test/blog"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Path("https://a.test/blog") == c.Path("https://a.test/shop") {
+		t.Error("expected sites on one host to get their own files")
+	}
+	if _, err := c.Load("https://a.test/shop"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no cache for another site, got %v", err)
+	}
+	if _, err := c.Load("https://a.test/blog/"); err != nil {
+		t.Errorf("expected a trailing slash to name the same site, got %v", err)
+	}
+
+	if removed, err := c.Clear("https://a.test/blog"); !removed || err != nil {
+		t.Errorf("expected the cache cleared, got %v %v", removed, err)
+	}
+	if removed, _ := c.Clear("https://a.test/blog"); removed {
+		t.Error("expected nothing left to clear")
+	}
+	c.Refresh(context.Background(), site, "https://a.test")
+	c.Refresh(context.Background(), site, "https://b.test")
+	if n, err := c.ClearAll(); n != 2 || err != nil {
+		t.Errorf("expected 2 caches cleared, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+)
+
+// Batch operation names accepted by POST /pages/{id}/elements/batch.
+const (
+	BatchAppend = "append"
+	BatchInsert = "insert"
+	BatchPatch  = "patch"
+	BatchMove   = "move"
+	BatchDelete = "delete"
+)
+
+// BatchOp is one operation in a BatchElements call. Build it with AppendOp,
+// InsertOp, PatchOp, MoveOp or DeleteOp, or decode it from JSON. An empty
+// ParentID means the page root.
+type BatchOp struct {
+	Op       string                   `json:"op"`
+	Elements []map[string]interface{} `json:"elements,omitempty"`
+	Patches  []map[string]interface{} `json:"patches,omitempty"`
+	IDs      []string                 `json:"ids,omitempty"`
+	ID       string                   `json:"id,omitempty"`
+	ParentID string                   `json:"parentId,omitempty"`
+	Index    *int                     `json:"index,omitempty"`
+}
+
+// AppendOp adds elements after the last child of parentID. With an empty
+// parentID the elements are appended to the flat list unchanged.
+func AppendOp(parentID string, elements ...map[string]interface{}) BatchOp {
+	return BatchOp{Op: BatchAppend, ParentID: parentID, Elements: elements}
+}
+
+// InsertOp adds elements at a child index of parentID.
+func InsertOp(parentID string, index int, elements ...map[string]interface{}) BatchOp {
+	return BatchOp{Op: BatchInsert, ParentID: parentID, Index: &index, Elements: elements}
+}
+
+// PatchOp applies element patches, as PatchElements does.
+func PatchOp(patches ...map[string]interface{}) BatchOp {
+	return BatchOp{Op: BatchPatch, Patches: patches}
+}
+
+// MoveOp moves an element and its subtree to a child index of parentID.
+func MoveOp(id, parentID string, index int) BatchOp {
+	return BatchOp{Op: BatchMove, ID: id, ParentID: parentID, Index: &index}
+}
+
+// DeleteOp removes elements by ID.
+func DeleteOp(ids ...string) BatchOp {
+	return BatchOp{Op: BatchDelete, IDs: ids}
+}
+
+// BatchOpResult reports what one operation changed.
+type BatchOpResult struct {
+	Op      string `json:"op"`
+	Added   int    `json:"added,omitempty"`
+	Patched int    `json:"patched,omitempty"`
+	Moved   int    `json:"moved,omitempty"`
+	Deleted int    `json:"deleted,omitempty"`
+}
+
+// BatchResponse from POST /pages/{id}/elements/batch.
+type BatchResponse struct {
+	Success     bool            `json:"success"`
+	ContentHash string          `json:"contentHash"`
+	Operations  []BatchOpResult `json:"operations"`
+	Count       int             `json:"count"`
+}
+
+// BatchOpError is a batch the plugin stopped at one operation; nothing was
+// written. It wraps the HTTP error.
+type BatchOpError struct {
+	Index   int
+	Message string
+	Err     error
+}
+
+func (e *BatchOpError) Error() string {
+	return fmt.Sprintf("batch op %d: %s", e.Index, e.Message)
+}
+
+func (e *BatchOpError) Unwrap() error { return e.Err }
+
+// BatchElements applies several operations in one atomic write guarded by
+// ifMatch. Either every operation is applied or none is.
+func (c *Client) BatchElements(pageID int, ops []BatchOp, ifMatch string) (*BatchResponse, error) {
+	return c.BatchElementsContext(context.Background(), pageID, ops, ifMatch)
+}
+
+// BatchElementsContext is BatchElements with a caller-supplied context. When
+// the plugin rejects an operation the error is a *BatchOpError.
+func (c *Client) BatchElementsContext(ctx context.Context, pageID int, ops []BatchOp, ifMatch string) (*BatchResponse, error) {
+	payload, _ := json.Marshal(map[string]interface{}{"operations": ops})
+	headers := map[string]string{}
+	if ifMatch != "" {
+		headers["If-Match"] = ifMatch
+	}
+	resp, err := c.doWithHeaders(ctx, "POST", fmt.Sprintf("/pages/%d/elements/batch", pageID), strings.NewReader(string(payload)), headers)
+	if err != nil {
+		return nil, batchOpError(err)
+	}
+	defer resp.Body.Close()
+	var result BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// batchOpError turns a plugin error naming failedOp into a *BatchOpError.
+func batchOpError(err error) error {
+	var cliErr *clierrors.CLIError
+	if !stderrors.As(err, &cliErr) {
+		return err
+	}
+	_, body, _ := strings.Cut(cliErr.Message, ": ")
+	var payload struct {
+		Error    string `json:"error"`
+		FailedOp *int   `json:"failedOp"`
+	}
+	if json.Unmarshal([]byte(body), &payload) != nil || payload.FailedOp == nil {
+		return err
+	}
+	msg := strings.TrimPrefix(payload.Error, fmt.Sprintf("Batch op %d: ", *payload.FailedOp))
+	return &BatchOpError{Index: *payload.FailedOp, Message: msg, Err: err}
+}
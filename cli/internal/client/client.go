@@ -2,7 +2,9 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -11,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
 )
@@ -20,17 +23,22 @@ type Client struct {
 	baseURL           string
 	apiKey            string
 	httpClient        *http.Client
+	timeout           time.Duration
+	retry             RetryPolicy
 	cliVersion        string
 	lastPluginVersion string
 	versionWarned     bool
 }
 
-// New creates a client using X-ATB-Key authentication.
+// New creates a client using X-ATB-Key authentication, with the default
+// per-request timeout and retry policy.
 func New(baseURL, apiKey string) *Client {
 	return &Client{
 		baseURL:    strings.TrimRight(baseURL, "/"),
 		apiKey:     apiKey,
 		httpClient: &http.Client{},
+		timeout:    DefaultTimeout,
+		retry:      DefaultRetryPolicy(),
 	}
 }
 
@@ -80,25 +88,17 @@ func (c *Client) checkVersionMismatch() {
 	fmt.Fprintf(os.Stderr, "\n  Version mismatch: CLI v%s, plugin v%s. Run: bricks update\n\n", cli, plugin)
 }
 
-func (c *Client) do(method, path string, body io.Reader) (*http.Response, error) {
-	return c.doWithHeaders(method, path, body, nil)
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return c.doWithHeaders(ctx, method, path, body, nil)
 }
 
-func (c *Client) doWithHeaders(method, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
+func (c *Client) doWithHeaders(ctx context.Context, method, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
 	url := c.baseURL + "/wp-json/agent-bricks/v1" + path
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("X-ATB-Key", c.apiKey)
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(ctx, method, url, body, headers)
 	if err != nil {
+		if stderrors.Is(err, context.DeadlineExceeded) {
+			return nil, clierrors.APIError("API_TIMEOUT", fmt.Sprintf("%s %s timed out: %v", method, path, err))
+		}
 		return nil, err
 	}
 	// Read plugin version header for mismatch detection
@@ -133,24 +133,67 @@ type SnapshotsListResponse struct {
 	Snapshots []Snapshot `json:"snapshots"`
 }
 
-// Snapshot represents a single snapshot entry.
+// Snapshot represents a single snapshot entry. The plugin lists snapshots
+// oldest first. Timestamp is in the site's timezone; TimestampGMT is empty
+// for snapshots taken by older plugins.
 type Snapshot struct {
-	ID          string `json:"id"`
-	ContentHash string `json:"contentHash"`
-	Label       string `json:"label"`
-	CreatedAt   string `json:"createdAt"`
-	Count       int    `json:"count"`
+	SnapshotID   string `json:"snapshotId"`
+	ContentHash  string `json:"contentHash"`
+	ElementCount int    `json:"elementCount"`
+	Timestamp    string `json:"timestamp"`
+	TimestampGMT string `json:"timestampGmt,omitempty"`
+	Label        string `json:"label"`
+}
+
+// CreatedAt parses the snapshot time, preferring the GMT timestamp. Without
+// one the site-local timestamp is read as local time.
+func (s Snapshot) CreatedAt() (time.Time, bool) {
+	return parseSnapshotTime(s.TimestampGMT, s.Timestamp)
+}
+
+// SnapshotDetail from GET /pages/{id}/snapshots/{snapshot_id}.
+type SnapshotDetail struct {
+	SnapshotID   string                   `json:"snapshotId"`
+	ContentHash  string                   `json:"contentHash"`
+	ElementCount int                      `json:"elementCount"`
+	Timestamp    string                   `json:"timestamp"`
+	TimestampGMT string                   `json:"timestampGmt,omitempty"`
+	Label        string                   `json:"label"`
+	Elements     []map[string]interface{} `json:"elements"`
+}
+
+func parseSnapshotTime(gmt, local string) (time.Time, bool) {
+	const layout = "2006-01-02 15:04:05"
+	if t, err := time.ParseInLocation(layout, gmt, time.UTC); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation(layout, local, time.Local); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// DeleteSnapshotResponse from DELETE /pages/{id}/snapshots/{snapshot_id}.
+type DeleteSnapshotResponse struct {
+	Deleted   string `json:"deleted"`
+	Remaining int    `json:"remaining"`
 }
 
 // RollbackResponse from POST /pages/{id}/snapshots/{snapshot_id}/rollback.
 type RollbackResponse struct {
 	Success     bool   `json:"success"`
 	ContentHash string `json:"contentHash"`
-	Restored    string `json:"restored"`
+	Count       int    `json:"count"`
+	Restored    string `json:"restoredFrom"`
 }
 
 func (c *Client) GetElements(pageID int) (*ElementsResponse, error) {
-	resp, err := c.do("GET", fmt.Sprintf("/pages/%d/elements", pageID), nil)
+	return c.GetElementsContext(context.Background(), pageID)
+}
+
+// GetElementsContext is GetElements with a caller-supplied context.
+func (c *Client) GetElementsContext(ctx context.Context, pageID int) (*ElementsResponse, error) {
+	resp, err := c.do(ctx, "GET", fmt.Sprintf("/pages/%d/elements", pageID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +206,12 @@ func (c *Client) GetElements(pageID int) (*ElementsResponse, error) {
 }
 
 func (c *Client) GetSiteInfo() (*SiteInfoResponse, error) {
-	resp, err := c.do("GET", "/site/info", nil)
+	return c.GetSiteInfoContext(context.Background())
+}
+
+// GetSiteInfoContext is GetSiteInfo with a caller-supplied context.
+func (c *Client) GetSiteInfoContext(ctx context.Context) (*SiteInfoResponse, error) {
+	resp, err := c.do(ctx, "GET", "/site/info", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -176,7 +224,12 @@ func (c *Client) GetSiteInfo() (*SiteInfoResponse, error) {
 }
 
 func (c *Client) GetFrameworks() (*FrameworksResponse, error) {
-	resp, err := c.do("GET", "/site/frameworks", nil)
+	return c.GetFrameworksContext(context.Background())
+}
+
+// GetFrameworksContext is GetFrameworks with a caller-supplied context.
+func (c *Client) GetFrameworksContext(ctx context.Context) (*FrameworksResponse, error) {
+	resp, err := c.do(ctx, "GET", "/site/frameworks", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -190,12 +243,17 @@ func (c *Client) GetFrameworks() (*FrameworksResponse, error) {
 
 // ReplaceElements does a full PUT replace of all elements on a page.
 func (c *Client) ReplaceElements(pageID int, elements []map[string]interface{}, ifMatch string) (*MutationResponse, error) {
+	return c.ReplaceElementsContext(context.Background(), pageID, elements, ifMatch)
+}
+
+// ReplaceElementsContext is ReplaceElements with a caller-supplied context.
+func (c *Client) ReplaceElementsContext(ctx context.Context, pageID int, elements []map[string]interface{}, ifMatch string) (*MutationResponse, error) {
 	payload, _ := json.Marshal(map[string]interface{}{"elements": elements})
 	headers := map[string]string{}
 	if ifMatch != "" {
 		headers["If-Match"] = ifMatch
 	}
-	resp, err := c.doWithHeaders("PUT", fmt.Sprintf("/pages/%d/elements", pageID), strings.NewReader(string(payload)), headers)
+	resp, err := c.doWithHeaders(ctx, "PUT", fmt.Sprintf("/pages/%d/elements", pageID), strings.NewReader(string(payload)), headers)
 	if err != nil {
 		return nil, err
 	}
@@ -209,12 +267,17 @@ func (c *Client) ReplaceElements(pageID int, elements []map[string]interface{},
 
 // PatchElements updates specific elements by ID.
 func (c *Client) PatchElements(pageID int, patches []map[string]interface{}, ifMatch string) (*MutationResponse, error) {
+	return c.PatchElementsContext(context.Background(), pageID, patches, ifMatch)
+}
+
+// PatchElementsContext is PatchElements with a caller-supplied context.
+func (c *Client) PatchElementsContext(ctx context.Context, pageID int, patches []map[string]interface{}, ifMatch string) (*MutationResponse, error) {
 	payload, _ := json.Marshal(map[string]interface{}{"patches": patches})
 	headers := map[string]string{}
 	if ifMatch != "" {
 		headers["If-Match"] = ifMatch
 	}
-	resp, err := c.doWithHeaders("PATCH", fmt.Sprintf("/pages/%d/elements", pageID), strings.NewReader(string(payload)), headers)
+	resp, err := c.doWithHeaders(ctx, "PATCH", fmt.Sprintf("/pages/%d/elements", pageID), strings.NewReader(string(payload)), headers)
 	if err != nil {
 		return nil, err
 	}
@@ -228,12 +291,17 @@ func (c *Client) PatchElements(pageID int, patches []map[string]interface{}, ifM
 
 // AppendElements adds new elements to a page.
 func (c *Client) AppendElements(pageID int, elements []map[string]interface{}, ifMatch string) (*MutationResponse, error) {
+	return c.AppendElementsContext(context.Background(), pageID, elements, ifMatch)
+}
+
+// AppendElementsContext is AppendElements with a caller-supplied context.
+func (c *Client) AppendElementsContext(ctx context.Context, pageID int, elements []map[string]interface{}, ifMatch string) (*MutationResponse, error) {
 	payload, _ := json.Marshal(map[string]interface{}{"elements": elements})
 	headers := map[string]string{}
 	if ifMatch != "" {
 		headers["If-Match"] = ifMatch
 	}
-	resp, err := c.doWithHeaders("POST", fmt.Sprintf("/pages/%d/elements", pageID), strings.NewReader(string(payload)), headers)
+	resp, err := c.doWithHeaders(ctx, "POST", fmt.Sprintf("/pages/%d/elements", pageID), strings.NewReader(string(payload)), headers)
 	if err != nil {
 		return nil, err
 	}
@@ -247,12 +315,17 @@ func (c *Client) AppendElements(pageID int, elements []map[string]interface{}, i
 
 // DeleteElements removes elements by ID.
 func (c *Client) DeleteElements(pageID int, ids []string, ifMatch string) (*MutationResponse, error) {
+	return c.DeleteElementsContext(context.Background(), pageID, ids, ifMatch)
+}
+
+// DeleteElementsContext is DeleteElements with a caller-supplied context.
+func (c *Client) DeleteElementsContext(ctx context.Context, pageID int, ids []string, ifMatch string) (*MutationResponse, error) {
 	payload, _ := json.Marshal(map[string]interface{}{"ids": ids})
 	headers := map[string]string{}
 	if ifMatch != "" {
 		headers["If-Match"] = ifMatch
 	}
-	resp, err := c.doWithHeaders("DELETE", fmt.Sprintf("/pages/%d/elements", pageID), strings.NewReader(string(payload)), headers)
+	resp, err := c.doWithHeaders(ctx, "DELETE", fmt.Sprintf("/pages/%d/elements", pageID), strings.NewReader(string(payload)), headers)
 	if err != nil {
 		return nil, err
 	}
@@ -266,8 +339,13 @@ func (c *Client) DeleteElements(pageID int, ids []string, ifMatch string) (*Muta
 
 // CreateSnapshot creates a snapshot of the current page state.
 func (c *Client) CreateSnapshot(pageID int, label string) (*SnapshotResponse, error) {
+	return c.CreateSnapshotContext(context.Background(), pageID, label)
+}
+
+// CreateSnapshotContext is CreateSnapshot with a caller-supplied context.
+func (c *Client) CreateSnapshotContext(ctx context.Context, pageID int, label string) (*SnapshotResponse, error) {
 	payload, _ := json.Marshal(map[string]string{"label": label})
-	resp, err := c.do("POST", fmt.Sprintf("/pages/%d/snapshots", pageID), strings.NewReader(string(payload)))
+	resp, err := c.do(ctx, "POST", fmt.Sprintf("/pages/%d/snapshots", pageID), strings.NewReader(string(payload)))
 	if err != nil {
 		return nil, err
 	}
@@ -281,7 +359,12 @@ func (c *Client) CreateSnapshot(pageID int, label string) (*SnapshotResponse, er
 
 // ListSnapshots lists all snapshots for a page.
 func (c *Client) ListSnapshots(pageID int) (*SnapshotsListResponse, error) {
-	resp, err := c.do("GET", fmt.Sprintf("/pages/%d/snapshots", pageID), nil)
+	return c.ListSnapshotsContext(context.Background(), pageID)
+}
+
+// ListSnapshotsContext is ListSnapshots with a caller-supplied context.
+func (c *Client) ListSnapshotsContext(ctx context.Context, pageID int) (*SnapshotsListResponse, error) {
+	resp, err := c.do(ctx, "GET", fmt.Sprintf("/pages/%d/snapshots", pageID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -293,11 +376,52 @@ func (c *Client) ListSnapshots(pageID int) (*SnapshotsListResponse, error) {
 	return &result, nil
 }
 
+// GetSnapshot fetches a single snapshot including its elements.
+func (c *Client) GetSnapshot(pageID int, snapshotID string) (*SnapshotDetail, error) {
+	return c.GetSnapshotContext(context.Background(), pageID, snapshotID)
+}
+
+// GetSnapshotContext is GetSnapshot with a caller-supplied context.
+func (c *Client) GetSnapshotContext(ctx context.Context, pageID int, snapshotID string) (*SnapshotDetail, error) {
+	resp, err := c.do(ctx, "GET", fmt.Sprintf("/pages/%d/snapshots/%s", pageID, url.PathEscape(snapshotID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result SnapshotDetail
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeleteSnapshot removes one snapshot from a page.
+func (c *Client) DeleteSnapshot(pageID int, snapshotID string) (*DeleteSnapshotResponse, error) {
+	return c.DeleteSnapshotContext(context.Background(), pageID, snapshotID)
+}
+
+// DeleteSnapshotContext is DeleteSnapshot with a caller-supplied context.
+func (c *Client) DeleteSnapshotContext(ctx context.Context, pageID int, snapshotID string) (*DeleteSnapshotResponse, error) {
+	resp, err := c.do(ctx, "DELETE", fmt.Sprintf("/pages/%d/snapshots/%s", pageID, url.PathEscape(snapshotID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result DeleteSnapshotResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // ClassesResponse from GET /classes.
 type ClassesResponse struct {
 	Classes []map[string]interface{} `json:"classes"`
 	Count   int                      `json:"count"`
 	Total   int                      `json:"total"`
+	// Hash identifies the site's stored classes, for ListClassesIfChanged.
+	// Older plugins don't send it.
+	Hash string `json:"hash,omitempty"`
 }
 
 // StylesResponse from GET /styles.
@@ -316,13 +440,18 @@ type VariablesResponse struct {
 
 // ListClasses returns all global classes, optionally filtered by framework.
 func (c *Client) ListClasses(framework string) (*ClassesResponse, error) {
+	return c.ListClassesContext(context.Background(), framework)
+}
+
+// ListClassesContext is ListClasses with a caller-supplied context.
+func (c *Client) ListClassesContext(ctx context.Context, framework string) (*ClassesResponse, error) {
 	path := "/classes"
 	if framework != "" {
 		v := url.Values{}
 		v.Set("framework", framework)
 		path += "?" + v.Encode()
 	}
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -334,14 +463,52 @@ func (c *Client) ListClasses(framework string) (*ClassesResponse, error) {
 	return &result, nil
 }
 
+// ListClassesIfChanged lists all global classes unless they still match
+// hash (from an earlier ClassesResponse), in which case the site answers
+// 304 Not Modified and it returns nil and false. An empty hash, or a plugin
+// without revalidation support, always gets the full list.
+func (c *Client) ListClassesIfChanged(hash string) (*ClassesResponse, bool, error) {
+	return c.ListClassesIfChangedContext(context.Background(), hash)
+}
+
+// ListClassesIfChangedContext is ListClassesIfChanged with a caller-supplied
+// context.
+func (c *Client) ListClassesIfChangedContext(ctx context.Context, hash string) (*ClassesResponse, bool, error) {
+	var headers map[string]string
+	if hash != "" {
+		headers = map[string]string{"If-None-Match": `"` + hash + `"`}
+	}
+	resp, err := c.doWithHeaders(ctx, "GET", "/classes", nil, headers)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+	var result ClassesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, false, err
+	}
+	if result.Hash == "" {
+		result.Hash = strings.Trim(strings.TrimPrefix(resp.Header.Get("ETag"), "W/"), `"`)
+	}
+	return &result, true, nil
+}
+
 // CreateClass creates a new global class.
 func (c *Client) CreateClass(name string, settings map[string]interface{}) (map[string]interface{}, error) {
+	return c.CreateClassContext(context.Background(), name, settings)
+}
+
+// CreateClassContext is CreateClass with a caller-supplied context.
+func (c *Client) CreateClassContext(ctx context.Context, name string, settings map[string]interface{}) (map[string]interface{}, error) {
 	payload := map[string]interface{}{"name": name}
 	if settings != nil {
 		payload["settings"] = settings
 	}
 	data, _ := json.Marshal(payload)
-	resp, err := c.do("POST", "/classes", strings.NewReader(string(data)))
+	resp, err := c.do(ctx, "POST", "/classes", strings.NewReader(string(data)))
 	if err != nil {
 		return nil, err
 	}
@@ -355,7 +522,12 @@ func (c *Client) CreateClass(name string, settings map[string]interface{}) (map[
 
 // DeleteClass removes a global class by ID.
 func (c *Client) DeleteClass(classID string) error {
-	resp, err := c.do("DELETE", "/classes/"+classID, nil)
+	return c.DeleteClassContext(context.Background(), classID)
+}
+
+// DeleteClassContext is DeleteClass with a caller-supplied context.
+func (c *Client) DeleteClassContext(ctx context.Context, classID string) error {
+	resp, err := c.do(ctx, "DELETE", "/classes/"+classID, nil)
 	if err != nil {
 		return err
 	}
@@ -365,7 +537,12 @@ func (c *Client) DeleteClass(classID string) error {
 
 // GetStyles returns theme styles and color palette.
 func (c *Client) GetStyles() (*StylesResponse, error) {
-	resp, err := c.do("GET", "/styles", nil)
+	return c.GetStylesContext(context.Background())
+}
+
+// GetStylesContext is GetStyles with a caller-supplied context.
+func (c *Client) GetStylesContext(ctx context.Context) (*StylesResponse, error) {
+	resp, err := c.do(ctx, "GET", "/styles", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -379,7 +556,12 @@ func (c *Client) GetStyles() (*StylesResponse, error) {
 
 // GetVariables returns CSS custom properties.
 func (c *Client) GetVariables() (*VariablesResponse, error) {
-	resp, err := c.do("GET", "/variables", nil)
+	return c.GetVariablesContext(context.Background())
+}
+
+// GetVariablesContext is GetVariables with a caller-supplied context.
+func (c *Client) GetVariablesContext(ctx context.Context) (*VariablesResponse, error) {
+	resp, err := c.do(ctx, "GET", "/variables", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -393,7 +575,12 @@ func (c *Client) GetVariables() (*VariablesResponse, error) {
 
 // Rollback restores a snapshot.
 func (c *Client) Rollback(pageID int, snapshotID string) (*RollbackResponse, error) {
-	resp, err := c.do("POST", fmt.Sprintf("/pages/%d/snapshots/%s/rollback", pageID, snapshotID), nil)
+	return c.RollbackContext(context.Background(), pageID, snapshotID)
+}
+
+// RollbackContext is Rollback with a caller-supplied context.
+func (c *Client) RollbackContext(ctx context.Context, pageID int, snapshotID string) (*RollbackResponse, error) {
+	resp, err := c.do(ctx, "POST", fmt.Sprintf("/pages/%d/snapshots/%s/rollback", pageID, snapshotID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -502,6 +689,11 @@ type WooTermsResponse struct {
 
 // ListElementTypes returns rich element type metadata.
 func (c *Client) ListElementTypes(includeControls bool, category string) (*ElementTypesResponse, error) {
+	return c.ListElementTypesContext(context.Background(), includeControls, category)
+}
+
+// ListElementTypesContext is ListElementTypes with a caller-supplied context.
+func (c *Client) ListElementTypesContext(ctx context.Context, includeControls bool, category string) (*ElementTypesResponse, error) {
 	v := url.Values{}
 	if includeControls {
 		v.Set("include_controls", "1")
@@ -514,7 +706,7 @@ func (c *Client) ListElementTypes(includeControls bool, category string) (*Eleme
 		path += "?" + v.Encode()
 	}
 
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -528,7 +720,12 @@ func (c *Client) ListElementTypes(includeControls bool, category string) (*Eleme
 
 // GetSiteFeatures returns machine-discoverable site capabilities.
 func (c *Client) GetSiteFeatures() (*SiteFeaturesResponse, error) {
-	resp, err := c.do("GET", "/site/features", nil)
+	return c.GetSiteFeaturesContext(context.Background())
+}
+
+// GetSiteFeaturesContext is GetSiteFeatures with a caller-supplied context.
+func (c *Client) GetSiteFeaturesContext(ctx context.Context) (*SiteFeaturesResponse, error) {
+	resp, err := c.do(ctx, "GET", "/site/features", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -542,6 +739,11 @@ func (c *Client) GetSiteFeatures() (*SiteFeaturesResponse, error) {
 
 // ListQueryElementTypes returns element types with a query control.
 func (c *Client) ListQueryElementTypes(includeControls bool) (*QueryElementTypesResponse, error) {
+	return c.ListQueryElementTypesContext(context.Background(), includeControls)
+}
+
+// ListQueryElementTypesContext is ListQueryElementTypes with a caller-supplied context.
+func (c *Client) ListQueryElementTypesContext(ctx context.Context, includeControls bool) (*QueryElementTypesResponse, error) {
 	v := url.Values{}
 	if includeControls {
 		v.Set("include_controls", "1")
@@ -552,7 +754,7 @@ func (c *Client) ListQueryElementTypes(includeControls bool) (*QueryElementTypes
 		path += "?" + v.Encode()
 	}
 
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -566,7 +768,12 @@ func (c *Client) ListQueryElementTypes(includeControls bool) (*QueryElementTypes
 
 // GetWooStatus returns WooCommerce availability and Bricks/Woo integration info.
 func (c *Client) GetWooStatus() (*WooStatusResponse, error) {
-	resp, err := c.do("GET", "/site/woocommerce", nil)
+	return c.GetWooStatusContext(context.Background())
+}
+
+// GetWooStatusContext is GetWooStatus with a caller-supplied context.
+func (c *Client) GetWooStatusContext(ctx context.Context) (*WooStatusResponse, error) {
+	resp, err := c.do(ctx, "GET", "/site/woocommerce", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -580,6 +787,11 @@ func (c *Client) GetWooStatus() (*WooStatusResponse, error) {
 
 // ListWooProducts returns WooCommerce products for discovery/autocomplete.
 func (c *Client) ListWooProducts(search string, perPage, page int) (*WooProductsResponse, error) {
+	return c.ListWooProductsContext(context.Background(), search, perPage, page)
+}
+
+// ListWooProductsContext is ListWooProducts with a caller-supplied context.
+func (c *Client) ListWooProductsContext(ctx context.Context, search string, perPage, page int) (*WooProductsResponse, error) {
 	v := url.Values{}
 	if search != "" {
 		v.Set("search", search)
@@ -596,7 +808,7 @@ func (c *Client) ListWooProducts(search string, perPage, page int) (*WooProducts
 		path += "?" + v.Encode()
 	}
 
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -610,15 +822,25 @@ func (c *Client) ListWooProducts(search string, perPage, page int) (*WooProducts
 
 // ListWooProductCategories returns WooCommerce product categories.
 func (c *Client) ListWooProductCategories(search string, perPage int) (*WooTermsResponse, error) {
-	return c.listWooTerms("/woo/product-categories", search, perPage)
+	return c.ListWooProductCategoriesContext(context.Background(), search, perPage)
+}
+
+// ListWooProductCategoriesContext is ListWooProductCategories with a caller-supplied context.
+func (c *Client) ListWooProductCategoriesContext(ctx context.Context, search string, perPage int) (*WooTermsResponse, error) {
+	return c.listWooTerms(ctx, "/woo/product-categories", search, perPage)
 }
 
 // ListWooProductTags returns WooCommerce product tags.
 func (c *Client) ListWooProductTags(search string, perPage int) (*WooTermsResponse, error) {
-	return c.listWooTerms("/woo/product-tags", search, perPage)
+	return c.ListWooProductTagsContext(context.Background(), search, perPage)
+}
+
+// ListWooProductTagsContext is ListWooProductTags with a caller-supplied context.
+func (c *Client) ListWooProductTagsContext(ctx context.Context, search string, perPage int) (*WooTermsResponse, error) {
+	return c.listWooTerms(ctx, "/woo/product-tags", search, perPage)
 }
 
-func (c *Client) listWooTerms(basePath, search string, perPage int) (*WooTermsResponse, error) {
+func (c *Client) listWooTerms(ctx context.Context, basePath, search string, perPage int) (*WooTermsResponse, error) {
 	v := url.Values{}
 	if search != "" {
 		v.Set("search", search)
@@ -632,7 +854,7 @@ func (c *Client) listWooTerms(basePath, search string, perPage int) (*WooTermsRe
 		path += "?" + v.Encode()
 	}
 
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -654,8 +876,13 @@ type PluginUpdateResponse struct {
 
 // TriggerPluginUpdate tells the plugin to self-update to the given version.
 func (c *Client) TriggerPluginUpdate(version string) (*PluginUpdateResponse, error) {
+	return c.TriggerPluginUpdateContext(context.Background(), version)
+}
+
+// TriggerPluginUpdateContext is TriggerPluginUpdate with a caller-supplied context.
+func (c *Client) TriggerPluginUpdateContext(ctx context.Context, version string) (*PluginUpdateResponse, error) {
 	payload, _ := json.Marshal(map[string]string{"version": version})
-	resp, err := c.do("POST", "/site/update", strings.NewReader(string(payload)))
+	resp, err := c.do(ctx, "POST", "/site/update", strings.NewReader(string(payload)))
 	if err != nil {
 		return nil, err
 	}
@@ -697,13 +924,18 @@ type MediaUploadResponse struct {
 
 // ListMedia returns media library items, optionally filtered by search term.
 func (c *Client) ListMedia(search string) (*MediaListResponse, error) {
+	return c.ListMediaContext(context.Background(), search)
+}
+
+// ListMediaContext is ListMedia with a caller-supplied context.
+func (c *Client) ListMediaContext(ctx context.Context, search string) (*MediaListResponse, error) {
 	path := "/media"
 	if search != "" {
 		v := url.Values{}
 		v.Set("search", search)
 		path += "?" + v.Encode()
 	}
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -793,6 +1025,11 @@ type SearchResponse struct {
 
 // SearchElements searches elements across all Bricks content.
 func (c *Client) SearchElements(params SearchParams) (*SearchResponse, error) {
+	return c.SearchElementsContext(context.Background(), params)
+}
+
+// SearchElementsContext is SearchElements with a caller-supplied context.
+func (c *Client) SearchElementsContext(ctx context.Context, params SearchParams) (*SearchResponse, error) {
 	v := url.Values{}
 	if params.ElementType != "" {
 		v.Set("element_type", params.ElementType)
@@ -829,7 +1066,7 @@ func (c *Client) SearchElements(params SearchParams) (*SearchResponse, error) {
 	}
 	path := "/search/elements?" + v.Encode()
 
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -872,7 +1109,12 @@ type ComponentDetailResponse struct {
 
 // ListComponents returns reusable components (section-type templates).
 func (c *Client) ListComponents() (*ComponentsResponse, error) {
-	resp, err := c.do("GET", "/components", nil)
+	return c.ListComponentsContext(context.Background())
+}
+
+// ListComponentsContext is ListComponents with a caller-supplied context.
+func (c *Client) ListComponentsContext(ctx context.Context) (*ComponentsResponse, error) {
+	resp, err := c.do(ctx, "GET", "/components", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -886,7 +1128,12 @@ func (c *Client) ListComponents() (*ComponentsResponse, error) {
 
 // GetComponent returns a single component with its element tree.
 func (c *Client) GetComponent(id int) (*ComponentDetailResponse, error) {
-	resp, err := c.do("GET", fmt.Sprintf("/components/%d", id), nil)
+	return c.GetComponentContext(context.Background(), id)
+}
+
+// GetComponentContext is GetComponent with a caller-supplied context.
+func (c *Client) GetComponentContext(ctx context.Context, id int) (*ComponentDetailResponse, error) {
+	resp, err := c.do(ctx, "GET", fmt.Sprintf("/components/%d", id), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -900,6 +1147,11 @@ func (c *Client) GetComponent(id int) (*ComponentDetailResponse, error) {
 
 // UploadMedia uploads a file to the WordPress media library via multipart POST.
 func (c *Client) UploadMedia(filePath string) (*MediaUploadResponse, error) {
+	return c.UploadMediaContext(context.Background(), filePath)
+}
+
+// UploadMediaContext is UploadMedia with a caller-supplied context.
+func (c *Client) UploadMediaContext(ctx context.Context, filePath string) (*MediaUploadResponse, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open file: %w", err)
@@ -920,7 +1172,7 @@ func (c *Client) UploadMedia(filePath string) (*MediaUploadResponse, error) {
 	headers := map[string]string{
 		"Content-Type": writer.FormDataContentType(),
 	}
-	resp, err := c.doWithHeaders("POST", "/media/upload", &buf, headers)
+	resp, err := c.doWithHeaders(ctx, "POST", "/media/upload", &buf, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -966,17 +1218,17 @@ type AbilityCategory struct {
 // GetAbilities fetches abilities from the WordPress Abilities API (WP 6.9+).
 // Returns empty slice (not error) if the site doesn't support abilities.
 func (c *Client) GetAbilities(category string) ([]Ability, error) {
+	return c.GetAbilitiesContext(context.Background(), category)
+}
+
+// GetAbilitiesContext is GetAbilities with a caller-supplied context.
+func (c *Client) GetAbilitiesContext(ctx context.Context, category string) ([]Ability, error) {
 	path := "/abilities"
 	if category != "" {
 		path += "?category=" + url.QueryEscape(category)
 	}
 	abilitiesURL := c.baseURL + "/wp-json/wp-abilities/v1" + path
-	req, err := http.NewRequest("GET", abilitiesURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("X-ATB-Key", c.apiKey)
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(ctx, "GET", abilitiesURL, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1004,13 +1256,13 @@ func (c *Client) GetAbilities(category string) ([]Ability, error) {
 
 // GetAbilityCategories fetches ability categories (WP 6.9+).
 func (c *Client) GetAbilityCategories() ([]AbilityCategory, error) {
+	return c.GetAbilityCategoriesContext(context.Background())
+}
+
+// GetAbilityCategoriesContext is GetAbilityCategories with a caller-supplied context.
+func (c *Client) GetAbilityCategoriesContext(ctx context.Context) ([]AbilityCategory, error) {
 	abilitiesURL := c.baseURL + "/wp-json/wp-abilities/v1/categories"
-	req, err := http.NewRequest("GET", abilitiesURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("X-ATB-Key", c.apiKey)
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(ctx, "GET", abilitiesURL, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package client_test
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/nerveband/agent-to-bricks/internal/client"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
 )
 
 func TestGetElements(t *testing.T) {
@@ -165,6 +167,74 @@ func TestPatchElements(t *testing.T) {
 	}
 }
 
+func TestBatchElements(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/wp-json/agent-bricks/v1/pages/2005/elements/batch" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("If-Match") != "oldhash" {
+			t.Errorf("expected If-Match oldhash, got %s", r.Header.Get("If-Match"))
+		}
+		var body struct {
+			Operations []map[string]interface{} `json:"operations"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if len(body.Operations) != 2 || body.Operations[0]["op"] != "insert" || body.Operations[0]["index"] != float64(0) {
+			t.Fatalf("unexpected operations: %v", body.Operations)
+		}
+		if body.Operations[1]["op"] != "delete" {
+			t.Fatalf("expected delete op, got %v", body.Operations[1])
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":     true,
+			"contentHash": "batched",
+			"count":       3,
+			"operations": []map[string]interface{}{
+				{"op": "insert", "added": 1},
+				{"op": "delete", "deleted": 1},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	ops := []client.BatchOp{
+		client.InsertOp("sec001", 0, map[string]interface{}{"id": "new001", "name": "heading"}),
+		client.DeleteOp("old001"),
+	}
+	resp, err := c.BatchElements(2005, ops, "oldhash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ContentHash != "batched" || len(resp.Operations) != 2 || resp.Operations[1].Deleted != 1 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestBatchElements_FailedOp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"Batch op 1: element 'gone01' not found.","failedOp":1}`))
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	_, err := c.BatchElements(2005, []client.BatchOp{client.DeleteOp("a"), client.MoveOp("gone01", "", 0)}, "hash")
+	var opErr *client.BatchOpError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("expected *BatchOpError, got %v", err)
+	}
+	if opErr.Index != 1 || opErr.Message != "element 'gone01' not found." {
+		t.Errorf("unexpected error %+v", opErr)
+	}
+	var cliErr *clierrors.CLIError
+	if !errors.As(err, &cliErr) || cliErr.Code != "API_NOT_FOUND" {
+		t.Errorf("expected the HTTP error to be wrapped, got %v", err)
+	}
+}
+
 func TestCreateSnapshot(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "POST" {
@@ -741,3 +811,120 @@ func TestSearchElementsWithSettingFilter(t *testing.T) {
 		t.Errorf("expected 0, got %d", resp.Total)
 	}
 }
+
+func TestListPagesAndAllPages(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/agent-bricks/v1/pages" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		queries = append(queries, r.URL.RawQuery)
+		page := r.URL.Query().Get("page")
+		w.Header().Set("X-WP-Total", "3")
+		w.Header().Set("X-WP-TotalPages", "2")
+		if page == "2" {
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"id": 3, "title": "Pricing", "slug": "pricing", "status": "publish", "path": "pricing"},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"id": 1, "title": "Home", "slug": "home", "status": "publish"},
+			{"id": 2, "title": "About", "slug": "about", "status": "draft"},
+		})
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	resp, err := c.ListPages(client.PageParams{Slug: "services/web", PerPage: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queries[0] != "per_page=2&slug=services%2Fweb" {
+		t.Errorf("unexpected query %q", queries[0])
+	}
+	if resp.Total != 3 || resp.TotalPages != 2 || resp.Page != 1 || len(resp.Pages) != 2 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	all, err := c.AllPages(client.PageParams{Search: "a", PerPage: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 3 || all[2].Path != "pricing" {
+		t.Errorf("expected 3 pages across 2 requests, got %+v", all)
+	}
+	if len(queries) != 3 || queries[2] != "page=2&per_page=2&search=a" {
+		t.Errorf("unexpected queries %v", queries)
+	}
+}
+
+func TestListAndDeleteSnapshots(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /wp-json/agent-bricks/v1/pages/2005/snapshots":
+			json.NewEncoder(w).Encode(map[string]interface{}{"snapshots": []map[string]interface{}{
+				{"snapshotId": "snap_abc", "contentHash": "h1", "elementCount": 4, "timestamp": "2026-03-01 14:00:00", "timestampGmt": "2026-03-01 12:00:00", "label": "Before edit"},
+			}})
+		case "DELETE /wp-json/agent-bricks/v1/pages/2005/snapshots/snap_abc":
+			json.NewEncoder(w).Encode(map[string]interface{}{"deleted": "snap_abc", "remaining": 0})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	list, err := c.ListSnapshots(2005)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Snapshots) != 1 || list.Snapshots[0].SnapshotID != "snap_abc" || list.Snapshots[0].ElementCount != 4 {
+		t.Fatalf("unexpected snapshots %+v", list.Snapshots)
+	}
+	at, ok := list.Snapshots[0].CreatedAt()
+	if !ok || at.UTC().Hour() != 12 {
+		t.Errorf("expected CreatedAt from the GMT timestamp, got %v", at)
+	}
+
+	resp, err := c.DeleteSnapshot(2005, "snap_abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Deleted != "snap_abc" || resp.Remaining != 0 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestListClassesIfChanged(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `W/"abc"`)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"classes": []map[string]interface{}{{"id": "c1", "name": "card"}},
+			"count":   1,
+			"total":   1,
+		})
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	resp, changed, err := c.ListClassesIfChanged("")
+	if err != nil || !changed || len(resp.Classes) != 1 {
+		t.Fatalf("expected the full list, got %+v %v %v", resp, changed, err)
+	}
+	if resp.Hash != "abc" {
+		t.Errorf("expected the hash from the ETag, got %q", resp.Hash)
+	}
+
+	resp, changed, err = c.ListClassesIfChanged("abc")
+	if err != nil || changed || resp != nil {
+		t.Errorf("expected not modified, got %+v %v %v", resp, changed, err)
+	}
+	if _, changed, _ = c.ListClassesIfChanged("old"); !changed {
+		t.Error("expected a stale hash to get the full list")
+	}
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// maxPagesPerPage is the largest per_page GET /pages accepts.
+const maxPagesPerPage = 50
+
+// Page is one entry from GET /pages. Older plugins leave Path and Link empty.
+type Page struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	Status   string `json:"status"`
+	Modified string `json:"modified"`
+	Path     string `json:"path,omitempty"`
+	Link     string `json:"link,omitempty"`
+}
+
+// PageParams for GET /pages. Slug matches a page slug, or a full page path
+// such as "services/web-design".
+type PageParams struct {
+	Search  string
+	Slug    string
+	PerPage int
+	Page    int
+}
+
+// PagesResponse is one page of GET /pages results. Total and TotalPages come
+// from the X-WP-Total headers and are zero when the plugin does not send them.
+type PagesResponse struct {
+	Pages      []Page `json:"pages"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page"`
+	PerPage    int    `json:"perPage"`
+	TotalPages int    `json:"totalPages"`
+}
+
+// ListPages returns one page of the site's pages.
+func (c *Client) ListPages(params PageParams) (*PagesResponse, error) {
+	return c.ListPagesContext(context.Background(), params)
+}
+
+// ListPagesContext is ListPages with a caller-supplied context.
+func (c *Client) ListPagesContext(ctx context.Context, params PageParams) (*PagesResponse, error) {
+	v := url.Values{}
+	if params.Search != "" {
+		v.Set("search", params.Search)
+	}
+	if params.Slug != "" {
+		v.Set("slug", params.Slug)
+	}
+	if params.PerPage > 0 {
+		v.Set("per_page", fmt.Sprintf("%d", params.PerPage))
+	}
+	if params.Page > 0 {
+		v.Set("page", fmt.Sprintf("%d", params.Page))
+	}
+	path := "/pages"
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+
+	resp, err := c.do(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	result := PagesResponse{Page: params.Page, PerPage: params.PerPage}
+	if err := json.NewDecoder(resp.Body).Decode(&result.Pages); err != nil {
+		return nil, err
+	}
+	if result.Page == 0 {
+		result.Page = 1
+	}
+	result.Total, _ = strconv.Atoi(resp.Header.Get("X-WP-Total"))
+	result.TotalPages, _ = strconv.Atoi(resp.Header.Get("X-WP-TotalPages"))
+	return &result, nil
+}
+
+// AllPages follows pagination and returns every page matching params.
+// params.Page is ignored. Against a plugin that does not report totals only
+// the first page is fetched.
+func (c *Client) AllPages(params PageParams) ([]Page, error) {
+	return c.AllPagesContext(context.Background(), params)
+}
+
+// AllPagesContext is AllPages with a caller-supplied context.
+func (c *Client) AllPagesContext(ctx context.Context, params PageParams) ([]Page, error) {
+	if params.PerPage <= 0 || params.PerPage > maxPagesPerPage {
+		params.PerPage = maxPagesPerPage
+	}
+	var all []Page
+	for params.Page = 1; ; params.Page++ {
+		resp, err := c.ListPagesContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Pages...)
+		if resp.TotalPages == 0 || params.Page >= resp.TotalPages || len(resp.Pages) == 0 {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Transport defaults used by New.
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultMaxRetries = 3
+)
+
+// IdempotencyKeyHeader marks a write request as safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RetryPolicy controls how failed requests are retried.
+//
+// Only idempotent requests are retried: GET/HEAD/OPTIONS always, and writes
+// only when they carry an idempotency key (see WithIdempotencyKey). A request
+// is retried on network errors and on HTTP 429, 502, 503 and 504.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used by New.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: DefaultMaxRetries,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   30 * time.Second,
+	}
+}
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey returns a context that sends the given key as an
+// Idempotency-Key header, which makes PUT/POST/PATCH/DELETE calls made with
+// it eligible for retries.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+func idempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+	return key
+}
+
+// SetTimeout sets the per-attempt request timeout. Zero disables it.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.timeout = d
+}
+
+// SetRetryPolicy replaces the retry policy.
+func (c *Client) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
+}
+
+// send performs an HTTP request with per-attempt timeouts and retries. It
+// returns the final response whatever its status; callers map errors.
+func (c *Client) send(ctx context.Context, method, url string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = data
+	}
+
+	if key := idempotencyKey(ctx); key != "" {
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		headers[IdempotencyKeyHeader] = key
+	}
+	retryable := isIdempotent(method) || headers[IdempotencyKeyHeader] != ""
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, method, url, payload, body != nil, headers)
+
+		canRetry := retryable && attempt < c.retry.MaxRetries && ctx.Err() == nil
+		if err == nil && (!canRetry || !isRetryableStatus(resp.StatusCode)) {
+			return resp, nil
+		}
+		if err != nil && !canRetry {
+			return nil, err
+		}
+
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if ra, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = ra
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, url string, payload []byte, hasBody bool, headers map[string]string) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+
+	var body io.Reader
+	if hasBody {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("X-ATB-Key", c.apiKey)
+	if hasBody {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The timeout must outlive this call so callers can read the body.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff returns the exponential delay for a retry attempt with equal jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.retry.BaseDelay << attempt
+	if d <= 0 || (c.retry.MaxDelay > 0 && d > c.retry.MaxDelay) {
+		d = c.retry.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header in either delta-seconds or
+// HTTP-date form.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,172 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+)
+
+func fastRetries(c *client.Client, n int) {
+	c.SetRetryPolicy(client.RetryPolicy{MaxRetries: n, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+}
+
+func TestRetryGETOnServiceUnavailable(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"elements": []interface{}{}, "contentHash": "ok"})
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	fastRetries(c, 3)
+	resp, err := c.GetElements(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ContentHash != "ok" {
+		t.Errorf("expected hash ok, got %s", resp.ContentHash)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestRetryGivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(502)
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	fastRetries(c, 2)
+	_, err := c.GetSiteInfo()
+	if err == nil {
+		t.Fatal("expected error after retries are exhausted")
+	}
+	if calls != 3 {
+		t.Errorf("expected 1 attempt + 2 retries, got %d", calls)
+	}
+}
+
+func TestNoRetryForPUTWithoutIdempotencyKey(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(503)
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	fastRetries(c, 3)
+	if _, err := c.ReplaceElements(1, nil, "hash"); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("PUT must not be retried without an idempotency key, got %d attempts", calls)
+	}
+}
+
+func TestRetryPUTWithIdempotencyKey(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(client.IdempotencyKeyHeader) != "key-1" {
+			t.Errorf("expected idempotency key header, got %q", r.Header.Get(client.IdempotencyKeyHeader))
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(429)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "contentHash": "new"})
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	fastRetries(c, 3)
+	ctx := client.WithIdempotencyKey(context.Background(), "key-1")
+	resp, err := c.ReplaceElementsContext(ctx, 1, []map[string]interface{}{{"id": "a", "name": "div"}}, "hash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ContentHash != "new" || calls != 2 {
+		t.Errorf("expected success on 2nd attempt, got hash %q after %d attempts", resp.ContentHash, calls)
+	}
+}
+
+func TestRetryAfterIsHonoured(t *testing.T) {
+	var calls int32
+	var first time.Time
+	var gap time.Duration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(429)
+			return
+		}
+		gap = time.Since(first)
+		json.NewEncoder(w).Encode(map[string]interface{}{})
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	fastRetries(c, 1)
+	if _, err := c.GetStyles(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gap < 900*time.Millisecond {
+		t.Errorf("expected retry to wait for Retry-After, waited %s", gap)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	c.SetTimeout(20 * time.Millisecond)
+	fastRetries(c, 0)
+	_, err := c.GetSiteInfo()
+	cliErr, ok := err.(*clierrors.CLIError)
+	if !ok || cliErr.Code != "API_TIMEOUT" {
+		t.Fatalf("expected API_TIMEOUT error, got %v", err)
+	}
+}
+
+func TestContextCancellationStopsRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(503)
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "atb_testkey")
+	c.SetRetryPolicy(client.RetryPolicy{MaxRetries: 5, BaseDelay: time.Second, MaxDelay: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetElementsContext(ctx, 1); err == nil {
+		t.Fatal("expected error after context cancellation")
+	}
+	if calls != 1 {
+		t.Errorf("expected cancellation during backoff after 1 attempt, got %d", calls)
+	}
+}
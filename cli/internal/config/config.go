@@ -1,14 +1,29 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Config is the on-disk CLI configuration.
+//
+// Older configs hold a single top-level `site` block. Newer configs hold named
+// profiles under `sites` plus a `default` profile name. In both cases Site is
+// the active site after Load or UseSite, so callers only ever read cfg.Site.
 type Config struct {
-	Site SiteConfig `yaml:"site"`
+	Site    SiteConfig            `yaml:"site,omitempty"`
+	Default string                `yaml:"default,omitempty"`
+	Sites   map[string]SiteConfig `yaml:"sites,omitempty"`
+	HTTP    HTTPConfig            `yaml:"http,omitempty"`
+	Safety  SafetyConfig          `yaml:"safety,omitempty"`
+	Cache   CacheConfig           `yaml:"cache,omitempty"`
+
+	active string
 }
 
 type SiteConfig struct {
@@ -16,6 +31,33 @@ type SiteConfig struct {
 	APIKey string `yaml:"api_key"`
 }
 
+// HTTPConfig tunes the API transport. Unset values fall back to the client
+// defaults.
+type HTTPConfig struct {
+	Timeout time.Duration `yaml:"timeout,omitempty"` // per request, e.g. "45s"
+	Retries *int          `yaml:"retries,omitempty"` // retries for idempotent requests
+}
+
+// SafetyConfig controls the safety net around page writes.
+type SafetyConfig struct {
+	Snapshots *bool `yaml:"snapshots,omitempty"` // snapshot and journal before writes (default true)
+}
+
+// CacheConfig tunes the local caches of site data.
+type CacheConfig struct {
+	ClassesTTL time.Duration `yaml:"classes_ttl,omitempty"` // how long cached classes are used without revalidating, e.g. "6h"
+}
+
+// SafetySnapshots reports whether mutating commands take a server snapshot
+// and record an undo journal entry before writing. It defaults to true.
+func (c *Config) SafetySnapshots() bool {
+	return c.Safety.Snapshots == nil || *c.Safety.Snapshots
+}
+
+// LegacyProfile is the profile name given to a single-site config when it is
+// migrated into named profiles.
+const LegacyProfile = "default"
+
 func DefaultPath() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -33,11 +75,27 @@ func Load(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
+	if len(cfg.Sites) > 0 {
+		name := cfg.Default
+		if _, ok := cfg.Sites[name]; !ok {
+			name = cfg.SiteNames()[0]
+		}
+		cfg.active = name
+		cfg.Site = cfg.Sites[name]
+	}
 	return &cfg, nil
 }
 
 func (c *Config) Save(path string) error {
-	data, err := yaml.Marshal(c)
+	out := *c
+	if len(c.Sites) > 0 {
+		// Site mirrors the active profile; write edits back and keep it out of the file.
+		if c.active != "" {
+			c.Sites[c.active] = c.Site
+		}
+		out.Site = SiteConfig{}
+	}
+	data, err := yaml.Marshal(&out)
 	if err != nil {
 		return err
 	}
@@ -46,3 +104,83 @@ func (c *Config) Save(path string) error {
 	}
 	return os.WriteFile(path, data, 0600)
 }
+
+// ActiveSite returns the name of the selected profile, or "" for a legacy
+// single-site config.
+func (c *Config) ActiveSite() string {
+	return c.active
+}
+
+// SiteNames returns all profile names in sorted order.
+func (c *Config) SiteNames() []string {
+	names := make([]string, 0, len(c.Sites))
+	for n := range c.Sites {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseSite makes the named profile active for this process. It does not change
+// the saved default; set Default for that.
+func (c *Config) UseSite(name string) error {
+	s, ok := c.Sites[name]
+	if !ok {
+		if len(c.Sites) == 0 && name == LegacyProfile && c.Site.URL != "" {
+			return nil
+		}
+		return fmt.Errorf("site profile %q not found", name)
+	}
+	if c.active != "" && c.active != name {
+		c.Sites[c.active] = c.Site
+	}
+	c.active = name
+	c.Site = s
+	return nil
+}
+
+// AddSite creates or replaces a named profile. A legacy single-site config is
+// migrated into the LegacyProfile profile first so it is not lost. The first
+// profile added becomes the default.
+func (c *Config) AddSite(name string, site SiteConfig) {
+	if c.Sites == nil {
+		c.Sites = make(map[string]SiteConfig)
+	}
+	if c.active == "" && c.Site.URL != "" && name != LegacyProfile {
+		if _, exists := c.Sites[LegacyProfile]; !exists {
+			c.Sites[LegacyProfile] = c.Site
+			c.active = LegacyProfile
+			if c.Default == "" {
+				c.Default = LegacyProfile
+			}
+		}
+	}
+	c.Sites[name] = site
+	if c.Default == "" {
+		c.Default = name
+	}
+	if c.active == "" || c.active == name {
+		c.active = name
+		c.Site = site
+	}
+}
+
+// RemoveSite deletes a named profile. Removing the default profile is refused
+// while other profiles remain, so the config never points at a missing site.
+func (c *Config) RemoveSite(name string) error {
+	if _, ok := c.Sites[name]; !ok {
+		return fmt.Errorf("site profile %q not found", name)
+	}
+	if name == c.Default && len(c.Sites) > 1 {
+		return fmt.Errorf("site profile %q is the default; switch with 'bricks config use' first", name)
+	}
+	delete(c.Sites, name)
+	if name == c.Default {
+		c.Default = ""
+	}
+	if name == c.active {
+		c.active = ""
+		c.Site = SiteConfig{}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/nerveband/agent-to-bricks/internal/config"
@@ -60,3 +61,103 @@ func TestLoadMissing(t *testing.T) {
 		t.Error("expected error for missing file")
 	}
 }
+
+func TestLoadSiteProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "config.yaml")
+
+	os.WriteFile(cfgPath, []byte(`
+default: prod
+sites:
+  staging:
+    url: https://staging.example.com
+    api_key: atb_staging
+  prod:
+    url: https://example.com
+    api_key: atb_prod
+`), 0644)
+
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ActiveSite() != "prod" {
+		t.Errorf("expected active site prod, got %q", cfg.ActiveSite())
+	}
+	if cfg.Site.URL != "https://example.com" {
+		t.Errorf("expected default site URL, got %s", cfg.Site.URL)
+	}
+
+	if err := cfg.UseSite("staging"); err != nil {
+		t.Fatalf("UseSite: %v", err)
+	}
+	if cfg.Site.APIKey != "atb_staging" {
+		t.Errorf("expected staging key, got %s", cfg.Site.APIKey)
+	}
+	if err := cfg.UseSite("missing"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestLegacyConfigHasNoProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(cfgPath, []byte("site:\n  url: https://example.com\n  api_key: atb_x\n"), 0644)
+
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ActiveSite() != "" {
+		t.Errorf("expected no active profile for legacy config, got %q", cfg.ActiveSite())
+	}
+	if err := cfg.UseSite(config.LegacyProfile); err != nil {
+		t.Errorf("legacy config should accept the %q profile: %v", config.LegacyProfile, err)
+	}
+}
+
+func TestAddSiteMigratesLegacy(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgPath := filepath.Join(tmpDir, "config.yaml")
+
+	cfg := &config.Config{Site: config.SiteConfig{URL: "https://old.com", APIKey: "atb_old"}}
+	cfg.AddSite("staging", config.SiteConfig{URL: "https://staging.com", APIKey: "atb_new"})
+
+	if err := cfg.Save(cfgPath); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	loaded, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if names := loaded.SiteNames(); len(names) != 2 {
+		t.Fatalf("expected 2 profiles, got %v", names)
+	}
+	if loaded.Default != config.LegacyProfile {
+		t.Errorf("expected legacy site to stay default, got %q", loaded.Default)
+	}
+	if loaded.Site.URL != "https://old.com" {
+		t.Errorf("expected active site to be the migrated one, got %s", loaded.Site.URL)
+	}
+
+	data, _ := os.ReadFile(cfgPath)
+	if strings.Contains(string(data), "\nsite:") || strings.HasPrefix(string(data), "site:") {
+		t.Errorf("profile config should not write a top-level site block:\n%s", data)
+	}
+}
+
+func TestRemoveDefaultSite(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.AddSite("a", config.SiteConfig{URL: "https://a.com"})
+	cfg.AddSite("b", config.SiteConfig{URL: "https://b.com"})
+
+	if err := cfg.RemoveSite("a"); err == nil {
+		t.Error("expected error removing the default while other profiles exist")
+	}
+	if err := cfg.RemoveSite("b"); err != nil {
+		t.Fatalf("RemoveSite: %v", err)
+	}
+	if err := cfg.RemoveSite("b"); err == nil {
+		t.Error("expected error removing a missing profile")
+	}
+}
@@ -0,0 +1,134 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Breakpoint is a Bricks responsive breakpoint. Settings for a non-base
+// breakpoint are stored under suffixed keys, e.g. "_padding:tablet_portrait".
+type Breakpoint struct {
+	Key   string `json:"key"`
+	Width int    `json:"width"`
+	Base  bool   `json:"base,omitempty"`
+}
+
+// DefaultBreakpoints are the breakpoints of a stock Bricks install.
+var DefaultBreakpoints = []Breakpoint{
+	{Key: "desktop", Width: 1279, Base: true},
+	{Key: "tablet_portrait", Width: 991},
+	{Key: "mobile_landscape", Width: 767},
+	{Key: "mobile_portrait", Width: 478},
+}
+
+// BreakpointsFromSite reads the breakpoint list from a site info response.
+// Entries without a key or width are skipped; with none left it returns
+// DefaultBreakpoints.
+func BreakpointsFromSite(raw []map[string]interface{}) []Breakpoint {
+	var bps []Breakpoint
+	for _, m := range raw {
+		key, _ := m["key"].(string)
+		width := 0
+		switch w := m["width"].(type) {
+		case float64:
+			width = int(w)
+		case string:
+			width, _ = strconv.Atoi(w)
+		}
+		if key == "" || width <= 0 {
+			continue
+		}
+		base, _ := m["base"].(bool)
+		bps = append(bps, Breakpoint{Key: key, Width: width, Base: base || key == "desktop"})
+	}
+	if len(bps) == 0 {
+		return DefaultBreakpoints
+	}
+	return bps
+}
+
+var maxWidthQuery = regexp.MustCompile(`^\(\s*max-width\s*:\s*([0-9.]+)(px|em|rem)\s*\)$`)
+
+// mediaBreakpoint maps an @media condition onto a breakpoint key ("" for the
+// base breakpoint). Only max-width queries, optionally preceded by a media
+// type, can be mapped. A width that matches no breakpoint exactly falls to
+// the nearest non-base one with a warning; the base breakpoint would apply
+// the rule at every width.
+func mediaBreakpoint(media string, bps []Breakpoint) (key, warning string, err error) {
+	cond := strings.ToLower(strings.Join(strings.Fields(media), " "))
+	for _, prefix := range []string{"only screen and ", "screen and ", "all and "} {
+		cond = strings.TrimPrefix(cond, prefix)
+	}
+	m := maxWidthQuery.FindStringSubmatch(cond)
+	if m == nil {
+		return "", "", fmt.Errorf("unsupported @media %s: only max-width queries map to breakpoints", media)
+	}
+	width, _ := strconv.ParseFloat(m[1], 64)
+	if m[2] != "px" {
+		width *= 16
+	}
+
+	var best *Breakpoint
+	for i := range bps {
+		bp := &bps[i]
+		if float64(bp.Width) == width {
+			if bp.Base {
+				return "", "", nil
+			}
+			return bp.Key, "", nil
+		}
+		if bp.Base {
+			continue
+		}
+		if best == nil || math.Abs(float64(bp.Width)-width) < math.Abs(float64(best.Width)-width) {
+			best = bp
+		}
+	}
+	if best == nil {
+		return "", "", fmt.Errorf("unsupported @media %s: matches no breakpoint", media)
+	}
+	warning = fmt.Sprintf("@media %s matches no breakpoint; using nearest %s (%dpx)", media, best.Key, best.Width)
+	return best.Key, warning, nil
+}
+
+// breakpointsFile is the JSON format of the breakpoint cache.
+type breakpointsFile struct {
+	FetchedAt   time.Time    `json:"fetchedAt"`
+	SiteURL     string       `json:"siteUrl"`
+	Breakpoints []Breakpoint `json:"breakpoints"`
+}
+
+// SaveBreakpoints caches a site's breakpoints at path.
+func SaveBreakpoints(path, siteURL string, bps []Breakpoint) error {
+	data, err := json.MarshalIndent(breakpointsFile{FetchedAt: time.Now().UTC(), SiteURL: siteURL, Breakpoints: bps}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadBreakpoints reads cached breakpoints for siteURL. A cache written for
+// another site is an error.
+func LoadBreakpoints(path, siteURL string) ([]Breakpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bf breakpointsFile
+	if err := json.Unmarshal(data, &bf); err != nil {
+		return nil, err
+	}
+	if bf.SiteURL != siteURL {
+		return nil, fmt.Errorf("cached breakpoints are for %s", bf.SiteURL)
+	}
+	if len(bf.Breakpoints) == 0 {
+		return nil, fmt.Errorf("no cached breakpoints")
+	}
+	return bf.Breakpoints, nil
+}
@@ -0,0 +1,115 @@
+package convert_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+func TestConvertMediaQueriesToBreakpoints(t *testing.T) {
+	html := `<style>
+		.box { padding: 32px; color: black }
+		@media (max-width: 991px) { .box { padding: 16px } }
+		@media screen and (max-width: 47.9375em) { .box { color: red } }
+		@media (max-width: 700px) { .box { gap: 4px } }
+		@media (min-width: 1200px) { .box { gap: 64px } }
+	</style><div class="box"></div>`
+
+	res, err := convert.Convert(html, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := res.Elements[0]["settings"].(map[string]interface{})
+
+	if pad := settings["_padding"].(map[string]interface{}); pad["top"] != "32px" {
+		t.Errorf("expected desktop padding 32px, got %v", pad)
+	}
+	if pad, _ := settings["_padding:tablet_portrait"].(map[string]interface{}); pad["top"] != "16px" {
+		t.Errorf("expected tablet_portrait padding, got %v", settings)
+	}
+	if typo, _ := settings["_typography:mobile_landscape"].(map[string]interface{}); typo == nil {
+		t.Errorf("expected 47.9375em (767px) to map to mobile_landscape, got %v", settings)
+	}
+	if settings["_gap:mobile_landscape"] != "4px" {
+		t.Errorf("expected 700px to fall to the nearest breakpoint, got %v", settings)
+	}
+	if _, ok := settings["_gap"]; ok {
+		t.Errorf("min-width rules should be skipped, got %v", settings)
+	}
+
+	joined := strings.Join(res.Warnings, "\n")
+	if !strings.Contains(joined, "(max-width: 700px) matches no breakpoint; using nearest mobile_landscape") {
+		t.Errorf("expected a nearest-breakpoint warning, got %v", res.Warnings)
+	}
+	if !strings.Contains(joined, "unsupported @media (min-width: 1200px)") {
+		t.Errorf("expected a min-width warning, got %v", res.Warnings)
+	}
+}
+
+func TestConvertMediaUsesSiteBreakpoints(t *testing.T) {
+	bps := convert.BreakpointsFromSite([]map[string]interface{}{
+		{"key": "desktop", "width": float64(1440), "base": true},
+		{"key": "tablet", "width": "1024"},
+		{"key": "phone", "width": float64(600)},
+	})
+	if len(bps) != 3 || bps[1].Width != 1024 {
+		t.Fatalf("unexpected breakpoints %+v", bps)
+	}
+
+	res, err := convert.Convert(`<style>@media (max-width: 600px) { p { opacity: .5 } }</style><p>x</p>`,
+		convert.Options{Breakpoints: bps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := res.Elements[0]["settings"].(map[string]interface{})
+	if settings["_opacity:phone"] != ".5" || len(res.Warnings) != 0 {
+		t.Errorf("expected exact phone breakpoint match, got %v (warnings %v)", settings, res.Warnings)
+	}
+}
+
+func TestConvertMediaNeverFallsToBase(t *testing.T) {
+	html := `<style>
+		.box { padding: 32px }
+		@media (max-width: 1100px) { .box { padding: 8px } }
+	</style><div class="box"></div>`
+
+	res, err := convert.Convert(html, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := res.Elements[0]["settings"].(map[string]interface{})
+	if pad := settings["_padding"].(map[string]interface{}); pad["top"] != "32px" {
+		t.Errorf("expected desktop padding to stay 32px, got %v", pad)
+	}
+	if pad, _ := settings["_padding:tablet_portrait"].(map[string]interface{}); pad["top"] != "8px" {
+		t.Errorf("expected 1100px to fall to tablet_portrait, got %v", settings)
+	}
+
+	// With only a base breakpoint, nothing fits and the rule is skipped
+	res, err = convert.Convert(html, convert.Options{Breakpoints: []convert.Breakpoint{{Key: "desktop", Width: 1440, Base: true}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings = res.Elements[0]["settings"].(map[string]interface{})
+	if pad := settings["_padding"].(map[string]interface{}); pad["top"] != "32px" {
+		t.Errorf("expected the rule to be skipped, got %v", pad)
+	}
+	if !strings.Contains(strings.Join(res.Warnings, "\n"), "(max-width: 1100px): matches no breakpoint") {
+		t.Errorf("expected a warning for the skipped rule, got %v", res.Warnings)
+	}
+}
+
+func TestBreakpointCacheIsSiteScoped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breakpoints.json")
+	if err := convert.SaveBreakpoints(path, "https://a.example", convert.DefaultBreakpoints); err != nil {
+		t.Fatal(err)
+	}
+	if bps, err := convert.LoadBreakpoints(path, "https://a.example"); err != nil || len(bps) != len(convert.DefaultBreakpoints) {
+		t.Errorf("expected cached breakpoints, got %v, %v", bps, err)
+	}
+	if _, err := convert.LoadBreakpoints(path, "https://b.example"); err == nil {
+		t.Error("expected an error loading another site's breakpoints")
+	}
+}
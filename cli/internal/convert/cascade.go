@@ -0,0 +1,348 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nerveband/agent-to-bricks/internal/elementid"
+	"golang.org/x/net/html"
+)
+
+// inlineSpecificity ranks the style attribute above any selector.
+var inlineSpecificity = specificity{1 << 20}
+
+// styleRule is one selector of a stylesheet rule, ready to match.
+type styleRule struct {
+	sel   *selector
+	spec  specificity
+	order int
+	decls []Declaration
+	target
+}
+
+// target says where a rule's declarations land. breakpoint is the Bricks
+// breakpoint key of the rule's @media ("" for base styles) and state its
+// Bricks state (":hover"). A rule Bricks settings can't express has custom
+// set to its %root% selector and goes to _cssCustom, wrapped in media.
+type target struct {
+	breakpoint string
+	state      string
+	custom     string
+	media      string
+}
+
+// cascadeDecl is a declaration with the weight it carries in the cascade.
+type cascadeDecl struct {
+	Declaration
+	spec   specificity
+	order  int
+	source string
+	target
+}
+
+// generatedClass is a global class built from single-class rules in
+// StyleClasses mode. It is only emitted once an element uses it.
+type generatedClass struct {
+	id    string
+	name  string
+	decls []cascadeDecl
+	used  bool
+}
+
+// converter holds the state of one Convert call: the elements built so far,
+// the mapping rules and the stylesheet.
+type converter struct {
+	opts        Options
+	mapper      *Mapper
+	elements    []map[string]interface{}
+	accordions  map[*html.Node]string // <details> → its accordion element
+	breakpoints []Breakpoint
+	ids         *elementid.Generator
+	explicit    map[string]bool // IDs the input asks for (data-bricks-id, id)
+	preserved   map[string]bool // explicit IDs already used
+	childCount  map[string]int  // elements appended per parent ID
+	rules       []styleRule
+	classes     map[string]*generatedClass
+	order       []string // generated class names in first-use order
+	warnings    []string
+	warned      map[string]bool
+}
+
+func newConverter(doc *html.Node, opts Options, ids *elementid.Generator) *converter {
+	c := &converter{
+		opts:        opts,
+		breakpoints: opts.Breakpoints,
+		ids:         ids,
+		accordions:  make(map[*html.Node]string),
+		preserved:   make(map[string]bool),
+		childCount:  make(map[string]int),
+		classes:     make(map[string]*generatedClass),
+		warned:      make(map[string]bool),
+	}
+	if len(c.breakpoints) == 0 {
+		c.breakpoints = DefaultBreakpoints
+	}
+
+	sources := []string{opts.CSS}
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "style" {
+			var sb strings.Builder
+			for t := n.FirstChild; t != nil; t = t.NextSibling {
+				if t.Type == html.TextNode {
+					sb.WriteString(t.Data)
+				}
+			}
+			sources = append(sources, sb.String())
+			return
+		}
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			collect(ch)
+		}
+	}
+	collect(doc)
+
+	order := 0
+	for _, src := range sources {
+		if strings.TrimSpace(src) == "" {
+			continue
+		}
+		sheet := ParseStylesheet(src)
+		for _, w := range sheet.Warnings {
+			c.warn(w)
+		}
+		for _, rule := range sheet.Rules {
+			order++
+			breakpoint := ""
+			if rule.Media != "" {
+				bp, warning, err := mediaBreakpoint(rule.Media, c.breakpoints)
+				if err != nil {
+					c.warn(err.Error())
+					continue
+				}
+				if warning != "" {
+					c.warn(warning)
+				}
+				breakpoint = bp
+			}
+			for _, raw := range rule.Selectors {
+				sel, err := parseSelector(raw)
+				if err != nil {
+					c.warn(err.Error())
+					continue
+				}
+				tg := target{breakpoint: breakpoint, media: rule.Media}
+				tg.state, tg.custom = sel.state()
+				if name, ok := sel.singleClass(); ok && c.classMode(name) {
+					gc := c.classes[name]
+					if gc == nil {
+						gc = &generatedClass{name: name}
+						c.classes[name] = gc
+					}
+					for _, d := range rule.Declarations {
+						gc.decls = append(gc.decls, cascadeDecl{Declaration: d, spec: sel.specificity(), order: order, source: raw, target: tg})
+					}
+					continue
+				}
+				c.rules = append(c.rules, styleRule{sel: sel, spec: sel.specificity(), order: order, decls: rule.Declarations, target: tg})
+			}
+		}
+	}
+	return c
+}
+
+// classMode reports whether rules for class name become a generated class.
+// Classes the registry already knows keep their site definition, so their
+// rules are inlined instead.
+func (c *converter) classMode(name string) bool {
+	if c.opts.StyleMode != StyleClasses {
+		return c.createsClass(name)
+	}
+	if c.opts.Registry != nil {
+		if _, _, found := c.opts.Registry.Lookup(name); found {
+			return false
+		}
+	}
+	return true
+}
+
+// createsClass reports whether an unresolved class name becomes a new
+// global class under Options.CreateClasses.
+func (c *converter) createsClass(name string) bool {
+	if c.opts.CreateClasses == nil || !c.opts.CreateClasses.Allows(name) {
+		return false
+	}
+	if c.opts.Registry != nil {
+		if _, _, found := c.opts.Registry.Lookup(name); found {
+			return false
+		}
+	}
+	return true
+}
+
+// useClass returns the ID of the generated class for name, marking it used.
+func (c *converter) useClass(name string) (string, bool) {
+	gc := c.classes[name]
+	if gc == nil {
+		return "", false
+	}
+	if !gc.used {
+		gc.used = true
+		gc.id = c.ids.Next(elementid.Seed("class", name))
+		c.order = append(c.order, name)
+	}
+	return gc.id, true
+}
+
+// applyStyles writes the stylesheet rules matching n, then its style
+// attribute, onto settings in cascade order.
+func (c *converter) applyStyles(n *html.Node, settings map[string]interface{}) {
+	var decls []cascadeDecl
+	for _, r := range c.rules {
+		if !r.sel.matches(n) {
+			continue
+		}
+		for _, d := range r.decls {
+			decls = append(decls, cascadeDecl{Declaration: d, spec: r.spec, order: r.order, source: r.sel.raw, target: r.target})
+		}
+	}
+	for _, d := range parseDeclarations(attrValue(n, "style")) {
+		decls = append(decls, cascadeDecl{Declaration: d, spec: inlineSpecificity, source: "style attribute"})
+	}
+	c.apply(settings, decls)
+}
+
+func (c *converter) apply(settings map[string]interface{}, decls []cascadeDecl) {
+	sort.SliceStable(decls, func(i, j int) bool {
+		a, b := decls[i], decls[j]
+		if a.Important != b.Important {
+			return !a.Important
+		}
+		if a.spec != b.spec {
+			return a.spec.less(b.spec)
+		}
+		return a.order < b.order
+	})
+	// Breakpoint and state styles are mapped on their own, then stored under
+	// suffixed keys ("_padding:tablet_portrait", "_background:hover").
+	suffixed := make(map[string]map[string]interface{})
+	var suffixes []string
+	var custom customCSS
+	for _, d := range decls {
+		if d.custom != "" {
+			custom.add(d.media, d.custom, d.Declaration)
+			continue
+		}
+		target := settings
+		if suffix := breakpointSuffix(d.breakpoint) + d.state; suffix != "" {
+			if suffixed[suffix] == nil {
+				suffixed[suffix] = make(map[string]interface{})
+				suffixes = append(suffixes, suffix)
+			}
+			target = suffixed[suffix]
+		}
+		if !applyDeclaration(target, d.Property, d.Value) {
+			if d.state != "" || c.opts.Unmapped == UnmappedCustom {
+				// e.g. content on ::before: keep it as custom CSS
+				custom.add(d.media, "%root%"+d.state, d.Declaration)
+				continue
+			}
+			c.warn(fmt.Sprintf("unsupported declaration %q in %s", d.Property+": "+d.Value, d.source))
+		}
+	}
+	for _, suffix := range suffixes {
+		for k, v := range suffixed[suffix] {
+			settings[k+suffix] = v
+		}
+	}
+	if css := custom.String(); css != "" {
+		existing, _ := settings["_cssCustom"].(string)
+		if names := legacyClassList(existing); names != nil {
+			// The class-list form can't hold CSS, so the classes move to
+			// _cssClasses
+			if cls, _ := settings["_cssClasses"].(string); cls != "" {
+				names = append(strings.Fields(cls), names...)
+			}
+			settings["_cssClasses"] = strings.Join(names, " ")
+		} else if existing != "" {
+			css = existing + "\n" + css
+		}
+		settings["_cssCustom"] = css
+	}
+}
+
+func breakpointSuffix(bp string) string {
+	if bp == "" {
+		return ""
+	}
+	return ":" + bp
+}
+
+// customCSS collects declarations for _cssCustom, grouped by media and
+// selector in first-seen order.
+type customCSS struct {
+	blocks []customBlock
+}
+
+type customBlock struct {
+	media, selector string
+	decls           []string
+}
+
+func (cc *customCSS) add(media, selector string, d Declaration) {
+	decl := d.Property + ": " + d.Value
+	if d.Important {
+		decl += " !important"
+	}
+	for i := range cc.blocks {
+		if cc.blocks[i].media == media && cc.blocks[i].selector == selector {
+			cc.blocks[i].decls = append(cc.blocks[i].decls, decl)
+			return
+		}
+	}
+	cc.blocks = append(cc.blocks, customBlock{media: media, selector: selector, decls: []string{decl}})
+}
+
+func (cc *customCSS) String() string {
+	var sb strings.Builder
+	for _, b := range cc.blocks {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		indent := ""
+		if b.media != "" {
+			sb.WriteString("@media " + b.media + " {\n")
+			indent = "  "
+		}
+		sb.WriteString(indent + b.selector + " {\n")
+		for _, d := range b.decls {
+			sb.WriteString(indent + "  " + d + ";\n")
+		}
+		sb.WriteString(indent + "}\n")
+		if b.media != "" {
+			sb.WriteString("}\n")
+		}
+	}
+	return sb.String()
+}
+
+// generatedClasses returns the used generated classes as {id, name, settings}.
+func (c *converter) generatedClasses() []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, name := range c.order {
+		gc := c.classes[name]
+		settings := make(map[string]interface{})
+		c.apply(settings, gc.decls)
+		out = append(out, map[string]interface{}{"id": gc.id, "name": gc.name, "settings": settings})
+	}
+	return out
+}
+
+func (c *converter) warn(msg string) {
+	if c.warned[msg] {
+		return
+	}
+	c.warned[msg] = true
+	c.warnings = append(c.warnings, msg)
+}
@@ -0,0 +1,38 @@
+package convert
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultSkipPrefixes are class prefixes CreateClasses leaves alone: Frames
+// (fr-) and Bricks' own element classes, whose styles come from elsewhere.
+var DefaultSkipPrefixes = []string{"fr-", "brxe-", "brx-"}
+
+// ClassFilter picks the unresolved class names Options.CreateClasses turns
+// into new global classes.
+type ClassFilter struct {
+	// Allow lists glob patterns (path.Match syntax, e.g. "card*") a name
+	// must match. Empty allows every name.
+	Allow []string
+	// SkipPrefixes lists prefixes whose names are never created.
+	SkipPrefixes []string
+}
+
+// Allows reports whether a class named name may be created.
+func (f *ClassFilter) Allows(name string) bool {
+	for _, prefix := range f.SkipPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	if len(f.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range f.Allow {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package convert_test
+
+import (
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+func TestClassFilter_Allows(t *testing.T) {
+	f := &convert.ClassFilter{Allow: []string{"card*", "hero"}, SkipPrefixes: convert.DefaultSkipPrefixes}
+	tests := map[string]bool{
+		"card":        true,
+		"card__title": true,
+		"hero":        true,
+		"hero-title":  false,
+		"fr-card":     false,
+		"brxe-abc123": false,
+		"unrelated":   false,
+	}
+	for name, want := range tests {
+		if got := f.Allows(name); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if !(&convert.ClassFilter{}).Allows("anything") {
+		t.Error("expected an empty filter to allow every name")
+	}
+}
+
+func TestConvertCreateClasses(t *testing.T) {
+	reg := convert.NewClassRegistry()
+	reg.Add("mt-l", "acss_import_mt-l", "acss")
+	html := `<style>.card { padding: 10px } .card .title { color: #ff0000 } .mt-l { margin-top: 5px }</style>
+<div class="card mt-l fr-grid badge"><h2 class="title">Hi</h2></div>`
+	res, err := convert.Convert(html, convert.Options{
+		Registry:      reg,
+		CreateClasses: &convert.ClassFilter{SkipPrefixes: convert.DefaultSkipPrefixes},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byName := map[string]map[string]interface{}{}
+	for _, cls := range res.Classes {
+		byName[cls["name"].(string)] = cls
+	}
+	if len(byName) != 3 || byName["card"] == nil || byName["badge"] == nil || byName["title"] == nil {
+		t.Fatalf("expected card, badge and title classes, got %v", res.Classes)
+	}
+	card := byName["card"]["settings"].(map[string]interface{})
+	if pad, _ := card["_padding"].(map[string]interface{}); pad["top"] != "10px" {
+		t.Errorf("expected card's rule as its settings, got %v", card)
+	}
+	if len(byName["badge"]["settings"].(map[string]interface{})) != 0 {
+		t.Errorf("expected badge created empty, got %v", byName["badge"])
+	}
+
+	settings := res.Elements[0]["settings"].(map[string]interface{})
+	if _, ok := settings["_padding"]; ok {
+		t.Errorf("expected card's rule not inlined, got %v", settings)
+	}
+	refs := settings["_cssGlobalClasses"].([]interface{})
+	if len(refs) != 3 {
+		t.Errorf("expected card, mt-l and badge referenced, got %v", refs)
+	}
+	if settings["_cssClasses"] != "fr-grid" {
+		t.Errorf("expected the skipped prefix left unresolved, got %v", settings["_cssClasses"])
+	}
+	// Descendant rules still apply to the element
+	heading := res.Elements[1]["settings"].(map[string]interface{})
+	if typo, _ := heading["_typography"].(map[string]interface{}); typo == nil {
+		t.Errorf("expected the .card .title rule inlined, got %v", heading)
+	}
+}
@@ -1,17 +1,16 @@
 package convert
 
 import (
-	"encoding/json"
-	"os"
 	"sort"
 	"strings"
-	"time"
 )
 
-// classEntry holds the ID and source for a single CSS class.
+// classEntry holds the ID, source and style settings for a single CSS
+// class.
 type classEntry struct {
-	ID     string
-	Source string // "acss" or "frames"
+	ID       string
+	Source   string // "acss" or "frames"
+	Settings map[string]interface{}
 }
 
 // ClassRegistry maps class names to their IDs and sources.
@@ -38,6 +37,20 @@ func (r *ClassRegistry) Add(name, id, source string) {
 	r.byName[name] = classEntry{ID: id, Source: source}
 }
 
+// SetSettings records the Bricks settings of a registered class, used to
+// infer the class from matching element styles. Unknown names are ignored.
+func (r *ClassRegistry) SetSettings(name string, settings map[string]interface{}) {
+	if e, ok := r.byName[name]; ok {
+		e.Settings = settings
+		r.byName[name] = e
+	}
+}
+
+// Settings returns the Bricks settings recorded for a class, or nil.
+func (r *ClassRegistry) Settings(name string) map[string]interface{} {
+	return r.byName[name].Settings
+}
+
 // Lookup returns the id, source, and whether the class was found.
 func (r *ClassRegistry) Lookup(name string) (id, source string, found bool) {
 	e, ok := r.byName[name]
@@ -47,6 +60,16 @@ func (r *ClassRegistry) Lookup(name string) (id, source string, found bool) {
 	return e.ID, e.Source, true
 }
 
+// NameOf returns the class name registered under id.
+func (r *ClassRegistry) NameOf(id string) (string, bool) {
+	for name, e := range r.byName {
+		if e.ID == id {
+			return name, true
+		}
+	}
+	return "", false
+}
+
 // Stats returns aggregate counts for the registry.
 func (r *ClassRegistry) Stats() RegistryStats {
 	var s RegistryStats
@@ -85,7 +108,8 @@ func (r *ClassRegistry) BySource(source string) []string {
 }
 
 // BuildRegistryFromClasses builds a ClassRegistry from an API response.
-// Each map in the slice is expected to have "id" and "name" keys (both strings).
+// Each map in the slice is expected to have "id" and "name" keys (both strings),
+// and may have a "settings" object, which is kept for class inference.
 // Classes whose id starts with "acss_import_" are classified as "acss";
 // everything else is "frames".
 func BuildRegistryFromClasses(classes []map[string]interface{}) *ClassRegistry {
@@ -101,47 +125,28 @@ func BuildRegistryFromClasses(classes []map[string]interface{}) *ClassRegistry {
 			source = "acss"
 		}
 		r.Add(nameVal, idVal, source)
+		if settings, ok := c["settings"].(map[string]interface{}); ok && len(settings) > 0 {
+			r.SetSettings(nameVal, settings)
+		}
 	}
 	return r
 }
 
-// registryFile is the JSON-serialisable format for the class registry cache.
-type registryFile struct {
-	FetchedAt time.Time            `json:"fetchedAt"`
-	SiteURL   string               `json:"siteUrl"`
-	ByName    map[string][2]string `json:"byName"` // name → [id, source]
-}
-
-// SaveToFile writes the registry to a JSON file at path.
-func (r *ClassRegistry) SaveToFile(path, siteURL string) error {
-	rf := registryFile{
-		FetchedAt: time.Now().UTC(),
-		SiteURL:   siteURL,
-		ByName:    make(map[string][2]string, len(r.byName)),
-	}
-	for name, e := range r.byName {
-		rf.ByName[name] = [2]string{e.ID, e.Source}
-	}
-	data, err := json.MarshalIndent(rf, "", "  ")
-	if err != nil {
-		return err
+// RemapClassIDs rewrites _cssGlobalClasses references in elements using ids
+// (old ID → new ID), e.g. once generated classes have been created on a site.
+func RemapClassIDs(elements []map[string]interface{}, ids map[string]string) {
+	if len(ids) == 0 {
+		return
 	}
-	return os.WriteFile(path, data, 0644)
-}
-
-// LoadRegistryFromFile reads a cached registry JSON file and returns a ClassRegistry.
-func LoadRegistryFromFile(path string) (*ClassRegistry, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	var rf registryFile
-	if err := json.Unmarshal(data, &rf); err != nil {
-		return nil, err
-	}
-	r := NewClassRegistry()
-	for name, pair := range rf.ByName {
-		r.Add(name, pair[0], pair[1])
+	for _, el := range elements {
+		settings, _ := el["settings"].(map[string]interface{})
+		refs, _ := settings["_cssGlobalClasses"].([]interface{})
+		for i, ref := range refs {
+			if s, ok := ref.(string); ok {
+				if id, found := ids[s]; found {
+					refs[i] = id
+				}
+			}
+		}
 	}
-	return r, nil
 }
@@ -1,10 +1,6 @@
 package convert
 
-import (
-	"os"
-	"path/filepath"
-	"testing"
-)
+import "testing"
 
 func TestClassRegistry_Lookup(t *testing.T) {
 	r := NewClassRegistry()
@@ -121,51 +117,15 @@ func TestBuildRegistryFromClasses(t *testing.T) {
 	}
 }
 
-func TestClassRegistry_SaveLoad(t *testing.T) {
-	// Build a registry
-	r := NewClassRegistry()
-	r.Add("mt-l", "acss_import_mt-l", "acss")
-	r.Add("mb-m", "acss_import_mb-m", "acss")
-	r.Add("hero-section", "frm_style_abc123", "frames")
-
-	// Save to temp file
-	tmpDir := t.TempDir()
-	path := filepath.Join(tmpDir, "class-registry.json")
-	if err := r.SaveToFile(path, "https://example.com"); err != nil {
-		t.Fatalf("SaveToFile failed: %v", err)
-	}
-
-	// Verify file exists
-	if _, err := os.Stat(path); err != nil {
-		t.Fatalf("expected file to exist: %v", err)
-	}
-
-	// Load back
-	loaded, err := LoadRegistryFromFile(path)
-	if err != nil {
-		t.Fatalf("LoadRegistryFromFile failed: %v", err)
-	}
-
-	// Verify all entries preserved
-	for _, name := range r.Names() {
-		origID, origSource, _ := r.Lookup(name)
-		loadedID, loadedSource, found := loaded.Lookup(name)
-		if !found {
-			t.Errorf("class %q not found in loaded registry", name)
-			continue
-		}
-		if loadedID != origID {
-			t.Errorf("class %q: expected id %q, got %q", name, origID, loadedID)
-		}
-		if loadedSource != origSource {
-			t.Errorf("class %q: expected source %q, got %q", name, origSource, loadedSource)
-		}
+func TestClassRegistry_Settings(t *testing.T) {
+	r := BuildRegistryFromClasses([]map[string]interface{}{
+		{"id": "acss_import_gap--m", "name": "gap--m", "settings": map[string]interface{}{"_gap": "var(--space-m)"}},
+		{"id": "frm_style_abc123", "name": "hero-section"},
+	})
+	if got := r.Settings("gap--m"); got["_gap"] != "var(--space-m)" {
+		t.Errorf("expected gap--m settings, got %v", got)
 	}
-
-	// Verify counts match
-	origStats := r.Stats()
-	loadedStats := loaded.Stats()
-	if origStats != loadedStats {
-		t.Errorf("stats mismatch: orig %+v, loaded %+v", origStats, loadedStats)
+	if got := r.Settings("hero-section"); got != nil {
+		t.Errorf("expected no settings for hero-section, got %v", got)
 	}
 }
@@ -0,0 +1,108 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// pageChrome are elements around a page's content — site navigation and
+// boilerplate — dropped when MainContent falls back to the whole body.
+var pageChrome = map[string]bool{"header": true, "footer": true, "nav": true, "aside": true}
+
+// nonContent are elements that never convert to Bricks elements.
+var nonContent = map[string]bool{
+	"script": true, "noscript": true, "style": true, "template": true,
+	"link": true, "meta": true,
+}
+
+// MainContent isolates the content of a full page. With a selector, the
+// first matching element is kept. Otherwise it is the <main> element (or
+// role="main"), then a single <article>, then the <body> without its
+// header, footer, nav and aside. Scripts, styles and other non-content
+// elements are removed either way.
+func MainContent(doc, sel string) (string, error) {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return "", err
+	}
+
+	var content *html.Node
+	inner := true
+	if sel != "" {
+		s, err := parseSelector(sel)
+		if err != nil {
+			return "", err
+		}
+		content = findNode(root, s.matches)
+		if content == nil {
+			return "", fmt.Errorf("no element matches %q", sel)
+		}
+		inner = false
+	} else {
+		content = findNode(root, func(n *html.Node) bool {
+			return n.Data == "main" || attrValue(n, "role") == "main"
+		})
+		if content == nil {
+			var articles []*html.Node
+			walkElements(root, func(n *html.Node) {
+				if n.Data == "article" {
+					articles = append(articles, n)
+				}
+			})
+			if len(articles) == 1 {
+				content, inner = articles[0], false
+			}
+		}
+		if content == nil {
+			content = findNode(root, func(n *html.Node) bool { return n.Data == "body" })
+			if content == nil {
+				return "", fmt.Errorf("document has no body")
+			}
+			removeNodes(content, func(n *html.Node) bool { return pageChrome[n.Data] })
+		}
+	}
+	removeNodes(content, func(n *html.Node) bool { return nonContent[n.Data] })
+
+	if inner {
+		return innerHTML(content), nil
+	}
+	return outerHTML(content), nil
+}
+
+// findNode returns the first element, in document order, for which match
+// is true.
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkElements(c, fn)
+	}
+}
+
+// removeNodes removes the elements below n for which drop is true.
+func removeNodes(n *html.Node, drop func(*html.Node) bool) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && drop(c) {
+			n.RemoveChild(c)
+		} else {
+			removeNodes(c, drop)
+		}
+		c = next
+	}
+}
@@ -0,0 +1,43 @@
+package convert_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+const fullPage = `<!DOCTYPE html><html><head><title>T</title><script>x()</script></head>
+<body><header><nav>Menu</nav></header>
+<div class="wrap"><h1>Title</h1><p class="intro">Body</p><script>y()</script></div>
+<aside>Sidebar</aside><footer>Footer</footer></body></html>`
+
+func TestMainContent(t *testing.T) {
+	got, err := convert.MainContent(fullPage, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, gone := range []string{"Menu", "Sidebar", "Footer", "<script", "<title"} {
+		if strings.Contains(got, gone) {
+			t.Errorf("expected %q removed, got %s", gone, got)
+		}
+	}
+	if !strings.Contains(got, "<h1>Title</h1>") {
+		t.Errorf("expected the body content kept, got %s", got)
+	}
+
+	withMain := strings.Replace(fullPage, `<div class="wrap">`, `<main><div class="wrap">`, 1)
+	withMain = strings.Replace(withMain, `</div>`, `</div></main>`, 1)
+	got, _ = convert.MainContent(withMain, "")
+	if !strings.HasPrefix(got, `<div class="wrap">`) {
+		t.Errorf("expected main's inner HTML, got %s", got)
+	}
+
+	got, err = convert.MainContent(fullPage, "p.intro")
+	if err != nil || got != `<p class="intro">Body</p>` {
+		t.Errorf("expected the selected element, got %q (%v)", got, err)
+	}
+	if _, err := convert.MainContent(fullPage, ".missing"); err == nil {
+		t.Error("expected an error when the selector matches nothing")
+	}
+}
@@ -0,0 +1,201 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stylesheet is a parsed CSS stylesheet: its style rules in source order.
+type Stylesheet struct {
+	Rules []CSSRule
+	// Warnings lists at-rules and malformed blocks the parser skipped.
+	Warnings []string
+}
+
+// CSSRule is one style rule. A rule with a selector list ("h1, h2") keeps
+// every selector; they share the rule's declarations and source order.
+type CSSRule struct {
+	Selectors    []string
+	Declarations []Declaration
+	// Media is the condition of the enclosing @media rule, e.g.
+	// "(max-width: 767px)", or "" for a top-level rule.
+	Media string
+}
+
+// ParseStylesheet parses CSS source into style rules. Comments are dropped;
+// rules inside @media keep their condition, other at-rules are skipped with
+// a warning.
+func ParseStylesheet(css string) *Stylesheet {
+	sheet := &Stylesheet{}
+	for _, b := range splitCSSBlocks(stripCSSComments(css)) {
+		if b.block && strings.HasPrefix(b.prelude, "@media") {
+			media := strings.TrimSpace(strings.TrimPrefix(b.prelude, "@media"))
+			inner := ParseStylesheet(b.body)
+			sheet.Warnings = append(sheet.Warnings, inner.Warnings...)
+			for _, r := range inner.Rules {
+				if r.Media != "" {
+					sheet.Warnings = append(sheet.Warnings, fmt.Sprintf("skipped nested @media %s inside @media %s", r.Media, media))
+					continue
+				}
+				r.Media = media
+				sheet.Rules = append(sheet.Rules, r)
+			}
+			continue
+		}
+		if strings.HasPrefix(b.prelude, "@") {
+			name := b.prelude
+			if i := strings.IndexAny(name, " \t\n("); i > 0 {
+				name = name[:i]
+			}
+			sheet.Warnings = append(sheet.Warnings, fmt.Sprintf("skipped unsupported at-rule %s", name))
+			continue
+		}
+		if !b.block {
+			sheet.Warnings = append(sheet.Warnings, fmt.Sprintf("skipped malformed CSS %q", truncateCSS(b.prelude)))
+			continue
+		}
+		var selectors []string
+		for _, sel := range splitTopLevel(b.prelude, ',') {
+			if sel = strings.Join(strings.Fields(sel), " "); sel != "" {
+				selectors = append(selectors, sel)
+			}
+		}
+		if len(selectors) == 0 {
+			continue
+		}
+		sheet.Rules = append(sheet.Rules, CSSRule{
+			Selectors:    selectors,
+			Declarations: parseDeclarations(b.body),
+		})
+	}
+	return sheet
+}
+
+// cssBlock is a prelude followed by a {}-delimited body. block is false for
+// a trailing prelude that never opened a body, or for a statement ended by
+// ';' such as @import.
+type cssBlock struct {
+	prelude string
+	body    string
+	block   bool
+}
+
+// splitCSSBlocks splits CSS into top-level blocks, keeping nested braces
+// (e.g. inside @media) in the body and ignoring braces inside strings.
+func splitCSSBlocks(css string) []cssBlock {
+	var blocks []cssBlock
+	var prelude, body strings.Builder
+	depth := 0
+	quote := rune(0)
+	escape := false
+
+	for _, ch := range css {
+		cur := &prelude
+		if depth > 0 {
+			cur = &body
+		}
+		if quote != 0 {
+			cur.WriteRune(ch)
+			if escape {
+				escape = false
+			} else if ch == '\\' {
+				escape = true
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '\'', '"':
+			quote = ch
+			cur.WriteRune(ch)
+		case '{':
+			if depth > 0 {
+				body.WriteRune(ch)
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth > 0 {
+				body.WriteRune(ch)
+				continue
+			}
+			blocks = append(blocks, cssBlock{prelude: strings.TrimSpace(prelude.String()), body: strings.TrimSpace(body.String()), block: true})
+			prelude.Reset()
+			body.Reset()
+		case ';':
+			if depth == 0 {
+				if p := strings.TrimSpace(prelude.String()); p != "" {
+					blocks = append(blocks, cssBlock{prelude: p})
+				}
+				prelude.Reset()
+				continue
+			}
+			cur.WriteRune(ch)
+		default:
+			cur.WriteRune(ch)
+		}
+	}
+	if p := strings.TrimSpace(prelude.String()); p != "" {
+		blocks = append(blocks, cssBlock{prelude: p})
+	}
+	return blocks
+}
+
+func stripCSSComments(css string) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			sb.WriteString(css)
+			return sb.String()
+		}
+		sb.WriteString(css[:start])
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			return sb.String()
+		}
+		css = css[start+2+end+2:]
+	}
+}
+
+// splitTopLevel splits s on sep outside parentheses, brackets and strings.
+func splitTopLevel(s string, sep rune) []string {
+	var parts []string
+	var current strings.Builder
+	depth := 0
+	quote := rune(0)
+	for _, ch := range s {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '(' || ch == '[':
+			depth++
+		case ch == ')' || ch == ']':
+			if depth > 0 {
+				depth--
+			}
+		case ch == sep && depth == 0:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(ch)
+	}
+	return append(parts, current.String())
+}
+
+func truncateCSS(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
@@ -0,0 +1,245 @@
+package convert_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+func TestParseStylesheet(t *testing.T) {
+	sheet := convert.ParseStylesheet(`
+		/* headline */
+		h1, .title { color: red; font-size: 2rem !important }
+		a[href] { content: "}" }
+		@font-face { font-family: X; }
+		@import url(x.css);
+	`)
+	if len(sheet.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", sheet.Rules)
+	}
+	r := sheet.Rules[0]
+	if len(r.Selectors) != 2 || r.Selectors[1] != ".title" {
+		t.Errorf("unexpected selectors %v", r.Selectors)
+	}
+	if len(r.Declarations) != 2 || !r.Declarations[1].Important || r.Declarations[1].Value != "2rem" {
+		t.Errorf("unexpected declarations %+v", r.Declarations)
+	}
+	if sheet.Rules[1].Declarations[0].Value != `"}"` {
+		t.Errorf("brace inside a string should not end the rule: %+v", sheet.Rules[1])
+	}
+	if len(sheet.Warnings) != 2 {
+		t.Errorf("expected warnings for @font-face and @import, got %v", sheet.Warnings)
+	}
+}
+
+func textColor(el map[string]interface{}) interface{} {
+	settings, _ := el["settings"].(map[string]interface{})
+	typo, _ := settings["_typography"].(map[string]interface{})
+	color, _ := typo["color"].(map[string]interface{})
+	return color["raw"]
+}
+
+func TestConvertStyleBlockCascade(t *testing.T) {
+	html := `<html><head><style>
+		#intro { color: green }
+		p.lead { color: blue }
+		p { color: red; padding: 8px }
+		section p { margin-top: 4px }
+		.hero > p { font-weight: 700 }
+		.warn { color: orange !important }
+	</style></head><body>
+	<section class="hero">
+		<p id="intro" class="lead">A</p>
+		<p class="lead" style="color: black">B</p>
+		<p class="warn" style="color: black">C</p>
+		<div><p>D</p></div>
+	</section></body></html>`
+
+	res, err := convert.Convert(html, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := map[string]map[string]interface{}{}
+	for _, el := range res.Elements {
+		if el["name"] == "text-basic" {
+			settings := el["settings"].(map[string]interface{})
+			ps[settings["text"].(string)] = el
+		}
+	}
+
+	want := map[string]string{"A": "green", "B": "black", "C": "orange", "D": "red"}
+	for text, color := range want {
+		if got := textColor(ps[text]); got != color {
+			t.Errorf("%s: expected color %s, got %v", text, color, got)
+		}
+	}
+
+	a := ps["A"]["settings"].(map[string]interface{})
+	if _, ok := a["_padding"]; !ok {
+		t.Error("expected p rule padding on A")
+	}
+	if m, _ := a["_margin"].(map[string]interface{}); m["top"] != "4px" {
+		t.Errorf("expected descendant rule margin on A, got %v", a["_margin"])
+	}
+	if typo := a["_typography"].(map[string]interface{}); typo["font-weight"] != "700" {
+		t.Errorf("expected child rule font-weight on A, got %v", typo)
+	}
+	d := ps["D"]["settings"].(map[string]interface{})
+	if typo := d["_typography"].(map[string]interface{}); typo["font-weight"] != nil {
+		t.Errorf("child rule should not match a grandchild, got %v", typo)
+	}
+}
+
+func TestConvertExternalCSSAndWarnings(t *testing.T) {
+	res, err := convert.Convert(`<div class="box"><p>Hi</p></div>`, convert.Options{
+		CSS: `.box { max-width: 640px; clip-path: circle(50%) } .box + p { color: red }`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := res.Elements[0]["settings"].(map[string]interface{})
+	if settings["_maxWidth"] != "640px" {
+		t.Errorf("expected _maxWidth from --css, got %v", settings)
+	}
+	joined := strings.Join(res.Warnings, "\n")
+	if !strings.Contains(joined, "clip-path: circle(50%)") || !strings.Contains(joined, "sibling combinators") {
+		t.Errorf("expected unsupported declaration and selector warnings, got %v", res.Warnings)
+	}
+}
+
+func TestConvertStyleClassesMode(t *testing.T) {
+	reg := convert.NewClassRegistry()
+	reg.Add("btn", "acss_import_btn", "acss")
+
+	html := `<style>.card { padding: 16px } .btn { color: red } .unused { gap: 1rem }</style>
+		<div class="card extra"><a class="btn" href="#">Go</a></div>`
+	res, err := convert.Convert(html, convert.Options{Registry: reg, StyleMode: convert.StyleClasses})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Classes) != 1 || res.Classes[0]["name"] != "card" {
+		t.Fatalf("expected only the used, unregistered class to be generated, got %v", res.Classes)
+	}
+	classID := res.Classes[0]["id"]
+	if pad, _ := res.Classes[0]["settings"].(map[string]interface{})["_padding"].(map[string]interface{}); pad["top"] != "16px" {
+		t.Errorf("expected class padding, got %v", res.Classes[0]["settings"])
+	}
+
+	div := res.Elements[0]["settings"].(map[string]interface{})
+	if refs, _ := div["_cssGlobalClasses"].([]interface{}); len(refs) != 1 || refs[0] != classID {
+		t.Errorf("expected div to reference generated class %v, got %v", classID, div)
+	}
+	if div["_cssClasses"] != "extra" || div["_padding"] != nil {
+		t.Errorf("expected padding on the class only and extra unresolved, got %v", div)
+	}
+
+	// Registered classes keep their site definition; their rule is inlined.
+	link := res.Elements[1]["settings"].(map[string]interface{})
+	if refs, _ := link["_cssGlobalClasses"].([]interface{}); len(refs) != 1 || refs[0] != "acss_import_btn" {
+		t.Errorf("expected registry class on link, got %v", link)
+	}
+	if textColor(res.Elements[1]) != "red" {
+		t.Errorf("expected .btn rule inlined on link, got %v", link)
+	}
+
+	convert.RemapClassIDs(res.Elements, map[string]string{classID.(string): "site01"})
+	if refs := div["_cssGlobalClasses"].([]interface{}); refs[0] != "site01" {
+		t.Errorf("expected remapped class ID, got %v", refs)
+	}
+}
+
+func TestConvertPseudoStates(t *testing.T) {
+	html := `<style>
+		.btn { color: white }
+		.btn:hover { color: yellow; filter: brightness(1.1) }
+		.btn:focus { outline: 2px solid blue }
+		.btn::before { content: "→"; margin-right: 4px }
+		.btn:after { opacity: .5 }
+		@media (max-width: 767px) { .btn:hover { color: orange } }
+		.btn:first-child { margin-top: 0 }
+		.card:hover .btn { color: red }
+	</style><div class="card"><a class="btn" href="#">Go</a></div>`
+
+	res, err := convert.Convert(html, convert.Options{Registry: convert.NewClassRegistry()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := res.Elements[1]["settings"].(map[string]interface{})
+
+	if typo, _ := settings["_typography:hover"].(map[string]interface{}); typo["color"].(map[string]interface{})["raw"] != "yellow" {
+		t.Errorf("expected hover color, got %v", settings)
+	}
+	if typo, _ := settings["_typography:mobile_landscape:hover"].(map[string]interface{}); typo == nil {
+		t.Errorf("expected breakpoint hover color, got %v", settings)
+	}
+	if m, _ := settings["_margin::before"].(map[string]interface{}); m["right"] != "4px" {
+		t.Errorf("expected ::before margin, got %v", settings)
+	}
+	if settings["_opacity::after"] != ".5" {
+		t.Errorf("expected legacy :after normalised to ::after, got %v", settings)
+	}
+
+	custom, _ := settings["_cssCustom"].(string)
+	for _, want := range []string{
+		"%root%:hover {\n  filter: brightness(1.1);\n}",
+		"%root%:focus {\n  outline: 2px solid blue;\n}",
+		"%root%::before {\n  content: \"→\";\n}",
+		"%root%:first-child {\n  margin-top: 0;\n}",
+		".card:hover %root% {\n  color: red;\n}",
+	} {
+		if !strings.Contains(custom, want) {
+			t.Errorf("expected _cssCustom to contain %q, got:\n%s", want, custom)
+		}
+	}
+	if len(res.Warnings) != 0 {
+		t.Errorf("pseudo rules should not be reported as lost, got %v", res.Warnings)
+	}
+}
+
+func TestConvertPseudoStatesWithoutRegistry(t *testing.T) {
+	html := `<style>.card:hover { filter: brightness(1.1) } .card::before { content: "x" }</style>
+<div class="card hero">x</div>`
+	res, err := convert.Convert(html, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := res.Elements[0]["settings"].(map[string]interface{})
+	if settings["_cssClasses"] != "card hero" {
+		t.Errorf("expected the classes moved to _cssClasses, got %v", settings)
+	}
+	custom, _ := settings["_cssCustom"].(string)
+	if !strings.HasPrefix(custom, "%root%") || !strings.Contains(custom, "%root%:hover {") || !strings.Contains(custom, "%root%::before {\n  content: \"x\";\n}") {
+		t.Errorf("expected only CSS in _cssCustom, got:\n%s", custom)
+	}
+
+	out := convert.RenderHTML(res.Elements, convert.RenderOptions{}).HTML
+	if !strings.Contains(out, `class="card hero"`) || strings.Contains(out, ".card\n") {
+		t.Errorf("expected the classes kept on render, got:\n%s", out)
+	}
+}
+
+func TestConvertUnmappedAsCustomCSS(t *testing.T) {
+	html := `<style>.box { clip-path: circle(50%) } @media (max-width: 767px) { .box { filter: blur(1px) } }</style>
+<div class="box" style="mix-blend-mode: multiply; border: 1px solid red">x</div>`
+	res, err := convert.Convert(html, convert.Options{Unmapped: convert.UnmappedCustom})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := res.Elements[0]["settings"].(map[string]interface{})
+	custom, _ := settings["_cssCustom"].(string)
+	for _, want := range []string{
+		"%root% {\n  clip-path: circle(50%);\n  mix-blend-mode: multiply;\n}",
+		"@media (max-width: 767px) {\n  %root% {\n    filter: blur(1px);\n  }\n}",
+	} {
+		if !strings.Contains(custom, want) {
+			t.Errorf("expected _cssCustom to contain %q, got:\n%s", want, custom)
+		}
+	}
+	if settings["_border"] == nil {
+		t.Errorf("expected the border mapped, got %v", settings)
+	}
+	if len(res.Warnings) != 0 {
+		t.Errorf("custom CSS is not lost, got warnings %v", res.Warnings)
+	}
+}
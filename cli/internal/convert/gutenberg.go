@@ -0,0 +1,371 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// gutenbergBlock is one parsed block: its name ("core/columns"), its
+// attributes and its content, a mix of saved HTML and inner blocks.
+type gutenbergBlock struct {
+	name    string
+	attrs   map[string]interface{}
+	void    bool
+	content []interface{} // string or *gutenbergBlock
+}
+
+// blockDelimiter matches <!-- wp:name {attrs} -->, <!-- /wp:name --> and
+// <!-- wp:name {attrs} /-->. Attribute JSON never contains "-->":
+// WordPress escapes "--" when it saves a block.
+var blockDelimiter = regexp.MustCompile(`(?s)<!--\s+(/)?wp:([a-z][a-z0-9_-]*/)?([a-z][a-z0-9_-]*)\s+(\{.*?\}\s+)?(/)?-->`)
+
+// GutenbergToHTML converts WordPress block markup (post_content with
+// <!-- wp:... --> comments) to HTML shaped for Convert. Columns, groups,
+// covers, buttons, images and headings become structured layouts; other
+// blocks keep their saved HTML. Top-level blocks outside groups and covers
+// are wrapped in sections, and WordPress's generated classes (wp-block-*,
+// has-*, is-*, ...) are dropped. Warnings report unbalanced delimiters,
+// invalid attributes and dynamic blocks, which have no saved HTML.
+func GutenbergToHTML(content string) (string, []string) {
+	g := &gutenbergConverter{}
+	root := g.parse(content)
+
+	var out, run strings.Builder
+	flush := func() {
+		if strings.TrimSpace(run.String()) != "" {
+			out.WriteString("<section><div " + AttrElementName + `="container">` + run.String() + "</div></section>\n")
+		}
+		run.Reset()
+	}
+	for _, item := range root.content {
+		b, ok := item.(*gutenbergBlock)
+		if !ok {
+			run.WriteString(item.(string))
+			continue
+		}
+		switch blockName(b) {
+		case "group", "cover":
+			flush()
+			out.WriteString(g.block(b, 0))
+		default:
+			run.WriteString(g.block(b, 1))
+		}
+	}
+	flush()
+	return stripWordPressClasses(out.String()), g.warnings
+}
+
+type gutenbergConverter struct {
+	warnings []string
+}
+
+func (g *gutenbergConverter) warn(msg string) {
+	g.warnings = append(g.warnings, msg)
+}
+
+// parse builds the block tree; the root block has no name.
+func (g *gutenbergConverter) parse(content string) *gutenbergBlock {
+	root := &gutenbergBlock{}
+	stack := []*gutenbergBlock{root}
+	last := 0
+	for _, m := range blockDelimiter.FindAllStringSubmatchIndex(content, -1) {
+		top := stack[len(stack)-1]
+		if m[0] > last {
+			top.content = append(top.content, content[last:m[0]])
+		}
+		last = m[1]
+
+		closer := m[2] >= 0
+		namespace := "core/"
+		if m[4] >= 0 {
+			namespace = content[m[4]:m[5]]
+		}
+		name := namespace + content[m[6]:m[7]]
+
+		if closer {
+			i := len(stack) - 1
+			for i > 0 && stack[i].name != name {
+				i--
+			}
+			if i == 0 {
+				g.warn(fmt.Sprintf("ignoring unmatched closing <!-- /wp:%s -->", strings.TrimPrefix(name, "core/")))
+				continue
+			}
+			if i != len(stack)-1 {
+				g.warn(fmt.Sprintf("block %s was not closed", stack[len(stack)-1].name))
+			}
+			stack = stack[:i]
+			continue
+		}
+
+		b := &gutenbergBlock{name: name, attrs: map[string]interface{}{}, void: m[10] >= 0}
+		if m[8] >= 0 {
+			if err := json.Unmarshal([]byte(content[m[8]:m[9]]), &b.attrs); err != nil {
+				g.warn(fmt.Sprintf("ignoring invalid attributes of block %s: %v", name, err))
+				b.attrs = map[string]interface{}{}
+			}
+		}
+		top.content = append(top.content, b)
+		if !b.void {
+			stack = append(stack, b)
+		}
+	}
+	if last < len(content) {
+		stack[len(stack)-1].content = append(stack[len(stack)-1].content, content[last:])
+	}
+	for _, b := range stack[1:] {
+		g.warn(fmt.Sprintf("block %s was not closed", b.name))
+	}
+	return root
+}
+
+// blockName is a block's name without the core/ namespace.
+func blockName(b *gutenbergBlock) string {
+	return strings.TrimPrefix(b.name, "core/")
+}
+
+// block renders b as HTML. depth is 0 for blocks that become sections.
+func (g *gutenbergConverter) block(b *gutenbergBlock, depth int) string {
+	if b.void {
+		g.warn(fmt.Sprintf("skipping dynamic block %s: it has no saved HTML", b.name))
+		return ""
+	}
+	switch blockName(b) {
+	case "columns":
+		settings := map[string]interface{}{"_direction": "row"}
+		if stacked, ok := b.attrs["isStackedOnMobile"].(bool); !ok || stacked {
+			settings["_direction:mobile_portrait"] = "column"
+		}
+		return g.wrap(b, "block", settings, depth)
+	case "column":
+		settings := map[string]interface{}{}
+		switch w := b.attrs["width"].(type) {
+		case string:
+			settings["_width"] = w
+		case float64:
+			settings["_width"] = fmt.Sprintf("%g%%", w)
+		}
+		return g.wrap(b, "block", settings, depth)
+	case "group":
+		settings := layoutSettings(b.attrs)
+		if depth == 0 {
+			return g.section(b, map[string]interface{}{}, settings)
+		}
+		return g.wrap(b, "block", settings, depth)
+	case "cover":
+		settings := map[string]interface{}{}
+		if url, _ := b.attrs["url"].(string); url != "" {
+			// The attachment ID belongs to the source site; media
+			// localization sets the target site's.
+			settings["_background"] = map[string]interface{}{
+				"image": map[string]interface{}{"url": url, "full": url},
+			}
+		}
+		if h, ok := b.attrs["minHeight"].(float64); ok {
+			unit, _ := b.attrs["minHeightUnit"].(string)
+			if unit == "" {
+				unit = "px"
+			}
+			settings["_minHeight"] = fmt.Sprintf("%g%s", h, unit)
+		}
+		if depth == 0 {
+			return g.section(b, settings, map[string]interface{}{})
+		}
+		return g.wrap(b, "block", settings, depth)
+	case "buttons":
+		settings := layoutSettings(b.attrs)
+		settings["_direction"] = "row"
+		return g.wrap(b, "block", settings, depth)
+	case "button":
+		return patchRoot(g.saved(b, depth), "a", func(n *html.Node) {
+			setAttr(n, AttrElementName, "button")
+		})
+	case "image":
+		// As with covers, the source site's attachment ID and size are
+		// left to media localization.
+		return patchRoot(g.saved(b, depth), "", func(n *html.Node) {
+			if img := findDescendant(n, "img"); img != nil {
+				if src := attrValue(img, "src"); src != "" {
+					setSettingsAttr(n, map[string]interface{}{
+						"image": map[string]interface{}{"url": src, "full": src},
+					})
+				}
+			}
+		})
+	}
+	out := g.saved(b, depth)
+	if align, _ := b.attrs["textAlign"].(string); align != "" {
+		out = patchRoot(out, "", func(n *html.Node) {
+			style := attrValue(n, "style")
+			if style != "" && !strings.HasSuffix(strings.TrimSpace(style), ";") {
+				style += ";"
+			}
+			setAttr(n, "style", style+"text-align: "+align)
+		})
+	}
+	return out
+}
+
+// saved is a block's saved HTML with its inner blocks rendered in place.
+func (g *gutenbergConverter) saved(b *gutenbergBlock, depth int) string {
+	var sb strings.Builder
+	for _, item := range b.content {
+		if inner, ok := item.(*gutenbergBlock); ok {
+			sb.WriteString(g.block(inner, depth+1))
+		} else {
+			sb.WriteString(item.(string))
+		}
+	}
+	return sb.String()
+}
+
+// inner renders only b's inner blocks, dropping its own wrapper markup.
+func (g *gutenbergConverter) inner(b *gutenbergBlock, depth int) string {
+	var sb strings.Builder
+	for _, item := range b.content {
+		if inner, ok := item.(*gutenbergBlock); ok {
+			sb.WriteString(g.block(inner, depth+1))
+		}
+	}
+	return sb.String()
+}
+
+// wrap renders b as the named element around its inner blocks.
+func (g *gutenbergConverter) wrap(b *gutenbergBlock, element string, settings map[string]interface{}, depth int) string {
+	return "<div" + blockAttrs(b, element, settings) + ">" + g.inner(b, depth) + "</div>\n"
+}
+
+// section renders b as a section and container around its inner blocks.
+func (g *gutenbergConverter) section(b *gutenbergBlock, settings, container map[string]interface{}) string {
+	tag := "section"
+	if t, _ := b.attrs["tagName"].(string); t == "header" || t == "footer" || t == "main" || t == "article" {
+		tag = t
+	}
+	return "<" + tag + blockAttrs(b, "section", settings) + ">" +
+		"<div" + blockAttrs(&gutenbergBlock{}, "container", container) + ">" + g.inner(b, 0) + "</div></" + tag + ">\n"
+}
+
+// blockAttrs writes the marker, settings, custom classes and anchor of an
+// element made from b.
+func blockAttrs(b *gutenbergBlock, element string, settings map[string]interface{}) string {
+	var sb strings.Builder
+	sb.WriteString(" " + AttrElementName + `="` + element + `"`)
+	if len(settings) > 0 {
+		data, _ := json.Marshal(settings)
+		sb.WriteString(" " + AttrSettings + `="` + html.EscapeString(string(data)) + `"`)
+	}
+	if cls, _ := b.attrs["className"].(string); cls != "" {
+		sb.WriteString(` class="` + html.EscapeString(cls) + `"`)
+	}
+	if anchor, _ := b.attrs["anchor"].(string); anchor != "" {
+		sb.WriteString(` id="` + html.EscapeString(anchor) + `"`)
+	}
+	return sb.String()
+}
+
+// layoutSettings maps a block's layout attribute onto flex or grid
+// settings.
+func layoutSettings(attrs map[string]interface{}) map[string]interface{} {
+	settings := map[string]interface{}{}
+	layout, _ := attrs["layout"].(map[string]interface{})
+	switch layout["type"] {
+	case "flex":
+		if layout["orientation"] != "vertical" {
+			settings["_direction"] = "row"
+		}
+		if j, _ := layout["justifyContent"].(string); j != "" {
+			justify := map[string]string{"left": "flex-start", "right": "flex-end", "center": "center", "space-between": "space-between"}[j]
+			if justify != "" {
+				settings["_justifyContent"] = justify
+			}
+		}
+	case "grid":
+		settings["_display"] = "grid"
+		if n, ok := layout["columnCount"].(float64); ok {
+			settings["_gridTemplateColumns"] = fmt.Sprintf("repeat(%g, 1fr)", n)
+		}
+	}
+	return settings
+}
+
+// patchRoot applies fn to the first element of fragment named tag (the
+// first top-level element when tag is ""). With a tag, only that element
+// is kept.
+func patchRoot(fragment, tag string, fn func(*html.Node)) string {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return fragment
+	}
+	var find func(*html.Node) *html.Node
+	find = func(n *html.Node) *html.Node {
+		if n.Type == html.ElementNode && (tag == "" || n.Data == tag) {
+			return n
+		}
+		if tag == "" {
+			return nil
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if found := find(c); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	for _, n := range nodes {
+		found := find(n)
+		if found == nil {
+			continue
+		}
+		fn(found)
+		if tag != "" {
+			return outerHTML(found) + "\n"
+		}
+		var sb strings.Builder
+		for _, n := range nodes {
+			html.Render(&sb, n)
+		}
+		return sb.String()
+	}
+	return fragment
+}
+
+func setSettingsAttr(n *html.Node, settings map[string]interface{}) {
+	data, _ := json.Marshal(settings)
+	setAttr(n, AttrSettings, string(data))
+}
+
+// wordPressClassPrefixes are the classes WordPress generates for blocks;
+// the layouts they describe come from block attributes instead.
+var wordPressClassPrefixes = []string{"wp-", "has-", "is-", "size-", "align", "are-"}
+
+// stripWordPressClasses removes WordPress's generated classes, keeping
+// classes users added.
+func stripWordPressClasses(doc string) string {
+	return classAttrPattern.ReplaceAllStringFunc(doc, func(m string) string {
+		sub := classAttrPattern.FindStringSubmatch(m)
+		var keep []string
+		for _, cls := range strings.Fields(sub[1]) {
+			generated := false
+			for _, p := range wordPressClassPrefixes {
+				if strings.HasPrefix(cls, p) {
+					generated = true
+					break
+				}
+			}
+			if !generated {
+				keep = append(keep, cls)
+			}
+		}
+		if len(keep) == 0 {
+			return ""
+		}
+		return ` class="` + strings.Join(keep, " ") + `"`
+	})
+}
+
+var classAttrPattern = regexp.MustCompile(`\sclass="([^"]*)"`)
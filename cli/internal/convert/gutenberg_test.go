@@ -0,0 +1,100 @@
+package convert_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+const gutenbergPost = `<!-- wp:cover {"url":"https://example.com/hero.jpg","id":12,"minHeight":480} -->
+<div class="wp-block-cover" style="min-height:480px"><div class="wp-block-cover__inner-container"><!-- wp:heading {"textAlign":"center","level":1} -->
+<h1 class="wp-block-heading has-text-align-center">Welcome</h1>
+<!-- /wp:heading --></div></div>
+<!-- /wp:cover -->
+
+<!-- wp:columns -->
+<div class="wp-block-columns"><!-- wp:column {"width":"66.66%"} -->
+<div class="wp-block-column" style="flex-basis:66.66%"><!-- wp:paragraph {"className":"lead"} -->
+<p class="lead">Left <strong>side</strong></p>
+<!-- /wp:paragraph --></div>
+<!-- /wp:column -->
+
+<!-- wp:column -->
+<div class="wp-block-column"><!-- wp:image {"id":34,"sizeSlug":"large"} -->
+<figure class="wp-block-image size-large"><img src="https://example.com/a.jpg" alt="A" class="wp-image-34"/><figcaption>Caption</figcaption></figure>
+<!-- /wp:image --></div>
+<!-- /wp:column --></div>
+<!-- /wp:columns -->
+
+<!-- wp:buttons {"layout":{"type":"flex","justifyContent":"center"}} -->
+<div class="wp-block-buttons"><!-- wp:button -->
+<div class="wp-block-button"><a class="wp-block-button__link wp-element-button" href="/contact">Contact</a></div>
+<!-- /wp:button --></div>
+<!-- /wp:buttons -->
+
+<!-- wp:latest-posts {"postsToShow":3} /-->`
+
+func TestGutenbergToHTML(t *testing.T) {
+	doc, warnings := convert.GutenbergToHTML(gutenbergPost)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "latest-posts") {
+		t.Errorf("expected a warning for the dynamic block, got %v", warnings)
+	}
+	if strings.Contains(doc, "wp-block") || strings.Contains(doc, "has-text-align") {
+		t.Errorf("expected WordPress classes removed, got\n%s", doc)
+	}
+
+	res, err := convert.Convert(doc, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string][]map[string]interface{}{}
+	for _, el := range res.Elements {
+		name := el["name"].(string)
+		byName[name] = append(byName[name], el)
+	}
+
+	cover := byName["section"][0]["settings"].(map[string]interface{})
+	bg, _ := cover["_background"].(map[string]interface{})
+	if img, _ := bg["image"].(map[string]interface{}); img["url"] != "https://example.com/hero.jpg" || img["full"] != img["url"] || img["id"] != nil {
+		t.Errorf("expected the cover image URL, without the source attachment ID, as section background, got %v", cover)
+	}
+	h := byName["heading"][0]["settings"].(map[string]interface{})
+	if h["tag"] != "h1" || h["text"] != "Welcome" {
+		t.Errorf("unexpected heading %v", h)
+	}
+
+	var row map[string]interface{}
+	for _, b := range byName["block"] {
+		s, _ := b["settings"].(map[string]interface{})
+		if s["_direction"] == "row" && len(b["children"].([]interface{})) == 2 {
+			row = s
+		}
+	}
+	if row == nil || row["_direction:mobile_portrait"] != "column" {
+		t.Errorf("expected columns as a row stacking on mobile, got %v", byName["block"])
+	}
+
+	img := byName["image"][0]["settings"].(map[string]interface{})
+	if image := img["image"].(map[string]interface{}); image["id"] != nil || image["size"] != nil || image["url"] == nil || image["full"] != image["url"] {
+		t.Errorf("expected only the image URL, without the source attachment ID, got %v", img)
+	}
+	if len(byName["button"]) != 1 {
+		t.Fatalf("expected one button, got %v", res.Elements)
+	}
+	if btn := byName["button"][0]["settings"].(map[string]interface{}); btn["text"] != "Contact" {
+		t.Errorf("unexpected button %v", btn)
+	}
+	if len(byName["text"]) != 1 || !strings.Contains(byName["text"][0]["settings"].(map[string]interface{})["text"].(string), "<strong>side</strong>") {
+		t.Errorf("expected the paragraph as rich text, got %v", byName["text"])
+	}
+}
+
+func TestGutenbergMalformedMarkup(t *testing.T) {
+	_, warnings := convert.GutenbergToHTML(`<!-- wp:group {"bad": } -->
+<div class="wp-block-group"><p>x</p></div>
+<!-- /wp:columns -->`)
+	if len(warnings) < 2 {
+		t.Errorf("expected warnings for bad attributes and the mismatched closer, got %v", warnings)
+	}
+}
@@ -1,11 +1,12 @@
 package convert
 
 import (
-	"crypto/rand"
-	"encoding/hex"
-	"io"
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/nerveband/agent-to-bricks/internal/elementid"
 	"golang.org/x/net/html"
 )
 
@@ -21,63 +22,134 @@ func HTMLToBricks(htmlStr string) ([]map[string]interface{}, error) {
 // When registry is provided, resolved classes go to _cssGlobalClasses and
 // unresolved classes go to _cssClasses.
 func HTMLToBricksWithRegistry(htmlStr string, registry *ClassRegistry) ([]map[string]interface{}, error) {
-	doc, err := html.Parse(strings.NewReader(htmlStr))
+	res, err := Convert(htmlStr, Options{Registry: registry})
 	if err != nil {
 		return nil, err
 	}
+	return res.Elements, nil
+}
 
-	var elements []map[string]interface{}
-	usedIDs := make(map[string]bool)
-
-	// Process the document body
-	var processNode func(*html.Node, string)
-	processNode = func(n *html.Node, parentID string) {
-		if n.Type == html.ElementNode {
-			bricksName := mapTagToElement(n.Data)
-			if bricksName == "" {
-				// Skip unknown tags but process children
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					processNode(c, parentID)
-				}
-				return
-			}
+// StyleMode selects where matching stylesheet rules are written.
+type StyleMode string
 
-			id := generateID(usedIDs)
-			el := map[string]interface{}{
-				"id":       id,
-				"name":     bricksName,
-				"parent":   parentID,
-				"children": []interface{}{},
-				"settings": map[string]interface{}{},
-			}
+const (
+	// StyleInline maps every matching rule onto the element's own settings.
+	StyleInline StyleMode = "inline"
+	// StyleClasses turns single-class rules (".card { ... }") whose class is
+	// not in the registry into new global classes; other rules are inlined.
+	StyleClasses StyleMode = "classes"
+)
 
-			// Extract settings from attributes and content
-			settings := extractSettings(n, bricksName, registry)
-			if len(settings) > 0 {
-				el["settings"] = settings
-			}
+// UnmappedMode selects what happens to declarations no Bricks setting can
+// hold.
+type UnmappedMode string
 
-			// Collect child IDs
-			var childIDs []interface{}
-			for c := n.FirstChild; c != nil; c = c.NextSibling {
-				if c.Type == html.ElementNode {
-					childName := mapTagToElement(c.Data)
-					if childName != "" {
-						childID := peekID(usedIDs)
-						childIDs = append(childIDs, childID)
-					}
-				}
-			}
-			el["children"] = childIDs
+const (
+	// UnmappedWarn drops them with a warning.
+	UnmappedWarn UnmappedMode = "warn"
+	// UnmappedCustom keeps them in the element's _cssCustom under %root%.
+	UnmappedCustom UnmappedMode = "custom"
+)
 
-			elements = append(elements, el)
+// Options configures Convert.
+type Options struct {
+	// Registry resolves class names to global class IDs. May be nil.
+	Registry *ClassRegistry
+	// CSS is extra stylesheet source (e.g. from --css files), applied as if
+	// linked before the document's own <style> blocks.
+	CSS string
+	// StyleMode defaults to StyleInline.
+	StyleMode StyleMode
+	// Unmapped defaults to UnmappedWarn.
+	Unmapped UnmappedMode
+	// Breakpoints maps @media (max-width) rules onto Bricks breakpoints.
+	// Defaults to DefaultBreakpoints.
+	Breakpoints []Breakpoint
+	// Mapping picks the Bricks element for each HTML element. Defaults to
+	// the built-in rules.
+	Mapping *Mapper
+	// InlineThreshold is how many inline formatting elements (strong, em,
+	// a, br, span, ...) a text block needs to be kept as rich text with its
+	// inner HTML; below it, inline elements become elements of their own.
+	// 0 means DefaultInlineThreshold; a negative value turns rich text off.
+	InlineThreshold int
+	// PreserveIDs reuses data-bricks-id attributes (from RenderHTML) as
+	// element IDs, so re-converting edited HTML updates the same elements.
+	PreserveIDs bool
+	// IDs picks how new element IDs are made. Defaults to elementid.Random;
+	// elementid.Hash derives them from each element's position and content
+	// so the same HTML always gets the same IDs, and elementid.Explicit
+	// also takes them from data-bricks-id and id attributes.
+	IDs elementid.Strategy
+	// ReservedIDs are IDs new elements must not take, such as those already
+	// on a page the elements will be appended to.
+	ReservedIDs []string
+	// CreateClasses turns class names the registry doesn't resolve into
+	// new global classes, as StyleClasses does for single-class rules: their
+	// single-class rules become the class settings (an empty class when
+	// there are none) and elements reference them in _cssGlobalClasses
+	// rather than _cssClasses. Names the filter rejects stay unresolved.
+	// Nil leaves unresolved classes alone.
+	CreateClasses *ClassFilter
+}
+
+// Result is the output of Convert.
+type Result struct {
+	Elements []map[string]interface{}
+	// Classes are global classes generated from stylesheet rules
+	// (StyleClasses) or for unresolved class names (CreateClasses), as
+	// {id, name, settings}. Elements reference them by
+	// these IDs, which are local until the classes are created on a site.
+	Classes []map[string]interface{}
+	// Warnings lists selectors, declarations (unless kept as custom CSS)
+	// and at-rules that could not be represented in Bricks.
+	Warnings []string
+}
+
+// Convert converts an HTML document to Bricks elements. Styles come from
+// inline style attributes, <style> blocks and opts.CSS; stylesheet rules are
+// applied in cascade order (specificity, then source order, with !important
+// last) and inline styles override non-important rules. Rules inside
+// @media (max-width: ...) land on breakpoint-suffixed setting keys.
+func Convert(htmlStr string, opts Options) (*Result, error) {
+	doc, err := html.Parse(strings.NewReader(htmlStr))
+	if err != nil {
+		return nil, err
+	}
 
-			// Process children
+	mapper := opts.Mapping
+	if mapper == nil {
+		mapper, _ = NewMapper(nil)
+	}
+	ids := elementid.New(opts.IDs)
+	ids.Reserve(opts.ReservedIDs...)
+	explicit := make(map[string]bool)
+	if opts.PreserveIDs || opts.IDs == elementid.Explicit {
+		// Reserve the explicit IDs so generated ones can't collide. IDs
+		// already reserved (on the target page) can't be kept.
+		var invalid error
+		var walk func(*html.Node)
+		walk = func(n *html.Node) {
+			id := explicitID(n, opts.IDs)
+			if id != "" && !elementid.Valid(id) && invalid == nil {
+				invalid = &InvalidIDError{ID: id, Tag: n.Data}
+			}
+			if elementid.Valid(id) && !ids.Used(id) {
+				ids.Reserve(id)
+				explicit[id] = true
+			}
 			for c := n.FirstChild; c != nil; c = c.NextSibling {
-				processNode(c, id)
+				walk(c)
 			}
 		}
+		walk(doc)
+		if invalid != nil {
+			return nil, invalid
+		}
 	}
+	conv := newConverter(doc, opts, ids)
+	conv.mapper = mapper
+	conv.explicit = explicit
 
 	// Find body or process entire document
 	var body *html.Node
@@ -94,17 +166,15 @@ func HTMLToBricksWithRegistry(htmlStr string, registry *ClassRegistry) ([]map[st
 	findBody(doc)
 
 	if body != nil {
-		for c := body.FirstChild; c != nil; c = c.NextSibling {
-			processNode(c, "0")
-		}
+		conv.processNodes(childNodes(body), "0")
 	} else {
 		for c := doc.FirstChild; c != nil; c = c.NextSibling {
-			processNode(c, "0")
+			conv.processNode(c, "0")
 		}
 	}
 
-	// Fix children arrays (they were populated with peek IDs which aren't accurate)
-	// Rebuild from parent references
+	// Build children arrays from parent references
+	elements := conv.elements
 	idToChildren := make(map[string][]interface{})
 	for _, el := range elements {
 		parent, _ := el["parent"].(string)
@@ -120,41 +190,206 @@ func HTMLToBricksWithRegistry(htmlStr string, registry *ClassRegistry) ([]map[st
 		}
 	}
 
-	return elements, nil
+	return &Result{Elements: elements, Classes: conv.generatedClasses(), Warnings: conv.warnings}, nil
+}
+
+// processNode converts n and its subtree under parentID using the first
+// matching mapping rule. Elements no rule matches are skipped, but their
+// children are still converted; scripts, styles and other non-content
+// elements are skipped with their children.
+func (c *converter) processNode(n *html.Node, parentID string) {
+	if n.Type != html.ElementNode || nonContent[n.Data] {
+		return
+	}
+	if name := attrValue(n, AttrElementName); name != "" {
+		c.addMarkedElement(n, name, parentID)
+		return
+	}
+	rule := c.mapper.match(n)
+	if rule == nil {
+		c.processNodes(childNodes(n), parentID)
+		return
+	}
+	if rule.build != nil {
+		rule.build(c, n, parentID)
+		return
+	}
+	if textElements[rule.Element] && rule.Children != ChildrenSkip && c.richText(childNodes(n)) {
+		c.addRichText(n, rule, parentID)
+		return
+	}
+	id, _ := c.addElement(n, rule, parentID)
+	switch {
+	case rule.Children == ChildrenSkip:
+	case textElements[rule.Element]:
+		// The direct text is already in the settings
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			c.processNode(ch, id)
+		}
+	default:
+		c.processNodes(childNodes(n), id)
+	}
+}
+
+// textElements take their text from the HTML element's direct text.
+var textElements = map[string]bool{
+	"heading": true, "text-basic": true, "button": true, "text-link": true,
 }
 
-// mapTagToElement maps HTML tags to Bricks element names.
-func mapTagToElement(tag string) string {
-	tag = strings.ToLower(tag)
-	mapping := map[string]string{
-		"section":    "section",
-		"div":        "div",
-		"header":     "section",
-		"footer":     "section",
-		"main":       "section",
-		"article":    "section",
-		"aside":      "div",
-		"nav":        "div",
-		"h1":         "heading",
-		"h2":         "heading",
-		"h3":         "heading",
-		"h4":         "heading",
-		"h5":         "heading",
-		"h6":         "heading",
-		"p":          "text-basic",
-		"span":       "text-basic",
-		"a":          "text-link",
-		"button":     "button",
-		"img":        "image",
-		"video":      "video",
-		"ul":         "list",
-		"ol":         "list",
-		"form":       "form",
-		"code":       "code",
-		"pre":        "code",
-		"blockquote": "text-basic",
+// addRichText adds a text block whose content is inline formatting only,
+// keeping its inner HTML: headings keep their element, other text blocks
+// become a rich text element.
+func (c *converter) addRichText(n *html.Node, rule *MappingRule, parentID string) {
+	r := *rule
+	if r.Element == "text-basic" {
+		r.Element = "text"
 	}
-	return mapping[tag]
+	_, settings := c.addElement(n, &r, parentID)
+	if _, ok := r.Settings["text"]; !ok {
+		settings["text"] = innerHTML(n)
+	}
+}
+
+// addMarkedElement adds an element whose Bricks name is given by
+// data-bricks-element. Elements with content settings (text, code) take
+// the node's content; others convert its children.
+func (c *converter) addMarkedElement(n *html.Node, name, parentID string) {
+	id, settings := c.addElement(n, &MappingRule{Element: name}, parentID)
+	if content, ok := elementContent[name]; ok {
+		value := textContent(n)
+		if content.markup {
+			value = innerHTML(n)
+		}
+		if value != "" {
+			settings[content.key] = value
+		}
+		return
+	}
+	c.processNodes(childNodes(n), id)
+}
+
+// addElement appends the element for n under parentID. Its settings come
+// from data-bricks-settings, then the HTML attributes, content and
+// stylesheets, then the rule's rendered settings.
+func (c *converter) addElement(n *html.Node, rule *MappingRule, parentID string) (string, map[string]interface{}) {
+	settings := extractSettings(n, rule.Element, c.opts.Registry, c)
+	if raw := attrValue(n, AttrSettings); raw != "" {
+		var base map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &base); err != nil {
+			c.warn(fmt.Sprintf("ignoring invalid %s on <%s>: %v", AttrSettings, n.Data, err))
+		} else {
+			settings = mergeSettings(base, settings)
+		}
+	}
+	if rendered, ok := renderSettings(rule.Settings, n).(map[string]interface{}); ok {
+		settings = mergeSettings(settings, rendered)
+	}
+	id := ""
+	if keep := explicitID(n, c.opts.IDs); c.explicit[keep] && !c.preserved[keep] {
+		c.preserved[keep] = true
+		id = keep
+	}
+	if id == "" {
+		id = c.nextID(rule.Element, parentID, settings)
+	}
+	return c.appendElement(id, rule.Element, parentID, settings), settings
+}
+
+// InvalidIDError is returned by Convert when an element asks for an ID
+// Bricks can't use.
+type InvalidIDError struct {
+	ID  string
+	Tag string
+}
+
+func (e *InvalidIDError) Error() string {
+	return fmt.Sprintf("<%s> asks for element ID %q, but Bricks element IDs must match %s", e.Tag, e.ID, elementid.Pattern)
+}
+
+// explicitID returns the ID n asks for: its data-bricks-id, or with
+// elementid.Explicit its id attribute (without a "brxe-" prefix). Convert
+// rejects the input before conversion if any of these aren't valid.
+func explicitID(n *html.Node, strategy elementid.Strategy) string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	id := attrValue(n, AttrElementID)
+	if id == "" && strategy == elementid.Explicit {
+		id = strings.TrimPrefix(attrValue(n, "id"), "brxe-")
+	}
+	return id
+}
+
+// legacyClassList returns the class names in the legacy class-list form
+// of _cssCustom (".card.hero"), or nil when custom is CSS.
+func legacyClassList(custom interface{}) []string {
+	s, _ := custom.(string)
+	if !strings.HasPrefix(s, ".") || strings.Contains(s, "{") {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(s, "."), ".")
+}
+
+// mergeSettings merges over into base, recursing into nested settings
+// maps. Global class lists are combined.
+func mergeSettings(base, over map[string]interface{}) map[string]interface{} {
+	for k, v := range over {
+		switch ov := v.(type) {
+		case map[string]interface{}:
+			if bv, ok := base[k].(map[string]interface{}); ok {
+				base[k] = mergeSettings(bv, ov)
+				continue
+			}
+		case []interface{}:
+			if bv, ok := base[k].([]interface{}); ok && k == "_cssGlobalClasses" {
+				seen := make(map[interface{}]bool)
+				for _, id := range bv {
+					seen[id] = true
+				}
+				for _, id := range ov {
+					if !seen[id] {
+						bv = append(bv, id)
+					}
+				}
+				base[k] = bv
+				continue
+			}
+		}
+		base[k] = v
+	}
+	return base
+}
+
+// newElement appends an element with the given settings and returns its ID.
+func (c *converter) newElement(name, parentID string, settings map[string]interface{}) string {
+	return c.appendElement(c.nextID(name, parentID, settings), name, parentID, settings)
+}
+
+// nextID makes a new element ID. Hash seeds are the element's path (its
+// parent's ID and its position among the parent's children) and content
+// (its name and settings so far), so identical elements in different places
+// still get different IDs.
+func (c *converter) nextID(name, parentID string, settings map[string]interface{}) string {
+	if c.ids.Strategy() == elementid.Random {
+		return c.ids.Next("")
+	}
+	content, _ := json.Marshal(settings)
+	return c.ids.Next(elementid.Seed(parentID, strconv.Itoa(c.childCount[parentID]), name, string(content)))
+}
+
+func (c *converter) appendElement(id, name, parentID string, settings map[string]interface{}) string {
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	c.childCount[parentID]++
+	c.elements = append(c.elements, map[string]interface{}{
+		"id":       id,
+		"name":     name,
+		"parent":   parentID,
+		"children": []interface{}{},
+		"settings": settings,
+	})
+	return id
 }
 
 // extractSettings extracts Bricks settings from an HTML element.
@@ -163,7 +398,7 @@ func mapTagToElement(tag string) string {
 //   - Unresolved classes are added to _cssClasses (space-separated string)
 //
 // When registry is nil, the original _cssCustom behavior is preserved.
-func extractSettings(n *html.Node, bricksName string, registry *ClassRegistry) map[string]interface{} {
+func extractSettings(n *html.Node, bricksName string, registry *ClassRegistry, c *converter) map[string]interface{} {
 	settings := make(map[string]interface{})
 
 	// Extract text content for text elements
@@ -188,9 +423,23 @@ func extractSettings(n *html.Node, bricksName string, registry *ClassRegistry) m
 				continue
 			}
 
+			// Classes generated from stylesheet rules resolve first
+			var globalIDs []interface{}
+			var rest []string
+			for _, cls := range classes {
+				if c.createsClass(cls) && c.classes[cls] == nil {
+					c.classes[cls] = &generatedClass{name: cls}
+				}
+				if id, ok := c.useClass(cls); ok {
+					globalIDs = append(globalIDs, id)
+				} else {
+					rest = append(rest, cls)
+				}
+			}
+			classes = rest
+
 			if registry != nil {
 				// Registry mode: resolve classes
-				var globalIDs []interface{}
 				var unresolved []string
 				for _, cls := range classes {
 					if id, _, found := registry.Lookup(cls); found {
@@ -199,16 +448,16 @@ func extractSettings(n *html.Node, bricksName string, registry *ClassRegistry) m
 						unresolved = append(unresolved, cls)
 					}
 				}
-				if len(globalIDs) > 0 {
-					settings["_cssGlobalClasses"] = globalIDs
-				}
 				if len(unresolved) > 0 {
 					settings["_cssClasses"] = strings.Join(unresolved, " ")
 				}
-			} else {
+			} else if len(classes) > 0 {
 				// Backward compat: store as _cssCustom
 				settings["_cssCustom"] = "." + strings.Join(classes, ".")
 			}
+			if len(globalIDs) > 0 {
+				settings["_cssGlobalClasses"] = globalIDs
+			}
 		case "href":
 			if bricksName == "text-link" || bricksName == "button" {
 				settings["link"] = map[string]interface{}{
@@ -231,16 +480,14 @@ func extractSettings(n *html.Node, bricksName string, registry *ClassRegistry) m
 					img["alt"] = attr.Val
 				}
 			}
-		case "style":
-			styleSettings := ParseInlineStyles(attr.Val)
-			for k, v := range styleSettings {
-				settings[k] = v
-			}
 		case "id":
-			settings["_htmlId"] = attr.Val
+			// RenderHTML's default brxe- IDs are not custom HTML IDs
+			if attr.Val != "brxe-"+attrValue(n, AttrElementID) {
+				settings["_htmlId"] = attr.Val
+			}
 		default:
 			// Capture data-* attributes
-			if strings.HasPrefix(attr.Key, "data-") {
+			if strings.HasPrefix(attr.Key, "data-") && !strings.HasPrefix(attr.Key, "data-bricks-") {
 				attrs, _ := settings["_attributes"].([]interface{})
 				attrs = append(attrs, map[string]interface{}{
 					"name":  attr.Key,
@@ -251,6 +498,9 @@ func extractSettings(n *html.Node, bricksName string, registry *ClassRegistry) m
 		}
 	}
 
+	// Stylesheet rules and the inline style attribute, in cascade order
+	c.applyStyles(n, settings)
+
 	return settings
 }
 
@@ -264,20 +514,3 @@ func extractText(n *html.Node) string {
 	}
 	return strings.TrimSpace(sb.String())
 }
-
-func generateID(used map[string]bool) string {
-	for {
-		b := make([]byte, 3)
-		io.ReadFull(rand.Reader, b)
-		id := hex.EncodeToString(b)
-		if !used[id] {
-			used[id] = true
-			return id
-		}
-	}
-}
-
-func peekID(used map[string]bool) string {
-	// This is a placeholder - actual IDs are assigned during processNode
-	return ""
-}
@@ -1,9 +1,13 @@
 package convert_test
 
 import (
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/nerveband/agent-to-bricks/internal/convert"
+	"github.com/nerveband/agent-to-bricks/internal/elementid"
 )
 
 func TestConvertSimpleHTML(t *testing.T) {
@@ -223,3 +227,58 @@ func TestConvertHTMLWithRegistry(t *testing.T) {
 		t.Errorf("expected 'my-custom' as unresolved class, got %q", pCustom)
 	}
 }
+
+func TestHashIDsAreStable(t *testing.T) {
+	page := `<section><p>Same</p><p>Same</p></section><form><input type="email" name="e"></form>`
+	a, _ := convert.Convert(page, convert.Options{IDs: elementid.Hash})
+	b, _ := convert.Convert(page, convert.Options{IDs: elementid.Hash})
+	if !reflect.DeepEqual(a.Elements, b.Elements) {
+		t.Errorf("expected identical output across runs\n%v\n%v", a.Elements, b.Elements)
+	}
+	if a.Elements[1]["id"] == a.Elements[2]["id"] {
+		t.Error("identical siblings need different IDs")
+	}
+
+	edited, _ := convert.Convert(`<section><p>Same</p><p>Changed</p></section>`, convert.Options{IDs: elementid.Hash})
+	if edited.Elements[1]["id"] != a.Elements[1]["id"] || edited.Elements[2]["id"] == a.Elements[2]["id"] {
+		t.Error("expected only the edited element's ID to change")
+	}
+
+	reserved, _ := convert.Convert(page, convert.Options{IDs: elementid.Hash, ReservedIDs: []string{a.Elements[0]["id"].(string)}})
+	if reserved.Elements[0]["id"] == a.Elements[0]["id"] {
+		t.Error("expected reserved IDs to be avoided")
+	}
+}
+
+func TestExplicitIDs(t *testing.T) {
+	res, err := convert.Convert(`<div id="hero01"><p id="brxe-abc123">A</p><p id="hero01">C</p></div>`,
+		convert.Options{IDs: elementid.Explicit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []interface{}{res.Elements[0]["id"], res.Elements[1]["id"], res.Elements[2]["id"]}
+	if ids[0] != "hero01" || ids[1] != "abc123" {
+		t.Errorf("expected IDs from id attributes, got %v", ids)
+	}
+	if ids[2] == "hero01" {
+		t.Errorf("duplicate IDs need generated ones, got %v", ids)
+	}
+}
+
+func TestExplicitIDsRejectInvalid(t *testing.T) {
+	for _, html := range []string{
+		`<div id="hero"></div>`,
+		`<div id="Bad ID"></div>`,
+		`<div id="abcdefghijk"></div>`,
+		`<div data-bricks-id="x1"></div>`,
+	} {
+		_, err := convert.Convert(html, convert.Options{IDs: elementid.Explicit})
+		var idErr *convert.InvalidIDError
+		if !errors.As(err, &idErr) || !strings.Contains(err.Error(), "^[a-z0-9]{6,10}$") {
+			t.Errorf("%s: expected an invalid ID error naming the pattern, got %v", html, err)
+		}
+	}
+	if _, err := convert.Convert(`<div id="hero"></div>`, convert.Options{IDs: elementid.Hash}); err != nil {
+		t.Errorf("id attributes are only element IDs with explicit IDs, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package convert
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// inferableClass is a registry class whose settings can be matched against
+// element styles.
+type inferableClass struct {
+	name, id string
+	settings map[string]interface{}
+	values   int // leaf values in settings
+}
+
+// classSettingsSkip are settings a class may carry that element styles
+// can't be compared with, so classes holding them are never inferred.
+var classSettingsSkip = map[string]bool{
+	"_cssCustom":        true,
+	"_cssGlobalClasses": true,
+	"_cssClasses":       true,
+}
+
+// inferableClasses returns the classes with comparable settings, those
+// covering the most values first so a class like .flex--row-center wins
+// over the .flex--row it contains.
+func (r *ClassRegistry) inferableClasses() []inferableClass {
+	var classes []inferableClass
+	for name, e := range r.byName {
+		skip := false
+		for key := range e.Settings {
+			if classSettingsSkip[key] {
+				skip = true
+			}
+		}
+		if n := countValues(e.Settings); !skip && n > 0 {
+			classes = append(classes, inferableClass{name: name, id: e.ID, settings: e.Settings, values: n})
+		}
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		a, b := classes[i], classes[j]
+		if a.values != b.values {
+			return a.values > b.values
+		}
+		return a.name < b.name
+	})
+	return classes
+}
+
+// InferClasses attaches to each element the classes whose settings its
+// styles contain, removing those settings from the element, and returns
+// how many classes it attached. Values must match exactly, at the same
+// breakpoint and state; only classes with recorded settings take part.
+func (r *ClassRegistry) InferClasses(elements []map[string]interface{}) int {
+	classes := r.inferableClasses()
+	if len(classes) == 0 {
+		return 0
+	}
+	attached := 0
+	for _, el := range elements {
+		settings, _ := el["settings"].(map[string]interface{})
+		if settings == nil {
+			continue
+		}
+		for _, cls := range classes {
+			if !containsSettings(settings, cls.settings) {
+				continue
+			}
+			removeSettings(settings, cls.settings)
+			refs, _ := settings["_cssGlobalClasses"].([]interface{})
+			if !containsRef(refs, cls.id) {
+				settings["_cssGlobalClasses"] = append(refs, cls.id)
+				attached++
+			}
+		}
+	}
+	return attached
+}
+
+func containsRef(refs []interface{}, id string) bool {
+	for _, ref := range refs {
+		if ref == id {
+			return true
+		}
+	}
+	return false
+}
+
+// containsSettings reports whether every value in want is in have. Nested
+// objects match when have holds all of want's keys, so an element's
+// _typography can hold more than the class's.
+func containsSettings(have, want map[string]interface{}) bool {
+	for key, w := range want {
+		h, ok := have[key]
+		if !ok {
+			return false
+		}
+		if wm, ok := w.(map[string]interface{}); ok {
+			hm, ok := h.(map[string]interface{})
+			if !ok || !containsSettings(hm, wm) {
+				return false
+			}
+			continue
+		}
+		if !sameValue(h, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// removeSettings deletes want's values from have, dropping objects left
+// empty.
+func removeSettings(have, want map[string]interface{}) {
+	for key, w := range want {
+		wm, wok := w.(map[string]interface{})
+		hm, hok := have[key].(map[string]interface{})
+		if wok && hok {
+			removeSettings(hm, wm)
+			if len(hm) > 0 {
+				continue
+			}
+		}
+		delete(have, key)
+	}
+}
+
+// sameValue compares setting values by their JSON form, so numbers decoded
+// from the API match the converter's.
+func sameValue(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func countValues(settings map[string]interface{}) int {
+	n := 0
+	for _, v := range settings {
+		if m, ok := v.(map[string]interface{}); ok {
+			n += countValues(m)
+		} else {
+			n++
+		}
+	}
+	return n
+}
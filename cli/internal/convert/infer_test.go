@@ -0,0 +1,75 @@
+package convert_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+func inferRegistry() *convert.ClassRegistry {
+	r := convert.NewClassRegistry()
+	r.Add("flex--row", "acss_import_flex--row", "acss")
+	r.SetSettings("flex--row", map[string]interface{}{"_display": "flex", "_direction": "row"})
+	r.Add("flex--col", "acss_import_flex--col", "acss")
+	r.SetSettings("flex--col", map[string]interface{}{"_display": "flex", "_direction": "column"})
+	r.Add("gap--m", "acss_import_gap--m", "acss")
+	r.SetSettings("gap--m", map[string]interface{}{"_gap": "var(--space-m)"})
+	r.Add("text--l", "acss_import_text--l", "acss")
+	r.SetSettings("text--l", map[string]interface{}{"_typography": map[string]interface{}{"font-size": "var(--text-l)"}})
+	r.Add("custom", "frm_custom", "frames")
+	r.SetSettings("custom", map[string]interface{}{"_gap": "var(--space-m)", "_cssCustom": "%root% { outline: 0 }"})
+	r.Add("no-settings", "frm_none", "frames")
+	return r
+}
+
+// inferred converts html with the registry and infers classes on the
+// result, as the CLI does after snapping tokens.
+func inferred(t *testing.T, html string) map[string]interface{} {
+	t.Helper()
+	registry := inferRegistry()
+	res, err := convert.Convert(html, convert.Options{Registry: registry})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	registry.InferClasses(res.Elements)
+	return res.Elements[0]["settings"].(map[string]interface{})
+}
+
+func TestInferClasses(t *testing.T) {
+	settings := inferred(t, `<div style="display: flex; flex-direction: row; gap: var(--space-m); font-size: var(--text-l); color: #ff0000; padding: 10px">x</div>`)
+
+	want := []interface{}{"acss_import_flex--row", "acss_import_gap--m", "acss_import_text--l"}
+	if got := settings["_cssGlobalClasses"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected inferred classes %v, got %v", want, got)
+	}
+	for _, key := range []string{"_display", "_direction", "_gap"} {
+		if _, ok := settings[key]; ok {
+			t.Errorf("expected %s dropped, got %v", key, settings[key])
+		}
+	}
+	typo := settings["_typography"].(map[string]interface{})
+	if _, ok := typo["font-size"]; ok || typo["color"] == nil {
+		t.Errorf("expected only the class's font size dropped, got %v", typo)
+	}
+	if settings["_padding"] == nil {
+		t.Error("expected settings no class matches kept")
+	}
+}
+
+func TestInferClasses_ExactMatch(t *testing.T) {
+	settings := inferred(t, `<div class="gap--m" style="display: flex; flex-direction: row-reverse; gap: var(--space-m)">x</div>`)
+	// The class already used is not attached twice, but its duplicate is dropped
+	if got := settings["_cssGlobalClasses"]; !reflect.DeepEqual(got, []interface{}{"acss_import_gap--m"}) {
+		t.Errorf("expected only gap--m, got %v", got)
+	}
+	if settings["_display"] != "flex" || settings["_direction"] != "row-reverse" || settings["_gap"] != nil {
+		t.Errorf("expected partial matches kept and the duplicate gap dropped, got %v", settings)
+	}
+
+	// Convert alone never infers
+	res, _ := convert.Convert(`<div style="gap: var(--space-m)">x</div>`, convert.Options{Registry: inferRegistry()})
+	if settings := res.Elements[0]["settings"].(map[string]interface{}); settings["_cssGlobalClasses"] != nil {
+		t.Errorf("expected no inference without InferClasses, got %v", settings)
+	}
+}
@@ -0,0 +1,458 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"gopkg.in/yaml.v3"
+)
+
+// Children modes for a MappingRule.
+const (
+	// ChildrenConvert converts the element's children as nested elements.
+	ChildrenConvert = "convert"
+	// ChildrenSkip drops the children, for rules whose settings already
+	// carry the content (e.g. "{{outer}}").
+	ChildrenSkip = "skip"
+)
+
+// MappingRule maps matching HTML elements onto a Bricks element. Tag (a
+// glob, e.g. "*-*" for custom elements), Class, Attr (CSS attribute syntax:
+// "data-role", "src*=youtube.com") and Has (a descendant tag) must all
+// match; empty fields match anything.
+//
+// String values in Settings are templates: {{text}}, {{html}}, {{outer}},
+// {{tag}} and {{attr.NAME}} read the element, and a leading tag reads its
+// first descendant of that tag ({{img.attr.src}}, {{figcaption.text}}).
+// Filters follow a pipe: {{attr.src | youtube_id}}, vimeo_id, query:NAME,
+// lower and trim. Settings that render empty are dropped.
+type MappingRule struct {
+	Name     string                 `yaml:"name,omitempty" json:"name,omitempty"`
+	Tag      string                 `yaml:"tag,omitempty" json:"tag,omitempty"`
+	Class    string                 `yaml:"class,omitempty" json:"class,omitempty"`
+	Attr     string                 `yaml:"attr,omitempty" json:"attr,omitempty"`
+	Has      string                 `yaml:"has,omitempty" json:"has,omitempty"`
+	Element  string                 `yaml:"element" json:"element"`
+	Settings map[string]interface{} `yaml:"settings,omitempty" json:"settings,omitempty"`
+	Children string                 `yaml:"children,omitempty" json:"children,omitempty"`
+	// Source is "builtin" or the rules file the rule came from.
+	Source string `yaml:"-" json:"source"`
+
+	attr  *attrMatcher
+	build func(c *converter, n *html.Node, parentID string)
+}
+
+// Match describes what the rule matches, in selector-like form.
+func (r *MappingRule) Match() string {
+	var sb strings.Builder
+	if r.Tag != "" {
+		sb.WriteString(r.Tag)
+	}
+	if r.Class != "" {
+		sb.WriteString("." + r.Class)
+	}
+	if r.Attr != "" {
+		sb.WriteString("[" + r.Attr + "]")
+	}
+	if r.Has != "" {
+		sb.WriteString(":has(" + r.Has + ")")
+	}
+	if sb.Len() == 0 {
+		return "*"
+	}
+	return sb.String()
+}
+
+func (r *MappingRule) compile() error {
+	if r.Element == "" {
+		return fmt.Errorf("element is required")
+	}
+	if r.Tag == "" && r.Class == "" && r.Attr == "" {
+		return fmt.Errorf("needs at least one of tag, class or attr")
+	}
+	if _, err := path.Match(r.Tag, ""); err != nil {
+		return fmt.Errorf("invalid tag pattern %q", r.Tag)
+	}
+	switch r.Children {
+	case "", ChildrenConvert, ChildrenSkip:
+	default:
+		return fmt.Errorf("children must be %q or %q, got %q", ChildrenConvert, ChildrenSkip, r.Children)
+	}
+	r.Tag = strings.ToLower(r.Tag)
+	r.Has = strings.ToLower(r.Has)
+	if r.Attr != "" {
+		m, err := parseAttrMatcher(r.Attr)
+		if err != nil {
+			return err
+		}
+		r.attr = m
+	}
+	return nil
+}
+
+func (r *MappingRule) matches(n *html.Node) bool {
+	if r.Tag != "" {
+		if ok, _ := path.Match(r.Tag, n.Data); !ok {
+			return false
+		}
+	}
+	if r.Class != "" && !hasClass(n, r.Class) {
+		return false
+	}
+	if r.attr != nil && !r.attr.matches(n) {
+		return false
+	}
+	if r.Has != "" && findDescendant(n, r.Has) == nil {
+		return false
+	}
+	return true
+}
+
+// attrMatcher is a parsed CSS attribute condition: name, op and value.
+type attrMatcher struct {
+	name, op, value string
+}
+
+var attrMatcherPattern = regexp.MustCompile(`^\s*([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*(?:([*^$~|]?=)\s*(.*?))?\s*$`)
+
+func parseAttrMatcher(s string) (*attrMatcher, error) {
+	m := attrMatcherPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid attr condition %q", s)
+	}
+	return &attrMatcher{name: strings.ToLower(m[1]), op: m[2], value: strings.Trim(m[3], `"'`)}, nil
+}
+
+func (m *attrMatcher) matches(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if a.Key != m.name {
+			continue
+		}
+		switch m.op {
+		case "":
+			return true
+		case "=":
+			return a.Val == m.value
+		case "*=":
+			return strings.Contains(a.Val, m.value)
+		case "^=":
+			return strings.HasPrefix(a.Val, m.value)
+		case "$=":
+			return strings.HasSuffix(a.Val, m.value)
+		case "~=":
+			for _, f := range strings.Fields(a.Val) {
+				if f == m.value {
+					return true
+				}
+			}
+		case "|=":
+			return a.Val == m.value || strings.HasPrefix(a.Val, m.value+"-")
+		}
+		return false
+	}
+	return false
+}
+
+// Mapper picks the mapping rule for each HTML element: user rules first, in
+// file order, then the built-in rules. The first match wins.
+type Mapper struct {
+	rules []*MappingRule
+}
+
+// NewMapper returns a Mapper with the given user rules ahead of the
+// built-in ones.
+func NewMapper(user []MappingRule) (*Mapper, error) {
+	m := &Mapper{}
+	for i := range user {
+		r := user[i]
+		if err := r.compile(); err != nil {
+			label := r.Name
+			if label == "" {
+				label = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("mapping rule %s: %w", label, err)
+		}
+		m.rules = append(m.rules, &r)
+	}
+	for _, r := range builtinMappingRules() {
+		m.rules = append(m.rules, r)
+	}
+	return m, nil
+}
+
+// Rules returns the active rules in match order.
+func (m *Mapper) Rules() []MappingRule {
+	out := make([]MappingRule, len(m.rules))
+	for i, r := range m.rules {
+		out[i] = *r
+	}
+	return out
+}
+
+func (m *Mapper) match(n *html.Node) *MappingRule {
+	for _, r := range m.rules {
+		if r.matches(n) {
+			return r
+		}
+	}
+	return nil
+}
+
+// mappingFile is the YAML/JSON format of a rules file.
+type mappingFile struct {
+	Rules []MappingRule `yaml:"rules" json:"rules"`
+}
+
+// LoadMappingRules reads user mapping rules from a YAML or JSON file of the
+// form {rules: [...]}.
+func LoadMappingRules(filePath string) ([]MappingRule, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var mf mappingFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&mf); err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	for i := range mf.Rules {
+		mf.Rules[i].Source = filePath
+		probe := mf.Rules[i]
+		if err := probe.compile(); err != nil {
+			return nil, fmt.Errorf("%s: rule %d: %w", filePath, i+1, err)
+		}
+	}
+	return mf.Rules, nil
+}
+
+// builtinTags are the plain tag-to-element mappings.
+var builtinTags = []struct{ tag, element string }{
+	{"section", "section"}, {"div", "div"}, {"header", "section"}, {"footer", "section"},
+	{"main", "section"}, {"article", "section"}, {"aside", "div"}, {"nav", "div"},
+	{"h1", "heading"}, {"h2", "heading"}, {"h3", "heading"}, {"h4", "heading"},
+	{"h5", "heading"}, {"h6", "heading"},
+	{"p", "text-basic"}, {"span", "text-basic"}, {"blockquote", "text-basic"},
+	{"address", "text-basic"}, {"figcaption", "text-basic"}, {"label", "text-basic"},
+	{"small", "text-basic"}, {"cite", "text-basic"}, {"time", "text-basic"},
+	{"a", "text-link"}, {"button", "button"}, {"img", "image"}, {"video", "video"},
+	{"ul", "list"}, {"ol", "list"}, {"code", "code"}, {"pre", "code"}, {"hr", "divider"},
+}
+
+// builtinMappingRules returns fresh copies of the built-in rules, most
+// specific first.
+func builtinMappingRules() []*MappingRule {
+	rules := []*MappingRule{
+		{Name: "figure-image", Tag: "figure", Has: "img", Element: "image", Children: ChildrenSkip, Settings: map[string]interface{}{
+			"image":         map[string]interface{}{"url": "{{img.attr.src}}", "alt": "{{img.attr.alt}}"},
+			"caption":       "custom",
+			"captionCustom": "{{figcaption.text}}",
+		}},
+		{Name: "figure", Tag: "figure", Element: "div"},
+		{Name: "picture", Tag: "picture", Has: "img", Element: "image", Children: ChildrenSkip, Settings: map[string]interface{}{
+			"image": map[string]interface{}{"url": "{{img.attr.src}}", "alt": "{{img.attr.alt}}"},
+		}},
+		{Name: "svg", Tag: "svg", Element: "svg", Children: ChildrenSkip, build: buildSVG},
+		{Name: "youtube", Tag: "iframe", Attr: "src*=youtube.com", Element: "video", Children: ChildrenSkip, Settings: map[string]interface{}{
+			"videoType": "youtube", "youTubeId": "{{attr.src | youtube_id}}",
+		}},
+		{Name: "youtu.be", Tag: "iframe", Attr: "src*=youtu.be", Element: "video", Children: ChildrenSkip, Settings: map[string]interface{}{
+			"videoType": "youtube", "youTubeId": "{{attr.src | youtube_id}}",
+		}},
+		{Name: "vimeo", Tag: "iframe", Attr: "src*=vimeo.com", Element: "video", Children: ChildrenSkip, Settings: map[string]interface{}{
+			"videoType": "vimeo", "vimeoId": "{{attr.src | vimeo_id}}",
+		}},
+		{Name: "google-map", Tag: "iframe", Attr: "src*=google.com/maps", Element: "map", Children: ChildrenSkip, build: buildMap},
+		{Name: "iframe", Tag: "iframe", Element: "code", Children: ChildrenSkip, build: buildIframe},
+		{Name: "audio", Tag: "audio", Element: "audio", Children: ChildrenSkip, Settings: map[string]interface{}{
+			"file": map[string]interface{}{"url": "{{attr.src}}"},
+		}},
+		{Name: "table", Tag: "table", Element: "text", Children: ChildrenSkip, Settings: map[string]interface{}{
+			"text": "{{outer}}",
+		}},
+		{Name: "dl", Tag: "dl", Element: "text", Children: ChildrenSkip, Settings: map[string]interface{}{
+			"text": "{{outer}}",
+		}},
+		{Name: "details", Tag: "details", Element: "accordion-nested", build: buildAccordion},
+		{Name: "form", Tag: "form", Element: "form", Children: ChildrenSkip, build: buildForm},
+	}
+	for _, t := range builtinTags {
+		rules = append(rules, &MappingRule{Tag: t.tag, Element: t.element})
+	}
+	rules = append(rules, &MappingRule{Name: "custom-element", Tag: "*-*", Element: "div", Settings: map[string]interface{}{
+		"tag": "custom", "customTag": "{{tag}}",
+	}})
+	for _, r := range rules {
+		r.Source = "builtin"
+		if err := r.compile(); err != nil {
+			panic("convert: invalid builtin mapping rule " + r.Match() + ": " + err.Error())
+		}
+	}
+	return rules
+}
+
+var templateExpr = regexp.MustCompile(`\{\{\s*(.*?)\s*\}\}`)
+
+// renderSettings renders a rule's settings templates against n, dropping
+// values that render empty.
+func renderSettings(v interface{}, n *html.Node) interface{} {
+	switch t := v.(type) {
+	case string:
+		out := templateExpr.ReplaceAllStringFunc(t, func(m string) string {
+			return evalTemplate(templateExpr.FindStringSubmatch(m)[1], n)
+		})
+		if strings.TrimSpace(out) == "" {
+			return nil
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		for k, val := range t {
+			if r := renderSettings(val, n); r != nil {
+				out[k] = r
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case []interface{}:
+		var out []interface{}
+		for _, val := range t {
+			if r := renderSettings(val, n); r != nil {
+				out = append(out, r)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func evalTemplate(expr string, n *html.Node) string {
+	parts := strings.Split(expr, "|")
+	val := templateVar(strings.TrimSpace(parts[0]), n)
+	for _, f := range parts[1:] {
+		name, arg, _ := strings.Cut(strings.TrimSpace(f), ":")
+		switch name {
+		case "youtube_id":
+			val = youtubeID(val)
+		case "vimeo_id":
+			val = vimeoID(val)
+		case "query":
+			if u, err := url.Parse(val); err == nil {
+				val = u.Query().Get(arg)
+			} else {
+				val = ""
+			}
+		case "lower":
+			val = strings.ToLower(val)
+		case "trim":
+			val = strings.TrimSpace(val)
+		}
+	}
+	return val
+}
+
+func templateVar(name string, n *html.Node) string {
+	switch {
+	case name == "text":
+		return strings.Join(strings.Fields(textContent(n)), " ")
+	case name == "html":
+		return innerHTML(n)
+	case name == "outer":
+		return outerHTML(n)
+	case name == "tag":
+		return n.Data
+	case strings.HasPrefix(name, "attr."):
+		return attrValue(n, strings.TrimPrefix(name, "attr."))
+	}
+	tag, rest, ok := strings.Cut(name, ".")
+	if !ok {
+		return ""
+	}
+	d := findDescendant(n, strings.ToLower(tag))
+	if d == nil {
+		return ""
+	}
+	return templateVar(rest, d)
+}
+
+var (
+	youtubeIDPattern = regexp.MustCompile(`(?:youtube(?:-nocookie)?\.com/(?:embed/|watch\?v=|v/|shorts/)|youtu\.be/)([A-Za-z0-9_-]{6,})`)
+	vimeoIDPattern   = regexp.MustCompile(`vimeo\.com/(?:video/)?([0-9]+)`)
+)
+
+func youtubeID(u string) string {
+	if m := youtubeIDPattern.FindStringSubmatch(u); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func vimeoID(u string) string {
+	if m := vimeoIDPattern.FindStringSubmatch(u); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func findDescendant(n *html.Node, tag string) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return c
+		}
+		if d := findDescendant(c, tag); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attrValue(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent returns all text under n.
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+func innerHTML(n *html.Node) string {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&buf, c)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func outerHTML(n *html.Node) string {
+	var buf bytes.Buffer
+	html.Render(&buf, n)
+	return buf.String()
+}
@@ -0,0 +1,279 @@
+package convert
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nerveband/agent-to-bricks/internal/elementid"
+	"golang.org/x/net/html"
+)
+
+// buildAccordion converts <details> into a nested accordion item: a block
+// holding a title div (the <summary> as a heading) and a content div with
+// the remaining children. Adjacent <details> share one accordion.
+func buildAccordion(c *converter, n *html.Node, parentID string) {
+	accID := c.accordions[prevElementSibling(n)]
+	if accID == "" {
+		accID, _ = c.addElement(n, &MappingRule{Element: "accordion-nested"}, parentID)
+	}
+	c.accordions[n] = accID
+
+	item := c.newElement("block", accID, nil)
+	title := c.newElement("div", item, nil)
+	summary := findChild(n, "summary")
+	text := "Details"
+	if summary != nil {
+		if t := strings.Join(strings.Fields(textContent(summary)), " "); t != "" {
+			text = t
+		}
+	}
+	c.newElement("heading", title, map[string]interface{}{"text": text, "tag": "h3"})
+
+	content := c.newElement("div", item, nil)
+	var body []*html.Node
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		if ch != summary {
+			body = append(body, ch)
+		}
+	}
+	c.processNodes(body, content)
+}
+
+// formFieldTypes maps <input type> onto Bricks form field types.
+var formFieldTypes = map[string]string{
+	"email": "email", "tel": "tel", "number": "number", "url": "url",
+	"checkbox": "checkbox", "radio": "radio", "file": "file",
+	"password": "password", "hidden": "hidden",
+	"date": "datepicker", "datetime-local": "datepicker",
+}
+
+// buildForm converts <form> into a Bricks form element whose fields come
+// from the inputs, textareas and selects inside it. The submit button's
+// label becomes submitButtonText.
+func buildForm(c *converter, n *html.Node, parentID string) {
+	formID, settings := c.addElement(n, &MappingRule{Element: "form"}, parentID)
+
+	var fields []interface{}
+	var walk func(*html.Node)
+	walk = func(el *html.Node) {
+		for ch := el.FirstChild; ch != nil; ch = ch.NextSibling {
+			if ch.Type != html.ElementNode {
+				continue
+			}
+			fieldType := ""
+			switch ch.Data {
+			case "input":
+				t := strings.ToLower(attrValue(ch, "type"))
+				if t == "submit" || t == "button" {
+					if v := attrValue(ch, "value"); v != "" {
+						settings["submitButtonText"] = v
+					}
+					continue
+				}
+				fieldType = formFieldTypes[t]
+				if fieldType == "" {
+					fieldType = "text"
+				}
+			case "textarea":
+				fieldType = "textarea"
+			case "select":
+				fieldType = "select"
+			case "button":
+				if t := strings.ToLower(attrValue(ch, "type")); t == "" || t == "submit" {
+					if text := strings.Join(strings.Fields(textContent(ch)), " "); text != "" {
+						settings["submitButtonText"] = text
+					}
+				}
+				continue
+			default:
+				walk(ch)
+				continue
+			}
+
+			field := map[string]interface{}{
+				"id":   c.ids.Next(elementid.Seed(formID, "field", strconv.Itoa(len(fields)))),
+				"type": fieldType,
+			}
+			if label := fieldLabel(n, ch); label != "" {
+				field["label"] = label
+			}
+			if p := attrValue(ch, "placeholder"); p != "" {
+				field["placeholder"] = p
+			}
+			if name := attrValue(ch, "name"); name != "" {
+				field["name"] = name
+			}
+			for _, a := range ch.Attr {
+				if a.Key == "required" {
+					field["required"] = true
+				}
+			}
+			if fieldType == "select" {
+				var options []string
+				for opt := ch.FirstChild; opt != nil; opt = opt.NextSibling {
+					if opt.Type == html.ElementNode && opt.Data == "option" {
+						options = append(options, strings.TrimSpace(textContent(opt)))
+					}
+				}
+				field["options"] = strings.Join(options, "\n")
+			}
+			fields = append(fields, field)
+		}
+	}
+	walk(n)
+	if len(fields) > 0 {
+		settings["fields"] = fields
+	}
+}
+
+// fieldLabel finds a form control's label: a <label for> pointing at its ID,
+// or a <label> wrapping it.
+func fieldLabel(form, control *html.Node) string {
+	if id := attrValue(control, "id"); id != "" {
+		var found *html.Node
+		var walk func(*html.Node)
+		walk = func(n *html.Node) {
+			for ch := n.FirstChild; ch != nil && found == nil; ch = ch.NextSibling {
+				if ch.Type == html.ElementNode && ch.Data == "label" && attrValue(ch, "for") == id {
+					found = ch
+					return
+				}
+				walk(ch)
+			}
+		}
+		walk(form)
+		if found != nil {
+			return strings.Join(strings.Fields(textContent(found)), " ")
+		}
+	}
+	for p := control.Parent; p != nil && p != form; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			return strings.Join(strings.Fields(textContent(p)), " ")
+		}
+	}
+	return ""
+}
+
+func findChild(n *html.Node, tag string) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return c
+		}
+	}
+	return nil
+}
+
+func prevElementSibling(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+// iframeAttrs are the <iframe> attributes buildIframe carries over besides
+// src.
+var iframeAttrs = []string{"title", "width", "height", "allow", "allowfullscreen", "loading", "referrerpolicy"}
+
+// buildIframe converts an <iframe> no embed rule recognised into a code
+// element. The markup is rebuilt from a checked http(s) src and a few
+// presentational attributes; nothing else from the source survives, and
+// executeCode stays off, so a converted page can't run PHP or script on
+// the site. Iframes without a usable src are dropped with a warning.
+func buildIframe(c *converter, n *html.Node, parentID string) {
+	src := strings.TrimSpace(attrValue(n, "src"))
+	u, err := url.Parse(src)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.warn(fmt.Sprintf("dropped <iframe> without an http(s) src: %q", src))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(`<iframe src="` + html.EscapeString(u.String()) + `"`)
+	for _, key := range iframeAttrs {
+		for _, a := range n.Attr {
+			if a.Key == key {
+				b.WriteString(" " + key + `="` + html.EscapeString(a.Val) + `"`)
+				break
+			}
+		}
+	}
+	b.WriteString("></iframe>")
+
+	_, settings := c.addElement(n, &MappingRule{Element: "code"}, parentID)
+	settings["code"] = b.String()
+	c.warn(fmt.Sprintf("kept <iframe src=%q> as a code element with Execute code off; turn it on in Bricks to render the embed", u.String()))
+}
+
+// buildSVG converts inline <svg> into an svg element with the markup as
+// code, without scripts, event handlers or javascript: URLs, which Bricks
+// would otherwise output on the page as they are.
+func buildSVG(c *converter, n *html.Node, parentID string) {
+	clean, removed := sanitizeSVG(n)
+	if removed {
+		c.warn("removed scripts and event handlers from inline <svg>")
+	}
+	var sb strings.Builder
+	html.Render(&sb, clean)
+	_, settings := c.addElement(n, &MappingRule{Element: "svg"}, parentID)
+	settings["source"] = "code"
+	settings["code"] = sb.String()
+}
+
+// sanitizeSVG returns a copy of n without <script> elements, animations
+// that set event handlers, on* attributes and attributes holding
+// javascript: URLs (href, xlink:href, animation values), reporting whether
+// anything was removed.
+func sanitizeSVG(n *html.Node) (*html.Node, bool) {
+	out := &html.Node{Type: n.Type, DataAtom: n.DataAtom, Data: n.Data, Namespace: n.Namespace}
+	removed := false
+	for _, a := range n.Attr {
+		value := strings.ToLower(strings.Join(strings.Fields(a.Val), ""))
+		if strings.HasPrefix(strings.ToLower(a.Key), "on") || strings.HasPrefix(value, "javascript:") {
+			removed = true
+			continue
+		}
+		out.Attr = append(out.Attr, a)
+	}
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		if ch.Type == html.ElementNode && unsafeSVGChild(ch) {
+			removed = true
+			continue
+		}
+		clean, r := sanitizeSVG(ch)
+		out.AppendChild(clean)
+		removed = removed || r
+	}
+	return out, removed
+}
+
+func unsafeSVGChild(n *html.Node) bool {
+	switch strings.ToLower(n.Data) {
+	case "script":
+		return true
+	case "set", "animate":
+		for _, a := range n.Attr {
+			if strings.EqualFold(a.Key, "attributeName") && strings.HasPrefix(strings.ToLower(a.Val), "on") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildMap converts a Google Maps <iframe> into a map element with the
+// address from its q parameter. Embed URLs without one (/maps/embed?pb=...)
+// have no address the map element can use, so they are kept as iframes.
+func buildMap(c *converter, n *html.Node, parentID string) {
+	address := evalTemplate("attr.src | query:q", n)
+	if address == "" {
+		c.warn("Google Maps embed has no address (q parameter); kept as an iframe")
+		buildIframe(c, n, parentID)
+		return
+	}
+	_, settings := c.addElement(n, &MappingRule{Element: "map"}, parentID)
+	settings["address"] = address
+}
@@ -0,0 +1,274 @@
+package convert_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+func elementsByName(elements []map[string]interface{}, name string) []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, el := range elements {
+		if el["name"] == name {
+			out = append(out, el)
+		}
+	}
+	return out
+}
+
+func TestBuiltinMappings(t *testing.T) {
+	html := `<figure><img src="/a.jpg" alt="A"><figcaption>Caption</figcaption></figure>
+		<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ"></iframe>
+		<iframe src="https://www.google.com/maps?q=Berlin&output=embed"></iframe>
+		<svg viewBox="0 0 1 1"><path d="M0 0"/></svg>
+		<table><tr><td>1</td></tr></table>
+		<my-widget><p>Inside</p></my-widget>`
+
+	res, err := convert.Convert(html, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := elementsByName(res.Elements, "image")
+	if len(img) != 1 {
+		t.Fatalf("expected figure to become one image, got %v", res.Elements)
+	}
+	s := img[0]["settings"].(map[string]interface{})
+	if s["captionCustom"] != "Caption" || s["image"].(map[string]interface{})["url"] != "/a.jpg" {
+		t.Errorf("unexpected figure settings %v", s)
+	}
+	if len(elementsByName(res.Elements, "text-basic")) != 1 {
+		t.Errorf("figcaption should not be converted separately, got %v", res.Elements)
+	}
+
+	video := elementsByName(res.Elements, "video")
+	if len(video) != 1 || video[0]["settings"].(map[string]interface{})["youTubeId"] != "dQw4w9WgXcQ" {
+		t.Errorf("expected youtube video, got %v", video)
+	}
+	maps := elementsByName(res.Elements, "map")
+	if len(maps) != 1 || maps[0]["settings"].(map[string]interface{})["address"] != "Berlin" {
+		t.Errorf("expected map with address, got %v", maps)
+	}
+	svg := elementsByName(res.Elements, "svg")
+	if len(svg) != 1 || !strings.Contains(svg[0]["settings"].(map[string]interface{})["code"].(string), "<path") {
+		t.Errorf("expected inline svg code, got %v", svg)
+	}
+	table := elementsByName(res.Elements, "text")
+	if len(table) != 1 || !strings.HasPrefix(table[0]["settings"].(map[string]interface{})["text"].(string), "<table>") {
+		t.Errorf("expected table as rich text, got %v", table)
+	}
+
+	divs := elementsByName(res.Elements, "div")
+	if len(divs) != 1 || divs[0]["settings"].(map[string]interface{})["customTag"] != "my-widget" {
+		t.Fatalf("expected custom element as div with customTag, got %v", divs)
+	}
+	if children := divs[0]["children"].([]interface{}); len(children) != 1 {
+		t.Errorf("expected custom element children converted, got %v", children)
+	}
+}
+
+func TestDetailsBecomeOneAccordion(t *testing.T) {
+	html := `<details><summary>First</summary><p>One</p></details>
+		<details><summary>Second</summary><p>Two</p></details>`
+	res, err := convert.Convert(html, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := elementsByName(res.Elements, "accordion-nested")
+	if len(acc) != 1 {
+		t.Fatalf("expected adjacent details to share one accordion, got %v", res.Elements)
+	}
+	if items := acc[0]["children"].([]interface{}); len(items) != 2 {
+		t.Errorf("expected two accordion items, got %v", items)
+	}
+	headings := elementsByName(res.Elements, "heading")
+	if len(headings) != 2 || headings[1]["settings"].(map[string]interface{})["text"] != "Second" {
+		t.Errorf("expected summary headings, got %v", headings)
+	}
+	if len(elementsByName(res.Elements, "text-basic")) != 2 {
+		t.Errorf("expected details content converted, got %v", res.Elements)
+	}
+}
+
+func TestFormMapping(t *testing.T) {
+	html := `<form>
+		<label for="e">Email</label><input id="e" type="email" name="email" required>
+		<label>Message <textarea placeholder="Say hi"></textarea></label>
+		<select><option>A</option><option>B</option></select>
+		<button type="submit">Send it</button>
+	</form>`
+	res, err := convert.Convert(html, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Elements) != 1 || res.Elements[0]["name"] != "form" {
+		t.Fatalf("expected a single form element, got %v", res.Elements)
+	}
+	s := res.Elements[0]["settings"].(map[string]interface{})
+	if s["submitButtonText"] != "Send it" {
+		t.Errorf("expected submit text, got %v", s)
+	}
+	fields := s["fields"].([]interface{})
+	if len(fields) != 3 {
+		t.Fatalf("expected 3 fields, got %v", fields)
+	}
+	email := fields[0].(map[string]interface{})
+	if email["type"] != "email" || email["label"] != "Email" || email["required"] != true {
+		t.Errorf("unexpected email field %v", email)
+	}
+	if msg := fields[1].(map[string]interface{}); msg["label"] != "Message" || msg["placeholder"] != "Say hi" {
+		t.Errorf("unexpected textarea field %v", msg)
+	}
+	if sel := fields[2].(map[string]interface{}); sel["options"] != "A\nB" {
+		t.Errorf("unexpected select field %v", sel)
+	}
+}
+
+func TestIframeNeverExecutable(t *testing.T) {
+	html := `<iframe src="https://example.com/embed?a=1&b=2" title="Demo" onload="alert(1)"><?php system($_GET['c']); ?></iframe>
+		<iframe src="javascript:alert(1)"></iframe>
+		<iframe srcdoc="<?php phpinfo(); ?>"></iframe>`
+	res, err := convert.Convert(html, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := elementsByName(res.Elements, "code")
+	if len(code) != 1 {
+		t.Fatalf("expected only the https iframe to be kept, got %v", res.Elements)
+	}
+	s := code[0]["settings"].(map[string]interface{})
+	if _, ok := s["executeCode"]; ok {
+		t.Errorf("iframe code must not be executable, got %v", s)
+	}
+	want := `<iframe src="https://example.com/embed?a=1&amp;b=2" title="Demo"></iframe>`
+	if s["code"] != want {
+		t.Errorf("code = %q, want %q", s["code"], want)
+	}
+	for _, el := range res.Elements {
+		for _, v := range el["settings"].(map[string]interface{}) {
+			if str, ok := v.(string); ok && (strings.Contains(str, "<?php") || strings.Contains(str, "alert")) {
+				t.Errorf("iframe content passed through in %v", el)
+			}
+		}
+	}
+}
+
+func TestSVGSanitized(t *testing.T) {
+	html := `<svg viewBox="0 0 1 1" onload="alert(1)"><script>alert(2)</script>
+		<a href="javascript:alert(3)"><path d="M0 0" onclick="alert(4)"/></a>
+		<set attributeName="onmouseover" to="alert(5)"/><animate attributeName="href" values="javascript:alert(6)"/>
+		<use xlink:href="#icon"/></svg>`
+	res, err := convert.Convert(html, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := elementsByName(res.Elements, "svg")
+	if len(svg) != 1 {
+		t.Fatalf("expected one svg, got %v", res.Elements)
+	}
+	code := svg[0]["settings"].(map[string]interface{})["code"].(string)
+	for _, bad := range []string{"alert", "<script", "onload", "onclick", "javascript:"} {
+		if strings.Contains(code, bad) {
+			t.Errorf("expected %q removed, got %s", bad, code)
+		}
+	}
+	for _, keep := range []string{`<path d="M0 0"`, `href="#icon"`, `viewBox="0 0 1 1"`} {
+		if !strings.Contains(code, keep) {
+			t.Errorf("expected %q kept, got %s", keep, code)
+		}
+	}
+	if len(res.Warnings) == 0 {
+		t.Error("expected a warning about the removed markup")
+	}
+}
+
+func TestGoogleMapEmbedWithoutAddress(t *testing.T) {
+	res, err := convert.Convert(`<iframe src="https://www.google.com/maps/embed?pb=!1m18!1m12"></iframe>`, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elementsByName(res.Elements, "map")) != 0 {
+		t.Errorf("expected no empty map element, got %v", res.Elements)
+	}
+	code := elementsByName(res.Elements, "code")
+	if len(code) != 1 || !strings.Contains(code[0]["settings"].(map[string]interface{})["code"].(string), "maps/embed?pb=") {
+		t.Errorf("expected the embed kept as an iframe, got %v", res.Elements)
+	}
+	warned := false
+	for _, w := range res.Warnings {
+		warned = warned || strings.Contains(w, "Google Maps")
+	}
+	if !warned {
+		t.Errorf("expected a warning, got %v", res.Warnings)
+	}
+}
+
+func TestUserMappingRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	os.WriteFile(path, []byte(`rules:
+  - name: cta
+    class: cta
+    element: button
+    settings:
+      link: { type: external, url: "{{a.attr.href}}" }
+      text: "{{text | trim}}"
+    children: skip
+  - tag: div
+    attr: data-icon
+    element: icon
+    settings:
+      icon: { library: themify, icon: "ti-{{attr.data-icon | lower}}" }
+`), 0644)
+
+	rules, err := convert.LoadMappingRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapper, err := convert.NewMapper(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all := mapper.Rules(); all[0].Name != "cta" || all[0].Source != path || all[len(all)-1].Source != "builtin" {
+		t.Errorf("expected user rules before builtins, got %+v", all[0])
+	}
+
+	res, err := convert.Convert(`<div class="cta"><a href="/go"> Go now </a></div><div data-icon="Star"></div><div>plain</div>`,
+		convert.Options{Mapping: mapper})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Elements) != 4 {
+		t.Fatalf("expected 3 elements and the div's text, got %v", res.Elements)
+	}
+	btn := res.Elements[0]["settings"].(map[string]interface{})
+	if res.Elements[0]["name"] != "button" || btn["text"] != "Go now" || btn["link"].(map[string]interface{})["url"] != "/go" {
+		t.Errorf("unexpected cta element %v", res.Elements[0])
+	}
+	icon := res.Elements[1]["settings"].(map[string]interface{})["icon"].(map[string]interface{})
+	if res.Elements[1]["name"] != "icon" || icon["icon"] != "ti-star" {
+		t.Errorf("unexpected icon element %v", res.Elements[1])
+	}
+	if res.Elements[2]["name"] != "div" {
+		t.Errorf("expected builtin div fallback, got %v", res.Elements[2])
+	}
+}
+
+func TestInvalidMappingRules(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"no-element.yaml": "rules:\n  - tag: div\n",
+		"no-match.yaml":   "rules:\n  - element: div\n",
+		"unknown.yaml":    "rules:\n  - tag: div\n    element: div\n    colour: red\n",
+		"children.yaml":   "rules:\n  - tag: div\n    element: div\n    children: keep\n",
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(body), 0644)
+		if _, err := convert.LoadMappingRules(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
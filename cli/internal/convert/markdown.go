@@ -0,0 +1,190 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"golang.org/x/net/html"
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the YAML front matter of a Markdown document. Other keys
+// are ignored.
+type FrontMatter struct {
+	// Title becomes the page's h1 when the document has none.
+	Title string `yaml:"title"`
+	// Template names a template placed before the document's content.
+	Template string `yaml:"template"`
+	// Classes go on every section, ContainerClasses on every container.
+	Classes          ClassList `yaml:"classes"`
+	ContainerClasses ClassList `yaml:"container_classes"`
+}
+
+// ClassList is a list of class names, written in YAML as a list or a
+// space-separated string.
+type ClassList []string
+
+// UnmarshalYAML accepts both forms.
+func (l *ClassList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = strings.Fields(node.Value)
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// MarkdownDocument is a Markdown document rendered as HTML for Convert.
+type MarkdownDocument struct {
+	FrontMatter FrontMatter
+	HTML        string
+}
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.Table, extension.Strikethrough),
+	goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+)
+
+// MarkdownToHTML renders a CommonMark document (with GitHub tables and
+// strikethrough) as HTML shaped for Convert: content is grouped into a
+// section and container per h2, lists and blockquotes become rich text,
+// code blocks become code elements and lone images become images with
+// their title as caption. Front matter between "---" lines is parsed into
+// FrontMatter; its title and classes are applied to the HTML.
+func MarkdownToHTML(src []byte) (*MarkdownDocument, error) {
+	doc := &MarkdownDocument{}
+	body, fm, err := splitFrontMatter(src)
+	if err != nil {
+		return nil, err
+	}
+	if fm != nil {
+		if err := yaml.Unmarshal(fm, &doc.FrontMatter); err != nil {
+			return nil, fmt.Errorf("invalid front matter: %w", err)
+		}
+	}
+
+	root := markdown.Parser().Parse(text.NewReader(body))
+	var groups []*bytes.Buffer
+	hasH1 := false
+	for n := root.FirstChild(); n != nil; n = n.NextSibling() {
+		if h, ok := n.(*ast.Heading); ok {
+			hasH1 = hasH1 || h.Level == 1
+			if h.Level == 2 && len(groups) > 0 && groups[len(groups)-1].Len() > 0 {
+				groups = append(groups, &bytes.Buffer{})
+			}
+		}
+		if len(groups) == 0 {
+			groups = append(groups, &bytes.Buffer{})
+		}
+		if err := renderMarkdownBlock(groups[len(groups)-1], body, n); err != nil {
+			return nil, err
+		}
+	}
+	if !hasH1 && doc.FrontMatter.Title != "" {
+		title := "<h1>" + html.EscapeString(doc.FrontMatter.Title) + "</h1>\n"
+		if len(groups) == 0 {
+			groups = append(groups, &bytes.Buffer{})
+		}
+		groups[0] = bytes.NewBufferString(title + groups[0].String())
+	}
+
+	var out strings.Builder
+	for _, g := range groups {
+		out.WriteString("<section" + classAttr(doc.FrontMatter.Classes) + ">\n")
+		out.WriteString(`<div ` + AttrElementName + `="container"` + classAttr(doc.FrontMatter.ContainerClasses) + ">\n")
+		out.Write(g.Bytes())
+		out.WriteString("</div>\n</section>\n")
+	}
+	doc.HTML = out.String()
+	return doc, nil
+}
+
+// splitFrontMatter separates a leading "---" front matter block from the
+// document. fm is nil when there is none.
+func splitFrontMatter(src []byte) (body, fm []byte, err error) {
+	lines := strings.SplitAfter(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+	if strings.TrimSpace(lines[0]) != "---" {
+		return src, nil, nil
+	}
+	for i := 1; i < len(lines); i++ {
+		if l := strings.TrimSpace(lines[i]); l == "---" || l == "..." {
+			return []byte(strings.Join(lines[i+1:], "")), []byte(strings.Join(lines[1:i], "")), nil
+		}
+	}
+	return nil, nil, fmt.Errorf("front matter is not closed with ---")
+}
+
+// renderMarkdownBlock writes one top-level block as HTML.
+func renderMarkdownBlock(w *bytes.Buffer, src []byte, n ast.Node) error {
+	switch b := n.(type) {
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		settings := ""
+		if f, ok := b.(*ast.FencedCodeBlock); ok {
+			if lang := string(f.Language(src)); lang != "" {
+				data, _ := json.Marshal(map[string]string{"language": lang})
+				settings = " " + AttrSettings + `="` + html.EscapeString(string(data)) + `"`
+			}
+		}
+		var code strings.Builder
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			code.Write(seg.Value(src))
+		}
+		fmt.Fprintf(w, "<pre %s=\"code\"%s>%s</pre>\n", AttrElementName, settings,
+			html.EscapeString(strings.TrimRight(code.String(), "\n")))
+		return nil
+	case *ast.List, *ast.Blockquote:
+		// Bricks has no list or quote elements for prose; keep them as
+		// rich text
+		w.WriteString(`<div ` + AttrElementName + `="text">`)
+		if err := markdown.Renderer().Render(w, src, n); err != nil {
+			return err
+		}
+		w.WriteString("</div>\n")
+		return nil
+	case *ast.Paragraph:
+		if img, ok := b.FirstChild().(*ast.Image); ok && b.ChildCount() == 1 {
+			tag := fmt.Sprintf(`<img src="%s" alt="%s">`,
+				html.EscapeString(string(img.Destination)), html.EscapeString(plainText(img, src)))
+			if title := string(img.Title); title != "" {
+				fmt.Fprintf(w, "<figure>%s<figcaption>%s</figcaption></figure>\n", tag, html.EscapeString(title))
+			} else {
+				w.WriteString(tag + "\n")
+			}
+			return nil
+		}
+	}
+	return markdown.Renderer().Render(w, src, n)
+}
+
+// plainText returns the text inside an inline node, such as an image's
+// alt text.
+func plainText(n ast.Node, src []byte) string {
+	var sb strings.Builder
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if t, ok := c.(*ast.Text); ok && entering {
+			sb.Write(t.Segment.Value(src))
+		}
+		return ast.WalkContinue, nil
+	})
+	return sb.String()
+}
+
+func classAttr(classes []string) string {
+	if len(classes) == 0 {
+		return ""
+	}
+	return ` class="` + html.EscapeString(strings.Join(classes, " ")) + `"`
+}
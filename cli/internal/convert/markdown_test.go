@@ -0,0 +1,107 @@
+package convert_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+const markdownPost = `---
+title: Release notes
+classes: bg--dark
+container_classes: [stack, "gap--m"]
+template: hero
+author: ignored
+---
+Intro with *emphasis*.
+
+## Features
+
+- one **bold**
+- two
+
+> Quoted
+
+` + "```go\nif a < b {}\n```" + `
+
+![Diagram](/d.png "The flow")
+
+| a | b |
+|---|---|
+| 1 | 2 |
+
+## Next
+
+End.
+`
+
+func TestMarkdownToHTML(t *testing.T) {
+	doc, err := convert.MarkdownToHTML([]byte(markdownPost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm := doc.FrontMatter
+	if fm.Title != "Release notes" || fm.Template != "hero" ||
+		strings.Join(fm.Classes, " ") != "bg--dark" || strings.Join(fm.ContainerClasses, " ") != "stack gap--m" {
+		t.Errorf("unexpected front matter %+v", fm)
+	}
+
+	reg := convert.NewClassRegistry()
+	reg.Add("bg--dark", "acss_bg_dark", "acss")
+	res, err := convert.Convert(doc.HTML, convert.Options{Registry: reg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string][]map[string]interface{}{}
+	for _, el := range res.Elements {
+		name := el["name"].(string)
+		byName[name] = append(byName[name], el)
+	}
+	if len(byName["section"]) != 3 || len(byName["container"]) != 3 {
+		t.Fatalf("expected a section and container per h2 plus the intro, got %v", res.Elements)
+	}
+	section := byName["section"][0]["settings"].(map[string]interface{})
+	if classes, _ := section["_cssGlobalClasses"].([]interface{}); len(classes) != 1 || classes[0] != "acss_bg_dark" {
+		t.Errorf("expected front matter classes resolved, got %v", section)
+	}
+	if h := byName["heading"][0]["settings"].(map[string]interface{}); h["text"] != "Release notes" || h["tag"] != "h1" {
+		t.Errorf("expected the title as h1, got %v", h)
+	}
+
+	code := byName["code"][0]["settings"].(map[string]interface{})
+	if code["code"] != "if a < b {}" || code["language"] != "go" {
+		t.Errorf("unexpected code block %v", code)
+	}
+	img := byName["image"][0]["settings"].(map[string]interface{})
+	if img["captionCustom"] != "The flow" || img["image"].(map[string]interface{})["alt"] != "Diagram" {
+		t.Errorf("unexpected image %v", img)
+	}
+	var rich []string
+	for _, el := range byName["text"] {
+		rich = append(rich, el["settings"].(map[string]interface{})["text"].(string))
+	}
+	joined := strings.Join(rich, "\n")
+	for _, want := range []string{"<em>emphasis</em>", "<li>one <strong>bold</strong></li>", "<blockquote>", "<table>"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected rich text containing %q, got %v", want, rich)
+		}
+	}
+}
+
+func TestMarkdownWithoutFrontMatter(t *testing.T) {
+	doc, err := convert.MarkdownToHTML([]byte("# Title\n\nText\n\n---\n\nMore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.FrontMatter.Title != "" || !strings.Contains(doc.HTML, "<hr>") {
+		t.Errorf("a thematic break is not front matter, got %+v\n%s", doc.FrontMatter, doc.HTML)
+	}
+	if strings.Count(doc.HTML, "<section") != 1 {
+		t.Errorf("expected one section without h2s, got\n%s", doc.HTML)
+	}
+
+	if _, err := convert.MarkdownToHTML([]byte("---\ntitle: x\n\nno end")); err == nil {
+		t.Error("expected an error for unclosed front matter")
+	}
+}
@@ -0,0 +1,645 @@
+package convert
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// styleProperty maps one CSS property onto Bricks settings. Most hold the
+// value as it is: at setting, or at setting's key when key is set
+// ("_typography" → "font-size"). Properties whose value needs parsing
+// (shorthands, colors, transforms) use expand instead, which reports false
+// when the value has no Bricks form.
+type styleProperty struct {
+	setting string
+	key     string
+	expand  func(settings map[string]interface{}, val string) bool
+}
+
+// styleProperties is the CSS property map. Properties not listed have no
+// Bricks setting.
+var styleProperties = map[string]styleProperty{
+	// Typography
+	"color":           {expand: colorAt("_typography", "color")},
+	"font-size":       {setting: "_typography", key: "font-size"},
+	"font-weight":     {setting: "_typography", key: "font-weight"},
+	"font-style":      {setting: "_typography", key: "font-style"},
+	"font-family":     {setting: "_typography", key: "font-family"},
+	"line-height":     {setting: "_typography", key: "line-height"},
+	"letter-spacing":  {setting: "_typography", key: "letter-spacing"},
+	"text-align":      {setting: "_typography", key: "text-align"},
+	"text-transform":  {setting: "_typography", key: "text-transform"},
+	"text-decoration": {setting: "_typography", key: "text-decoration"},
+	"font":            {expand: expandFont},
+
+	// Spacing
+	"padding":        {expand: boxAt("_padding")},
+	"padding-top":    {setting: "_padding", key: "top"},
+	"padding-right":  {setting: "_padding", key: "right"},
+	"padding-bottom": {setting: "_padding", key: "bottom"},
+	"padding-left":   {setting: "_padding", key: "left"},
+	"margin":         {expand: boxAt("_margin")},
+	"margin-top":     {setting: "_margin", key: "top"},
+	"margin-right":   {setting: "_margin", key: "right"},
+	"margin-bottom":  {setting: "_margin", key: "bottom"},
+	"margin-left":    {setting: "_margin", key: "left"},
+
+	// Sizing
+	"width":        {setting: "_width"},
+	"min-width":    {setting: "_minWidth"},
+	"max-width":    {setting: "_maxWidth"},
+	"height":       {setting: "_height"},
+	"min-height":   {setting: "_minHeight"},
+	"max-height":   {setting: "_maxHeight"},
+	"aspect-ratio": {setting: "_aspectRatio"},
+	"object-fit":   {setting: "_objectFit"},
+
+	// Layout
+	"display":               {setting: "_display"},
+	"flex-direction":        {setting: "_direction"},
+	"flex-wrap":             {setting: "_flexWrap"},
+	"flex-grow":             {setting: "_flexGrow"},
+	"flex-shrink":           {setting: "_flexShrink"},
+	"flex-basis":            {setting: "_flexBasis"},
+	"flex":                  {expand: expandFlex},
+	"align-items":           {setting: "_alignItems"},
+	"align-self":            {setting: "_alignSelf"},
+	"align-content":         {setting: "_alignContent"},
+	"justify-content":       {setting: "_justifyContent"},
+	"order":                 {setting: "_order"},
+	"gap":                   {setting: "_gap"},
+	"row-gap":               {setting: "_rowGap"},
+	"column-gap":            {setting: "_columnGap"},
+	"grid-template-columns": {setting: "_gridTemplateColumns"},
+	"grid-template-rows":    {setting: "_gridTemplateRows"},
+	"grid-area":             {setting: "_gridArea"},
+	"grid-column":           {setting: "_gridColumn"},
+	"grid-row":              {setting: "_gridRow"},
+	"overflow":              {setting: "_overflow"},
+
+	// Position
+	"position": {setting: "_position"},
+	"inset":    {expand: expandInset},
+	"top":      {setting: "_top"},
+	"right":    {setting: "_right"},
+	"bottom":   {setting: "_bottom"},
+	"left":     {setting: "_left"},
+	"z-index":  {setting: "_zIndex"},
+
+	// Background
+	"background":            {expand: expandBackground},
+	"background-color":      {expand: colorAt("_background", "color")},
+	"background-image":      {expand: expandBackgroundImage},
+	"background-position":   {setting: "_background", key: "position"},
+	"background-size":       {setting: "_background", key: "size"},
+	"background-repeat":     {setting: "_background", key: "repeat"},
+	"background-attachment": {setting: "_background", key: "attachment"},
+
+	// Border
+	"border":              {expand: expandBorder("")},
+	"border-top":          {expand: expandBorder("top")},
+	"border-right":        {expand: expandBorder("right")},
+	"border-bottom":       {expand: expandBorder("bottom")},
+	"border-left":         {expand: expandBorder("left")},
+	"border-width":        {expand: boxAt("_border", "width")},
+	"border-top-width":    {expand: sideAt("top")},
+	"border-right-width":  {expand: sideAt("right")},
+	"border-bottom-width": {expand: sideAt("bottom")},
+	"border-left-width":   {expand: sideAt("left")},
+	"border-style":        {setting: "_border", key: "style"},
+	"border-color":        {expand: colorAt("_border", "color")},
+	"border-radius":       {setting: "_borderRadius"},
+
+	// Effects
+	"opacity":          {setting: "_opacity"},
+	"box-shadow":       {expand: expandBoxShadow},
+	"transform":        {expand: expandTransform},
+	"transform-origin": {setting: "_transformOrigin"},
+	"transition":       {setting: "_cssTransition"},
+	"cursor":           {setting: "_cursor"},
+}
+
+// applyDeclaration maps one CSS declaration onto Bricks settings. It reports
+// false when the property, or this value of it, has no Bricks equivalent.
+func applyDeclaration(settings map[string]interface{}, prop, val string) bool {
+	p, ok := styleProperties[prop]
+	switch {
+	case !ok:
+		return false
+	case p.expand != nil:
+		return p.expand(settings, val)
+	case p.key != "":
+		ensureMap(settings, p.setting)[p.key] = val
+	default:
+		settings[p.setting] = val
+	}
+	return true
+}
+
+// plainStyleSettings are the settings holding a single CSS value, by
+// setting key.
+var plainStyleSettings = map[string]string{}
+
+// typographyProperties are the _typography keys holding a CSS value as it
+// is, each named after its property.
+var typographyProperties []string
+
+func init() {
+	for prop, p := range styleProperties {
+		switch {
+		case p.expand != nil:
+		case p.key == "":
+			plainStyleSettings[p.setting] = prop
+		case p.setting == "_typography":
+			typographyProperties = append(typographyProperties, prop)
+		}
+	}
+	sort.Strings(typographyProperties)
+}
+
+func ensureMap(s map[string]interface{}, key string) map[string]interface{} {
+	if m, ok := s[key].(map[string]interface{}); ok {
+		return m
+	}
+	m := make(map[string]interface{})
+	s[key] = m
+	return m
+}
+
+// colorAt stores a color as {raw} at setting → key.
+func colorAt(setting, key string) func(map[string]interface{}, string) bool {
+	return func(s map[string]interface{}, val string) bool {
+		ensureMap(s, setting)[key] = map[string]interface{}{"raw": val}
+		return true
+	}
+}
+
+// boxAt expands a one-to-four value shorthand into {top, right, bottom,
+// left} at setting, or at setting → key.
+func boxAt(setting string, key ...string) func(map[string]interface{}, string) bool {
+	return func(s map[string]interface{}, val string) bool {
+		if len(key) == 0 {
+			s[setting] = expandBoxShorthand(val)
+		} else {
+			ensureMap(s, setting)[key[0]] = expandBoxShorthand(val)
+		}
+		return true
+	}
+}
+
+// sideAt sets one side of the border width.
+func sideAt(side string) func(map[string]interface{}, string) bool {
+	return func(s map[string]interface{}, val string) bool {
+		ensureMap(ensureMap(s, "_border"), "width")[side] = val
+		return true
+	}
+}
+
+// expandInset sets _top, _right, _bottom and _left.
+func expandInset(s map[string]interface{}, val string) bool {
+	for side, v := range expandBoxShorthand(val) {
+		s["_"+side] = v
+	}
+	return true
+}
+
+var borderStyles = map[string]bool{
+	"none": true, "hidden": true, "dotted": true, "dashed": true, "solid": true,
+	"double": true, "groove": true, "ridge": true, "inset": true, "outset": true,
+}
+
+// expandBorder expands border (side "") or border-<side>: the width goes
+// to that side, or all four; style and color apply to the whole border,
+// which is all Bricks has.
+func expandBorder(side string) func(map[string]interface{}, string) bool {
+	return func(s map[string]interface{}, val string) bool {
+		var width, style, color string
+		for _, tok := range splitCSSValue(val, ' ') {
+			lower := strings.ToLower(tok)
+			switch {
+			case borderStyles[lower] && style == "":
+				style = lower
+			case width == "" && color == "" && (isLength(tok) || lower == "thin" || lower == "medium" || lower == "thick" || (isFunction(tok) && !isColor(tok))):
+				width = tok
+			case color == "":
+				color = tok
+			default:
+				return false
+			}
+		}
+		border := ensureMap(s, "_border")
+		if width != "" {
+			if side == "" {
+				border["width"] = expandBoxShorthand(width)
+			} else {
+				ensureMap(border, "width")[side] = width
+			}
+		}
+		if style != "" {
+			border["style"] = style
+		}
+		if color != "" {
+			border["color"] = map[string]interface{}{"raw": color}
+		}
+		return true
+	}
+}
+
+// expandBoxShadow maps a single shadow onto _boxShadow {values, color,
+// inset}. Several shadows have no Bricks form.
+func expandBoxShadow(s map[string]interface{}, val string) bool {
+	if len(splitCSSValue(val, ',')) != 1 || strings.EqualFold(val, "none") {
+		return false
+	}
+	keys := []string{"offsetX", "offsetY", "blur", "spread"}
+	values := make(map[string]interface{})
+	shadow := map[string]interface{}{"values": values}
+	for _, tok := range splitCSSValue(val, ' ') {
+		switch {
+		case strings.EqualFold(tok, "inset"):
+			shadow["inset"] = true
+		case len(values) < len(keys) && (isLength(tok) || (isFunction(tok) && !isColor(tok) && len(values) < 2)):
+			values[keys[len(values)]] = tok
+		case shadow["color"] == nil:
+			shadow["color"] = map[string]interface{}{"raw": tok}
+		default:
+			return false
+		}
+	}
+	if len(values) < 2 {
+		return false
+	}
+	s["_boxShadow"] = shadow
+	return true
+}
+
+// transformFunctions maps transform functions onto _transform keys; the
+// first key takes the first argument and so on.
+var transformFunctions = map[string][]string{
+	"translate": {"translateX", "translateY"}, "translatex": {"translateX"}, "translatey": {"translateY"},
+	"scale": {"scaleX", "scaleY"}, "scalex": {"scaleX"}, "scaley": {"scaleY"},
+	"rotate": {"rotateZ"}, "rotatex": {"rotateX"}, "rotatey": {"rotateY"}, "rotatez": {"rotateZ"},
+	"skew": {"skewX", "skewY"}, "skewx": {"skewX"}, "skewy": {"skewY"},
+}
+
+// expandTransform maps translate, scale, rotate and skew functions onto
+// _transform. Other functions (matrix, perspective, 3D forms) have no
+// Bricks form.
+func expandTransform(s map[string]interface{}, val string) bool {
+	transform := make(map[string]interface{})
+	for _, tok := range splitCSSValue(val, ' ') {
+		name, args, ok := cssFunction(tok)
+		keys := transformFunctions[strings.ToLower(name)]
+		if !ok || keys == nil || len(args) == 0 || len(args) > len(keys) {
+			return false
+		}
+		for i, arg := range args {
+			transform[keys[i]] = arg
+		}
+		// scale(2) scales both axes
+		if strings.EqualFold(name, "scale") && len(args) == 1 {
+			transform["scaleY"] = args[0]
+		}
+	}
+	if len(transform) == 0 {
+		return false
+	}
+	s["_transform"] = transform
+	return true
+}
+
+// expandFlex expands the flex shorthand into _flexGrow, _flexShrink and
+// _flexBasis.
+func expandFlex(s map[string]interface{}, val string) bool {
+	grow, shrink, basis := "", "", ""
+	toks := splitCSSValue(val, ' ')
+	switch strings.ToLower(val) {
+	case "none":
+		grow, shrink, basis = "0", "0", "auto"
+	case "auto":
+		grow, shrink, basis = "1", "1", "auto"
+	case "initial":
+		grow, shrink, basis = "0", "1", "auto"
+	default:
+		switch {
+		case len(toks) == 1 && isNumber(toks[0]):
+			grow, shrink, basis = toks[0], "1", "0%"
+		case len(toks) == 1:
+			grow, shrink, basis = "1", "1", toks[0]
+		case len(toks) == 2 && isNumber(toks[0]) && isNumber(toks[1]):
+			grow, shrink, basis = toks[0], toks[1], "0%"
+		case len(toks) == 2 && isNumber(toks[0]):
+			grow, shrink, basis = toks[0], "1", toks[1]
+		case len(toks) == 3 && isNumber(toks[0]) && isNumber(toks[1]):
+			grow, shrink, basis = toks[0], toks[1], toks[2]
+		default:
+			return false
+		}
+	}
+	s["_flexGrow"], s["_flexShrink"], s["_flexBasis"] = grow, shrink, basis
+	return true
+}
+
+var (
+	fontStyles    = map[string]bool{"italic": true, "oblique": true}
+	fontWeights   = map[string]bool{"bold": true, "bolder": true, "lighter": true}
+	fontSizeWords = map[string]bool{
+		"xx-small": true, "x-small": true, "small": true, "medium": true, "large": true,
+		"x-large": true, "xx-large": true, "smaller": true, "larger": true,
+	}
+)
+
+// expandFont expands the font shorthand: [style] [weight] size[/line-height]
+// family. Variants, stretches and system fonts (font: menu) have no Bricks
+// form.
+func expandFont(s map[string]interface{}, val string) bool {
+	toks := splitCSSValue(val, ' ')
+	typo := map[string]interface{}{}
+	i := 0
+prefix:
+	for ; i < len(toks); i++ {
+		lower := strings.ToLower(toks[i])
+		switch {
+		case lower == "normal":
+		case fontStyles[lower]:
+			typo["font-style"] = lower
+		case fontWeights[lower] || (len(lower) == 3 && isNumber(lower)):
+			typo["font-weight"] = lower
+		default:
+			break prefix
+		}
+	}
+	if i >= len(toks) {
+		return false
+	}
+	size, lineHeight, _ := strings.Cut(toks[i], "/")
+	if !isLength(size) && !fontSizeWords[strings.ToLower(size)] && !isFunction(size) {
+		return false
+	}
+	i++
+	if lineHeight == "" && i < len(toks) && strings.HasPrefix(toks[i], "/") {
+		lineHeight = strings.TrimPrefix(toks[i], "/")
+		i++
+		if lineHeight == "" && i < len(toks) {
+			lineHeight = toks[i]
+			i++
+		}
+	}
+	if i >= len(toks) {
+		return false
+	}
+	typo["font-size"] = size
+	if lineHeight != "" {
+		typo["line-height"] = lineHeight
+	}
+	typo["font-family"] = strings.Join(toks[i:], " ")
+	t := ensureMap(s, "_typography")
+	for k, v := range typo {
+		t[k] = v
+	}
+	return true
+}
+
+var (
+	backgroundRepeats     = map[string]bool{"repeat": true, "no-repeat": true, "repeat-x": true, "repeat-y": true, "space": true, "round": true}
+	backgroundAttachments = map[string]bool{"fixed": true, "scroll": true, "local": true}
+	backgroundPositions   = map[string]bool{"left": true, "right": true, "top": true, "bottom": true, "center": true}
+	backgroundSizes       = map[string]bool{"cover": true, "contain": true, "auto": true}
+)
+
+// expandBackground expands the background shorthand into _background
+// (color, image, position, size, repeat, attachment) and _gradient. Several
+// layers, and origin or clip boxes, have no Bricks form.
+func expandBackground(s map[string]interface{}, val string) bool {
+	if len(splitCSSValue(val, ',')) != 1 {
+		return false
+	}
+	bg := map[string]interface{}{}
+	var gradient map[string]interface{}
+	var position, size []string
+	inSize := false
+	for _, tok := range splitCSSValue(val, ' ') {
+		lower := strings.ToLower(tok)
+		if strings.HasPrefix(tok, "/") {
+			inSize = true
+			if tok = strings.TrimPrefix(tok, "/"); tok == "" {
+				continue
+			}
+			lower = strings.ToLower(tok)
+		}
+		switch {
+		case lower == "none":
+		case strings.HasPrefix(lower, "url("):
+			bg["image"] = map[string]interface{}{"url": cssURL(tok)}
+		case strings.Contains(lower, "gradient("):
+			if gradient = parseGradient(tok); gradient == nil {
+				return false
+			}
+		case backgroundRepeats[lower]:
+			bg["repeat"] = lower
+		case backgroundAttachments[lower]:
+			bg["attachment"] = lower
+		case inSize && (backgroundSizes[lower] || isLength(tok)):
+			size = append(size, tok)
+		case backgroundPositions[lower] || isLength(tok):
+			position = append(position, tok)
+		case strings.HasSuffix(lower, "-box"):
+			return false
+		case bg["color"] == nil:
+			bg["color"] = map[string]interface{}{"raw": tok}
+		default:
+			return false
+		}
+	}
+	if len(position) > 0 {
+		bg["position"] = strings.Join(position, " ")
+	}
+	if len(size) > 0 {
+		bg["size"] = strings.Join(size, " ")
+	}
+	if len(bg) == 0 && gradient == nil {
+		return false
+	}
+	if len(bg) > 0 {
+		s["_background"] = bg
+	}
+	if gradient != nil {
+		s["_gradient"] = gradient
+	}
+	return true
+}
+
+// expandBackgroundImage maps one url() image or gradient.
+func expandBackgroundImage(s map[string]interface{}, val string) bool {
+	if len(splitCSSValue(val, ',')) != 1 {
+		return false
+	}
+	lower := strings.ToLower(val)
+	switch {
+	case strings.HasPrefix(lower, "url("):
+		ensureMap(s, "_background")["image"] = map[string]interface{}{"url": cssURL(val)}
+	case strings.Contains(lower, "gradient("):
+		gradient := parseGradient(val)
+		if gradient == nil {
+			return false
+		}
+		s["_gradient"] = gradient
+	default:
+		return false
+	}
+	return true
+}
+
+// gradientDirections are the "to <side>" directions as angles.
+var gradientDirections = map[string]string{
+	"to top": "0", "to right": "90", "to bottom": "180", "to left": "270",
+	"to top right": "45", "to right top": "45", "to bottom right": "135", "to right bottom": "135",
+	"to bottom left": "225", "to left bottom": "225", "to top left": "315", "to left top": "315",
+}
+
+// parseGradient maps a linear or radial gradient onto _gradient, or
+// returns nil when it has no Bricks form (radial shapes, non-degree angles,
+// stops in lengths).
+func parseGradient(val string) map[string]interface{} {
+	name, args, ok := cssFunction(val)
+	if !ok || len(args) < 2 {
+		return nil
+	}
+	gradient := map[string]interface{}{"applyTo": "background"}
+	switch strings.ToLower(name) {
+	case "linear-gradient":
+		gradient["gradientType"] = "linear"
+		first := strings.ToLower(args[0])
+		if angle, ok := gradientDirections[strings.Join(strings.Fields(first), " ")]; ok {
+			gradient["angle"] = angle
+			args = args[1:]
+		} else if strings.HasSuffix(first, "deg") && isNumber(strings.TrimSuffix(first, "deg")) {
+			gradient["angle"] = strings.TrimSuffix(first, "deg")
+			args = args[1:]
+		} else if isLength(first) || strings.HasPrefix(first, "to ") {
+			return nil
+		}
+	case "radial-gradient":
+		// Bricks radial gradients have no shape or position
+		first := strings.ToLower(args[0])
+		for _, word := range []string{"circle", "ellipse", "closest-", "farthest-", "at "} {
+			if strings.HasPrefix(first, word) || strings.Contains(first, " "+word) {
+				return nil
+			}
+		}
+		gradient["gradientType"] = "radial"
+	default:
+		return nil
+	}
+	var colors []interface{}
+	for _, arg := range args {
+		toks := splitCSSValue(arg, ' ')
+		stop := map[string]interface{}{"color": map[string]interface{}{"raw": toks[0]}}
+		switch {
+		case len(toks) == 1:
+		case len(toks) == 2 && strings.HasSuffix(toks[1], "%") && isNumber(strings.TrimSuffix(toks[1], "%")):
+			stop["stop"] = strings.TrimSuffix(toks[1], "%")
+		default:
+			return nil
+		}
+		colors = append(colors, stop)
+	}
+	if len(colors) < 2 {
+		return nil
+	}
+	gradient["colors"] = colors
+	return gradient
+}
+
+// splitCSSValue splits a value at sep (' ' meaning any whitespace) outside
+// parentheses and quotes.
+func splitCSSValue(val string, sep rune) []string {
+	var parts []string
+	var cur strings.Builder
+	depth := 0
+	quote := rune(0)
+	flush := func() {
+		if p := strings.TrimSpace(cur.String()); p != "" {
+			parts = append(parts, p)
+		}
+		cur.Reset()
+	}
+	for _, ch := range val {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')' && depth > 0:
+			depth--
+		case depth == 0 && (ch == sep || (sep == ' ' && (ch == '\t' || ch == '\n'))):
+			flush()
+			continue
+		}
+		cur.WriteRune(ch)
+	}
+	flush()
+	return parts
+}
+
+// cssFunction splits "name(a, b)" into its name and arguments.
+func cssFunction(tok string) (string, []string, bool) {
+	open := strings.IndexByte(tok, '(')
+	if open <= 0 || !strings.HasSuffix(tok, ")") {
+		return "", nil, false
+	}
+	return tok[:open], splitCSSValue(tok[open+1:len(tok)-1], ','), true
+}
+
+func isFunction(tok string) bool {
+	_, _, ok := cssFunction(tok)
+	return ok
+}
+
+// isNumber reports whether tok is a unitless number.
+func isNumber(tok string) bool {
+	_, err := strconv.ParseFloat(tok, 64)
+	return err == nil
+}
+
+// isLength reports whether tok is a number with or without a unit.
+func isLength(tok string) bool {
+	end := 0
+	for end < len(tok) && (tok[end] >= '0' && tok[end] <= '9' || tok[end] == '.' || (end == 0 && (tok[end] == '-' || tok[end] == '+'))) {
+		end++
+	}
+	if !isNumber(tok[:end]) {
+		return false
+	}
+	unit := strings.ToLower(tok[end:])
+	for _, r := range unit {
+		if (r < 'a' || r > 'z') && r != '%' {
+			return false
+		}
+	}
+	return true
+}
+
+// isColor reports whether tok is a color function or hex color.
+func isColor(tok string) bool {
+	lower := strings.ToLower(tok)
+	for _, prefix := range []string{"#", "rgb(", "rgba(", "hsl(", "hsla(", "hwb(", "lab(", "lch(", "oklab(", "oklch(", "color-mix("} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cssURL returns the address in url(...), unquoted.
+func cssURL(tok string) string {
+	if !strings.HasPrefix(strings.ToLower(tok), "url(") || !strings.HasSuffix(tok, ")") {
+		return tok
+	}
+	return strings.Trim(strings.TrimSpace(tok[4:len(tok)-1]), `'"`)
+}
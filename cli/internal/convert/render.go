@@ -0,0 +1,503 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Attributes that carry Bricks data through a render and back into Convert.
+const (
+	// AttrElementID holds the element's Bricks ID. Convert reuses it with
+	// Options.PreserveIDs.
+	AttrElementID = "data-bricks-id"
+	// AttrElementName names the Bricks element when the tag alone would map
+	// to a different one.
+	AttrElementName = "data-bricks-element"
+	// AttrSettings holds, as JSON, the settings the HTML can't express.
+	AttrSettings = "data-bricks-settings"
+)
+
+// elementContent names the setting holding an element's content and
+// whether it is markup (rendered as HTML) or plain text (escaped).
+var elementContent = map[string]struct {
+	key    string
+	markup bool
+}{
+	"heading": {"text", true}, "text-basic": {"text", true}, "text": {"text", true},
+	"rich-text": {"text", true}, "text-link": {"text", true}, "button": {"text", true},
+	"code": {"code", false}, "svg": {"code", true},
+}
+
+// RenderOptions configures RenderHTML.
+type RenderOptions struct {
+	// Registry resolves _cssGlobalClasses IDs back to class names. May be nil.
+	Registry *ClassRegistry
+	// StyleBlock writes element styles to the <style> block instead of
+	// style attributes.
+	StyleBlock bool
+	// Breakpoints gives the max-width of breakpoint-suffixed settings
+	// (_padding:tablet_portrait). Defaults to DefaultBreakpoints.
+	Breakpoints []Breakpoint
+}
+
+// RenderResult is the output of RenderHTML.
+type RenderResult struct {
+	HTML string
+	// Warnings lists class IDs the registry could not resolve.
+	Warnings []string
+}
+
+// RenderHTML renders Bricks elements as semantic HTML: sections, headings,
+// paragraphs, links and images get their HTML tags, global classes their
+// names, and style settings become CSS. Breakpoint and state settings
+// (_padding:mobile_portrait, _background:hover) and _cssCustom go to a
+// <style> block ahead of the markup. Each element carries its ID in
+// data-bricks-id; settings with no HTML form are kept in
+// data-bricks-settings, so Convert can rebuild the same elements.
+func RenderHTML(elements []map[string]interface{}, opts RenderOptions) *RenderResult {
+	if len(opts.Breakpoints) == 0 {
+		opts.Breakpoints = DefaultBreakpoints
+	}
+	mapper, _ := NewMapper(nil)
+	r := &renderer{opts: opts, mapper: mapper, byID: make(map[string]map[string]interface{})}
+	for _, el := range elements {
+		if id := elementString(el, "id"); id != "" {
+			r.byID[id] = el
+		}
+	}
+	byParent := make(map[string][]string)
+	var roots []string
+	for _, el := range elements {
+		id := elementString(el, "id")
+		if id == "" {
+			continue
+		}
+		parent := elementString(el, "parent")
+		if _, ok := r.byID[parent]; ok {
+			byParent[parent] = append(byParent[parent], id)
+		} else {
+			roots = append(roots, id)
+		}
+	}
+	r.byParent = byParent
+
+	var body strings.Builder
+	for _, id := range roots {
+		n := r.element(id, 0)
+		html.Render(&body, n)
+		body.WriteString("\n")
+	}
+
+	var out strings.Builder
+	if css := r.css.String(); css != "" {
+		out.WriteString("<style>\n" + css + "</style>\n")
+	}
+	out.WriteString(body.String())
+	return &RenderResult{HTML: out.String(), Warnings: r.warnings}
+}
+
+type renderer struct {
+	opts     RenderOptions
+	mapper   *Mapper
+	byID     map[string]map[string]interface{}
+	byParent map[string][]string
+	css      strings.Builder
+	warnings []string
+}
+
+func elementString(el map[string]interface{}, key string) string {
+	switch v := el[key].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// childIDs returns an element's children in order: its children list when
+// present, otherwise the elements naming it as parent.
+func (r *renderer) childIDs(el map[string]interface{}) []string {
+	list, ok := el["children"].([]interface{})
+	if !ok {
+		return r.byParent[elementString(el, "id")]
+	}
+	var ids []string
+	for _, v := range list {
+		if id, ok := v.(string); ok && r.byID[id] != nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// element renders one element and its subtree, indenting children one
+// level deeper than depth.
+func (r *renderer) element(id string, depth int) *html.Node {
+	el := r.byID[id]
+	name := elementString(el, "name")
+	settings, _ := el["settings"].(map[string]interface{})
+	used := make(map[string]bool)
+	str := func(key string) string {
+		s, _ := settings[key].(string)
+		used[key] = s != ""
+		return s
+	}
+
+	n := &html.Node{Type: html.ElementNode}
+	var wrapper *html.Node
+	switch name {
+	case "section":
+		n.Data = "section"
+	case "container", "block", "div":
+		n.Data = "div"
+	case "heading":
+		n.Data = "h3"
+	case "text-basic":
+		n.Data = "p"
+	case "text", "rich-text":
+		n.Data = "div"
+	case "text-link":
+		n.Data = "a"
+	case "button":
+		n.Data = "button"
+		if href := linkURL(settings); href != "" {
+			n.Data = "a"
+		}
+	case "list":
+		n.Data = "ul"
+	case "code":
+		n.Data = "pre"
+	case "divider":
+		n.Data = "hr"
+	case "image":
+		n.Data = "img"
+		img, _ := settings["image"].(map[string]interface{})
+		if src, _ := img["url"].(string); src != "" {
+			setAttr(n, "src", src)
+		}
+		if alt, _ := img["alt"].(string); alt != "" {
+			setAttr(n, "alt", alt)
+		}
+		used["image"] = onlyKeys(img, "url", "alt")
+		if caption := str("captionCustom"); caption != "" {
+			used["caption"] = true
+			wrapper = &html.Node{Type: html.ElementNode, Data: "figure"}
+			fc := &html.Node{Type: html.ElementNode, Data: "figcaption"}
+			fc.AppendChild(&html.Node{Type: html.TextNode, Data: caption})
+			wrapper.AppendChild(n)
+			wrapper.AppendChild(fc)
+		}
+	case "video":
+		switch {
+		case str("youTubeId") != "":
+			n.Data = "iframe"
+			setAttr(n, "src", "https://www.youtube.com/embed/"+settings["youTubeId"].(string))
+			used["videoType"] = true
+		case str("vimeoId") != "":
+			n.Data = "iframe"
+			setAttr(n, "src", "https://player.vimeo.com/video/"+settings["vimeoId"].(string))
+			used["videoType"] = true
+		default:
+			n.Data = "video"
+			if src := str("videoUrl"); src != "" {
+				setAttr(n, "src", src)
+			}
+		}
+	case "map":
+		n.Data = "iframe"
+		if addr := str("address"); addr != "" {
+			setAttr(n, "src", "https://maps.google.com/maps?q="+url.QueryEscape(addr)+"&output=embed")
+		}
+	case "audio":
+		n.Data = "audio"
+		file, _ := settings["file"].(map[string]interface{})
+		if src, _ := file["url"].(string); src != "" {
+			setAttr(n, "src", src)
+			setAttr(n, "controls", "")
+		}
+		used["file"] = onlyKeys(file, "url")
+	default:
+		n.Data = "div"
+	}
+	n.DataAtom = atom.Lookup([]byte(n.Data))
+
+	// A tag setting overrides the default tag
+	if tag := str("tag"); tag != "" {
+		if tag == "custom" {
+			if custom := str("customTag"); custom != "" {
+				n.Data = custom
+			}
+		} else {
+			n.Data = tag
+		}
+		n.DataAtom = atom.Lookup([]byte(n.Data))
+	}
+
+	// A figure wrapper carries the element's attributes
+	t := n
+	if wrapper != nil {
+		t = wrapper
+	}
+	setAttr(t, AttrElementID, id)
+	if href := linkURL(settings); href != "" && n.Data == "a" {
+		setAttr(n, "href", href)
+		used["link"] = onlyKeys(settings["link"].(map[string]interface{}), "type", "url")
+	}
+	if htmlID := str("_htmlId"); htmlID != "" {
+		setAttr(t, "id", htmlID)
+	}
+	r.classes(t, settings, used)
+	if attrs, ok := settings["_attributes"].([]interface{}); ok {
+		// Convert only reads data-* attributes back
+		used["_attributes"] = true
+		for _, a := range attrs {
+			m, _ := a.(map[string]interface{})
+			key, _ := m["name"].(string)
+			val, _ := m["value"].(string)
+			if key != "" {
+				setAttr(t, key, val)
+			}
+			if !strings.HasPrefix(key, "data-") {
+				used["_attributes"] = false
+			}
+		}
+	}
+
+	// Content
+	hasMarkup := false
+	if c, ok := elementContent[name]; ok {
+		if content := str(c.key); content != "" {
+			if !c.markup {
+				n.AppendChild(&html.Node{Type: html.TextNode, Data: content})
+			} else if nodes, err := html.ParseFragment(strings.NewReader(content), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}); err == nil {
+				for _, ch := range nodes {
+					if ch.Type == html.ElementNode {
+						hasMarkup = true
+					}
+					n.AppendChild(ch)
+				}
+			}
+			if name == "svg" {
+				used["source"] = true
+			}
+		}
+	}
+
+	if children := r.childIDs(el); len(children) > 0 {
+		pad := "\n" + strings.Repeat("  ", depth+1)
+		for _, childID := range children {
+			n.AppendChild(&html.Node{Type: html.TextNode, Data: pad})
+			n.AppendChild(r.element(childID, depth+1))
+		}
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: "\n" + strings.Repeat("  ", depth)})
+	}
+
+	r.styles(t, id, settings, used)
+
+	// Mark the element when its HTML alone would convert to another one.
+	// Code is always marked so Convert reads its text back into settings.
+	rule := r.mapper.match(t)
+	if rule == nil || rule.Element != name || (name == "text-basic" && hasMarkup) || name == "code" {
+		setAttr(t, AttrElementName, name)
+	}
+
+	leftover := make(map[string]interface{})
+	for k, v := range settings {
+		if !used[k] {
+			leftover[k] = v
+		}
+	}
+	if len(leftover) > 0 {
+		data, _ := json.Marshal(leftover)
+		setAttr(t, AttrSettings, string(data))
+	}
+	return t
+}
+
+func linkURL(settings map[string]interface{}) string {
+	link, _ := settings["link"].(map[string]interface{})
+	u, _ := link["url"].(string)
+	return u
+}
+
+// onlyKeys reports whether m has no keys beyond the given ones.
+func onlyKeys(m map[string]interface{}, keys ...string) bool {
+	for k := range m {
+		found := false
+		for _, want := range keys {
+			if k == want {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// classes writes the class attribute from _cssGlobalClasses (resolved by
+// name), _cssClasses and legacy class lists in _cssCustom.
+func (r *renderer) classes(n *html.Node, settings map[string]interface{}, used map[string]bool) {
+	var names []string
+	var unresolved []interface{}
+	if ids, ok := settings["_cssGlobalClasses"].([]interface{}); ok {
+		for _, v := range ids {
+			id, _ := v.(string)
+			if r.opts.Registry != nil {
+				if name, ok := r.opts.Registry.NameOf(id); ok {
+					names = append(names, name)
+					continue
+				}
+			}
+			unresolved = append(unresolved, id)
+			r.warnings = append(r.warnings, fmt.Sprintf("global class %s is not in the class registry; kept in %s", id, AttrSettings))
+		}
+		used["_cssGlobalClasses"] = len(unresolved) == 0
+	}
+	if cls, _ := settings["_cssClasses"].(string); cls != "" {
+		names = append(names, strings.Fields(cls)...)
+		used["_cssClasses"] = true
+	}
+	if legacy := legacyClassList(settings["_cssCustom"]); legacy != nil {
+		names = append(names, legacy...)
+		used["_cssCustom"] = true
+	}
+	if len(names) > 0 {
+		setAttr(n, "class", strings.Join(names, " "))
+	}
+}
+
+// styles writes the element's style settings: base settings to the style
+// attribute (or the style block), breakpoint and state settings and
+// _cssCustom to the style block under the element's #id.
+func (r *renderer) styles(n *html.Node, id string, settings map[string]interface{}, used map[string]bool) {
+	type group struct {
+		media int
+		state string
+		decls []Declaration
+	}
+	groups := make(map[string]*group)
+	var order []string
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		base, suffix, _ := strings.Cut(key, ":")
+		media, state, ok := r.suffix(suffix)
+		if !ok {
+			continue
+		}
+		decls, complete := settingDeclarations(base, settings[key])
+		if len(decls) == 0 {
+			continue
+		}
+		used[key] = complete
+		gk := suffix
+		if groups[gk] == nil {
+			groups[gk] = &group{media: media, state: state}
+			order = append(order, gk)
+		}
+		groups[gk].decls = append(groups[gk].decls, decls...)
+	}
+
+	selector := ""
+	sel := func() string {
+		if selector == "" {
+			htmlID, _ := settings["_htmlId"].(string)
+			if htmlID == "" {
+				htmlID = "brxe-" + id
+				setAttr(n, "id", htmlID)
+			}
+			selector = "#" + htmlID
+		}
+		return selector
+	}
+
+	// Base styles first, then breakpoints widest first
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := groups[order[i]], groups[order[j]]
+		if (a.media == 0) != (b.media == 0) {
+			return a.media == 0
+		}
+		return a.media > b.media
+	})
+	for _, gk := range order {
+		g := groups[gk]
+		if gk == "" && !r.opts.StyleBlock {
+			setAttr(n, "style", declarationText(g.decls, "; "))
+			continue
+		}
+		rule := sel() + g.state + " { " + declarationText(g.decls, "; ") + " }\n"
+		if g.media > 0 {
+			rule = fmt.Sprintf("@media (max-width: %dpx) { %s }\n", g.media, strings.TrimSuffix(rule, "\n"))
+		}
+		r.css.WriteString(rule)
+	}
+
+	if custom, _ := settings["_cssCustom"].(string); strings.Contains(custom, "{") {
+		r.css.WriteString(strings.TrimSpace(strings.ReplaceAll(custom, "%root%", sel())) + "\n")
+		used["_cssCustom"] = true
+	}
+}
+
+// suffix parses a setting key suffix ("tablet_portrait", "hover",
+// "mobile_landscape:hover", ":before") into a max-width and CSS state.
+func (r *renderer) suffix(s string) (media int, state string, ok bool) {
+	if s == "" {
+		return 0, "", true
+	}
+	if strings.HasPrefix(s, ":") {
+		return 0, ":" + s, bricksStates[":"+s]
+	}
+	bp, rest, _ := strings.Cut(s, ":")
+	found := false
+	for _, b := range r.opts.Breakpoints {
+		if b.Key == bp {
+			found = true
+			if !b.Base {
+				media = b.Width
+			}
+		}
+	}
+	if !found {
+		if rest != "" || !bricksStates[":"+bp] {
+			return 0, "", false
+		}
+		return 0, ":" + bp, true
+	}
+	if rest != "" {
+		state = ":" + rest
+		if !bricksStates[state] {
+			return 0, "", false
+		}
+	}
+	return media, state, true
+}
+
+func declarationText(decls []Declaration, sep string) string {
+	parts := make([]string, len(decls))
+	for i, d := range decls {
+		parts[i] = d.Property + ": " + d.Value
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,116 @@
+package convert_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+const renderPage = `[
+	{"id":"sec001","name":"section","parent":"0","children":["con001"],"settings":{
+		"_padding":{"top":"40px","right":"20px","bottom":"40px","left":"20px"},
+		"_padding:mobile_portrait":{"top":"10px","right":"10px","bottom":"10px","left":"10px"}}},
+	{"id":"con001","name":"container","parent":"sec001","children":["hed001","txt001","rtx001","img001","btn001","frm001"],"settings":{
+		"_cssGlobalClasses":["acss_grid","unknown"],"_gap":"1rem"}},
+	{"id":"hed001","name":"heading","parent":"con001","children":[],"settings":{
+		"text":"Hello <em>there</em>","tag":"h1",
+		"_typography":{"color":{"raw":"red"},"font-variant":"small-caps"},
+		"_typography:hover":{"color":{"raw":"blue"}}}},
+	{"id":"txt001","name":"text-basic","parent":"con001","children":[],"settings":{"text":"Plain & simple"}},
+	{"id":"rtx001","name":"text","parent":"con001","children":[],"settings":{"text":"<p>One <strong>two</strong></p><ul><li>x</li></ul>"}},
+	{"id":"img001","name":"image","parent":"con001","children":[],"settings":{
+		"image":{"url":"/a.jpg","alt":"A","id":12},"caption":"custom","captionCustom":"Cap"}},
+	{"id":"btn001","name":"button","parent":"con001","children":[],"settings":{
+		"text":"Go","link":{"type":"external","url":"/go"},"style":"primary"}},
+	{"id":"frm001","name":"form","parent":"con001","children":[],"settings":{"fields":[{"id":"f1","type":"email"}]}}
+]`
+
+func TestRenderHTML(t *testing.T) {
+	var elements []map[string]interface{}
+	if err := json.Unmarshal([]byte(renderPage), &elements); err != nil {
+		t.Fatal(err)
+	}
+	reg := convert.NewClassRegistry()
+	reg.Add("grid--3", "acss_grid", "acss")
+
+	res := convert.RenderHTML(elements, convert.RenderOptions{Registry: reg})
+	for _, want := range []string{
+		`<section data-bricks-id="sec001" style="padding: 40px 20px" id="brxe-sec001">`,
+		`@media (max-width: 478px) { #brxe-sec001 { padding: 10px } }`,
+		`#brxe-hed001:hover { color: blue }`,
+		`class="grid--3"`,
+		`<h1 data-bricks-id="hed001" style="color: red"`,
+		`>Hello <em>there</em></h1>`,
+		`<p data-bricks-id="txt001">Plain &amp; simple</p>`,
+		`<img src="/a.jpg" alt="A"/><figcaption>Cap</figcaption></figure>`,
+		`<a data-bricks-id="btn001" href="/go"`,
+		`data-bricks-element="form"`,
+	} {
+		if !strings.Contains(res.HTML, want) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", want, res.HTML)
+		}
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "unknown") {
+		t.Errorf("expected a warning for the unresolved class, got %v", res.Warnings)
+	}
+
+	res = convert.RenderHTML(elements, convert.RenderOptions{Registry: reg, StyleBlock: true})
+	if strings.Contains(res.HTML, "style=") || !strings.Contains(res.HTML, "#brxe-sec001 { padding: 40px 20px }") {
+		t.Errorf("expected base styles in the style block, got:\n%s", res.HTML)
+	}
+}
+
+func TestRenderRoundTripPreservesElements(t *testing.T) {
+	var elements []map[string]interface{}
+	if err := json.Unmarshal([]byte(renderPage), &elements); err != nil {
+		t.Fatal(err)
+	}
+	reg := convert.NewClassRegistry()
+	reg.Add("grid--3", "acss_grid", "acss")
+
+	rendered := convert.RenderHTML(elements, convert.RenderOptions{Registry: reg})
+	res, err := convert.Convert(rendered.HTML, convert.Options{Registry: reg, PreserveIDs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Warnings) != 0 {
+		t.Errorf("unexpected warnings %v", res.Warnings)
+	}
+
+	// Compare through JSON so number and slice types line up
+	want, _ := json.Marshal(elements)
+	got, _ := json.Marshal(res.Elements)
+	var a, b []interface{}
+	json.Unmarshal(want, &a)
+	json.Unmarshal(got, &b)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("round trip changed the elements\nwant %s\ngot  %s", want, got)
+	}
+}
+
+func TestPreserveIDsEditedHTML(t *testing.T) {
+	html := `<section data-bricks-id="abc123"><h2 data-bricks-id="abc124">New title</h2><p>Added</p><p data-bricks-id="abc124">Copy</p></section>`
+
+	res, err := convert.Convert(html, convert.Options{PreserveIDs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []interface{}{res.Elements[0]["id"], res.Elements[1]["id"], res.Elements[2]["id"], res.Elements[3]["id"]}
+	if ids[0] != "abc123" || ids[1] != "abc124" {
+		t.Errorf("expected preserved IDs, got %v", ids)
+	}
+	if ids[2] == "abc124" || ids[3] == "abc124" || ids[2] == ids[3] {
+		t.Errorf("new and duplicated elements need fresh IDs, got %v", ids)
+	}
+	if _, ok := res.Elements[0]["settings"].(map[string]interface{})["_attributes"]; ok {
+		t.Error("data-bricks-* attributes should not become element attributes")
+	}
+
+	res, _ = convert.Convert(html, convert.Options{})
+	if res.Elements[0]["id"] == "abc123" {
+		t.Error("IDs should only be preserved with PreserveIDs")
+	}
+}
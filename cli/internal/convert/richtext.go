@@ -0,0 +1,137 @@
+package convert
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultInlineThreshold is the number of inline formatting elements a text
+// block needs before it is kept as rich text.
+const DefaultInlineThreshold = 1
+
+// inlineTags are the phrasing elements kept inside rich text rather than
+// converted to elements of their own.
+var inlineTags = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "br": true,
+	"cite": true, "code": true, "data": true, "dfn": true, "del": true, "em": true,
+	"i": true, "ins": true, "kbd": true, "mark": true, "q": true, "s": true,
+	"samp": true, "small": true, "span": true, "strong": true, "sub": true,
+	"sup": true, "time": true, "u": true, "var": true, "wbr": true,
+}
+
+// inlineThreshold resolves Options.InlineThreshold, returning 0 when rich
+// text is off.
+func (c *converter) inlineThreshold() int {
+	switch t := c.opts.InlineThreshold; {
+	case t == 0:
+		return DefaultInlineThreshold
+	case t < 0:
+		return 0
+	default:
+		return t
+	}
+}
+
+// richText reports whether the nodes are inline content only, with at
+// least the threshold's worth of inline elements, so they can be kept as
+// HTML in one rich-text element.
+func (c *converter) richText(nodes []*html.Node) bool {
+	threshold := c.inlineThreshold()
+	if threshold == 0 {
+		return false
+	}
+	count := 0
+	var walk func(*html.Node) bool
+	walk = func(n *html.Node) bool {
+		switch n.Type {
+		case html.TextNode, html.CommentNode:
+			return true
+		case html.ElementNode:
+			if !inlineTags[n.Data] {
+				return false
+			}
+			count++
+			for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+				if !walk(ch) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	for _, n := range nodes {
+		if !walk(n) {
+			return false
+		}
+	}
+	return count >= threshold
+}
+
+// processNodes converts sibling nodes under parentID. Runs of inline
+// content that include bare text, such as "Intro <strong>bold</strong>"
+// beside a <p>, become one text element; block children become elements
+// of their own.
+func (c *converter) processNodes(nodes []*html.Node, parentID string) {
+	var run []*html.Node
+	flush := func() {
+		c.processInlineRun(run, parentID)
+		run = nil
+	}
+	for _, ch := range nodes {
+		if ch.Type == html.TextNode || (ch.Type == html.ElementNode && inlineTags[ch.Data]) {
+			run = append(run, ch)
+			continue
+		}
+		flush()
+		c.processNode(ch, parentID)
+	}
+	flush()
+}
+
+// processInlineRun converts a run of inline siblings. Without bare text the
+// inline elements convert as usual (a lone <a> stays a text-link); with it,
+// the run becomes rich text, or below the threshold a text-basic per text
+// node beside the converted inline elements.
+func (c *converter) processInlineRun(run []*html.Node, parentID string) {
+	hasText := false
+	for _, n := range run {
+		if n.Type == html.TextNode && strings.TrimSpace(n.Data) != "" {
+			hasText = true
+		}
+	}
+	if !hasText {
+		for _, n := range run {
+			c.processNode(n, parentID)
+		}
+		return
+	}
+	if c.richText(run) {
+		c.newElement("text", parentID, map[string]interface{}{"text": renderNodes(run)})
+		return
+	}
+	for _, n := range run {
+		if n.Type != html.TextNode {
+			c.processNode(n, parentID)
+		} else if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+			c.newElement("text-basic", parentID, map[string]interface{}{"text": text})
+		}
+	}
+}
+
+// renderNodes renders nodes back to HTML, trimmed.
+func renderNodes(nodes []*html.Node) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		html.Render(&sb, n)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func childNodes(n *html.Node) []*html.Node {
+	var out []*html.Node
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		out = append(out, ch)
+	}
+	return out
+}
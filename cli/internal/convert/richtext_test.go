@@ -0,0 +1,100 @@
+package convert_test
+
+import (
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/convert"
+)
+
+func TestInlineFormattingKeptAsRichText(t *testing.T) {
+	res, err := convert.Convert(`<p class="lead">Hello <strong>world</strong> and <a href="/x">link</a></p>
+		<h2>Big <em>news</em></h2>
+		<p>Plain</p>`, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Elements) != 3 {
+		t.Fatalf("expected no separate inline elements, got %v", res.Elements)
+	}
+	p := res.Elements[0]
+	settings := p["settings"].(map[string]interface{})
+	if p["name"] != "text" || settings["text"] != `Hello <strong>world</strong> and <a href="/x">link</a>` {
+		t.Errorf("expected rich text with inner HTML, got %v", p)
+	}
+	if settings["_cssCustom"] != ".lead" {
+		t.Errorf("expected the paragraph's classes kept, got %v", settings)
+	}
+	h := res.Elements[1]
+	if h["name"] != "heading" || h["settings"].(map[string]interface{})["text"] != "Big <em>news</em>" {
+		t.Errorf("expected heading with inline HTML, got %v", h)
+	}
+	if res.Elements[2]["name"] != "text-basic" {
+		t.Errorf("expected plain paragraph as text-basic, got %v", res.Elements[2])
+	}
+}
+
+func TestMixedContentSplitsBlocks(t *testing.T) {
+	res, err := convert.Convert(`<div>Intro <b>bold</b><p>Para</p><a href="/more">More</a></div>`, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []interface{}
+	for _, el := range res.Elements {
+		names = append(names, el["name"])
+	}
+	want := []interface{}{"div", "text", "text-basic", "text-link"}
+	if len(names) != len(want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, names)
+		}
+	}
+	if text := res.Elements[1]["settings"].(map[string]interface{})["text"]; text != "Intro <b>bold</b>" {
+		t.Errorf("expected inline run as rich text, got %v", text)
+	}
+}
+
+func TestNonContentNotConvertedToText(t *testing.T) {
+	res, err := convert.Convert(`<section><style>.card{padding:10px}</style><div class="card">Card</div>
+		<noscript>Enable JS</noscript><script>var a=1;</script><template><p>Later</p></template>
+		<div><script>alert(2)</script></div></section>`, convert.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, el := range res.Elements {
+		settings, _ := el["settings"].(map[string]interface{})
+		text, _ := settings["text"].(string)
+		if text != "" && text != "Card" {
+			t.Errorf("expected non-content elements dropped, got %v", el)
+		}
+	}
+	// The stylesheet still applies
+	styled := false
+	for _, el := range res.Elements {
+		if settings, _ := el["settings"].(map[string]interface{}); settings["_padding"] != nil {
+			styled = true
+		}
+	}
+	if !styled {
+		t.Errorf("expected the <style> rule applied, got %v", res.Elements)
+	}
+}
+
+func TestInlineThreshold(t *testing.T) {
+	html := `<p>One <strong>two</strong> <em>three</em></p>`
+
+	res, _ := convert.Convert(html, convert.Options{InlineThreshold: 3})
+	if res.Elements[0]["name"] != "text-basic" {
+		t.Errorf("expected text-basic below the threshold, got %v", res.Elements[0])
+	}
+	res, _ = convert.Convert(html, convert.Options{InlineThreshold: 2})
+	if res.Elements[0]["name"] != "text" {
+		t.Errorf("expected rich text at the threshold, got %v", res.Elements[0])
+	}
+	res, _ = convert.Convert(html, convert.Options{InlineThreshold: -1})
+	if res.Elements[0]["name"] != "text-basic" {
+		t.Errorf("expected rich text off, got %v", res.Elements[0])
+	}
+}
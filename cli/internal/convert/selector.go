@@ -0,0 +1,361 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// specificity is a selector's (id, class, type) weight.
+type specificity [3]int
+
+func (a specificity) less(b specificity) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// compoundSelector is a run of simple selectors with no combinator between
+// them, e.g. "a.button#cta".
+type compoundSelector struct {
+	tag     string // "" or "*" matches any element
+	id      string
+	classes []string
+	// pseudos are pseudo-classes and pseudo-elements (":hover",
+	// "::before", ":nth-child(2)"). Matching ignores them: the element is
+	// styled for the state, not matched against it.
+	pseudos []string
+}
+
+// selectorStep is a compound selector and the combinator joining it to the
+// step on its left: ' ' (descendant) or '>' (child).
+type selectorStep struct {
+	compound   compoundSelector
+	combinator byte
+}
+
+// selector is a parsed complex selector. Type, universal, class, ID and
+// pseudo selectors joined by descendant or child combinators are supported.
+type selector struct {
+	raw   string
+	steps []selectorStep
+}
+
+func parseSelector(raw string) (*selector, error) {
+	sel := &selector{raw: raw}
+	var cur compoundSelector
+	empty := true
+	pending := byte(0)
+
+	flush := func() {
+		if empty {
+			return
+		}
+		sel.steps = append(sel.steps, selectorStep{compound: cur, combinator: pending})
+		cur = compoundSelector{}
+		empty = true
+		pending = 0
+	}
+
+	for i := 0; i < len(raw); {
+		ch := raw[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			flush()
+			if len(sel.steps) > 0 && pending == 0 {
+				pending = ' '
+			}
+			i++
+		case ch == '>':
+			flush()
+			if len(sel.steps) == 0 {
+				return nil, fmt.Errorf("unsupported selector %q: leading combinator", raw)
+			}
+			pending = '>'
+			i++
+		case ch == '+' || ch == '~':
+			return nil, fmt.Errorf("unsupported selector %q: sibling combinators", raw)
+		case ch == '[':
+			return nil, fmt.Errorf("unsupported selector %q: attribute selectors", raw)
+		case ch == ':':
+			pseudo, n := readPseudo(raw[i:])
+			if pseudo == "" {
+				return nil, fmt.Errorf("invalid selector %q", raw)
+			}
+			cur.pseudos = append(cur.pseudos, pseudo)
+			empty = false
+			i += n
+		case ch == '*':
+			if !empty {
+				return nil, fmt.Errorf("invalid selector %q", raw)
+			}
+			cur.tag = "*"
+			empty = false
+			i++
+		case ch == '.' || ch == '#':
+			name, n := readCSSIdent(raw[i+1:])
+			if name == "" {
+				return nil, fmt.Errorf("invalid selector %q", raw)
+			}
+			if ch == '.' {
+				cur.classes = append(cur.classes, name)
+			} else {
+				cur.id = name
+			}
+			empty = false
+			i += 1 + n
+		default:
+			name, n := readCSSIdent(raw[i:])
+			if name == "" || !empty {
+				return nil, fmt.Errorf("invalid selector %q", raw)
+			}
+			cur.tag = strings.ToLower(name)
+			empty = false
+			i += n
+		}
+	}
+	flush()
+	if len(sel.steps) == 0 || pending != 0 {
+		return nil, fmt.Errorf("invalid selector %q", raw)
+	}
+	return sel, nil
+}
+
+// readCSSIdent reads an identifier (letters, digits, '-', '_', escapes and
+// non-ASCII) from the start of s, returning it unescaped and the bytes used.
+func readCSSIdent(s string) (string, int) {
+	var sb strings.Builder
+	i := 0
+	for i < len(s) {
+		ch := s[i]
+		switch {
+		case ch == '\\' && i+1 < len(s):
+			sb.WriteByte(s[i+1])
+			i += 2
+		case ch == '-' || ch == '_' || ch >= 0x80 ||
+			(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9'):
+			sb.WriteByte(ch)
+			i++
+		default:
+			return sb.String(), i
+		}
+	}
+	return sb.String(), i
+}
+
+// readPseudo reads ":name", "::name" or ":name(args)" from the start of s.
+// Legacy single-colon pseudo-elements are normalised to "::".
+func readPseudo(s string) (string, int) {
+	i := 1
+	if len(s) > 1 && s[1] == ':' {
+		i = 2
+	}
+	name, n := readCSSIdent(s[i:])
+	if name == "" {
+		return "", 0
+	}
+	name = strings.ToLower(name)
+	i += n
+	if i < len(s) && s[i] == '(' {
+		depth := 0
+		j := i
+		for ; j < len(s); j++ {
+			if s[j] == '(' {
+				depth++
+			} else if s[j] == ')' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if j == len(s) {
+			return "", 0
+		}
+		return ":" + name + s[i:j+1], j + 1
+	}
+	switch name {
+	case "before", "after", "first-line", "first-letter", "placeholder", "marker", "selection":
+		return "::" + name, i
+	}
+	return ":" + name, i
+}
+
+func (s *selector) specificity() specificity {
+	var sp specificity
+	for _, st := range s.steps {
+		if st.compound.id != "" {
+			sp[0]++
+		}
+		sp[1] += len(st.compound.classes)
+		if st.compound.tag != "" && st.compound.tag != "*" {
+			sp[2]++
+		}
+		for _, p := range st.compound.pseudos {
+			if strings.HasPrefix(p, "::") {
+				sp[2]++
+			} else {
+				sp[1]++
+			}
+		}
+	}
+	return sp
+}
+
+// bricksStates are the states Bricks stores as suffixed setting keys
+// ("_background:hover").
+var bricksStates = map[string]bool{
+	":hover": true, ":focus": true, ":active": true, "::before": true, "::after": true,
+}
+
+// state splits a selector's pseudos into what Bricks can represent. A single
+// supported pseudo on the subject becomes state, e.g. ":hover". Any other
+// pseudo makes the rule custom CSS: custom is the selector rewritten around
+// %root% (".card:hover %root%", "%root%:first-child").
+func (s *selector) state() (state, custom string) {
+	last := len(s.steps) - 1
+	ancestorPseudo := false
+	for _, st := range s.steps[:last] {
+		if len(st.compound.pseudos) > 0 {
+			ancestorPseudo = true
+		}
+	}
+	subject := s.steps[last].compound.pseudos
+	if !ancestorPseudo {
+		if len(subject) == 0 {
+			return "", ""
+		}
+		if len(subject) == 1 && bricksStates[subject[0]] {
+			return subject[0], ""
+		}
+	}
+	var sb strings.Builder
+	for i, st := range s.steps[:last] {
+		if i > 0 {
+			sb.WriteString(combinatorText(st.combinator))
+		}
+		sb.WriteString(st.compound.String())
+	}
+	if last > 0 {
+		sb.WriteString(combinatorText(s.steps[last].combinator))
+	}
+	sb.WriteString("%root%" + strings.Join(subject, ""))
+	return "", sb.String()
+}
+
+func combinatorText(c byte) string {
+	if c == '>' {
+		return " > "
+	}
+	return " "
+}
+
+// String renders the compound selector back to CSS.
+func (c compoundSelector) String() string {
+	var sb strings.Builder
+	if c.tag != "" {
+		sb.WriteString(c.tag)
+	}
+	if c.id != "" {
+		sb.WriteString("#" + c.id)
+	}
+	for _, cls := range c.classes {
+		sb.WriteString("." + cls)
+	}
+	for _, p := range c.pseudos {
+		sb.WriteString(p)
+	}
+	if sb.Len() == 0 {
+		return "*"
+	}
+	return sb.String()
+}
+
+// singleClass returns the class name when the selector is exactly one class
+// selector, optionally with one pseudo (".card", ".card:hover"), the only
+// shapes that can become a global class.
+func (s *selector) singleClass() (string, bool) {
+	if len(s.steps) != 1 {
+		return "", false
+	}
+	c := s.steps[0].compound
+	if c.tag != "" || c.id != "" || len(c.classes) != 1 || len(c.pseudos) > 1 {
+		return "", false
+	}
+	return c.classes[0], true
+}
+
+func (s *selector) matches(n *html.Node) bool {
+	return s.matchStep(len(s.steps)-1, n)
+}
+
+func (s *selector) matchStep(i int, n *html.Node) bool {
+	if !s.steps[i].compound.matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	switch s.steps[i].combinator {
+	case '>':
+		p := elementParent(n)
+		return p != nil && s.matchStep(i-1, p)
+	default:
+		for p := elementParent(n); p != nil; p = elementParent(p) {
+			if s.matchStep(i-1, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (c compoundSelector) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if c.tag != "" && c.tag != "*" && c.tag != n.Data {
+		return false
+	}
+	if c.id != "" && attrValue(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		have := strings.Fields(attrValue(n, "class"))
+		for _, want := range c.classes {
+			found := false
+			for _, h := range have {
+				if h == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func elementParent(n *html.Node) *html.Node {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode {
+			return p
+		}
+	}
+	return nil
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -1,102 +1,59 @@
 package convert
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+)
 
 // ParseInlineStyles converts a CSS style string to Bricks settings map.
 func ParseInlineStyles(style string) map[string]interface{} {
+	settings, _ := ParseStyles(style)
+	return settings
+}
+
+// ParseStyles converts a CSS style string to Bricks settings, returning the
+// declarations that have no Bricks setting.
+func ParseStyles(style string) (map[string]interface{}, []Declaration) {
 	settings := make(map[string]interface{})
-	for _, pair := range splitCSSDeclarations(style) {
-		pair = strings.TrimSpace(pair)
-		if pair == "" {
-			continue
+	var unmapped []Declaration
+	for _, d := range parseDeclarations(style) {
+		if !applyDeclaration(settings, d.Property, d.Value) {
+			unmapped = append(unmapped, d)
 		}
+	}
+	return settings, unmapped
+}
+
+// Declaration is a single CSS property/value pair.
+type Declaration struct {
+	Property  string
+	Value     string
+	Important bool
+}
+
+// parseDeclarations splits a declaration block ("color: red; padding: 0")
+// into declarations, lower-casing property names and stripping !important.
+func parseDeclarations(block string) []Declaration {
+	var decls []Declaration
+	for _, pair := range splitCSSDeclarations(block) {
 		parts := strings.SplitN(pair, ":", 2)
 		if len(parts) != 2 {
 			continue
 		}
-		prop := strings.TrimSpace(parts[0])
-		val := strings.TrimSpace(parts[1])
-
-		switch prop {
-		case "color":
-			ensureTypo(settings)["color"] = map[string]interface{}{"raw": val}
-		case "font-size":
-			ensureTypo(settings)["font-size"] = val
-		case "font-weight":
-			ensureTypo(settings)["font-weight"] = val
-		case "text-align":
-			ensureTypo(settings)["text-align"] = val
-		case "line-height":
-			ensureTypo(settings)["line-height"] = val
-		case "letter-spacing":
-			ensureTypo(settings)["letter-spacing"] = val
-		case "font-style":
-			ensureTypo(settings)["font-style"] = val
-		case "text-transform":
-			ensureTypo(settings)["text-transform"] = val
-		case "padding":
-			settings["_padding"] = expandBoxShorthand(val)
-		case "padding-top":
-			ensurePad(settings)["top"] = val
-		case "padding-bottom":
-			ensurePad(settings)["bottom"] = val
-		case "padding-left":
-			ensurePad(settings)["left"] = val
-		case "padding-right":
-			ensurePad(settings)["right"] = val
-		case "margin":
-			settings["_margin"] = expandBoxShorthand(val)
-		case "margin-top":
-			ensureMargin(settings)["top"] = val
-		case "margin-bottom":
-			ensureMargin(settings)["bottom"] = val
-		case "margin-left":
-			ensureMargin(settings)["left"] = val
-		case "margin-right":
-			ensureMargin(settings)["right"] = val
-		case "background-color", "background":
-			settings["_background"] = map[string]interface{}{
-				"color": map[string]interface{}{"raw": val},
-			}
-		case "gap":
-			settings["_gap"] = val
-		case "row-gap":
-			settings["_rowGap"] = val
-		case "column-gap":
-			settings["_columnGap"] = val
-		case "max-width":
-			settings["_maxWidth"] = val
-		case "width":
-			settings["_width"] = val
-		case "min-height":
-			settings["_minHeight"] = val
-		case "height":
-			settings["_height"] = val
-		case "display":
-			settings["_display"] = val
-		case "flex-direction":
-			settings["_direction"] = val
-		case "align-items":
-			settings["_alignItems"] = val
-		case "justify-content":
-			settings["_justifyContent"] = val
-		case "grid-template-columns":
-			settings["_gridTemplateColumns"] = val
-		case "grid-template-rows":
-			settings["_gridTemplateRows"] = val
-		case "border-radius":
-			settings["_borderRadius"] = val
-		case "overflow":
-			settings["_overflow"] = val
-		case "position":
-			settings["_position"] = val
-		case "z-index":
-			settings["_zIndex"] = val
-		case "opacity":
-			settings["_opacity"] = val
+		d := Declaration{
+			Property: strings.ToLower(strings.TrimSpace(parts[0])),
+			Value:    strings.TrimSpace(parts[1]),
+		}
+		if i := strings.LastIndex(strings.ToLower(d.Value), "!important"); i >= 0 {
+			d.Value = strings.TrimSpace(d.Value[:i])
+			d.Important = true
 		}
+		if d.Property == "" || d.Value == "" {
+			continue
+		}
+		decls = append(decls, d)
 	}
-	return settings
+	return decls
 }
 
 func splitCSSDeclarations(style string) []string {
@@ -156,33 +113,6 @@ func splitCSSDeclarations(style string) []string {
 	return declarations
 }
 
-func ensureTypo(s map[string]interface{}) map[string]interface{} {
-	if t, ok := s["_typography"].(map[string]interface{}); ok {
-		return t
-	}
-	t := make(map[string]interface{})
-	s["_typography"] = t
-	return t
-}
-
-func ensurePad(s map[string]interface{}) map[string]interface{} {
-	if p, ok := s["_padding"].(map[string]interface{}); ok {
-		return p
-	}
-	p := make(map[string]interface{})
-	s["_padding"] = p
-	return p
-}
-
-func ensureMargin(s map[string]interface{}) map[string]interface{} {
-	if m, ok := s["_margin"].(map[string]interface{}); ok {
-		return m
-	}
-	m := make(map[string]interface{})
-	s["_margin"] = m
-	return m
-}
-
 func expandBoxShorthand(val string) map[string]interface{} {
 	parts := strings.Fields(val)
 	switch len(parts) {
@@ -198,3 +128,114 @@ func expandBoxShorthand(val string) map[string]interface{} {
 		return map[string]interface{}{"top": val, "right": val, "bottom": val, "left": val}
 	}
 }
+
+// settingDeclarations turns one style setting back into CSS, the reverse
+// of applyDeclaration. complete is false when part of the value has no CSS
+// form that applyDeclaration would read back.
+func settingDeclarations(key string, val interface{}) (decls []Declaration, complete bool) {
+	if prop, ok := plainStyleSettings[key]; ok {
+		s := styleValue(val)
+		if s == "" {
+			return nil, false
+		}
+		return []Declaration{{Property: prop, Value: s}}, true
+	}
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	handled := 0
+	switch key {
+	case "_typography":
+		if c, ok := m["color"]; ok {
+			if v := colorValue(c); v != "" {
+				decls = append(decls, Declaration{Property: "color", Value: v})
+				handled += rawColor(c)
+			}
+		}
+		for _, prop := range typographyProperties {
+			if v := styleValue(m[prop]); v != "" {
+				decls = append(decls, Declaration{Property: prop, Value: v})
+				handled++
+			}
+		}
+	case "_padding", "_margin":
+		prop := strings.TrimPrefix(key, "_")
+		sides := []string{"top", "right", "bottom", "left"}
+		vals := make([]string, 4)
+		all := true
+		for i, side := range sides {
+			vals[i] = styleValue(m[side])
+			if vals[i] == "" {
+				all = false
+			} else {
+				handled++
+			}
+		}
+		if all {
+			decls = append(decls, Declaration{Property: prop, Value: boxShorthand(vals)})
+		} else {
+			for i, side := range sides {
+				if vals[i] != "" {
+					decls = append(decls, Declaration{Property: prop + "-" + side, Value: vals[i]})
+				}
+			}
+		}
+	case "_background":
+		if v := colorValue(m["color"]); v != "" {
+			decls = append(decls, Declaration{Property: "background-color", Value: v})
+			handled += rawColor(m["color"])
+		}
+	default:
+		return nil, false
+	}
+	return decls, handled == len(m)
+}
+
+// styleValue returns a setting value as CSS text: strings as they are,
+// numbers formatted, anything else empty.
+func styleValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return ""
+}
+
+// colorValue reads a Bricks color setting ({raw}, {hex} or {rgb}).
+func colorValue(v interface{}) string {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, k := range []string{"raw", "hex", "rgb"} {
+		if s, _ := m[k].(string); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// rawColor is 1 for a {raw} color, which applyDeclaration reads back
+// exactly, and 0 for other forms.
+func rawColor(v interface{}) int {
+	if m, _ := v.(map[string]interface{}); onlyKeys(m, "raw") {
+		return 1
+	}
+	return 0
+}
+
+// boxShorthand writes top/right/bottom/left as the shortest shorthand.
+func boxShorthand(v []string) string {
+	switch {
+	case v[0] == v[1] && v[1] == v[2] && v[2] == v[3]:
+		return v[0]
+	case v[0] == v[2] && v[1] == v[3]:
+		return v[0] + " " + v[1]
+	case v[1] == v[3]:
+		return v[0] + " " + v[1] + " " + v[2]
+	}
+	return strings.Join(v, " ")
+}
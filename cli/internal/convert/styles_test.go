@@ -1,6 +1,9 @@
 package convert
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestParseInlineStyles_Typography(t *testing.T) {
 	style := "color: var(--primary); font-size: var(--h2); font-weight: 700; text-align: center"
@@ -92,9 +95,9 @@ func TestParseInlineStyles_DataURI(t *testing.T) {
 	if !ok {
 		t.Fatal("expected _background settings")
 	}
-	bgColor, _ := bg["color"].(map[string]interface{})
-	if bgColor["raw"] != "url(data:text/plain;utf8,hello)" {
-		t.Errorf("expected data URI to stay intact, got %v", bgColor["raw"])
+	bgImage, _ := bg["image"].(map[string]interface{})
+	if bgImage["url"] != "data:text/plain;utf8,hello" {
+		t.Errorf("expected data URI to stay intact, got %v", bg)
 	}
 
 	typo, ok := settings["_typography"].(map[string]interface{})
@@ -127,3 +130,88 @@ func TestExpandBoxShorthand_FourValues(t *testing.T) {
 		t.Errorf("unexpected 4-value shorthand: %v", result)
 	}
 }
+
+func TestParseStyles_Shorthands(t *testing.T) {
+	settings, unmapped := ParseStyles(strings.Join([]string{
+		"border: 1px solid var(--border)",
+		"border-bottom: 3px dashed",
+		"box-shadow: inset 0 4px 12px rgba(0, 0, 0, .2)",
+		"transform: translate(10px, -50%) rotate(45deg) scale(1.1)",
+		"transition: all .3s ease",
+		"flex: 1 0 200px",
+		"flex-wrap: wrap",
+		"grid-area: main",
+		"font: italic 700 1.25rem/1.4 Inter, sans-serif",
+		"text-decoration: underline",
+		"background: #111 url('/bg.jpg') no-repeat center / cover",
+		"inset: 0 auto",
+		"clip-path: circle(50%)",
+		"filter: blur(2px)",
+	}, "; "))
+
+	border := settings["_border"].(map[string]interface{})
+	width := border["width"].(map[string]interface{})
+	if width["top"] != "1px" || width["bottom"] != "3px" || border["style"] != "dashed" ||
+		border["color"].(map[string]interface{})["raw"] != "var(--border)" {
+		t.Errorf("unexpected border %v", border)
+	}
+	shadow := settings["_boxShadow"].(map[string]interface{})
+	values := shadow["values"].(map[string]interface{})
+	if shadow["inset"] != true || values["offsetY"] != "4px" || values["blur"] != "12px" ||
+		shadow["color"].(map[string]interface{})["raw"] != "rgba(0, 0, 0, .2)" {
+		t.Errorf("unexpected box shadow %v", shadow)
+	}
+	transform := settings["_transform"].(map[string]interface{})
+	if transform["translateY"] != "-50%" || transform["rotateZ"] != "45deg" || transform["scaleX"] != "1.1" || transform["scaleY"] != "1.1" {
+		t.Errorf("unexpected transform %v", transform)
+	}
+	if settings["_cssTransition"] != "all .3s ease" || settings["_flexWrap"] != "wrap" || settings["_gridArea"] != "main" {
+		t.Errorf("expected plain settings, got %v", settings)
+	}
+	if settings["_flexGrow"] != "1" || settings["_flexShrink"] != "0" || settings["_flexBasis"] != "200px" {
+		t.Errorf("unexpected flex %v", settings)
+	}
+	typo := settings["_typography"].(map[string]interface{})
+	if typo["font-style"] != "italic" || typo["font-weight"] != "700" || typo["font-size"] != "1.25rem" ||
+		typo["line-height"] != "1.4" || typo["font-family"] != "Inter, sans-serif" || typo["text-decoration"] != "underline" {
+		t.Errorf("unexpected typography %v", typo)
+	}
+	bg := settings["_background"].(map[string]interface{})
+	if bg["color"].(map[string]interface{})["raw"] != "#111" || bg["image"].(map[string]interface{})["url"] != "/bg.jpg" ||
+		bg["repeat"] != "no-repeat" || bg["position"] != "center" || bg["size"] != "cover" {
+		t.Errorf("unexpected background %v", bg)
+	}
+	if settings["_top"] != "0" || settings["_right"] != "auto" || settings["_left"] != "auto" {
+		t.Errorf("unexpected inset %v", settings)
+	}
+
+	var props []string
+	for _, d := range unmapped {
+		props = append(props, d.Property)
+	}
+	if strings.Join(props, ",") != "clip-path,filter" {
+		t.Errorf("expected clip-path and filter reported unmapped, got %v", props)
+	}
+}
+
+func TestParseStyles_Gradients(t *testing.T) {
+	settings, unmapped := ParseStyles("background-image: linear-gradient(to right, var(--primary), #fff 80%)")
+	gradient, _ := settings["_gradient"].(map[string]interface{})
+	colors, _ := gradient["colors"].([]interface{})
+	if len(unmapped) != 0 || gradient["angle"] != "90" || len(colors) != 2 ||
+		colors[1].(map[string]interface{})["stop"] != "80" {
+		t.Errorf("unexpected gradient %v", gradient)
+	}
+
+	for _, style := range []string{
+		"background: url(a.png), url(b.png)",
+		"background-image: radial-gradient(circle at top, red, blue)",
+		"box-shadow: 0 1px red, 0 2px blue",
+		"transform: matrix(1, 0, 0, 1, 0, 0)",
+		"font: menu",
+	} {
+		if _, unmapped := ParseStyles(style); len(unmapped) != 1 {
+			t.Errorf("expected %q reported unmapped", style)
+		}
+	}
+}
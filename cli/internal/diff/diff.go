@@ -0,0 +1,366 @@
+// Package diff computes semantic differences between two Bricks element lists.
+package diff
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change types.
+const (
+	Added      = "added"
+	Removed    = "removed"
+	Moved      = "moved"      // same parent, different position among siblings
+	Reparented = "reparented" // different parent
+	Modified   = "modified"   // fields or settings changed only
+)
+
+// Change describes how one element differs between the two lists. A moved or
+// reparented element may also carry key changes.
+type Change struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	FromParent string      `json:"fromParent,omitempty"`
+	ToParent   string      `json:"toParent,omitempty"`
+	Keys       []KeyChange `json:"keys,omitempty"`
+}
+
+// KeyChange is one changed value. Path is dotted, e.g. "label",
+// "settings.text" or "settings._typography.font-size". From is omitted when
+// the key was added and To when it was removed. For _cssGlobalClasses,
+// Added and Removed list the class IDs that differ, ignoring order.
+type KeyChange struct {
+	Path    string      `json:"path"`
+	From    interface{} `json:"from,omitempty"`
+	To      interface{} `json:"to,omitempty"`
+	Added   []string    `json:"added,omitempty"`
+	Removed []string    `json:"removed,omitempty"`
+}
+
+// Summary counts changes by type.
+type Summary struct {
+	Added      int `json:"added"`
+	Removed    int `json:"removed"`
+	Moved      int `json:"moved"`
+	Reparented int `json:"reparented"`
+	Modified   int `json:"modified"`
+}
+
+// Result is the difference from list A to list B.
+type Result struct {
+	Summary Summary  `json:"summary"`
+	Changes []Change `json:"changes"`
+
+	to map[string]map[string]interface{}
+}
+
+// Empty reports whether the lists are equivalent.
+func (r *Result) Empty() bool {
+	return len(r.Changes) == 0
+}
+
+// Compare returns the changes that turn a into b. Elements are matched by ID.
+func Compare(a, b []map[string]interface{}) *Result {
+	aByID := index(a)
+	bByID := index(b)
+	moved := movedIDs(a, b, aByID, bByID)
+	res := &Result{Changes: []Change{}, to: bByID}
+
+	for _, el := range b {
+		id := idOf(el)
+		old, ok := aByID[id]
+		if !ok {
+			res.Changes = append(res.Changes, Change{ID: id, Name: nameOf(el), Type: Added, ToParent: parentOf(el)})
+			res.Summary.Added++
+			continue
+		}
+
+		ch := Change{ID: id, Name: nameOf(el), Keys: compareElements(old, el)}
+		switch {
+		case parentOf(old) != parentOf(el):
+			ch.Type = Reparented
+			ch.FromParent, ch.ToParent = parentOf(old), parentOf(el)
+			res.Summary.Reparented++
+		case moved[id]:
+			ch.Type = Moved
+			res.Summary.Moved++
+		case len(ch.Keys) > 0:
+			ch.Type = Modified
+			res.Summary.Modified++
+		default:
+			continue
+		}
+		res.Changes = append(res.Changes, ch)
+	}
+
+	for _, el := range a {
+		if _, ok := bByID[idOf(el)]; !ok {
+			res.Changes = append(res.Changes, Change{ID: idOf(el), Name: nameOf(el), Type: Removed, FromParent: parentOf(el)})
+			res.Summary.Removed++
+		}
+	}
+	return res
+}
+
+// Patches returns PatchElements payloads that apply the changes to elements
+// present in both lists. Nested settings are sent whole at their top-level
+// key, since the API merges settings one level deep. Added and removed
+// elements cannot be expressed as patches and are not included.
+func (r *Result) Patches() []map[string]interface{} {
+	patches := []map[string]interface{}{}
+	for _, ch := range r.Changes {
+		if ch.Type == Added || ch.Type == Removed || len(ch.Keys) == 0 {
+			continue
+		}
+		el := r.to[ch.ID]
+		patch := map[string]interface{}{"id": ch.ID}
+		settings := map[string]interface{}{}
+		newSettings, _ := el["settings"].(map[string]interface{})
+		for _, k := range ch.Keys {
+			parts := strings.SplitN(k.Path, ".", 3)
+			if parts[0] == "settings" && len(parts) > 1 {
+				settings[parts[1]] = newSettings[parts[1]]
+				continue
+			}
+			patch[parts[0]] = el[parts[0]]
+		}
+		if len(settings) > 0 {
+			patch["settings"] = settings
+		}
+		patches = append(patches, patch)
+	}
+	return patches
+}
+
+func compareElements(a, b map[string]interface{}) []KeyChange {
+	var out []KeyChange
+	for _, k := range unionKeys(a, b) {
+		if k == "id" {
+			continue
+		}
+		out = compareValues(out, k, k, a[k], b[k], hasKey(a, k), hasKey(b, k))
+	}
+	return out
+}
+
+func compareValues(out []KeyChange, path, key string, from, to interface{}, hasFrom, hasTo bool) []KeyChange {
+	if hasFrom && hasTo && reflect.DeepEqual(from, to) {
+		return out
+	}
+	if !hasFrom {
+		return append(out, KeyChange{Path: path, To: to})
+	}
+	if !hasTo {
+		return append(out, KeyChange{Path: path, From: from})
+	}
+
+	if key == "_cssGlobalClasses" {
+		if fromList, ok := stringList(from); ok {
+			if toList, ok := stringList(to); ok {
+				added, removed := setDiff(fromList, toList)
+				if len(added) == 0 && len(removed) == 0 {
+					return out
+				}
+				return append(out, KeyChange{Path: path, From: from, To: to, Added: added, Removed: removed})
+			}
+		}
+	}
+
+	fromMap, ok1 := from.(map[string]interface{})
+	toMap, ok2 := to.(map[string]interface{})
+	if ok1 && ok2 {
+		for _, k := range unionKeys(fromMap, toMap) {
+			out = compareValues(out, path+"."+k, k, fromMap[k], toMap[k], hasKey(fromMap, k), hasKey(toMap, k))
+		}
+		return out
+	}
+	return append(out, KeyChange{Path: path, From: from, To: to})
+}
+
+// movedIDs finds elements that kept their parent but changed position
+// relative to their siblings. Siblings that keep their relative order (the
+// longest common subsequence) are not reported, so an insertion does not mark
+// everything after it as moved.
+func movedIDs(a, b []map[string]interface{}, aByID, bByID map[string]map[string]interface{}) map[string]bool {
+	moved := map[string]bool{}
+	aOrder := siblingOrder(a)
+	bOrder := siblingOrder(b)
+	for parent, aList := range aOrder {
+		bList, ok := bOrder[parent]
+		if !ok {
+			continue
+		}
+		stay := func(id string) bool {
+			aEl, bEl := aByID[id], bByID[id]
+			return aEl != nil && bEl != nil && parentOf(aEl) == parent && parentOf(bEl) == parent
+		}
+		aCommon := filter(aList, stay)
+		bCommon := filter(bList, stay)
+		keep := map[string]bool{}
+		for _, id := range lcs(aCommon, bCommon) {
+			keep[id] = true
+		}
+		for _, id := range bCommon {
+			if !keep[id] {
+				moved[id] = true
+			}
+		}
+	}
+	return moved
+}
+
+// siblingOrder maps each parent ID to its ordered child IDs. Root elements
+// are ordered by their position in the flat list.
+func siblingOrder(elements []map[string]interface{}) map[string][]string {
+	order := map[string][]string{}
+	for _, el := range elements {
+		id := idOf(el)
+		if parentOf(el) == "0" {
+			order["0"] = append(order["0"], id)
+		}
+		if list, ok := stringList(el["children"]); ok {
+			order[id] = list
+		}
+	}
+	return order
+}
+
+func lcs(a, b []string) []string {
+	n, m := len(a), len(b)
+	dp := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else if dp[i+1][j] >= dp[i][j+1] {
+				dp[i][j] = dp[i+1][j]
+			} else {
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+	var out []string
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, a[i])
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return out
+}
+
+func setDiff(from, to []string) (added, removed []string) {
+	inFrom := map[string]bool{}
+	for _, s := range from {
+		inFrom[s] = true
+	}
+	inTo := map[string]bool{}
+	for _, s := range to {
+		inTo[s] = true
+		if !inFrom[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range from {
+		if !inTo[s] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+func stringList(v interface{}) ([]string, bool) {
+	switch t := v.(type) {
+	case []string:
+		return t, true
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, x := range t {
+			s, ok := x.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func filter(list []string, keep func(string) bool) []string {
+	var out []string
+	for _, s := range list {
+		if keep(s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []map[string]interface{}{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func hasKey(m map[string]interface{}, k string) bool {
+	_, ok := m[k]
+	return ok
+}
+
+func index(elements []map[string]interface{}) map[string]map[string]interface{} {
+	m := make(map[string]map[string]interface{}, len(elements))
+	for _, el := range elements {
+		if id := idOf(el); id != "" {
+			m[id] = el
+		}
+	}
+	return m
+}
+
+func idOf(el map[string]interface{}) string {
+	id, _ := el["id"].(string)
+	return id
+}
+
+func nameOf(el map[string]interface{}) string {
+	name, _ := el["name"].(string)
+	return name
+}
+
+// parentOf normalises the parent field; Bricks stores root parents as "0" or 0.
+func parentOf(el map[string]interface{}) string {
+	switch p := el["parent"].(type) {
+	case string:
+		if p == "" {
+			return "0"
+		}
+		return p
+	case float64:
+		if p != 0 {
+			return strconv.FormatFloat(p, 'f', -1, 64)
+		}
+	}
+	return "0"
+}
@@ -0,0 +1,163 @@
+package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/diff"
+)
+
+func tree() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"id": "sec001", "name": "section", "parent": "0", "children": []interface{}{"a00001", "b00001", "c00001"}, "settings": map[string]interface{}{}},
+		{"id": "a00001", "name": "heading", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{
+			"text":              "Hello",
+			"_typography":       map[string]interface{}{"font-size": "2rem", "font-weight": "700"},
+			"_cssGlobalClasses": []interface{}{"cls1", "cls2"},
+		}},
+		{"id": "b00001", "name": "text-basic", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{"text": "Body"}},
+		{"id": "c00001", "name": "button", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{}},
+		{"id": "sec002", "name": "section", "parent": "0", "children": []interface{}{}, "settings": map[string]interface{}{}},
+	}
+}
+
+func byID(changes []diff.Change, id string) *diff.Change {
+	for i := range changes {
+		if changes[i].ID == id {
+			return &changes[i]
+		}
+	}
+	return nil
+}
+
+func TestCompareIdentical(t *testing.T) {
+	if res := diff.Compare(tree(), tree()); !res.Empty() {
+		t.Fatalf("expected no changes, got %+v", res.Changes)
+	}
+}
+
+func TestCompareClassOrderIgnored(t *testing.T) {
+	b := tree()
+	b[1]["settings"].(map[string]interface{})["_cssGlobalClasses"] = []interface{}{"cls2", "cls1"}
+	if res := diff.Compare(tree(), b); !res.Empty() {
+		t.Fatalf("class reorder should not be a change, got %+v", res.Changes)
+	}
+}
+
+func TestCompareNestedSettings(t *testing.T) {
+	b := tree()
+	s := b[1]["settings"].(map[string]interface{})
+	s["_typography"] = map[string]interface{}{"font-size": "3rem", "font-weight": "700"}
+	s["_cssGlobalClasses"] = []interface{}{"cls1", "cls3"}
+	s["_padding"] = map[string]interface{}{"top": "10px"}
+
+	res := diff.Compare(tree(), b)
+	ch := byID(res.Changes, "a00001")
+	if ch == nil || ch.Type != diff.Modified {
+		t.Fatalf("expected a00001 modified, got %+v", res.Changes)
+	}
+
+	paths := map[string]diff.KeyChange{}
+	for _, k := range ch.Keys {
+		paths[k.Path] = k
+	}
+	if k, ok := paths["settings._typography.font-size"]; !ok || k.From != "2rem" || k.To != "3rem" {
+		t.Errorf("expected nested font-size change, got %+v", ch.Keys)
+	}
+	if _, ok := paths["settings._typography.font-weight"]; ok {
+		t.Error("unchanged nested key reported")
+	}
+	if k := paths["settings._cssGlobalClasses"]; len(k.Added) != 1 || k.Added[0] != "cls3" || len(k.Removed) != 1 || k.Removed[0] != "cls2" {
+		t.Errorf("unexpected class change: %+v", k)
+	}
+	if k, ok := paths["settings._padding"]; !ok || k.From != nil {
+		t.Errorf("expected added _padding, got %+v", k)
+	}
+
+	patches := res.Patches()
+	if len(patches) != 1 {
+		t.Fatalf("expected 1 patch, got %v", patches)
+	}
+	ps := patches[0]["settings"].(map[string]interface{})
+	typo, ok := ps["_typography"].(map[string]interface{})
+	if !ok || typo["font-size"] != "3rem" || typo["font-weight"] != "700" {
+		t.Errorf("nested setting should be patched whole, got %v", ps["_typography"])
+	}
+	if _, ok := ps["text"]; ok {
+		t.Error("unchanged setting included in patch")
+	}
+}
+
+func TestCompareStructure(t *testing.T) {
+	b := tree()
+	// Move c00001 to the front, reparent b00001 into sec002, add d00001, remove nothing.
+	b[0]["children"] = []interface{}{"c00001", "a00001"}
+	b[2]["parent"] = "sec002"
+	b[4]["children"] = []interface{}{"b00001", "d00001"}
+	b = append(b, map[string]interface{}{"id": "d00001", "name": "image", "parent": "sec002", "children": []interface{}{}, "settings": map[string]interface{}{}})
+
+	res := diff.Compare(tree(), b)
+	if ch := byID(res.Changes, "b00001"); ch == nil || ch.Type != diff.Reparented || ch.FromParent != "sec001" || ch.ToParent != "sec002" {
+		t.Errorf("expected b00001 reparented, got %+v", ch)
+	}
+	if ch := byID(res.Changes, "d00001"); ch == nil || ch.Type != diff.Added {
+		t.Errorf("expected d00001 added, got %+v", ch)
+	}
+	moved := byID(res.Changes, "c00001")
+	stayed := byID(res.Changes, "a00001")
+	if (moved == nil || moved.Type != diff.Moved) && (stayed == nil || stayed.Type != diff.Moved) {
+		t.Errorf("expected a reorder between a00001 and c00001, got %+v", res.Changes)
+	}
+	if res.Summary.Added != 1 || res.Summary.Reparented != 1 || res.Summary.Moved != 1 {
+		t.Errorf("unexpected summary %+v", res.Summary)
+	}
+
+	// Removing an element leaves later siblings in place.
+	c := tree()
+	c[0]["children"] = []interface{}{"b00001", "c00001"}
+	c = append(c[:1], c[2:]...)
+	res = diff.Compare(tree(), c)
+	if res.Summary.Removed != 1 || res.Summary.Moved != 0 {
+		t.Errorf("expected 1 removed and no moves, got %+v", res.Summary)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	b := tree()
+	b[2]["settings"] = map[string]interface{}{"text": "Changed"}
+
+	out := diff.Unified(tree(), b, "a.json", "b.json")
+	if !strings.HasPrefix(out, "--- a.json\n+++ b.json\n@@ ") {
+		t.Fatalf("unexpected header:\n%s", out)
+	}
+	if !strings.Contains(out, `-    "text": "Body"`) || !strings.Contains(out, `+    "text": "Changed"`) {
+		t.Errorf("missing changed lines:\n%s", out)
+	}
+	if diff.Unified(tree(), tree(), "a", "b") != "" {
+		t.Error("expected empty output for identical trees")
+	}
+}
+
+func TestUnifiedLargePage(t *testing.T) {
+	// 20,000 elements are about 200,000 lines a side; a quadratic table
+	// would need hundreds of gigabytes
+	page := func(edit string) []map[string]interface{} {
+		var els []map[string]interface{}
+		for i := 0; i < 20000; i++ {
+			text := fmt.Sprintf("Paragraph %d", i)
+			if i%2000 == 1000 {
+				text = fmt.Sprintf("%s %d", edit, i)
+			}
+			els = append(els, map[string]interface{}{
+				"id": fmt.Sprintf("el%05d", i), "name": "text-basic", "parent": "0", "children": []interface{}{},
+				"settings": map[string]interface{}{"text": text, "_margin": map[string]interface{}{"top": "1rem"}},
+			})
+		}
+		return els
+	}
+	out := diff.Unified(page("Before"), page("After"), "a", "b")
+	if !strings.Contains(out, `-    "text": "Before 19000"`) || !strings.Contains(out, `+    "text": "After 19000"`) || strings.Count(out, "@@ -") != 10 {
+		t.Errorf("expected a hunk per edit, got:\n%s", out)
+	}
+}
@@ -0,0 +1,291 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each hunk.
+const contextLines = 3
+
+// Unified renders a unified diff of the two lists. Each list is laid out in
+// tree order with one indented JSON object per element and sorted keys, so
+// the text is stable regardless of the flat order or key order of the input.
+func Unified(a, b []map[string]interface{}, fromLabel, toLabel string) string {
+	aLines := canonicalLines(a)
+	bLines := canonicalLines(b)
+	ops := lineOps(aLines, bLines)
+
+	var sb strings.Builder
+	hunks := groupHunks(ops)
+	if len(hunks) == 0 {
+		return ""
+	}
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromLabel, toLabel)
+	for _, h := range hunks {
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(h.aStart, h.aLen), hunkRange(h.bStart, h.bLen))
+		for _, op := range h.ops {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// canonicalLines lays out elements depth-first from the roots, then any
+// elements not reachable from a root, in flat order.
+func canonicalLines(elements []map[string]interface{}) []string {
+	byID := index(elements)
+	visited := map[string]bool{}
+	var lines []string
+
+	var walk func(el map[string]interface{})
+	walk = func(el map[string]interface{}) {
+		id := idOf(el)
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		data, _ := json.MarshalIndent(el, "", "  ")
+		lines = append(lines, strings.Split(string(data), "\n")...)
+		children, _ := stringList(el["children"])
+		for _, c := range children {
+			if child, ok := byID[c]; ok {
+				walk(child)
+			}
+		}
+	}
+
+	for _, el := range elements {
+		if parentOf(el) == "0" {
+			walk(el)
+		}
+	}
+	for _, el := range elements {
+		walk(el)
+	}
+	return lines
+}
+
+type lineOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+	a, b int // line numbers (0-based) in a and b before this op
+}
+
+// lineOps diffs a and b with Myers' algorithm, bisecting on the middle
+// snake so memory stays linear in the input. Common leading and trailing
+// lines are trimmed first, so a small edit to a large page is cheap.
+func lineOps(a, b []string) []lineOp {
+	// Compare interned line numbers rather than strings
+	ids := map[string]int{}
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, l := range lines {
+			id, ok := ids[l]
+			if !ok {
+				id = len(ids)
+				ids[l] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	d := &differ{a: a, b: b, x: intern(a), y: intern(b)}
+	d.compare(0, len(a), 0, len(b))
+	return d.ops
+}
+
+// differ emits the ops for a and b in order; i and j are the lines of a
+// and b emitted so far.
+type differ struct {
+	a, b []string
+	x, y []int
+	ops  []lineOp
+	i, j int
+}
+
+func (d *differ) equal(n int) {
+	for ; n > 0; n-- {
+		d.ops = append(d.ops, lineOp{' ', d.a[d.i], d.i, d.j})
+		d.i++
+		d.j++
+	}
+}
+
+func (d *differ) remove(n int) {
+	for ; n > 0; n-- {
+		d.ops = append(d.ops, lineOp{'-', d.a[d.i], d.i, d.j})
+		d.i++
+	}
+}
+
+func (d *differ) insert(n int) {
+	for ; n > 0; n-- {
+		d.ops = append(d.ops, lineOp{'+', d.b[d.j], d.i, d.j})
+		d.j++
+	}
+}
+
+// compare emits the ops turning a[a0:a1] into b[b0:b1].
+func (d *differ) compare(a0, a1, b0, b1 int) {
+	prefix := 0
+	for a0+prefix < a1 && b0+prefix < b1 && d.x[a0+prefix] == d.y[b0+prefix] {
+		prefix++
+	}
+	d.equal(prefix)
+	a0, b0 = a0+prefix, b0+prefix
+	suffix := 0
+	for a1-suffix > a0 && b1-suffix > b0 && d.x[a1-1-suffix] == d.y[b1-1-suffix] {
+		suffix++
+	}
+	a1, b1 = a1-suffix, b1-suffix
+
+	switch {
+	case a0 == a1:
+		d.insert(b1 - b0)
+	case b0 == b1:
+		d.remove(a1 - a0)
+	default:
+		// A split at a corner would not shrink the problem
+		if x, y, ok := d.bisect(a0, a1, b0, b1); ok && !(x == a0 && y == b0) && !(x == a1 && y == b1) {
+			d.compare(a0, x, b0, y)
+			d.compare(x, a1, y, b1)
+		} else {
+			d.remove(a1 - a0)
+			d.insert(b1 - b0)
+		}
+	}
+	d.equal(suffix)
+}
+
+// bisect finds where a shortest edit script for a[a0:a1] and b[b0:b1]
+// crosses the middle, searching forward from the start and backward from
+// the end at once (Myers 1986, section 4b).
+func (d *differ) bisect(a0, a1, b0, b1 int) (int, int, bool) {
+	n, m := a1-a0, b1-b0
+	maxD := (n + m + 1) / 2
+	off := maxD + 1
+	vf := make([]int, 2*off+1)
+	vb := make([]int, 2*off+1)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[off+1], vb[off+1] = 0, 0
+	delta := n - m
+	odd := delta%2 != 0
+	// Diagonals that ran off the edges are skipped from then on
+	var fStart, fEnd, bStart, bEnd int
+	for k := 0; k < maxD; k++ {
+		for kf := -k + fStart; kf <= k-fEnd; kf += 2 {
+			var x int
+			if kf == -k || (kf != k && vf[off+kf-1] < vf[off+kf+1]) {
+				x = vf[off+kf+1]
+			} else {
+				x = vf[off+kf-1] + 1
+			}
+			y := x - kf
+			for x < n && y < m && d.x[a0+x] == d.y[b0+y] {
+				x++
+				y++
+			}
+			vf[off+kf] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if kb := delta - kf; kb >= -off && kb <= off && vb[off+kb] != -1 && x >= n-vb[off+kb] {
+					return a0 + x, b0 + y, true
+				}
+			}
+		}
+		for kb := -k + bStart; kb <= k-bEnd; kb += 2 {
+			var x int
+			if kb == -k || (kb != k && vb[off+kb-1] < vb[off+kb+1]) {
+				x = vb[off+kb+1]
+			} else {
+				x = vb[off+kb-1] + 1
+			}
+			y := x - kb
+			for x < n && y < m && d.x[a1-1-x] == d.y[b1-1-y] {
+				x++
+				y++
+			}
+			vb[off+kb] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if kf := delta - kb; kf >= -off && kf <= off && vf[off+kf] != -1 {
+					fx := vf[off+kf]
+					if fx >= n-x {
+						return a0 + fx, b0 + fx - kf, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+type hunk struct {
+	aStart, aLen int
+	bStart, bLen int
+	ops          []lineOp
+}
+
+func groupHunks(ops []lineOp) []hunk {
+	var hunks []hunk
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+		// Extend while the next change is within two context windows.
+		end := i
+		for k := i; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				end = k
+			} else if k-end > 2*contextLines {
+				break
+			}
+		}
+		stop := end + contextLines + 1
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		h := hunk{aStart: ops[start].a, bStart: ops[start].b, ops: ops[start:stop]}
+		for _, op := range h.ops {
+			if op.kind != '+' {
+				h.aLen++
+			}
+			if op.kind != '-' {
+				h.bLen++
+			}
+		}
+		hunks = append(hunks, h)
+		i = stop
+	}
+	return hunks
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
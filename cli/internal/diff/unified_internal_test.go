@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// lcsLen is the textbook quadratic LCS, to check lineOps finds a shortest
+// edit script.
+func lcsLen(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				dp[i][j] = dp[i+1][j+1] + 1
+			case dp[i+1][j] >= dp[i][j+1]:
+				dp[i][j] = dp[i+1][j]
+			default:
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func TestLineOpsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "c", "d"}
+	random := func() []string {
+		out := make([]string, rng.Intn(30))
+		for i := range out {
+			out[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return out
+	}
+	for n := 0; n < 500; n++ {
+		a, b := random(), random()
+		ops := lineOps(a, b)
+		var gotA, gotB []string
+		same := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				if op.a != len(gotA) {
+					t.Fatalf("%v -> %v: op %+v has the wrong a line", a, b, op)
+				}
+				gotA = append(gotA, op.text)
+			}
+			if op.kind != '-' {
+				if op.b != len(gotB) {
+					t.Fatalf("%v -> %v: op %+v has the wrong b line", a, b, op)
+				}
+				gotB = append(gotB, op.text)
+			}
+			if op.kind == ' ' {
+				same++
+			}
+		}
+		if len(gotA) != len(a) || len(gotB) != len(b) {
+			t.Fatalf("%v -> %v: ops don't cover both sides: %+v", a, b, ops)
+		}
+		for i := range a {
+			if gotA[i] != a[i] {
+				t.Fatalf("%v -> %v: ops don't rebuild a: %v", a, b, gotA)
+			}
+		}
+		for i := range b {
+			if gotB[i] != b[i] {
+				t.Fatalf("%v -> %v: ops don't rebuild b: %v", a, b, gotB)
+			}
+		}
+		if want := lcsLen(a, b); same != want {
+			t.Fatalf("%v -> %v: kept %d lines, the LCS has %d", a, b, same, want)
+		}
+	}
+}
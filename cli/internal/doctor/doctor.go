@@ -14,7 +14,8 @@ type Issue struct {
 
 // Report holds all issues found during a health check.
 type Report struct {
-	Issues  []Issue `json:"issues"`
+	Site    string         `json:"site,omitempty"` // active site profile, set by the caller
+	Issues  []Issue        `json:"issues"`
 	Summary map[string]int `json:"summary"` // severity -> count
 }
 
@@ -0,0 +1,128 @@
+// Package elementid generates Bricks element IDs: random, or derived from a
+// seed so the same input converts to the same IDs every time.
+package elementid
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Strategy selects how new IDs are made.
+type Strategy string
+
+const (
+	// Random IDs are 6 random hex characters.
+	Random Strategy = "random"
+	// Hash IDs are the first 6 hex characters of the SHA-256 of a seed
+	// built from the element's position and content.
+	Hash Strategy = "hash"
+	// Explicit takes IDs from the input (data-bricks-id or id attributes,
+	// template element IDs) where they are valid and unused, and falls back
+	// to Hash.
+	Explicit Strategy = "explicit"
+)
+
+// Strategies lists the valid strategies.
+var Strategies = []Strategy{Random, Hash, Explicit}
+
+// ParseStrategy parses a strategy name; "" means Random.
+func ParseStrategy(s string) (Strategy, error) {
+	if s == "" {
+		return Random, nil
+	}
+	for _, st := range Strategies {
+		if string(st) == s {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("unknown ID strategy %q (want random, hash or explicit)", s)
+}
+
+// Pattern is the form of a Bricks element ID: 6 to 10 lower-case letters
+// and digits. The AI patch schema requires the same for node IDs.
+var Pattern = regexp.MustCompile(`^[a-z0-9]{6,10}$`)
+
+// Valid reports whether id can be used as a Bricks element ID.
+func Valid(id string) bool {
+	return Pattern.MatchString(id)
+}
+
+// Generator hands out IDs that are unique among the IDs it has made and
+// the ones reserved with Reserve.
+type Generator struct {
+	strategy Strategy
+	used     map[string]bool
+}
+
+// New returns a generator for strategy; "" means Random.
+func New(strategy Strategy) *Generator {
+	if strategy == "" {
+		strategy = Random
+	}
+	return &Generator{strategy: strategy, used: make(map[string]bool)}
+}
+
+// Strategy returns the generator's strategy.
+func (g *Generator) Strategy() Strategy {
+	return g.strategy
+}
+
+// Reserve marks ids as taken, e.g. the IDs already on the page elements
+// are appended to.
+func (g *Generator) Reserve(ids ...string) {
+	for _, id := range ids {
+		if id != "" {
+			g.used[id] = true
+		}
+	}
+}
+
+// Used reports whether id is taken.
+func (g *Generator) Used(id string) bool {
+	return g.used[id]
+}
+
+// Claim takes id if it is valid and unused, reporting whether it did.
+func (g *Generator) Claim(id string) bool {
+	if !Valid(id) || g.used[id] {
+		return false
+	}
+	g.used[id] = true
+	return true
+}
+
+// Next returns a new unused ID. seed is only read by Hash and Explicit;
+// on a collision the seed is re-hashed with a counter, so the result is
+// still deterministic.
+func (g *Generator) Next(seed string) string {
+	if g.strategy == Random {
+		for {
+			b := make([]byte, 3)
+			io.ReadFull(rand.Reader, b)
+			if id := hex.EncodeToString(b); g.Claim(id) {
+				return id
+			}
+		}
+	}
+	for n := 0; ; n++ {
+		s := seed
+		if n > 0 {
+			s = fmt.Sprintf("%s#%d", seed, n)
+		}
+		sum := sha256.Sum256([]byte(s))
+		if id := hex.EncodeToString(sum[:3]); g.Claim(id) {
+			return id
+		}
+	}
+}
+
+// Seed joins seed parts with a separator that can't be confused with the
+// parts' own content.
+func Seed(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,54 @@
+package elementid_test
+
+import (
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/elementid"
+)
+
+func TestHashIsDeterministic(t *testing.T) {
+	a, b := elementid.New(elementid.Hash), elementid.New(elementid.Hash)
+	for _, seed := range []string{"one", "two", "one"} {
+		if x, y := a.Next(seed), b.Next(seed); x != y || len(x) != 6 {
+			t.Errorf("expected the same 6-char ID for %q, got %q and %q", seed, x, y)
+		}
+	}
+}
+
+func TestNextAvoidsReserved(t *testing.T) {
+	g := elementid.New(elementid.Hash)
+	first := elementid.New(elementid.Hash).Next("seed")
+	g.Reserve(first)
+	if id := g.Next("seed"); id == first {
+		t.Errorf("expected a different ID than the reserved %q", first)
+	}
+
+	r := elementid.New(elementid.Random)
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		id := r.Next("")
+		if seen[id] {
+			t.Fatalf("random ID %q repeated", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestClaim(t *testing.T) {
+	g := elementid.New(elementid.Explicit)
+	if !g.Claim("hero01") || g.Claim("hero01") {
+		t.Error("expected a free ID to be claimed once")
+	}
+	if g.Claim("Not-Valid") || g.Claim("hero") || g.Claim("abcdefghijk") || g.Claim("") {
+		t.Error("expected invalid IDs refused")
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	if s, err := elementid.ParseStrategy(""); err != nil || s != elementid.Random {
+		t.Errorf("expected random by default, got %q %v", s, err)
+	}
+	if _, err := elementid.ParseStrategy("sequential"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
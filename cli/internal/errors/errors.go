@@ -3,11 +3,14 @@ package errors
 import "fmt"
 
 // CLIError is a structured error with a machine-readable code and exit code.
+// Details carries optional structured data for JSON output, such as the
+// candidates for an ambiguous page reference.
 type CLIError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Hint    string `json:"hint,omitempty"`
-	Exit    int    `json:"-"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Hint    string      `json:"hint,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+	Exit    int         `json:"-"`
 }
 
 func (e *CLIError) Error() string {
@@ -44,6 +47,8 @@ func FromHTTPStatus(status int, body string) *CLIError {
 		return APIError("API_NOT_FOUND", fmt.Sprintf("HTTP 404: %s", body))
 	case 409:
 		return ConflictError(fmt.Sprintf("HTTP 409: %s", body))
+	case 429:
+		return APIError("API_RATE_LIMITED", fmt.Sprintf("HTTP 429: %s", body))
 	default:
 		if status >= 500 {
 			return APIError("API_SERVER_ERROR", fmt.Sprintf("HTTP %d: %s", status, body))
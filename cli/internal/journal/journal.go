@@ -0,0 +1,133 @@
+// Package journal keeps the local undo journal: one JSON line per page write
+// made by a mutating command, pointing at the server snapshot taken just
+// before the write.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxEntries is how many entries the journal keeps across all pages.
+const maxEntries = 200
+
+// maxPerPage is how many entries the journal keeps for one page. The plugin
+// only keeps the last 10 snapshots per page, so older entries could not be
+// undone.
+const maxPerPage = 10
+
+// Entry records one page write.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Site       string    `json:"site"`
+	PageID     int       `json:"pageId"`
+	PrevHash   string    `json:"prevHash"`
+	NewHash    string    `json:"newHash"`
+	SnapshotID string    `json:"snapshotId"`
+	Command    string    `json:"command"`
+}
+
+// Journal is a JSON Lines file of entries, oldest first.
+type Journal struct {
+	Path string
+}
+
+// Entries returns all entries, oldest first. A missing journal is empty.
+// Lines that do not parse are skipped.
+func (j Journal) Entries() ([]Entry, error) {
+	data, err := os.ReadFile(j.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err == nil && e.PageID > 0 {
+			entries = append(entries, e)
+		}
+	}
+	return entries, sc.Err()
+}
+
+// Append adds an entry, dropping the page's oldest beyond maxPerPage and the
+// oldest overall beyond maxEntries.
+func (j Journal) Append(e Entry) error {
+	entries, err := j.Entries()
+	if err != nil {
+		return err
+	}
+	entries = append(entries, e)
+	same := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Site != e.Site || entries[i].PageID != e.PageID {
+			continue
+		}
+		if same++; same > maxPerPage {
+			entries = append(entries[:i], entries[i+1:]...)
+		}
+	}
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	return j.write(entries)
+}
+
+// Recent returns up to n of the newest entries for a site, newest first.
+func (j Journal) Recent(site string, n int) ([]Entry, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for i := len(entries) - 1; i >= 0 && len(out) < n; i-- {
+		if entries[i].Site == site {
+			out = append(out, entries[i])
+		}
+	}
+	return out, nil
+}
+
+// Remove deletes the entry with e's site, page and snapshot ID.
+func (j Journal) Remove(e Entry) error {
+	entries, err := j.Entries()
+	if err != nil {
+		return err
+	}
+	kept := entries[:0]
+	for _, x := range entries {
+		if x.Site == e.Site && x.PageID == e.PageID && x.SnapshotID == e.SnapshotID {
+			continue
+		}
+		kept = append(kept, x)
+	}
+	return j.write(kept)
+}
+
+// write replaces the journal atomically.
+func (j Journal) write(entries []Entry) error {
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0700); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	tmp := j.Path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.Path)
+}
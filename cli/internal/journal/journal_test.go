@@ -0,0 +1,83 @@
+package journal_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/journal"
+)
+
+func TestAppendRecentRemove(t *testing.T) {
+	j := journal.Journal{Path: filepath.Join(t.TempDir(), "journal.jsonl")}
+
+	if entries, err := j.Entries(); err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty journal, got %v, %v", entries, err)
+	}
+
+	for i, e := range []journal.Entry{
+		{Site: "https://a.test", PageID: 1, SnapshotID: "snap_1", NewHash: "h1"},
+		{Site: "https://b.test", PageID: 2, SnapshotID: "snap_2", NewHash: "h2"},
+		{Site: "https://a.test", PageID: 3, SnapshotID: "snap_3", NewHash: "h3"},
+	} {
+		e.Time = time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC)
+		if err := j.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recent, err := j.Recent("https://a.test", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || recent[0].SnapshotID != "snap_3" || recent[1].SnapshotID != "snap_1" {
+		t.Fatalf("expected site a entries newest first, got %+v", recent)
+	}
+
+	if err := j.Remove(recent[0]); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := j.Entries()
+	if len(entries) != 2 || entries[1].SnapshotID != "snap_2" {
+		t.Errorf("expected snap_3 removed, got %+v", entries)
+	}
+}
+
+func TestEntriesSkipsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	os.WriteFile(path, []byte("not json\n{\"pageId\":7,\"snapshotId\":\"snap_7\"}\n\n"), 0600)
+
+	entries, err := journal.Journal{Path: path}.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].PageID != 7 {
+		t.Errorf("expected one valid entry, got %+v", entries)
+	}
+}
+
+func TestAppendKeepsPluginRetentionPerPage(t *testing.T) {
+	j := journal.Journal{Path: filepath.Join(t.TempDir(), "journal.jsonl")}
+	j.Append(journal.Entry{Site: "https://a.test", PageID: 2, SnapshotID: "other"})
+	for i := 1; i <= 12; i++ {
+		if err := j.Append(journal.Entry{Site: "https://a.test", PageID: 1, SnapshotID: fmt.Sprintf("snap_%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recent, _ := j.Recent("https://a.test", 20)
+	var page1 []string
+	for _, e := range recent {
+		if e.PageID == 1 {
+			page1 = append(page1, e.SnapshotID)
+		}
+	}
+	if len(page1) != 10 || page1[0] != "snap_12" || page1[9] != "snap_3" {
+		t.Errorf("expected the newest 10 entries for page 1, got %v", page1)
+	}
+	if len(recent) != 11 || recent[10].SnapshotID != "other" {
+		t.Errorf("expected other pages' entries kept, got %+v", recent)
+	}
+}
@@ -0,0 +1,360 @@
+// Package media localizes the remote images and videos an element tree
+// links to: it uploads them to the site's media library and rewrites the
+// settings to reference the attachments.
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+)
+
+// Library is the site's media library. *client.Client implements it.
+type Library interface {
+	ListMediaContext(ctx context.Context, search string) (*client.MediaListResponse, error)
+	UploadMediaContext(ctx context.Context, filePath string) (*client.MediaUploadResponse, error)
+}
+
+// DefaultConcurrency is how many files Localize handles at once.
+const DefaultConcurrency = 4
+
+// DefaultMaxBytes caps the size of each downloaded file.
+const DefaultMaxBytes = 50 << 20
+
+// Options configures Localize.
+type Options struct {
+	// Client downloads the files. Defaults to a client with a 60s timeout.
+	Client *http.Client
+	// Concurrency bounds the downloads and uploads in flight. Defaults to
+	// DefaultConcurrency.
+	Concurrency int
+	// MaxBytes caps each file; larger ones fail. Defaults to
+	// DefaultMaxBytes.
+	MaxBytes int64
+	// DryRun looks for files already in the library but uploads nothing
+	// and leaves the elements unchanged.
+	DryRun bool
+	// SiteURL is the site the library belongs to. Files already on it are
+	// left alone; files elsewhere are localized even when their settings
+	// carry an attachment ID, which then belongs to another site.
+	SiteURL string
+}
+
+// Asset statuses.
+const (
+	StatusUploaded = "uploaded"
+	StatusExisting = "existing"
+	StatusPlanned  = "planned"
+	StatusFailed   = "failed"
+)
+
+// Asset is one remote file and the attachment that replaced it.
+type Asset struct {
+	Source   string `json:"source"`
+	Status   string `json:"status"`
+	ID       int    `json:"id,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Filename string `json:"filename"`
+	Bytes    int64  `json:"bytes,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+	// Uses counts the settings that referenced Source.
+	Uses  int    `json:"uses"`
+	Error string `json:"error,omitempty"`
+}
+
+// Manifest maps each remote URL to its attachment.
+type Manifest struct {
+	Assets []*Asset `json:"assets"`
+}
+
+// Counts returns how many assets have each status.
+func (m *Manifest) Counts() map[string]int {
+	counts := map[string]int{}
+	for _, a := range m.Assets {
+		counts[a.Status]++
+	}
+	return counts
+}
+
+// use is one setting referencing a remote URL, with how to rewrite it.
+type use struct {
+	url     string
+	rewrite func(*Asset)
+}
+
+// Localize uploads the remote image and video files referenced by elements
+// and rewrites their settings in place to {id, url, filename, size}. Each
+// URL is handled once; a file whose content already exists in the library
+// reuses that attachment. Files that fail are reported in the
+// manifest and left as they were.
+func Localize(ctx context.Context, lib Library, elements []map[string]interface{}, opts Options) *Manifest {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 60 * time.Second}
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+
+	// Deduplicate in order of first use
+	siteHost := ""
+	if u, err := url.Parse(opts.SiteURL); err == nil {
+		siteHost = u.Hostname()
+	}
+	manifest := &Manifest{}
+	byURL := map[string]*Asset{}
+	var uses []use
+	for _, el := range elements {
+		for _, u := range collect(el, siteHost) {
+			a := byURL[u.url]
+			if a == nil {
+				a = &Asset{Source: u.url}
+				byURL[u.url] = a
+				manifest.Assets = append(manifest.Assets, a)
+			}
+			a.Uses++
+			uses = append(uses, u)
+		}
+	}
+
+	l := &localizer{lib: lib, opts: opts}
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for _, a := range manifest.Assets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(a *Asset) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := l.localize(ctx, a); err != nil {
+				a.Status, a.Error = StatusFailed, err.Error()
+			}
+		}(a)
+	}
+	wg.Wait()
+
+	for _, u := range uses {
+		if a := byURL[u.url]; a.ID != 0 && !opts.DryRun {
+			u.rewrite(a)
+		}
+	}
+	return manifest
+}
+
+// collect returns the remote media URLs in an element's settings: image
+// settings, background images (in every breakpoint and state) and video
+// files. Files on siteHost, dynamic data and data: URLs are left alone.
+func collect(el map[string]interface{}, siteHost string) []use {
+	settings, _ := el["settings"].(map[string]interface{})
+	if settings == nil {
+		return nil
+	}
+	var uses []use
+	image := func(img map[string]interface{}) {
+		if img == nil {
+			return
+		}
+		src, _ := img["url"].(string)
+		if !isRemote(src, siteHost) {
+			return
+		}
+		uses = append(uses, use{url: src, rewrite: func(a *Asset) {
+			img["id"] = a.ID
+			img["url"] = a.URL
+			img["filename"] = a.Filename
+			if img["size"] == nil {
+				img["size"] = "full"
+			}
+		}})
+	}
+
+	img, _ := settings["image"].(map[string]interface{})
+	image(img)
+	for key, v := range settings {
+		if key == "_background" || strings.HasPrefix(key, "_background:") {
+			bg, _ := v.(map[string]interface{})
+			img, _ := bg["image"].(map[string]interface{})
+			image(img)
+		}
+	}
+
+	if el["name"] == "video" {
+		if src, _ := settings["videoUrl"].(string); isRemote(src, siteHost) {
+			uses = append(uses, use{url: src, rewrite: func(a *Asset) {
+				delete(settings, "videoUrl")
+				settings["videoType"] = "media"
+				settings["media"] = map[string]interface{}{
+					"id":       a.ID,
+					"url":      a.URL,
+					"filename": a.Filename,
+				}
+			}})
+		}
+	}
+	return uses
+}
+
+// isRemote reports whether raw is an http(s) URL on a host other than
+// siteHost.
+func isRemote(raw, siteHost string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		!strings.EqualFold(u.Hostname(), siteHost)
+}
+
+type localizer struct {
+	lib  Library
+	opts Options
+}
+
+// localize downloads an asset and finds or uploads its attachment.
+func (l *localizer) localize(ctx context.Context, a *Asset) error {
+	dir, err := os.MkdirTemp("", "bricks-media-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	data, contentType, err := l.download(ctx, a.Source)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	a.SHA256 = hex.EncodeToString(sum[:])
+	a.Bytes = int64(len(data))
+	a.Filename = filename(a.Source, contentType)
+
+	existing, err := l.find(ctx, a)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		a.Status, a.ID, a.URL = StatusExisting, existing.ID, existing.URL
+		a.Filename = path.Base(existing.URL)
+		return nil
+	}
+	if l.opts.DryRun {
+		a.Status = StatusPlanned
+		return nil
+	}
+
+	file := filepath.Join(dir, a.Filename)
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		return err
+	}
+	uploaded, err := l.lib.UploadMediaContext(ctx, file)
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	a.Status, a.ID, a.URL = StatusUploaded, uploaded.ID, uploaded.URL
+	if uploaded.Filename != "" {
+		a.Filename = uploaded.Filename
+	}
+	return nil
+}
+
+// find returns the library item with the asset's content. Items with the
+// asset's filename are compared first, but a name alone is not a match:
+// a different file uploaded under the same name isn't reused.
+func (l *localizer) find(ctx context.Context, a *Asset) (*client.MediaItem, error) {
+	stem := strings.TrimSuffix(a.Filename, path.Ext(a.Filename))
+	list, err := l.lib.ListMediaContext(ctx, stem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the media library: %w", err)
+	}
+	var named, others []*client.MediaItem
+	for i, item := range list.Media {
+		if strings.EqualFold(path.Base(item.URL), a.Filename) {
+			named = append(named, &list.Media[i])
+		} else {
+			others = append(others, &list.Media[i])
+		}
+	}
+	for _, item := range append(named, others...) {
+		if item.URL == "" || (item.Filesize > 0 && item.Filesize != a.Bytes) {
+			continue
+		}
+		data, _, err := l.download(ctx, item.URL)
+		if err != nil {
+			continue
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) == a.SHA256 {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+func (l *localizer) download(ctx context.Context, rawURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", "agent-to-bricks")
+	resp, err := l.opts.Client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("download failed: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, l.opts.MaxBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("download failed: %w", err)
+	}
+	if int64(len(data)) > l.opts.MaxBytes {
+		return nil, "", fmt.Errorf("file is larger than %d bytes", l.opts.MaxBytes)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// extensions are the usual extensions for common media types, which
+// mime.ExtensionsByType doesn't rank.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg", "image/png": ".png", "image/gif": ".gif", "image/webp": ".webp",
+	"image/avif": ".avif", "image/svg+xml": ".svg", "video/mp4": ".mp4", "video/webm": ".webm",
+}
+
+// filename derives an upload filename from a URL's path, adding an
+// extension from the content type when the path has none.
+func filename(rawURL, contentType string) string {
+	name := "media"
+	if u, err := url.Parse(rawURL); err == nil {
+		if base, err := url.PathUnescape(path.Base(u.Path)); err == nil && base != "/" && base != "." {
+			name = base
+		}
+	}
+	name = strings.Trim(unsafeFilename.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		name = "media"
+	}
+	if path.Ext(name) == "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			if ext, ok := extensions[mediaType]; ok {
+				name += ext
+			} else if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+				name += exts[0]
+			}
+		}
+	}
+	return name
+}
@@ -0,0 +1,154 @@
+package media_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	"github.com/nerveband/agent-to-bricks/internal/media"
+)
+
+// fakeLibrary is a media library holding items, recording uploads.
+type fakeLibrary struct {
+	mu       sync.Mutex
+	items    []client.MediaItem
+	uploaded []string
+}
+
+func (l *fakeLibrary) ListMediaContext(ctx context.Context, search string) (*client.MediaListResponse, error) {
+	return &client.MediaListResponse{Media: l.items, Count: len(l.items)}, nil
+}
+
+func (l *fakeLibrary) UploadMediaContext(ctx context.Context, filePath string) (*client.MediaUploadResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	name := filepath.Base(filePath)
+	l.uploaded = append(l.uploaded, name)
+	return &client.MediaUploadResponse{
+		ID:       100 + len(l.uploaded),
+		URL:      "https://site.test/uploads/" + name,
+		Filename: name,
+	}, nil
+}
+
+func fileServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	files := map[string]string{
+		"/img/hero.jpg":     "hero-bytes",
+		"/img/logo.png":     "logo-bytes",
+		"/img/copy.png":     "same-bytes",
+		"/img/photo.jpg":    "photo-bytes",
+		"/uploads/old.png":  "same-bytes",
+		"/uploads/logo.png": "logo-bytes",
+		"/uploads/hero.jpg": "other-hero",
+		"/video/intro.mp4":  "video-bytes",
+		"/render":           "rendered",
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/render" {
+			w.Header().Set("Content-Type", "image/png")
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestLocalize(t *testing.T) {
+	ts := fileServer(t)
+	lib := &fakeLibrary{items: []client.MediaItem{
+		{ID: 6, URL: ts.URL + "/uploads/hero.jpg", Filesize: int64(len("other-hero"))},
+		{ID: 7, URL: ts.URL + "/uploads/logo.png"},
+		{ID: 8, URL: ts.URL + "/uploads/old.png", Filesize: int64(len("same-bytes"))},
+	}}
+	hero := map[string]interface{}{"url": ts.URL + "/img/hero.jpg", "alt": "Hero"}
+	elements := []map[string]interface{}{
+		{"id": "a", "name": "image", "settings": map[string]interface{}{"image": hero}},
+		{"id": "b", "name": "section", "settings": map[string]interface{}{
+			"_background":                 map[string]interface{}{"image": map[string]interface{}{"url": ts.URL + "/img/hero.jpg"}},
+			"_background:mobile_portrait": map[string]interface{}{"image": map[string]interface{}{"url": ts.URL + "/img/logo.png"}},
+		}},
+		{"id": "c", "name": "image", "settings": map[string]interface{}{"image": map[string]interface{}{"url": ts.URL + "/img/copy.png"}}},
+		{"id": "d", "name": "video", "settings": map[string]interface{}{"videoUrl": ts.URL + "/video/intro.mp4"}},
+		{"id": "e", "name": "image", "settings": map[string]interface{}{"image": map[string]interface{}{"url": ts.URL + "/render"}}},
+		{"id": "f", "name": "image", "settings": map[string]interface{}{"image": map[string]interface{}{"url": ts.URL + "/missing.jpg"}}},
+		{"id": "g", "name": "image", "settings": map[string]interface{}{"image": map[string]interface{}{"id": 3, "url": "https://site.test/x.jpg"}}},
+		{"id": "h", "name": "image", "settings": map[string]interface{}{"image": map[string]interface{}{"url": "{featured_image}"}}},
+		{"id": "i", "name": "image", "settings": map[string]interface{}{"image": map[string]interface{}{"url": "https://SITE.test/uploads/y.jpg"}}},
+		{"id": "j", "name": "image", "settings": map[string]interface{}{"image": map[string]interface{}{"id": 34, "url": ts.URL + "/img/photo.jpg"}}},
+	}
+
+	manifest := media.Localize(context.Background(), lib, elements, media.Options{Concurrency: 2, SiteURL: "https://site.test"})
+
+	status := map[string]*media.Asset{}
+	for _, a := range manifest.Assets {
+		status[a.Source] = a
+	}
+	if len(manifest.Assets) != 7 {
+		t.Fatalf("expected 7 distinct files off the site, got %d", len(manifest.Assets))
+	}
+	if a := status[ts.URL+"/img/hero.jpg"]; a.Status != media.StatusUploaded || a.Uses != 2 {
+		t.Errorf("expected hero uploaded once for two uses, not matched to a different file of the same name, got %+v", a)
+	}
+	if a := status[ts.URL+"/img/logo.png"]; a.Status != media.StatusExisting || a.ID != 7 {
+		t.Errorf("expected logo matched by filename and content, got %+v", a)
+	}
+	if a := status[ts.URL+"/img/copy.png"]; a.Status != media.StatusExisting || a.ID != 8 {
+		t.Errorf("expected copy matched by content, got %+v", a)
+	}
+	if a := status[ts.URL+"/render"]; a.Filename != "render.png" {
+		t.Errorf("expected an extension from the content type, got %+v", a)
+	}
+	if a := status[ts.URL+"/missing.jpg"]; a.Status != media.StatusFailed || a.Error == "" {
+		t.Errorf("expected the missing file to fail, got %+v", a)
+	}
+	if len(lib.uploaded) != 4 {
+		t.Errorf("expected hero, intro, render and photo uploaded, got %v", lib.uploaded)
+	}
+
+	if hero["id"] == nil || hero["url"] != "https://site.test/uploads/hero.jpg" || hero["filename"] != "hero.jpg" ||
+		hero["size"] != "full" || hero["alt"] != "Hero" {
+		t.Errorf("unexpected rewritten image %v", hero)
+	}
+	bg := elements[1]["settings"].(map[string]interface{})["_background:mobile_portrait"].(map[string]interface{})["image"].(map[string]interface{})
+	if bg["id"] != 7 {
+		t.Errorf("expected breakpoint backgrounds rewritten, got %v", bg)
+	}
+	video := elements[3]["settings"].(map[string]interface{})
+	if video["videoType"] != "media" || video["videoUrl"] != nil || video["media"].(map[string]interface{})["filename"] != "intro.mp4" {
+		t.Errorf("unexpected rewritten video %v", video)
+	}
+	if img := elements[5]["settings"].(map[string]interface{})["image"].(map[string]interface{}); img["id"] != nil {
+		t.Errorf("expected the failed file left as it was, got %v", img)
+	}
+	if img := elements[6]["settings"].(map[string]interface{})["image"].(map[string]interface{}); img["id"] != 3 {
+		t.Errorf("expected media on the site left alone, got %v", img)
+	}
+	if img := elements[9]["settings"].(map[string]interface{})["image"].(map[string]interface{}); img["id"] == 34 || img["url"] != "https://site.test/uploads/photo.jpg" {
+		t.Errorf("expected an image with another site's attachment ID localized, got %v", img)
+	}
+}
+
+func TestLocalizeDryRun(t *testing.T) {
+	ts := fileServer(t)
+	lib := &fakeLibrary{}
+	img := map[string]interface{}{"url": ts.URL + "/img/hero.jpg"}
+	elements := []map[string]interface{}{{"id": "a", "name": "image", "settings": map[string]interface{}{"image": img}}}
+
+	manifest := media.Localize(context.Background(), lib, elements, media.Options{DryRun: true})
+	if len(lib.uploaded) != 0 || img["id"] != nil {
+		t.Errorf("expected nothing uploaded or rewritten, got %v %v", lib.uploaded, img)
+	}
+	if manifest.Counts()[media.StatusPlanned] != 1 {
+		t.Errorf("expected one planned upload, got %+v", manifest.Assets[0])
+	}
+}
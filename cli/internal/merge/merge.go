@@ -0,0 +1,296 @@
+// Package merge performs three-way merges of flat Bricks element lists.
+package merge
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Conflict kinds.
+const (
+	BothModified    = "both-modified"    // local and remote changed the same field differently
+	DeletedRemotely = "deleted-remotely" // local edited an element the remote deleted
+	DeletedLocally  = "deleted-locally"  // local deleted an element the remote edited
+	ParentDeleted   = "parent-deleted"   // an element survives but its parent does not
+)
+
+// Conflict describes one change that could not be merged automatically.
+type Conflict struct {
+	ID     string      `json:"id"`
+	Name   string      `json:"name,omitempty"`
+	Field  string      `json:"field"` // "element", "name", "settings.text", ...
+	Kind   string      `json:"kind"`
+	Base   interface{} `json:"base"`
+	Local  interface{} `json:"local"`
+	Remote interface{} `json:"remote"`
+}
+
+// Result is the outcome of a merge. Where a conflict was reported, Elements
+// holds the remote side, so a conflicted result is never "ours" by accident.
+type Result struct {
+	Elements  []map[string]interface{} `json:"elements"`
+	Conflicts []Conflict               `json:"conflicts"`
+}
+
+// Clean reports whether the merge completed without conflicts.
+func (r *Result) Clean() bool {
+	return len(r.Conflicts) == 0
+}
+
+// Merge combines local and remote edits of the same base element list.
+//
+// Elements are matched by ID. Changes are merged per top-level field and per
+// settings key, so edits to different settings of one element combine
+// cleanly. Children lists are merged as ordered sets: remote order wins, local
+// insertions and removals are replayed on top.
+func Merge(base, local, remote []map[string]interface{}) *Result {
+	res := &Result{Conflicts: []Conflict{}}
+	baseByID := index(base)
+	localByID := index(local)
+	remoteByID := index(remote)
+
+	// Remote order first, then elements only the local side has.
+	var order []string
+	seen := map[string]bool{}
+	for _, list := range [][]map[string]interface{}{remote, local} {
+		for _, el := range list {
+			id := idOf(el)
+			if id != "" && !seen[id] {
+				seen[id] = true
+				order = append(order, id)
+			}
+		}
+	}
+
+	kept := map[string]bool{}
+	for _, id := range order {
+		b, inBase := baseByID[id]
+		l, inLocal := localByID[id]
+		r, inRemote := remoteByID[id]
+
+		var merged map[string]interface{}
+		switch {
+		case inLocal && inRemote:
+			if !inBase {
+				b = map[string]interface{}{}
+			}
+			merged = mergeElement(id, b, l, r, res)
+		case inLocal && !inBase:
+			merged = l
+		case inRemote && !inBase:
+			merged = r
+		case inLocal: // deleted remotely
+			if !reflect.DeepEqual(b, l) {
+				res.Conflicts = append(res.Conflicts, Conflict{ID: id, Name: nameOf(l), Field: "element", Kind: DeletedRemotely, Base: b, Local: l})
+			}
+		case inRemote: // deleted locally
+			merged = r
+			if reflect.DeepEqual(b, r) {
+				merged = nil
+			} else {
+				res.Conflicts = append(res.Conflicts, Conflict{ID: id, Name: nameOf(r), Field: "element", Kind: DeletedLocally, Base: b, Remote: r})
+			}
+		}
+		if merged != nil {
+			res.Elements = append(res.Elements, shallowCopy(merged))
+			kept[id] = true
+		}
+	}
+
+	// Drop references to elements that did not survive, and flag orphans.
+	for _, el := range res.Elements {
+		if children, ok := el["children"].([]interface{}); ok {
+			filtered := make([]interface{}, 0, len(children))
+			for _, c := range children {
+				if s, _ := c.(string); kept[s] {
+					filtered = append(filtered, c)
+				}
+			}
+			el["children"] = filtered
+		}
+		if parent, _ := el["parent"].(string); parent != "" && parent != "0" && !kept[parent] {
+			res.Conflicts = append(res.Conflicts, Conflict{ID: idOf(el), Name: nameOf(el), Field: "parent", Kind: ParentDeleted, Local: parent})
+		}
+	}
+	if res.Elements == nil {
+		res.Elements = []map[string]interface{}{}
+	}
+	return res
+}
+
+type value struct {
+	v  interface{}
+	ok bool
+}
+
+func get(m map[string]interface{}, k string) value {
+	v, ok := m[k]
+	return value{v, ok}
+}
+
+func (a value) equal(b value) bool {
+	return a.ok == b.ok && (!a.ok || reflect.DeepEqual(a.v, b.v))
+}
+
+// merge3 resolves a single field. On conflict it returns the remote value.
+func merge3(b, l, r value) (value, bool) {
+	switch {
+	case l.equal(r):
+		return l, false
+	case b.equal(l):
+		return r, false
+	case b.equal(r):
+		return l, false
+	}
+	return r, true
+}
+
+func mergeElement(id string, b, l, r map[string]interface{}, res *Result) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, k := range keys(b, l, r) {
+		switch k {
+		case "settings":
+			continue
+		case "children":
+			out[k] = mergeChildren(b[k], l[k], r[k])
+			continue
+		}
+		m, conflict := merge3(get(b, k), get(l, k), get(r, k))
+		if conflict {
+			res.Conflicts = append(res.Conflicts, Conflict{ID: id, Name: nameOf(r), Field: k, Kind: BothModified, Base: b[k], Local: l[k], Remote: r[k]})
+		}
+		if m.ok {
+			out[k] = m.v
+		}
+	}
+
+	bs, _ := b["settings"].(map[string]interface{})
+	ls, _ := l["settings"].(map[string]interface{})
+	rs, _ := r["settings"].(map[string]interface{})
+	if bs != nil || ls != nil || rs != nil {
+		settings := map[string]interface{}{}
+		for _, k := range keys(bs, ls, rs) {
+			m, conflict := merge3(get(bs, k), get(ls, k), get(rs, k))
+			if conflict {
+				res.Conflicts = append(res.Conflicts, Conflict{ID: id, Name: nameOf(r), Field: "settings." + k, Kind: BothModified, Base: bs[k], Local: ls[k], Remote: rs[k]})
+			}
+			if m.ok {
+				settings[k] = m.v
+			}
+		}
+		out["settings"] = settings
+	}
+	return out
+}
+
+// mergeChildren merges two edits of an ordered child ID list.
+func mergeChildren(b, l, r interface{}) interface{} {
+	switch {
+	case reflect.DeepEqual(l, r):
+		return l
+	case reflect.DeepEqual(b, l):
+		return r
+	case reflect.DeepEqual(b, r):
+		return l
+	}
+	base := toSet(b)
+	localList, _ := l.([]interface{})
+	localSet := toSet(l)
+
+	var out []interface{}
+	inOut := map[string]bool{}
+	remoteList, _ := r.([]interface{})
+	for _, c := range remoteList {
+		s, _ := c.(string)
+		if base[s] && !localSet[s] {
+			continue // removed locally
+		}
+		out = append(out, c)
+		inOut[s] = true
+	}
+
+	// Insert local additions after their nearest preceding local sibling.
+	for i, c := range localList {
+		s, _ := c.(string)
+		if base[s] || inOut[s] {
+			continue
+		}
+		pos := 0
+		for j := i - 1; j >= 0; j-- {
+			prev, _ := localList[j].(string)
+			if idx := indexOf(out, prev); idx >= 0 {
+				pos = idx + 1
+				break
+			}
+		}
+		out = append(out[:pos], append([]interface{}{c}, out[pos:]...)...)
+		inOut[s] = true
+	}
+	if out == nil {
+		out = []interface{}{}
+	}
+	return out
+}
+
+func shallowCopy(el map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(el))
+	for k, v := range el {
+		out[k] = v
+	}
+	return out
+}
+
+func toSet(v interface{}) map[string]bool {
+	list, _ := v.([]interface{})
+	set := make(map[string]bool, len(list))
+	for _, c := range list {
+		if s, ok := c.(string); ok {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+func indexOf(list []interface{}, id string) int {
+	for i, c := range list {
+		if s, _ := c.(string); s == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func keys(maps ...map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				out = append(out, k)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func index(elements []map[string]interface{}) map[string]map[string]interface{} {
+	m := make(map[string]map[string]interface{}, len(elements))
+	for _, el := range elements {
+		if id := idOf(el); id != "" {
+			m[id] = el
+		}
+	}
+	return m
+}
+
+func idOf(el map[string]interface{}) string {
+	id, _ := el["id"].(string)
+	return id
+}
+
+func nameOf(el map[string]interface{}) string {
+	name, _ := el["name"].(string)
+	return name
+}
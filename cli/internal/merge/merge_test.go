@@ -0,0 +1,144 @@
+package merge_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/merge"
+)
+
+func el(id, name, parent string, children []interface{}, settings map[string]interface{}) map[string]interface{} {
+	if children == nil {
+		children = []interface{}{}
+	}
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+	return map[string]interface{}{"id": id, "name": name, "parent": parent, "children": children, "settings": settings}
+}
+
+func baseTree() []map[string]interface{} {
+	return []map[string]interface{}{
+		el("sec001", "section", "0", []interface{}{"hd0001", "tx0001"}, nil),
+		el("hd0001", "heading", "sec001", nil, map[string]interface{}{"text": "Hello", "tag": "h1"}),
+		el("tx0001", "text-basic", "sec001", nil, map[string]interface{}{"text": "World"}),
+	}
+}
+
+func find(elements []map[string]interface{}, id string) map[string]interface{} {
+	for _, e := range elements {
+		if e["id"] == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func TestMergeNonOverlappingSettings(t *testing.T) {
+	local := baseTree()
+	local[1] = el("hd0001", "heading", "sec001", nil, map[string]interface{}{"text": "Hi there", "tag": "h1"})
+	remote := baseTree()
+	remote[1] = el("hd0001", "heading", "sec001", nil, map[string]interface{}{"text": "Hello", "tag": "h2"})
+
+	res := merge.Merge(baseTree(), local, remote)
+	if !res.Clean() {
+		t.Fatalf("expected clean merge, got %+v", res.Conflicts)
+	}
+	settings := find(res.Elements, "hd0001")["settings"].(map[string]interface{})
+	if settings["text"] != "Hi there" || settings["tag"] != "h2" {
+		t.Errorf("expected both edits, got %v", settings)
+	}
+}
+
+func TestMergeSameSettingConflict(t *testing.T) {
+	local := baseTree()
+	local[2] = el("tx0001", "text-basic", "sec001", nil, map[string]interface{}{"text": "Local"})
+	remote := baseTree()
+	remote[2] = el("tx0001", "text-basic", "sec001", nil, map[string]interface{}{"text": "Remote"})
+
+	res := merge.Merge(baseTree(), local, remote)
+	if len(res.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %+v", res.Conflicts)
+	}
+	c := res.Conflicts[0]
+	if c.ID != "tx0001" || c.Field != "settings.text" || c.Kind != merge.BothModified {
+		t.Errorf("unexpected conflict: %+v", c)
+	}
+	if c.Base != "World" || c.Local != "Local" || c.Remote != "Remote" {
+		t.Errorf("unexpected conflict values: %+v", c)
+	}
+	if got := find(res.Elements, "tx0001")["settings"].(map[string]interface{})["text"]; got != "Remote" {
+		t.Errorf("conflicted field should keep the remote value, got %v", got)
+	}
+}
+
+func TestMergeAddsOnBothSides(t *testing.T) {
+	local := baseTree()
+	local[0] = el("sec001", "section", "0", []interface{}{"hd0001", "btn001", "tx0001"}, nil)
+	local = append(local, el("btn001", "button", "sec001", nil, nil))
+
+	remote := baseTree()
+	remote[0] = el("sec001", "section", "0", []interface{}{"hd0001", "tx0001", "img001"}, nil)
+	remote = append(remote, el("img001", "image", "sec001", nil, nil))
+
+	res := merge.Merge(baseTree(), local, remote)
+	if !res.Clean() {
+		t.Fatalf("expected clean merge, got %+v", res.Conflicts)
+	}
+	want := []interface{}{"hd0001", "btn001", "tx0001", "img001"}
+	if got := find(res.Elements, "sec001")["children"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("children = %v, want %v", got, want)
+	}
+	if find(res.Elements, "btn001") == nil || find(res.Elements, "img001") == nil {
+		t.Error("expected both added elements in the result")
+	}
+}
+
+func TestMergeDeletes(t *testing.T) {
+	// Local deletes an untouched element: clean.
+	local := baseTree()[:2]
+	local[0] = el("sec001", "section", "0", []interface{}{"hd0001"}, nil)
+	res := merge.Merge(baseTree(), local, baseTree())
+	if !res.Clean() {
+		t.Fatalf("expected clean merge, got %+v", res.Conflicts)
+	}
+	if find(res.Elements, "tx0001") != nil {
+		t.Error("expected tx0001 deleted")
+	}
+	if got := find(res.Elements, "sec001")["children"]; !reflect.DeepEqual(got, []interface{}{"hd0001"}) {
+		t.Errorf("children = %v", got)
+	}
+
+	// Remote deletes an element local edited: conflict.
+	local = baseTree()
+	local[2] = el("tx0001", "text-basic", "sec001", nil, map[string]interface{}{"text": "Edited"})
+	remote := baseTree()[:2]
+	res = merge.Merge(baseTree(), local, remote)
+	if len(res.Conflicts) != 1 || res.Conflicts[0].Kind != merge.DeletedRemotely {
+		t.Fatalf("expected deleted-remotely conflict, got %+v", res.Conflicts)
+	}
+}
+
+func TestApplyPatches(t *testing.T) {
+	base := baseTree()
+	out, err := merge.ApplyPatches(base, []map[string]interface{}{
+		{"id": "hd0001", "settings": map[string]interface{}{"text": "New", "tag": nil}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := find(out, "hd0001")["settings"].(map[string]interface{})
+	if settings["text"] != "New" {
+		t.Errorf("text = %v", settings["text"])
+	}
+	if _, ok := settings["tag"]; ok {
+		t.Error("null should remove the tag setting")
+	}
+	if find(base, "hd0001")["settings"].(map[string]interface{})["text"] != "Hello" {
+		t.Error("ApplyPatches must not modify its input")
+	}
+
+	if _, err := merge.ApplyPatches(base, []map[string]interface{}{{"id": "nope00"}}); err == nil {
+		t.Error("expected error for unknown element")
+	}
+}
@@ -0,0 +1,71 @@
+package merge
+
+import "fmt"
+
+// ApplyPatches returns a copy of elements with PATCH payloads applied the way
+// the plugin applies them: settings are merged key by key and a null value
+// removes a key. The input is not modified; with no patches the result is a
+// deep copy.
+func ApplyPatches(elements []map[string]interface{}, patches []map[string]interface{}) ([]map[string]interface{}, error) {
+	out := make([]map[string]interface{}, len(elements))
+	pos := make(map[string]int, len(elements))
+	for i, el := range elements {
+		out[i] = deepCopy(el).(map[string]interface{})
+		pos[idOf(el)] = i
+	}
+
+	for _, patch := range patches {
+		id := idOf(patch)
+		i, ok := pos[id]
+		if !ok {
+			return nil, fmt.Errorf("element %q not found on page", id)
+		}
+		el := out[i]
+		for k, v := range patch {
+			switch {
+			case k == "id":
+			case k == "settings":
+				incoming, ok := v.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				settings, _ := el["settings"].(map[string]interface{})
+				if settings == nil {
+					settings = map[string]interface{}{}
+				}
+				for sk, sv := range incoming {
+					if sv == nil {
+						delete(settings, sk)
+					} else {
+						settings[sk] = deepCopy(sv)
+					}
+				}
+				el["settings"] = settings
+			case v == nil:
+				delete(el, k)
+			default:
+				el[k] = deepCopy(v)
+			}
+		}
+	}
+	return out, nil
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, vv := range t {
+			m[k] = deepCopy(vv)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, vv := range t {
+			s[i] = deepCopy(vv)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,178 @@
+// Package pageref resolves the page references commands accept — an ID, a
+// slug, a title, a path or a URL — to a page ID.
+package pageref
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+)
+
+// Lister lists the site's pages. *client.Client implements it.
+type Lister interface {
+	ListPagesContext(ctx context.Context, params client.PageParams) (*client.PagesResponse, error)
+	AllPagesContext(ctx context.Context, params client.PageParams) ([]client.Page, error)
+}
+
+// How a reference was matched.
+const (
+	ByID    = "id"
+	ByPath  = "path"
+	BySlug  = "slug"
+	ByTitle = "title"
+)
+
+// Match is a resolved reference. Page is nil when the reference was a
+// numeric ID, which resolves without asking the site.
+type Match struct {
+	ID   int          `json:"id"`
+	By   string       `json:"by"`
+	Page *client.Page `json:"page,omitempty"`
+}
+
+// Candidates is the error detail for PAGE_AMBIGUOUS and PAGE_NOT_FOUND.
+type Candidates struct {
+	Ref        string        `json:"ref"`
+	Candidates []client.Page `json:"candidates"`
+}
+
+// maxSuggestions caps the near matches listed when nothing matches exactly.
+const maxSuggestions = 10
+
+// Resolve turns ref into a page ID. It tries, in order: a numeric ID, a URL
+// or path ("https://example.com/about/", "/services/web/"), including
+// ?page_id= links, an exact slug, and an exact title (case-insensitive).
+// Several pages matching at the same step is a PAGE_AMBIGUOUS error listing
+// them; no match is PAGE_NOT_FOUND with the closest search results.
+func Resolve(ctx context.Context, l Lister, ref string) (*Match, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, clierrors.ValidationError("INVALID_PAGE_ID", "empty page reference")
+	}
+	if id, err := strconv.Atoi(ref); err == nil {
+		if id <= 0 {
+			return nil, clierrors.ValidationError("INVALID_PAGE_ID", fmt.Sprintf("invalid page ID: %s", ref))
+		}
+		return &Match{ID: id, By: ByID}, nil
+	}
+
+	if path, id, ok := parseURL(ref); ok {
+		if id > 0 {
+			return &Match{ID: id, By: ByID}, nil
+		}
+		if path == "" {
+			return nil, notFound(ref, nil, "the front page cannot be resolved from its URL; use the page ID")
+		}
+		resp, err := l.ListPagesContext(ctx, client.PageParams{Slug: path, PerPage: 50})
+		if err != nil {
+			return nil, err
+		}
+		last := path[strings.LastIndex(path, "/")+1:]
+		matches := filter(resp.Pages, func(p client.Page) bool {
+			if p.Path != "" {
+				return strings.EqualFold(strings.Trim(p.Path, "/"), path)
+			}
+			return strings.EqualFold(p.Slug, last)
+		})
+		if m, err := pick(ref, ByPath, matches); m != nil || err != nil {
+			return m, err
+		}
+		return nil, notFound(ref, nil, "")
+	}
+
+	if !strings.ContainsAny(ref, " \t") {
+		resp, err := l.ListPagesContext(ctx, client.PageParams{Slug: ref, PerPage: 50})
+		if err != nil {
+			return nil, err
+		}
+		matches := filter(resp.Pages, func(p client.Page) bool { return strings.EqualFold(p.Slug, ref) })
+		if m, err := pick(ref, BySlug, matches); m != nil || err != nil {
+			return m, err
+		}
+	}
+
+	pages, err := l.AllPagesContext(ctx, client.PageParams{Search: ref})
+	if err != nil {
+		return nil, err
+	}
+	matches := filter(pages, func(p client.Page) bool { return strings.EqualFold(strings.TrimSpace(p.Title), ref) })
+	if m, err := pick(ref, ByTitle, matches); m != nil || err != nil {
+		return m, err
+	}
+	if len(pages) > maxSuggestions {
+		pages = pages[:maxSuggestions]
+	}
+	return nil, notFound(ref, pages, "")
+}
+
+// parseURL recognises absolute URLs and site paths. It returns the page path
+// without surrounding slashes, or a page ID from a ?page_id= or ?p= link.
+func parseURL(ref string) (path string, id int, ok bool) {
+	if !strings.Contains(ref, "://") && !strings.HasPrefix(ref, "/") {
+		return "", 0, false
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", 0, false
+	}
+	for _, key := range []string{"page_id", "p"} {
+		if n, err := strconv.Atoi(u.Query().Get(key)); err == nil && n > 0 {
+			return "", n, true
+		}
+	}
+	return strings.Trim(u.Path, "/"), 0, true
+}
+
+func filter(pages []client.Page, keep func(client.Page) bool) []client.Page {
+	var out []client.Page
+	for _, p := range pages {
+		if keep(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func pick(ref, by string, matches []client.Page) (*Match, error) {
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		p := matches[0]
+		return &Match{ID: p.ID, By: by, Page: &p}, nil
+	}
+	err := clierrors.ValidationError("PAGE_AMBIGUOUS", fmt.Sprintf("page reference %q matches %d pages by %s", ref, len(matches), by))
+	err.Hint = "Candidates: " + describe(matches) + ". Use the page ID."
+	err.Details = Candidates{Ref: ref, Candidates: matches}
+	return nil, err
+}
+
+func notFound(ref string, suggestions []client.Page, hint string) error {
+	err := clierrors.ValidationError("PAGE_NOT_FOUND", fmt.Sprintf("no page matches %q", ref))
+	switch {
+	case hint != "":
+		err.Hint = hint
+	case len(suggestions) > 0:
+		err.Hint = "Did you mean: " + describe(suggestions) + "?"
+	default:
+		err.Hint = "Run: bricks pages list"
+	}
+	if suggestions == nil {
+		suggestions = []client.Page{}
+	}
+	err.Details = Candidates{Ref: ref, Candidates: suggestions}
+	return err
+}
+
+func describe(pages []client.Page) string {
+	parts := make([]string, len(pages))
+	for i, p := range pages {
+		parts[i] = fmt.Sprintf("%d %q (%s, %s)", p.ID, p.Title, p.Slug, p.Status)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,127 @@
+package pageref_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/client"
+	clierrors "github.com/nerveband/agent-to-bricks/internal/errors"
+	"github.com/nerveband/agent-to-bricks/internal/pageref"
+)
+
+// fakeLister answers slug lookups by slug or path and searches by title
+// substring, like GET /pages.
+type fakeLister struct {
+	pages    []client.Page
+	requests int
+}
+
+func (f *fakeLister) ListPagesContext(_ context.Context, params client.PageParams) (*client.PagesResponse, error) {
+	f.requests++
+	var out []client.Page
+	for _, p := range f.pages {
+		if params.Slug != "" && (strings.EqualFold(p.Slug, params.Slug) || strings.EqualFold(p.Path, params.Slug)) {
+			out = append(out, p)
+		}
+	}
+	return &client.PagesResponse{Pages: out}, nil
+}
+
+func (f *fakeLister) AllPagesContext(_ context.Context, params client.PageParams) ([]client.Page, error) {
+	f.requests++
+	var out []client.Page
+	for _, p := range f.pages {
+		if strings.Contains(strings.ToLower(p.Title), strings.ToLower(params.Search)) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func site() *fakeLister {
+	return &fakeLister{pages: []client.Page{
+		{ID: 10, Title: "About Us", Slug: "about-us", Status: "publish", Path: "about-us"},
+		{ID: 11, Title: "Web Design", Slug: "web-design", Status: "publish", Path: "services/web-design"},
+		{ID: 12, Title: "Web Design", Slug: "web-design", Status: "draft", Path: "archive/web-design"},
+		{ID: 13, Title: "Pricing", Slug: "pricing", Status: "publish", Path: "pricing"},
+	}}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		ref    string
+		wantID int
+		wantBy string
+	}{
+		{"1460", 1460, pageref.ByID},
+		{"about-us", 10, pageref.BySlug},
+		{"ABOUT-US", 10, pageref.BySlug},
+		{"about us", 10, pageref.ByTitle},
+		{"Pricing", 13, pageref.BySlug},
+		{"/services/web-design/", 11, pageref.ByPath},
+		{"https://example.com/archive/web-design/", 12, pageref.ByPath},
+		{"https://example.com/?page_id=77", 77, pageref.ByID},
+	}
+	for _, tt := range tests {
+		m, err := pageref.Resolve(context.Background(), site(), tt.ref)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.ref, err)
+			continue
+		}
+		if m.ID != tt.wantID || m.By != tt.wantBy {
+			t.Errorf("%q: expected %d by %s, got %d by %s", tt.ref, tt.wantID, tt.wantBy, m.ID, m.By)
+		}
+	}
+}
+
+func TestResolveNumericMakesNoRequest(t *testing.T) {
+	l := site()
+	if _, err := pageref.Resolve(context.Background(), l, "42"); err != nil {
+		t.Fatal(err)
+	}
+	if l.requests != 0 {
+		t.Errorf("expected no requests for a numeric ID, got %d", l.requests)
+	}
+}
+
+func TestResolveAmbiguous(t *testing.T) {
+	_, err := pageref.Resolve(context.Background(), site(), "web-design")
+	var cliErr *clierrors.CLIError
+	if !errors.As(err, &cliErr) || cliErr.Code != "PAGE_AMBIGUOUS" {
+		t.Fatalf("expected PAGE_AMBIGUOUS, got %v", err)
+	}
+	details, ok := cliErr.Details.(pageref.Candidates)
+	if !ok || len(details.Candidates) != 2 {
+		t.Fatalf("expected two candidates in details, got %#v", cliErr.Details)
+	}
+	if !strings.Contains(cliErr.Hint, "11") || !strings.Contains(cliErr.Hint, "12") {
+		t.Errorf("expected hint to list both IDs, got %q", cliErr.Hint)
+	}
+}
+
+func TestResolveNotFound(t *testing.T) {
+	for _, ref := range []string{"contact", "Web", "0", "https://example.com/"} {
+		_, err := pageref.Resolve(context.Background(), site(), ref)
+		var cliErr *clierrors.CLIError
+		if !errors.As(err, &cliErr) {
+			t.Errorf("%q: expected a CLIError, got %v", ref, err)
+			continue
+		}
+		want := "PAGE_NOT_FOUND"
+		if ref == "0" {
+			want = "INVALID_PAGE_ID"
+		}
+		if cliErr.Code != want {
+			t.Errorf("%q: expected %s, got %s", ref, want, cliErr.Code)
+		}
+	}
+
+	_, err := pageref.Resolve(context.Background(), site(), "Web")
+	var cliErr *clierrors.CLIError
+	errors.As(err, &cliErr)
+	if !strings.Contains(cliErr.Hint, "Did you mean") {
+		t.Errorf("expected suggestions in hint, got %q", cliErr.Hint)
+	}
+}
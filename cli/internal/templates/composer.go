@@ -1,9 +1,10 @@
 package templates
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
+	"strconv"
+
+	"github.com/nerveband/agent-to-bricks/internal/elementid"
 )
 
 // ComposeResult holds composed elements and merged global classes.
@@ -12,59 +13,60 @@ type ComposeResult struct {
 	GlobalClasses []map[string]interface{}
 }
 
+// ComposeOptions configures ComposeWithOptions.
+type ComposeOptions struct {
+	// IDs picks how elements are given new IDs. Defaults to
+	// elementid.Random; elementid.Hash derives them from the template's
+	// name and position and the element's original ID, so composing the
+	// same templates always gives the same IDs, and elementid.Explicit keeps
+	// the templates' own IDs where they are free.
+	IDs elementid.Strategy
+	// ReservedIDs are IDs the composed elements must not take, such as
+	// those already on a page they will be appended to.
+	ReservedIDs []string
+}
+
 // Compose merges multiple templates into a single element list.
 // It remaps IDs to avoid collisions.
 func Compose(templates []*Template) ([]map[string]interface{}, error) {
-	if len(templates) == 0 {
-		return nil, fmt.Errorf("no templates to compose")
-	}
-
-	var result []map[string]interface{}
-	usedIDs := make(map[string]bool)
-
-	for _, tmpl := range templates {
-		// Build an ID remap table for this template
-		idMap := make(map[string]string)
-		for _, el := range tmpl.Elements {
-			oldID, _ := el["id"].(string)
-			if oldID != "" {
-				newID := generateUniqueID(usedIDs)
-				idMap[oldID] = newID
-				usedIDs[newID] = true
-			}
-		}
-
-		// Apply remap
-		for _, el := range tmpl.Elements {
-			remapped := remapElement(el, idMap)
-			result = append(result, remapped)
-		}
+	result, err := ComposeWithOptions(templates, ComposeOptions{})
+	if err != nil {
+		return nil, err
 	}
-
-	return result, nil
+	return result.Elements, nil
 }
 
 // ComposeWithClasses merges templates and their global classes.
 func ComposeWithClasses(templates []*Template) (*ComposeResult, error) {
+	return ComposeWithOptions(templates, ComposeOptions{})
+}
+
+// ComposeWithOptions merges templates and their global classes, giving
+// the elements IDs as opts says.
+func ComposeWithOptions(templates []*Template, opts ComposeOptions) (*ComposeResult, error) {
 	if len(templates) == 0 {
 		return nil, fmt.Errorf("no templates to compose")
 	}
 
 	var allElements []map[string]interface{}
-	usedIDs := make(map[string]bool)
+	ids := elementid.New(opts.IDs)
+	ids.Reserve(opts.ReservedIDs...)
 	seenClasses := make(map[string]bool)
 	var mergedClasses []map[string]interface{}
 
-	for _, tmpl := range templates {
+	for i, tmpl := range templates {
 		// Build an ID remap table for this template
 		idMap := make(map[string]string)
 		for _, el := range tmpl.Elements {
 			oldID, _ := el["id"].(string)
-			if oldID != "" {
-				newID := generateUniqueID(usedIDs)
-				idMap[oldID] = newID
-				usedIDs[newID] = true
+			if oldID == "" {
+				continue
+			}
+			if opts.IDs == elementid.Explicit && ids.Claim(oldID) {
+				idMap[oldID] = oldID
+				continue
 			}
+			idMap[oldID] = ids.Next(elementid.Seed(tmpl.Name, strconv.Itoa(i), oldID))
 		}
 
 		// Apply remap
@@ -128,15 +130,3 @@ func remapElement(el map[string]interface{}, idMap map[string]string) map[string
 
 	return copy
 }
-
-// generateUniqueID creates a 6-char hex ID not already in use.
-func generateUniqueID(used map[string]bool) string {
-	for {
-		b := make([]byte, 3)
-		rand.Read(b)
-		id := hex.EncodeToString(b)
-		if !used[id] {
-			return id
-		}
-	}
-}
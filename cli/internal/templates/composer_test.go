@@ -1,8 +1,10 @@
 package templates_test
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/nerveband/agent-to-bricks/internal/elementid"
 	"github.com/nerveband/agent-to-bricks/internal/templates"
 )
 
@@ -159,3 +161,31 @@ func TestComposeWithClassesEmpty(t *testing.T) {
 		t.Error("expected error for empty compose")
 	}
 }
+
+func TestComposeIDStrategies(t *testing.T) {
+	tmpl := &templates.Template{
+		Name: "hero",
+		Elements: []map[string]interface{}{
+			{"id": "sect01", "name": "section", "parent": "0", "children": []interface{}{"head01"}},
+			{"id": "head01", "name": "heading", "parent": "sect01", "children": []interface{}{}},
+		},
+	}
+	twice := []*templates.Template{tmpl, tmpl}
+
+	a, _ := templates.ComposeWithOptions(twice, templates.ComposeOptions{IDs: elementid.Hash})
+	b, _ := templates.ComposeWithOptions(twice, templates.ComposeOptions{IDs: elementid.Hash})
+	if !reflect.DeepEqual(a.Elements, b.Elements) {
+		t.Errorf("expected hash IDs to be stable, got\n%v\n%v", a.Elements, b.Elements)
+	}
+	if a.Elements[0]["id"] == a.Elements[2]["id"] {
+		t.Error("each copy of a template needs its own IDs")
+	}
+	if a.Elements[1]["parent"] != a.Elements[0]["id"] {
+		t.Errorf("expected parent remapped, got %v", a.Elements[1])
+	}
+
+	e, _ := templates.ComposeWithOptions(twice, templates.ComposeOptions{IDs: elementid.Explicit, ReservedIDs: []string{"head01"}})
+	if e.Elements[0]["id"] != "sect01" || e.Elements[1]["id"] == "head01" || e.Elements[2]["id"] == "sect01" {
+		t.Errorf("expected free template IDs kept and taken ones replaced, got %v", e.Elements)
+	}
+}
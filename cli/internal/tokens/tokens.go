@@ -0,0 +1,344 @@
+// Package tokens snaps raw CSS values in element settings to the site's
+// design tokens: lengths to the nearest spacing or text-size variable,
+// colors to the perceptually nearest color variable.
+package tokens
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nerveband/agent-to-bricks/internal/framework"
+)
+
+// Kind is the kind of value a token stands for.
+type Kind string
+
+const (
+	Spacing  Kind = "spacing"
+	FontSize Kind = "font-size"
+	Color    Kind = "color"
+)
+
+// Variable is a CSS custom property as the site reports it.
+type Variable struct {
+	Name  string
+	Value string
+}
+
+// Defaults for Options.
+const (
+	DefaultRootFontSize    = 16
+	DefaultViewport        = 1440
+	DefaultLengthTolerance = 0.1
+	DefaultColorTolerance  = 3.0
+)
+
+// Options configures a Set.
+type Options struct {
+	// RootFontSize is the px size of rem and em. Defaults to 16.
+	RootFontSize float64
+	// Viewport is the px width vw units and clamp() are computed at.
+	// Defaults to 1440.
+	Viewport float64
+	// LengthTolerance is the largest relative difference (0.1 = 10%) a
+	// length may have from a token to snap to it. 0 snaps exact matches
+	// only; DefaultLengthTolerance is the CLI default.
+	LengthTolerance float64
+	// ColorTolerance is the largest CIEDE2000 difference a color may have
+	// from a token to snap to it. 0 snaps exact matches only;
+	// DefaultColorTolerance is the CLI default.
+	ColorTolerance float64
+}
+
+// Set holds the tokens values can snap to.
+type Set struct {
+	opts    Options
+	eval    lengthEvaluator
+	lengths map[Kind][]lengthToken
+	colors  []colorToken
+}
+
+type lengthToken struct {
+	name, value string
+	px          float64
+}
+
+type colorToken struct {
+	name, value string
+	lab         lab
+}
+
+// Match is a raw value and the token nearest to it. Distance is the
+// relative difference for lengths and the CIEDE2000 difference for colors.
+type Match struct {
+	Owner      string  `json:"owner"`
+	Setting    string  `json:"setting"`
+	Value      string  `json:"value"`
+	Token      string  `json:"token"`
+	TokenValue string  `json:"tokenValue"`
+	Distance   float64 `json:"distance"`
+}
+
+// Report lists the values a snap replaced, and those whose nearest token
+// was within twice the tolerance but not within it.
+type Report struct {
+	Substitutions []Match `json:"substitutions"`
+	NearMisses    []Match `json:"nearMisses"`
+}
+
+var (
+	spaceName = regexp.MustCompile(`^--(.*-)?space(-|$)`)
+	textName  = regexp.MustCompile(`^--(text-(xs|s|m|l|xl|xxl|\d*xl)|h[1-6]|font-size-.+)$`)
+)
+
+// NewSet resolves the site's variables into tokens. Spacing tokens are the
+// frameworks' spacing variables and other --*space* variables; text-size
+// tokens are --text-* and --h1 to --h6; any variable holding a solid color
+// is a color token. References to other variables are followed.
+func NewSet(vars []Variable, frameworks []*framework.Framework, opts Options) *Set {
+	if opts.RootFontSize <= 0 {
+		opts.RootFontSize = DefaultRootFontSize
+	}
+	if opts.Viewport <= 0 {
+		opts.Viewport = DefaultViewport
+	}
+	s := &Set{
+		opts:    opts,
+		eval:    lengthEvaluator{rootFontSize: opts.RootFontSize, viewport: opts.Viewport},
+		lengths: make(map[Kind][]lengthToken),
+	}
+
+	spacing := map[string]bool{}
+	text := map[string]bool{}
+	for _, fw := range frameworks {
+		for _, v := range fw.Spacing.Variables {
+			spacing[v] = true
+		}
+		for size := range fw.Typography.TextSizes {
+			text["--text-"+size] = true
+		}
+		for _, h := range fw.Typography.Headings {
+			text["--"+h] = true
+		}
+	}
+
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		if _, seen := values[v.Name]; !seen {
+			values[v.Name] = v.Value
+		}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := resolve(values[name], values, 0)
+		if c, ok := parseColor(value); ok {
+			if c.a == 1 {
+				s.colors = append(s.colors, colorToken{name: name, value: value, lab: c.lab()})
+			}
+			continue
+		}
+		px, ok := s.eval.px(value)
+		if !ok || px <= 0 {
+			continue
+		}
+		t := lengthToken{name: name, value: value, px: px}
+		switch {
+		case spacing[name] || spaceName.MatchString(name):
+			s.lengths[Spacing] = append(s.lengths[Spacing], t)
+		case text[name] || textName.MatchString(name):
+			s.lengths[FontSize] = append(s.lengths[FontSize], t)
+		}
+	}
+	return s
+}
+
+// resolve substitutes var() references, using their fallback when the
+// variable is unknown.
+func resolve(value string, values map[string]string, depth int) string {
+	if depth > 8 {
+		return value
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "var(")
+		if start < 0 {
+			b.WriteString(value)
+			return b.String()
+		}
+		end, comma, nest := -1, -1, 0
+		for i := start + 4; i < len(value) && end < 0; i++ {
+			switch value[i] {
+			case '(':
+				nest++
+			case ')':
+				if nest == 0 {
+					end = i
+				}
+				nest--
+			case ',':
+				if nest == 0 && comma < 0 {
+					comma = i
+				}
+			}
+		}
+		if end < 0 {
+			b.WriteString(value)
+			return b.String()
+		}
+		b.WriteString(value[:start])
+		name, fallback := value[start+4:end], ""
+		if comma >= 0 {
+			name, fallback = value[start+4:comma], strings.TrimSpace(value[comma+1:end])
+		}
+		if v, ok := values[strings.TrimSpace(name)]; ok {
+			b.WriteString(resolve(v, values, depth+1))
+		} else if fallback != "" {
+			b.WriteString(resolve(fallback, values, depth+1))
+		} else {
+			b.WriteString(value[start : end+1])
+		}
+		value = value[end+1:]
+	}
+}
+
+// Len returns how many tokens of a kind the set has.
+func (s *Set) Len(kind Kind) int {
+	if kind == Color {
+		return len(s.colors)
+	}
+	return len(s.lengths[kind])
+}
+
+// Snap rewrites the raw values in each element's settings to tokens,
+// recording what it did in r. Elements are identified by ID.
+func (s *Set) Snap(elements []map[string]interface{}, r *Report) {
+	for _, el := range elements {
+		settings, _ := el["settings"].(map[string]interface{})
+		id, _ := el["id"].(string)
+		s.SnapSettings(id, settings, r)
+	}
+}
+
+// SnapSettings rewrites the raw values in one settings map, such as a
+// global class's, recording them under owner. Padding, margin and gap snap
+// to spacing tokens, font sizes to text-size tokens, and typography,
+// background, border, shadow and gradient colors to color tokens, at every
+// breakpoint and state.
+func (s *Set) SnapSettings(owner string, settings map[string]interface{}, r *Report) {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		base, _, _ := strings.Cut(key, ":")
+		switch base {
+		case "_padding", "_margin":
+			if m, ok := settings[key].(map[string]interface{}); ok {
+				for _, side := range []string{"top", "right", "bottom", "left"} {
+					if v, ok := m[side].(string); ok {
+						m[side] = s.snapLength(Spacing, owner, key+"."+side, v, r)
+					}
+				}
+			}
+		case "_gap", "_rowGap", "_columnGap":
+			if v, ok := settings[key].(string); ok {
+				settings[key] = s.snapLength(Spacing, owner, key, v, r)
+			}
+		case "_typography":
+			if m, ok := settings[key].(map[string]interface{}); ok {
+				if v, ok := m["font-size"].(string); ok {
+					m["font-size"] = s.snapLength(FontSize, owner, key+".font-size", v, r)
+				}
+				s.snapColor(m, "color", owner, key+".color", r)
+			}
+		case "_background", "_border", "_boxShadow":
+			if m, ok := settings[key].(map[string]interface{}); ok {
+				s.snapColor(m, "color", owner, key+".color", r)
+			}
+		case "_gradient":
+			m, _ := settings[key].(map[string]interface{})
+			colors, _ := m["colors"].([]interface{})
+			for i, c := range colors {
+				if stop, ok := c.(map[string]interface{}); ok {
+					s.snapColor(stop, "color", owner, key+".colors."+strconv.Itoa(i)+".color", r)
+				}
+			}
+		}
+	}
+}
+
+// snapLength snaps each length in a space-separated value.
+func (s *Set) snapLength(kind Kind, owner, setting, value string, r *Report) string {
+	candidates := s.lengths[kind]
+	if len(candidates) == 0 || strings.Contains(value, "var(") {
+		return value
+	}
+	fields := strings.Fields(value)
+	for i, f := range fields {
+		px, ok := s.eval.px(f)
+		if !ok || px <= 0 {
+			continue
+		}
+		best, dist := -1, math.Inf(1)
+		for j, t := range candidates {
+			if d := math.Abs(px-t.px) / t.px; d < dist {
+				best, dist = j, d
+			}
+		}
+		t := candidates[best]
+		m := Match{Owner: owner, Setting: setting, Value: f, Token: t.name, TokenValue: t.value, Distance: round(dist)}
+		switch {
+		case dist <= s.opts.LengthTolerance:
+			fields[i] = "var(" + t.name + ")"
+			r.Substitutions = append(r.Substitutions, m)
+		case dist <= 2*s.opts.LengthTolerance:
+			r.NearMisses = append(r.NearMisses, m)
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// snapColor snaps the Bricks color setting ({raw} or {hex}) at m[key].
+func (s *Set) snapColor(m map[string]interface{}, key, owner, setting string, r *Report) {
+	c, ok := m[key].(map[string]interface{})
+	if !ok || len(s.colors) == 0 {
+		return
+	}
+	value, _ := c["raw"].(string)
+	if value == "" {
+		value, _ = c["hex"].(string)
+	}
+	parsed, ok := parseColor(value)
+	if !ok || parsed.a != 1 {
+		return
+	}
+	l := parsed.lab()
+	best, dist := -1, math.Inf(1)
+	for j, t := range s.colors {
+		if d := deltaE2000(l, t.lab); d < dist {
+			best, dist = j, d
+		}
+	}
+	t := s.colors[best]
+	match := Match{Owner: owner, Setting: setting, Value: value, Token: t.name, TokenValue: t.value, Distance: round(dist)}
+	switch {
+	case dist <= s.opts.ColorTolerance:
+		m[key] = map[string]interface{}{"raw": "var(" + t.name + ")"}
+		r.Substitutions = append(r.Substitutions, match)
+	case dist <= 2*s.opts.ColorTolerance:
+		r.NearMisses = append(r.NearMisses, match)
+	}
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
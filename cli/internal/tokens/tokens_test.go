@@ -0,0 +1,155 @@
+package tokens_test
+
+import (
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/framework"
+	"github.com/nerveband/agent-to-bricks/internal/tokens"
+)
+
+var siteVars = []tokens.Variable{
+	{Name: "--space-s", Value: "1rem"},
+	{Name: "--space-m", Value: "clamp(1.25rem, calc(1rem + 0.5vw), 2rem)"},
+	{Name: "--space-l", Value: "calc(var(--space-m) * 2)"},
+	{Name: "--text-l", Value: "1.5rem"},
+	{Name: "--primary", Value: "#3366ff"},
+	{Name: "--accent", Value: "var(--brand, hsl(0, 100%, 50%))"},
+	{Name: "--overlay", Value: "rgba(0, 0, 0, 0.5)"},
+	{Name: "--container", Value: "1200px"},
+}
+
+var defaults = tokens.Options{
+	LengthTolerance: tokens.DefaultLengthTolerance,
+	ColorTolerance:  tokens.DefaultColorTolerance,
+}
+
+func TestNewSet_ClassifiesVariables(t *testing.T) {
+	s := tokens.NewSet(siteVars, nil, defaults)
+	if n := s.Len(tokens.Spacing); n != 3 {
+		t.Errorf("expected 3 spacing tokens, got %d", n)
+	}
+	if n := s.Len(tokens.FontSize); n != 1 {
+		t.Errorf("expected 1 text-size token, got %d", n)
+	}
+	// Translucent colors don't take part
+	if n := s.Len(tokens.Color); n != 2 {
+		t.Errorf("expected 2 color tokens, got %d", n)
+	}
+
+	fw := &framework.Framework{}
+	fw.Spacing.Variables = map[string]string{"gutter": "--gutter"}
+	s = tokens.NewSet(append(siteVars, tokens.Variable{Name: "--gutter", Value: "48px"}), []*framework.Framework{fw}, defaults)
+	if n := s.Len(tokens.Spacing); n != 4 {
+		t.Errorf("expected framework spacing variables counted, got %d", n)
+	}
+}
+
+func TestSnap_Lengths(t *testing.T) {
+	s := tokens.NewSet(siteVars, nil, defaults)
+	settings := map[string]interface{}{
+		// --space-m is 23.2px at the 1440px viewport
+		"_padding":             map[string]interface{}{"top": "23px", "right": "16px", "bottom": "auto", "left": "var(--space-s)"},
+		"_gap:mobile_portrait": "46px",
+		"_typography":          map[string]interface{}{"font-size": "25px"},
+		"_width":               "1200px",
+		"_margin":              map[string]interface{}{"top": "27px"},
+	}
+	var r tokens.Report
+	s.Snap([]map[string]interface{}{{"id": "abc123", "settings": settings}}, &r)
+
+	pad := settings["_padding"].(map[string]interface{})
+	if pad["top"] != "var(--space-m)" || pad["right"] != "var(--space-s)" {
+		t.Errorf("expected padding snapped, got %v", pad)
+	}
+	if pad["bottom"] != "auto" || pad["left"] != "var(--space-s)" {
+		t.Errorf("expected keywords and variables left alone, got %v", pad)
+	}
+	if settings["_gap:mobile_portrait"] != "var(--space-l)" {
+		t.Errorf("expected breakpoint gap snapped, got %v", settings["_gap:mobile_portrait"])
+	}
+	if typo := settings["_typography"].(map[string]interface{}); typo["font-size"] != "var(--text-l)" {
+		t.Errorf("expected font size snapped to a text token, got %v", typo)
+	}
+	if settings["_width"] != "1200px" {
+		t.Errorf("expected width left alone, got %v", settings["_width"])
+	}
+	if len(r.Substitutions) != 4 {
+		t.Errorf("expected 4 substitutions, got %+v", r.Substitutions)
+	}
+	if r.Substitutions[0].Owner != "abc123" {
+		t.Errorf("expected substitutions owned by the element ID, got %+v", r.Substitutions[0])
+	}
+	// 27px is 16% off --space-m: outside the tolerance, within twice it
+	if m := settings["_margin"].(map[string]interface{}); m["top"] != "27px" {
+		t.Errorf("expected the near miss left alone, got %v", m)
+	}
+	if len(r.NearMisses) != 1 || r.NearMisses[0].Token != "--space-m" || r.NearMisses[0].Setting != "_margin.top" {
+		t.Errorf("expected one near miss for the margin, got %+v", r.NearMisses)
+	}
+}
+
+func TestSnap_Colors(t *testing.T) {
+	s := tokens.NewSet(siteVars, nil, defaults)
+	settings := map[string]interface{}{
+		"_typography":       map[string]interface{}{"color": map[string]interface{}{"raw": "#3467fe"}},
+		"_background:hover": map[string]interface{}{"color": map[string]interface{}{"hex": "#ff0000"}},
+		"_border":           map[string]interface{}{"color": map[string]interface{}{"raw": "#00ff00"}},
+		"_gradient": map[string]interface{}{"colors": []interface{}{
+			map[string]interface{}{"color": map[string]interface{}{"raw": "rgb(51, 102, 255)"}},
+			map[string]interface{}{"color": map[string]interface{}{"raw": "rgba(51, 102, 255, 0.5)"}},
+		}},
+	}
+	var r tokens.Report
+	s.SnapSettings(".card", settings, &r)
+
+	color := func(key string) interface{} {
+		return settings[key].(map[string]interface{})["color"].(map[string]interface{})["raw"]
+	}
+	if color("_typography") != "var(--primary)" {
+		t.Errorf("expected a near-identical color snapped, got %v", color("_typography"))
+	}
+	if color("_background:hover") != "var(--accent)" {
+		t.Errorf("expected hex color snapped to the fallback's token, got %v", settings["_background:hover"])
+	}
+	if color("_border") != "#00ff00" {
+		t.Errorf("expected a distant color left alone, got %v", color("_border"))
+	}
+	stops := settings["_gradient"].(map[string]interface{})["colors"].([]interface{})
+	if c := stops[0].(map[string]interface{})["color"].(map[string]interface{})["raw"]; c != "var(--primary)" {
+		t.Errorf("expected gradient stop snapped, got %v", c)
+	}
+	if c := stops[1].(map[string]interface{})["color"].(map[string]interface{})["raw"]; c != "rgba(51, 102, 255, 0.5)" {
+		t.Errorf("expected translucent stop left alone, got %v", c)
+	}
+	if len(r.Substitutions) != 3 || r.Substitutions[0].Owner != ".card" {
+		t.Errorf("expected 3 substitutions for .card, got %+v", r.Substitutions)
+	}
+}
+
+func TestSnap_Tolerance(t *testing.T) {
+	s := tokens.NewSet(siteVars, nil, tokens.Options{LengthTolerance: 0.01})
+	settings := map[string]interface{}{"_gap": "23px"}
+	var r tokens.Report
+	s.SnapSettings("x", settings, &r)
+	if settings["_gap"] != "var(--space-m)" {
+		t.Errorf("expected 23px within 1%% of 23.2px, got %v", settings["_gap"])
+	}
+	settings["_gap"] = "24px"
+	s.SnapSettings("x", settings, &r)
+	if settings["_gap"] != "24px" || len(r.NearMisses) != 0 {
+		t.Errorf("expected 24px left alone without a near miss, got %v %+v", settings["_gap"], r.NearMisses)
+	}
+}
+
+func TestSnap_ZeroToleranceIsExact(t *testing.T) {
+	s := tokens.NewSet(siteVars, nil, tokens.Options{})
+	settings := map[string]interface{}{"_gap": "16px", "_margin": map[string]interface{}{"top": "15px"}}
+	var r tokens.Report
+	s.SnapSettings("x", settings, &r)
+	if settings["_gap"] != "var(--space-s)" {
+		t.Errorf("expected 16px to match 1rem exactly, got %v", settings["_gap"])
+	}
+	if m := settings["_margin"].(map[string]interface{}); m["top"] != "15px" || len(r.NearMisses) != 0 {
+		t.Errorf("expected 15px left alone without a near miss, got %v %+v", m["top"], r.NearMisses)
+	}
+}
@@ -0,0 +1,413 @@
+package tokens
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// lengthEvaluator computes CSS lengths in px: numbers with px, rem, em and
+// vw units, and calc(), clamp(), min() and max() over them.
+type lengthEvaluator struct {
+	rootFontSize float64
+	viewport     float64
+}
+
+// quantity is a computed value: px, or a plain number when unitless.
+type quantity struct {
+	v        float64
+	unitless bool
+}
+
+// px returns the value in px, or false when it isn't a length.
+func (e lengthEvaluator) px(value string) (float64, bool) {
+	p := &lengthParser{src: strings.ToLower(strings.TrimSpace(value)), e: e}
+	q, err := p.expr()
+	if err != nil {
+		return 0, false
+	}
+	if p.skipSpace(); p.pos != len(p.src) {
+		return 0, false
+	}
+	// 0 is a length without a unit
+	if q.unitless && q.v != 0 {
+		return 0, false
+	}
+	return q.v, true
+}
+
+type lengthParser struct {
+	src string
+	pos int
+	e   lengthEvaluator
+}
+
+func (p *lengthParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *lengthParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *lengthParser) expr() (quantity, error) {
+	left, err := p.term()
+	if err != nil {
+		return left, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return left, err
+		}
+		if left.unitless != right.unitless && left.v != 0 && right.v != 0 {
+			return left, fmt.Errorf("mixed units")
+		}
+		unitless := left.unitless && right.unitless
+		if op == '+' {
+			left = quantity{left.v + right.v, unitless}
+		} else {
+			left = quantity{left.v - right.v, unitless}
+		}
+	}
+}
+
+func (p *lengthParser) term() (quantity, error) {
+	left, err := p.factor()
+	if err != nil {
+		return left, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return left, err
+		}
+		switch {
+		case op == '*' && (left.unitless || right.unitless):
+			left = quantity{left.v * right.v, left.unitless && right.unitless}
+		case op == '/' && right.unitless && right.v != 0:
+			left = quantity{left.v / right.v, left.unitless}
+		default:
+			return left, fmt.Errorf("invalid %c", op)
+		}
+	}
+}
+
+func (p *lengthParser) factor() (quantity, error) {
+	switch c := p.peek(); {
+	case c == '-':
+		p.pos++
+		q, err := p.factor()
+		q.v = -q.v
+		return q, err
+	case c == '(':
+		p.pos++
+		q, err := p.expr()
+		if err != nil {
+			return q, err
+		}
+		if p.peek() != ')' {
+			return q, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return q, nil
+	case c == '.' || (c >= '0' && c <= '9') || c == '+':
+		return p.number()
+	case c >= 'a' && c <= 'z':
+		return p.function()
+	}
+	return quantity{}, fmt.Errorf("unexpected input at %d", p.pos)
+}
+
+func (p *lengthParser) number() (quantity, error) {
+	start := p.pos
+	if p.src[p.pos] == '+' {
+		p.pos++
+	}
+	for p.pos < len(p.src) && (p.src[p.pos] == '.' || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+		p.pos++
+	}
+	v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return quantity{}, err
+	}
+	unitStart := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z' {
+		p.pos++
+	}
+	switch p.src[unitStart:p.pos] {
+	case "":
+		return quantity{v, true}, nil
+	case "px":
+		return quantity{v, false}, nil
+	case "rem", "em":
+		return quantity{v * p.e.rootFontSize, false}, nil
+	case "vw":
+		return quantity{v * p.e.viewport / 100, false}, nil
+	}
+	return quantity{}, fmt.Errorf("unsupported unit %q", p.src[unitStart:p.pos])
+}
+
+func (p *lengthParser) function() (quantity, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z') {
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+	if p.peek() != '(' {
+		return quantity{}, fmt.Errorf("unexpected %q", name)
+	}
+	p.pos++
+	var args []quantity
+	for {
+		q, err := p.expr()
+		if err != nil {
+			return q, err
+		}
+		args = append(args, q)
+		c := p.peek()
+		p.pos++
+		if c == ')' {
+			break
+		}
+		if c != ',' {
+			return q, fmt.Errorf("expected , or )")
+		}
+	}
+	switch {
+	case name == "calc" && len(args) == 1:
+		return args[0], nil
+	case name == "clamp" && len(args) == 3:
+		return quantity{math.Max(args[0].v, math.Min(args[1].v, args[2].v)), args[1].unitless}, nil
+	case name == "min" || name == "max":
+		q := args[0]
+		for _, a := range args[1:] {
+			if (name == "min") == (a.v < q.v) {
+				q = a
+			}
+		}
+		return q, nil
+	}
+	return quantity{}, fmt.Errorf("unsupported function %s()", name)
+}
+
+// rgba is a color with 0-255 channels and 0-1 alpha.
+type rgba struct{ r, g, b, a float64 }
+
+var namedColors = map[string]rgba{
+	"black": {0, 0, 0, 1}, "white": {255, 255, 255, 1}, "red": {255, 0, 0, 1},
+	"green": {0, 128, 0, 1}, "blue": {0, 0, 255, 1}, "yellow": {255, 255, 0, 1},
+	"gray": {128, 128, 128, 1}, "grey": {128, 128, 128, 1}, "silver": {192, 192, 192, 1},
+	"maroon": {128, 0, 0, 1}, "purple": {128, 0, 128, 1}, "fuchsia": {255, 0, 255, 1},
+	"lime": {0, 255, 0, 1}, "olive": {128, 128, 0, 1}, "navy": {0, 0, 128, 1},
+	"teal": {0, 128, 128, 1}, "aqua": {0, 255, 255, 1}, "orange": {255, 165, 0, 1},
+}
+
+// parseColor reads hex, rgb(), rgba(), hsl(), hsla() and basic named
+// colors.
+func parseColor(value string) (rgba, bool) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if c, ok := namedColors[v]; ok {
+		return c, true
+	}
+	if strings.HasPrefix(v, "#") {
+		return parseHex(v[1:])
+	}
+	open := strings.IndexByte(v, '(')
+	if open < 0 || !strings.HasSuffix(v, ")") {
+		return rgba{}, false
+	}
+	name := v[:open]
+	args := strings.FieldsFunc(v[open+1:len(v)-1], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+	if len(args) != 3 && len(args) != 4 {
+		return rgba{}, false
+	}
+	alpha := 1.0
+	if len(args) == 4 {
+		a, ok := channel(args[3], 1)
+		if !ok {
+			return rgba{}, false
+		}
+		alpha = a
+	}
+	switch name {
+	case "rgb", "rgba":
+		var ch [3]float64
+		for i := range ch {
+			c, ok := channel(args[i], 255)
+			if !ok {
+				return rgba{}, false
+			}
+			ch[i] = c
+		}
+		return rgba{ch[0], ch[1], ch[2], alpha}, true
+	case "hsl", "hsla":
+		h, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "deg"), 64)
+		s, okS := channel(args[1], 1)
+		l, okL := channel(args[2], 1)
+		if err != nil || !okS || !okL || !strings.HasSuffix(args[1], "%") || !strings.HasSuffix(args[2], "%") {
+			return rgba{}, false
+		}
+		r, g, b := hslToRGB(h, s, l)
+		return rgba{r, g, b, alpha}, true
+	}
+	return rgba{}, false
+}
+
+// channel reads a number or percentage, with percentages scaled to max.
+func channel(s string, max float64) (float64, bool) {
+	if strings.HasSuffix(s, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		return f / 100 * max, err == nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+func parseHex(h string) (rgba, bool) {
+	if len(h) == 3 || len(h) == 4 {
+		var long strings.Builder
+		for _, c := range h {
+			long.WriteRune(c)
+			long.WriteRune(c)
+		}
+		h = long.String()
+	}
+	if len(h) != 6 && len(h) != 8 {
+		return rgba{}, false
+	}
+	n, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return rgba{}, false
+	}
+	if len(h) == 6 {
+		return rgba{float64(n >> 16 & 0xff), float64(n >> 8 & 0xff), float64(n & 0xff), 1}, true
+	}
+	return rgba{float64(n >> 24 & 0xff), float64(n >> 16 & 0xff), float64(n >> 8 & 0xff), float64(n&0xff) / 255}, true
+}
+
+func hslToRGB(h, s, l float64) (float64, float64, float64) {
+	h = math.Mod(math.Mod(h, 360)+360, 360) / 360
+	if s == 0 {
+		return l * 255, l * 255, l * 255
+	}
+	q := l * (1 + s)
+	if l >= 0.5 {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	hue := func(t float64) float64 {
+		t = math.Mod(t+1, 1)
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 0.5:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		}
+		return p
+	}
+	return hue(h+1.0/3) * 255, hue(h) * 255, hue(h-1.0/3) * 255
+}
+
+// lab is a CIELAB color (D65).
+type lab struct{ l, a, b float64 }
+
+func (c rgba) lab() lab {
+	lin := func(v float64) float64 {
+		v /= 255
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	r, g, b := lin(c.r), lin(c.g), lin(c.b)
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return lab{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// deltaE2000 is the CIEDE2000 color difference.
+func deltaE2000(c1, c2 lab) float64 {
+	rad := math.Pi / 180
+	C1 := math.Hypot(c1.a, c1.b)
+	C2 := math.Hypot(c2.a, c2.b)
+	Cm := (C1 + C2) / 2
+	G := 0.5 * (1 - math.Sqrt(math.Pow(Cm, 7)/(math.Pow(Cm, 7)+math.Pow(25, 7))))
+	a1, a2 := (1+G)*c1.a, (1+G)*c2.a
+	C1p, C2p := math.Hypot(a1, c1.b), math.Hypot(a2, c2.b)
+	hp := func(a, b float64) float64 {
+		if a == 0 && b == 0 {
+			return 0
+		}
+		h := math.Atan2(b, a) / rad
+		if h < 0 {
+			h += 360
+		}
+		return h
+	}
+	h1, h2 := hp(a1, c1.b), hp(a2, c2.b)
+
+	dL := c2.l - c1.l
+	dC := C2p - C1p
+	dh := 0.0
+	if C1p*C2p != 0 {
+		dh = h2 - h1
+		if dh > 180 {
+			dh -= 360
+		} else if dh < -180 {
+			dh += 360
+		}
+	}
+	dH := 2 * math.Sqrt(C1p*C2p) * math.Sin(dh/2*rad)
+
+	Lm := (c1.l + c2.l) / 2
+	Cmp := (C1p + C2p) / 2
+	hm := h1 + h2
+	if C1p*C2p != 0 {
+		switch {
+		case math.Abs(h1-h2) <= 180:
+			hm = (h1 + h2) / 2
+		case h1+h2 < 360:
+			hm = (h1 + h2 + 360) / 2
+		default:
+			hm = (h1 + h2 - 360) / 2
+		}
+	}
+	T := 1 - 0.17*math.Cos((hm-30)*rad) + 0.24*math.Cos(2*hm*rad) +
+		0.32*math.Cos((3*hm+6)*rad) - 0.20*math.Cos((4*hm-63)*rad)
+	dTheta := 30 * math.Exp(-math.Pow((hm-275)/25, 2))
+	Rc := 2 * math.Sqrt(math.Pow(Cmp, 7)/(math.Pow(Cmp, 7)+math.Pow(25, 7)))
+	Sl := 1 + 0.015*math.Pow(Lm-50, 2)/math.Sqrt(20+math.Pow(Lm-50, 2))
+	Sc := 1 + 0.045*Cmp
+	Sh := 1 + 0.015*Cmp*T
+	Rt := -math.Sin(2*dTheta*rad) * Rc
+
+	return math.Sqrt(math.Pow(dL/Sl, 2) + math.Pow(dC/Sc, 2) + math.Pow(dH/Sh, 2) + Rt*(dC/Sc)*(dH/Sh))
+}
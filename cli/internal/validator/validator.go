@@ -9,8 +9,9 @@ import (
 // Known valid Bricks element types.
 var ValidTypes = map[string]bool{
 	"section": true, "container": true, "block": true, "div": true,
-	"heading": true, "text-basic": true, "rich-text": true, "text-link": true,
-	"button": true, "icon": true, "image": true, "video": true,
+	"heading": true, "text-basic": true, "rich-text": true, "text-link": true, "text": true,
+	"button": true, "icon": true, "image": true, "video": true, "audio": true,
+	"svg": true, "divider": true,
 	"nav-menu": true, "nav-nested": true, "offcanvas": true,
 	"accordion": true, "accordion-nested": true, "tabs": true, "tabs-nested": true,
 	"slider": true, "slider-nested": true, "carousel": true,
@@ -0,0 +1,254 @@
+// Package webpage fetches a live page for conversion: its HTML with
+// relative URLs made absolute, and the stylesheets it uses.
+package webpage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultMaxBytes caps the size of each fetched resource.
+const DefaultMaxBytes = 10 << 20
+
+// ErrInvalidURL is returned by Fetch for a URL that is not http or https.
+var ErrInvalidURL = errors.New("want an http or https URL")
+
+// Options configures Fetch.
+type Options struct {
+	// Client makes the requests. Defaults to a client with a 30s timeout.
+	Client *http.Client
+	// MaxBytes caps each resource; larger ones are an error (the page) or
+	// skipped with a warning (stylesheets). Defaults to DefaultMaxBytes.
+	MaxBytes int64
+	// SkipCSS leaves linked stylesheets and <style> blocks out.
+	SkipCSS bool
+}
+
+// Page is a fetched page.
+type Page struct {
+	// URL is the page's final URL, after redirects.
+	URL string
+	// HTML is the document with src, href, srcset, poster and action
+	// URLs, and url() in style attributes, made absolute.
+	HTML string
+	// CSS is the page's linked stylesheets and <style> blocks in document
+	// order, with url() references made absolute.
+	CSS string
+	// Stylesheets lists the stylesheet URLs fetched.
+	Stylesheets []string
+	// Warnings lists stylesheets that could not be fetched and @import
+	// rules, which are not followed.
+	Warnings []string
+}
+
+// Fetch downloads the page at rawURL and its stylesheets.
+func Fetch(ctx context.Context, rawURL string, opts Options) (*Page, error) {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, ErrInvalidURL)
+	}
+
+	body, final, err := get(ctx, opts, u.String())
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", final, err)
+	}
+
+	page := &Page{URL: final.String()}
+	base := final
+	if b := firstElement(doc, "base"); b != nil {
+		if href := attr(b, "href"); href != "" {
+			if ref, err := final.Parse(href); err == nil {
+				base = ref
+			}
+		}
+	}
+
+	var css strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			absolutize(n, base)
+			switch {
+			case opts.SkipCSS:
+			case n.Data == "link" && hasToken(attr(n, "rel"), "stylesheet") && attr(n, "media") != "print":
+				if href := attr(n, "href"); href != "" {
+					page.addStylesheet(ctx, opts, href, &css)
+				}
+			case n.Data == "style":
+				page.addCSS(textOf(n), base, &css)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, err
+	}
+	page.HTML = buf.String()
+	page.CSS = css.String()
+	return page, nil
+}
+
+// get fetches one resource, returning its body and final URL.
+func get(ctx context.Context, opts Options, rawURL string) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", "agent-to-bricks")
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to fetch %s: HTTP %d", rawURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, opts.MaxBytes+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+	if int64(len(body)) > opts.MaxBytes {
+		return nil, nil, fmt.Errorf("%s is larger than %d bytes", rawURL, opts.MaxBytes)
+	}
+	return body, resp.Request.URL, nil
+}
+
+func (p *Page) addStylesheet(ctx context.Context, opts Options, href string, css *strings.Builder) {
+	body, final, err := get(ctx, opts, href)
+	if err != nil {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("skipping stylesheet: %v", err))
+		return
+	}
+	p.Stylesheets = append(p.Stylesheets, final.String())
+	p.addCSS(string(body), final, css)
+}
+
+var (
+	cssURL    = regexp.MustCompile(`url\(\s*(['"]?)([^'")]*)(['"]?)\s*\)`)
+	cssImport = regexp.MustCompile(`(?i)@import\s+[^;]+;`)
+)
+
+func (p *Page) addCSS(src string, base *url.URL, css *strings.Builder) {
+	src = cssImport.ReplaceAllStringFunc(src, func(rule string) string {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("not following %s", strings.TrimSpace(rule)))
+		return ""
+	})
+	css.WriteString(resolveCSSURLs(src, base))
+	css.WriteString("\n")
+}
+
+// resolveCSSURLs makes the url() references in CSS absolute.
+func resolveCSSURLs(src string, base *url.URL) string {
+	return cssURL.ReplaceAllStringFunc(src, func(m string) string {
+		parts := cssURL.FindStringSubmatch(m)
+		ref := parts[2]
+		if ref == "" || strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+			return m
+		}
+		abs, err := base.Parse(ref)
+		if err != nil {
+			return m
+		}
+		return "url(" + parts[1] + abs.String() + parts[3] + ")"
+	})
+}
+
+// urlAttributes are the attributes holding a URL.
+var urlAttributes = map[string]bool{"src": true, "href": true, "poster": true, "action": true, "data-src": true}
+
+// absolutize makes n's URL attributes absolute.
+func absolutize(n *html.Node, base *url.URL) {
+	for i, a := range n.Attr {
+		switch {
+		case urlAttributes[a.Key]:
+			v := strings.TrimSpace(a.Val)
+			if v == "" || strings.HasPrefix(v, "#") || strings.HasPrefix(v, "data:") ||
+				strings.HasPrefix(v, "mailto:") || strings.HasPrefix(v, "tel:") || strings.HasPrefix(v, "javascript:") {
+				continue
+			}
+			if abs, err := base.Parse(v); err == nil {
+				n.Attr[i].Val = abs.String()
+			}
+		case a.Key == "srcset":
+			var out []string
+			for _, candidate := range strings.Split(a.Val, ",") {
+				fields := strings.Fields(candidate)
+				if len(fields) == 0 {
+					continue
+				}
+				if abs, err := base.Parse(fields[0]); err == nil {
+					fields[0] = abs.String()
+				}
+				out = append(out, strings.Join(fields, " "))
+			}
+			n.Attr[i].Val = strings.Join(out, ", ")
+		case a.Key == "style":
+			n.Attr[i].Val = resolveCSSURLs(a.Val, base)
+		}
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(strings.ToLower(list)) {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
+
+func textOf(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+	}
+	return sb.String()
+}
+
+func firstElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := firstElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package webpage_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/webpage"
+)
+
+func fixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blog/post", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head>
+<link rel="stylesheet" href="../css/site.css">
+<link rel="stylesheet" href="/css/print.css" media="print">
+<link rel="stylesheet" href="/css/missing.css">
+<style>.hero { background: url(img/bg.png) }</style>
+</head><body>
+<img src="img/a.png" srcset="img/a.png 1x, /img/a@2x.png 2x">
+<a href="#top">top</a> <a href="mailto:a@b.c">mail</a> <a href="/about">about</a>
+<div style="background-image: url('hero.jpg')"></div>
+</body></html>`))
+	})
+	mux.HandleFunc("/css/site.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`@import url("reset.css");
+.card { background: url(../img/card.png); }`))
+	})
+	mux.HandleFunc("/css/print.css", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("print stylesheet should not be fetched")
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestFetch(t *testing.T) {
+	ts := fixtureServer(t)
+	page, err := webpage.Fetch(context.Background(), ts.URL+"/blog/post", webpage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`src="` + ts.URL + `/blog/img/a.png"`,
+		`srcset="` + ts.URL + `/blog/img/a.png 1x, ` + ts.URL + `/img/a@2x.png 2x"`,
+		`href="#top"`, `href="mailto:a@b.c"`, `href="` + ts.URL + `/about"`,
+		`url(&#39;` + ts.URL + `/blog/hero.jpg&#39;)`,
+	} {
+		if !strings.Contains(page.HTML, want) {
+			t.Errorf("expected HTML containing %s, got\n%s", want, page.HTML)
+		}
+	}
+
+	if !strings.Contains(page.CSS, "url("+ts.URL+"/img/card.png)") || !strings.Contains(page.CSS, "url("+ts.URL+"/blog/img/bg.png)") {
+		t.Errorf("expected url() resolved against each stylesheet, got\n%s", page.CSS)
+	}
+	if strings.Contains(page.CSS, "@import") {
+		t.Errorf("expected @import removed, got\n%s", page.CSS)
+	}
+	if len(page.Stylesheets) != 1 || page.Stylesheets[0] != ts.URL+"/css/site.css" {
+		t.Errorf("unexpected stylesheets %v", page.Stylesheets)
+	}
+	if len(page.Warnings) != 2 {
+		t.Errorf("expected warnings for the missing stylesheet and @import, got %v", page.Warnings)
+	}
+}
+
+func TestFetchSkipCSSAndErrors(t *testing.T) {
+	ts := fixtureServer(t)
+	page, err := webpage.Fetch(context.Background(), ts.URL+"/blog/post", webpage.Options{SkipCSS: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.CSS != "" || len(page.Stylesheets) != 0 || len(page.Warnings) != 0 {
+		t.Errorf("expected no CSS with SkipCSS, got %q %v %v", page.CSS, page.Stylesheets, page.Warnings)
+	}
+
+	if _, err := webpage.Fetch(context.Background(), ts.URL+"/nope", webpage.Options{}); err == nil {
+		t.Error("expected an error for a 404 page")
+	}
+	if _, err := webpage.Fetch(context.Background(), "file:///etc/passwd", webpage.Options{}); err == nil {
+		t.Error("expected an error for a non-HTTP URL")
+	}
+	if _, err := webpage.Fetch(context.Background(), ts.URL+"/blog/post", webpage.Options{MaxBytes: 10}); err == nil {
+		t.Error("expected an error for a page over MaxBytes")
+	}
+}
@@ -0,0 +1,86 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// keepBases is how many pulled versions are kept per page in a BaseCache.
+const keepBases = 5
+
+// BaseCache keeps copies of pulled element lists keyed by page and content
+// hash, so a later push of an edited file can be three-way merged against the
+// version it was pulled from.
+type BaseCache struct {
+	Dir string
+}
+
+func (b BaseCache) pageDir(pageID int) string {
+	return filepath.Join(b.Dir, fmt.Sprintf("%d", pageID))
+}
+
+// Save stores elements for a page version and prunes old versions.
+func (b BaseCache) Save(pageID int, hash string, elements []map[string]interface{}) error {
+	if hash == "" || strings.ContainsAny(hash, `/\.`) {
+		return nil
+	}
+	dir := b.pageDir(pageID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(elements)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, hash+".json"), data, 0644); err != nil {
+		return err
+	}
+	return b.prune(dir)
+}
+
+// Load returns the elements stored for a page version.
+func (b BaseCache) Load(pageID int, hash string) ([]map[string]interface{}, bool) {
+	if hash == "" || strings.ContainsAny(hash, `/\.`) {
+		return nil, false
+	}
+	data, err := os.ReadFile(filepath.Join(b.pageDir(pageID), hash+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var elements []map[string]interface{}
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, false
+	}
+	return elements, true
+}
+
+func (b BaseCache) prune(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) <= keepBases {
+		return nil
+	}
+	type file struct {
+		name string
+		mod  int64
+	}
+	var files []file
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{e.Name(), info.ModTime().UnixNano()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod > files[j].mod })
+	for _, f := range files[keepBases:] {
+		os.Remove(filepath.Join(dir, f.name))
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package workspace
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Change summarises how one element differs from the base.
+type Change struct {
+	ID   string   `json:"id"`
+	Name string   `json:"name"`
+	Kind string   `json:"kind"`           // "added", "removed", "modified"
+	Keys []string `json:"keys,omitempty"` // changed keys, e.g. "children", "settings.text"
+}
+
+// Delta is the minimal set of API operations that turns base into local.
+type Delta struct {
+	Added   []map[string]interface{} // new elements, in local order
+	Removed []string                 // IDs of deleted elements
+	Patches []map[string]interface{} // PATCH payloads; null settings remove keys
+	Changes []Change
+}
+
+// Empty reports whether local matches base.
+func (d *Delta) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// ComputeDelta compares two flat element lists by element ID.
+func ComputeDelta(base, local []map[string]interface{}) *Delta {
+	d := &Delta{}
+	baseByID := indexByID(base)
+	localByID := indexByID(local)
+
+	for _, el := range local {
+		id, _ := el["id"].(string)
+		name, _ := el["name"].(string)
+		old, ok := baseByID[id]
+		if !ok {
+			d.Added = append(d.Added, el)
+			d.Changes = append(d.Changes, Change{ID: id, Name: name, Kind: "added"})
+			continue
+		}
+		patch, keys := elementPatch(old, el)
+		if len(keys) > 0 {
+			d.Patches = append(d.Patches, patch)
+			d.Changes = append(d.Changes, Change{ID: id, Name: name, Kind: "modified", Keys: keys})
+		}
+	}
+
+	for _, el := range base {
+		id, _ := el["id"].(string)
+		if _, ok := localByID[id]; !ok {
+			name, _ := el["name"].(string)
+			d.Removed = append(d.Removed, id)
+			d.Changes = append(d.Changes, Change{ID: id, Name: name, Kind: "removed"})
+		}
+	}
+
+	return d
+}
+
+// elementPatch builds a PATCH payload for one element and lists the changed keys.
+func elementPatch(old, cur map[string]interface{}) (map[string]interface{}, []string) {
+	patch := map[string]interface{}{"id": cur["id"]}
+	var keys []string
+
+	for _, k := range unionKeys(old, cur) {
+		if k == "id" || k == "settings" {
+			continue
+		}
+		ov, oldHas := old[k]
+		nv, curHas := cur[k]
+		if oldHas == curHas && reflect.DeepEqual(ov, nv) {
+			continue
+		}
+		if curHas {
+			patch[k] = nv
+		} else {
+			patch[k] = nil
+		}
+		keys = append(keys, k)
+	}
+
+	oldSettings, _ := old["settings"].(map[string]interface{})
+	curSettings, _ := cur["settings"].(map[string]interface{})
+	settings := map[string]interface{}{}
+	for _, k := range unionKeys(oldSettings, curSettings) {
+		ov, oldHas := oldSettings[k]
+		nv, curHas := curSettings[k]
+		if oldHas == curHas && reflect.DeepEqual(ov, nv) {
+			continue
+		}
+		if curHas {
+			settings[k] = nv
+		} else {
+			settings[k] = nil
+		}
+		keys = append(keys, "settings."+k)
+	}
+	if len(settings) > 0 {
+		patch["settings"] = settings
+	}
+
+	return patch, keys
+}
+
+func indexByID(elements []map[string]interface{}) map[string]map[string]interface{} {
+	m := make(map[string]map[string]interface{}, len(elements))
+	for _, el := range elements {
+		if id, _ := el["id"].(string); id != "" {
+			m[id] = el
+		}
+	}
+	return m
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for k := range a {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	for k := range b {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,184 @@
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetaDir is the metadata directory at the root of a workspace.
+const MetaDir = ".bricks"
+
+// ErrNotWorkspace is returned by Find when no .bricks directory exists in the
+// given directory or any of its parents.
+var ErrNotWorkspace = errors.New("not a bricks workspace (or any parent directory): .bricks not found")
+
+// Workspace is a local working copy of one or more Bricks pages.
+//
+// Layout:
+//
+//	page-123.json           editable working file (same shape as `site pull`)
+//	.bricks/pages/123.json  PageState: base hash and base elements
+type Workspace struct {
+	Root string
+}
+
+// PageState records what a page looked like when it was last cloned or
+// committed, so local and remote edits can be detected against it.
+type PageState struct {
+	PageID       int                      `json:"pageId"`
+	SiteURL      string                   `json:"siteUrl"`
+	File         string                   `json:"file"`
+	BaseHash     string                   `json:"baseHash"`
+	BaseElements []map[string]interface{} `json:"baseElements"`
+	UpdatedAt    time.Time                `json:"updatedAt"`
+}
+
+// workingFile mirrors the payload written by `bricks site pull`.
+type workingFile struct {
+	Elements    []map[string]interface{} `json:"elements"`
+	ContentHash string                   `json:"contentHash"`
+	Count       int                      `json:"count"`
+}
+
+// Init creates (or reuses) a workspace rooted at dir.
+func Init(dir string) (*Workspace, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(abs, MetaDir, "pages"), 0755); err != nil {
+		return nil, err
+	}
+	return &Workspace{Root: abs}, nil
+}
+
+// Find locates the workspace containing dir by walking up to the filesystem root.
+func Find(dir string) (*Workspace, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(abs, MetaDir)); err == nil && info.IsDir() {
+			return &Workspace{Root: abs}, nil
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return nil, ErrNotWorkspace
+		}
+		abs = parent
+	}
+}
+
+// DefaultFile returns the working file name used for a newly cloned page.
+func DefaultFile(pageID int) string {
+	return fmt.Sprintf("page-%d.json", pageID)
+}
+
+func (w *Workspace) statePath(pageID int) string {
+	return filepath.Join(w.Root, MetaDir, "pages", fmt.Sprintf("%d.json", pageID))
+}
+
+// Page loads the tracked state for a page.
+func (w *Workspace) Page(pageID int) (*PageState, error) {
+	data, err := os.ReadFile(w.statePath(pageID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("page %d is not tracked in this workspace", pageID)
+		}
+		return nil, err
+	}
+	var p PageState
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("corrupt state for page %d: %w", pageID, err)
+	}
+	return &p, nil
+}
+
+// Pages returns every tracked page, ordered by page ID.
+func (w *Workspace) Pages() ([]*PageState, error) {
+	entries, err := os.ReadDir(filepath.Join(w.Root, MetaDir, "pages"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var pages []*PageState
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		var id int
+		if _, err := fmt.Sscanf(e.Name(), "%d.json", &id); err != nil {
+			continue
+		}
+		p, err := w.Page(id)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, p)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].PageID < pages[j].PageID })
+	return pages, nil
+}
+
+// SaveState writes the tracked state for a page.
+func (w *Workspace) SaveState(p *PageState) error {
+	p.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.statePath(p.PageID)), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(w.statePath(p.PageID), data, 0644)
+}
+
+// Checkout records elements as the new base for a page and overwrites its
+// working file with them.
+func (w *Workspace) Checkout(p *PageState, elements []map[string]interface{}, hash string) error {
+	if elements == nil {
+		elements = []map[string]interface{}{}
+	}
+	p.BaseHash = hash
+	p.BaseElements = elements
+	if err := w.SaveState(p); err != nil {
+		return err
+	}
+	return w.WriteLocal(p, elements)
+}
+
+// ReadLocal reads the elements from a page's working file.
+func (w *Workspace) ReadLocal(p *PageState) ([]map[string]interface{}, error) {
+	data, err := os.ReadFile(filepath.Join(w.Root, p.File))
+	if err != nil {
+		return nil, err
+	}
+	var wf workingFile
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", p.File, err)
+	}
+	return wf.Elements, nil
+}
+
+// WriteLocal overwrites a page's working file. The file carries the base hash
+// so it can still be sent with `bricks site push`.
+func (w *Workspace) WriteLocal(p *PageState, elements []map[string]interface{}) error {
+	data, err := json.MarshalIndent(workingFile{
+		Elements:    elements,
+		ContentHash: p.BaseHash,
+		Count:       len(elements),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(w.Root, p.File), data, 0644)
+}
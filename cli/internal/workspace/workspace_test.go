@@ -0,0 +1,121 @@
+package workspace_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nerveband/agent-to-bricks/internal/workspace"
+)
+
+func sampleElements() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"id": "sec001", "name": "section", "parent": "0", "children": []interface{}{"hd0001", "tx0001"}, "settings": map[string]interface{}{}},
+		{"id": "hd0001", "name": "heading", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{"text": "Hello", "tag": "h1"}},
+		{"id": "tx0001", "name": "text-basic", "parent": "sec001", "children": []interface{}{}, "settings": map[string]interface{}{"text": "World"}},
+	}
+}
+
+func TestComputeDeltaNoChanges(t *testing.T) {
+	d := workspace.ComputeDelta(sampleElements(), sampleElements())
+	if !d.Empty() {
+		t.Fatalf("expected empty delta, got %+v", d.Changes)
+	}
+}
+
+func TestComputeDelta(t *testing.T) {
+	base := sampleElements()
+	local := sampleElements()
+
+	// Edit heading text, drop its tag, remove the text element, add a button.
+	local[1]["settings"] = map[string]interface{}{"text": "Hi"}
+	local = local[:2]
+	local[0]["children"] = []interface{}{"hd0001", "btn001"}
+	local = append(local, map[string]interface{}{
+		"id": "btn001", "name": "button", "parent": "sec001", "children": []interface{}{},
+		"settings": map[string]interface{}{"text": "Go"},
+	})
+
+	d := workspace.ComputeDelta(base, local)
+
+	if len(d.Added) != 1 || d.Added[0]["id"] != "btn001" {
+		t.Errorf("expected btn001 added, got %v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0] != "tx0001" {
+		t.Errorf("expected tx0001 removed, got %v", d.Removed)
+	}
+	if len(d.Patches) != 2 {
+		t.Fatalf("expected 2 patches, got %d: %v", len(d.Patches), d.Patches)
+	}
+
+	sec := d.Patches[0]
+	if sec["id"] != "sec001" || sec["children"] == nil {
+		t.Errorf("expected section children patch, got %v", sec)
+	}
+	if _, ok := sec["settings"]; ok {
+		t.Errorf("section settings unchanged, should not be patched: %v", sec)
+	}
+
+	hd := d.Patches[1]
+	settings := hd["settings"].(map[string]interface{})
+	if settings["text"] != "Hi" {
+		t.Errorf("expected text Hi, got %v", settings["text"])
+	}
+	if v, ok := settings["tag"]; !ok || v != nil {
+		t.Errorf("expected removed tag to be patched as null, got %v", settings)
+	}
+	if _, ok := hd["name"]; ok {
+		t.Errorf("unchanged name should not be patched: %v", hd)
+	}
+
+	if len(d.Changes) != 4 {
+		t.Errorf("expected 4 changes, got %d", len(d.Changes))
+	}
+}
+
+func TestWorkspaceCheckoutAndFind(t *testing.T) {
+	root := t.TempDir()
+	ws, err := workspace.Init(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := &workspace.PageState{PageID: 42, SiteURL: "https://example.com", File: workspace.DefaultFile(42)}
+	if err := ws.Checkout(state, sampleElements(), "hash1"); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(root, "nested", "dir")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	found, err := workspace.Find(sub)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if found.Root != ws.Root {
+		t.Errorf("expected root %s, got %s", ws.Root, found.Root)
+	}
+
+	pages, err := found.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 || pages[0].PageID != 42 || pages[0].BaseHash != "hash1" {
+		t.Fatalf("unexpected pages: %+v", pages)
+	}
+
+	local, err := found.ReadLocal(pages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !workspace.ComputeDelta(pages[0].BaseElements, local).Empty() {
+		t.Error("fresh checkout should have no local changes")
+	}
+}
+
+func TestFindOutsideWorkspace(t *testing.T) {
+	if _, err := workspace.Find(t.TempDir()); err != workspace.ErrNotWorkspace {
+		t.Errorf("expected ErrNotWorkspace, got %v", err)
+	}
+}
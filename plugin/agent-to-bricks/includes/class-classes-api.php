@@ -56,11 +56,24 @@ class ATB_Classes_API {
 
 	/**
 	 * List all global classes with framework tagging.
+	 *
+	 * The response carries a hash of the stored classes, also sent as the
+	 * ETag. A request whose If-None-Match matches it gets 304 Not Modified
+	 * with no body, so clients can revalidate a cached copy cheaply.
 	 */
 	public static function list_classes( $request ) {
 		$classes  = self::get_all_classes();
 		$framework_filter = $request->get_param( 'framework' );
 
+		$hash = md5( wp_json_encode( $classes ) );
+		$etag = '"' . $hash . '"';
+		$if_none_match = $request->get_header( 'if_none_match' );
+		if ( $if_none_match && in_array( $etag, array_map( 'trim', explode( ',', $if_none_match ) ), true ) ) {
+			$response = new WP_REST_Response( null, 304 );
+			$response->header( 'ETag', $etag );
+			return $response;
+		}
+
 		$result = array();
 		foreach ( $classes as $class ) {
 			$tagged = self::tag_class( $class );
@@ -70,11 +83,14 @@ class ATB_Classes_API {
 			$result[] = $tagged;
 		}
 
-		return new WP_REST_Response( array(
+		$response = new WP_REST_Response( array(
 			'classes' => $result,
 			'count'   => count( $result ),
 			'total'   => count( $classes ),
+			'hash'    => $hash,
 		), 200 );
+		$response->header( 'ETag', $etag );
+		return $response;
 	}
 
 	/**
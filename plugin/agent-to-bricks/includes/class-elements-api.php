@@ -383,7 +383,16 @@ class ATB_Elements_API {
 
 	/**
 	 * POST /pages/{id}/elements/batch — execute multiple operations atomically.
-	 * Body: { "operations": [{ "op": "append|patch|delete", ... }] }
+	 * Body: { "operations": [{ "op": "append|insert|patch|move|delete", ... }] }
+	 *
+	 * append: { elements, parentId? }          add to the end of a parent (or the page)
+	 * insert: { elements, parentId?, index }   add at a child index of a parent
+	 * move:   { id, parentId?, index? }        move an element (and its subtree)
+	 * patch:  { patches }                      same payload as PATCH /elements
+	 * delete: { ids }                          same payload as DELETE /elements
+	 *
+	 * A missing or "0" parentId means the page root. Errors include failedOp,
+	 * the index of the operation that stopped the batch; nothing is written.
 	 */
 	public static function batch_operations( $request ) {
 		$post_id = (int) $request->get_param( 'id' );
@@ -411,10 +420,65 @@ class ATB_Elements_API {
 
 			switch ( $op_type ) {
 				case 'append':
+				case 'insert':
 					$new_els = ATB_Element_Validator::sanitize_flat_elements( $op['elements'] ?? array() );
 					$new_els = self::sanitize_elements( $new_els );
-					$elements = array_merge( $elements, $new_els );
-					$results[] = array( 'op' => 'append', 'added' => count( $new_els ) );
+					$existing = array();
+					foreach ( $elements as $el ) {
+						if ( isset( $el['id'] ) ) $existing[ $el['id'] ] = true;
+					}
+					foreach ( $new_els as $el ) {
+						$el_id = $el['id'] ?? '';
+						if ( isset( $existing[ $el_id ] ) ) {
+							return self::batch_error( $op_idx, "element \"$el_id\" already exists.", 400 );
+						}
+						$existing[ $el_id ] = true;
+					}
+					if ( $op_type === 'append' && ! isset( $op['parentId'] ) ) {
+						$elements = array_merge( $elements, $new_els );
+					} else {
+						$parent_id = sanitize_text_field( $op['parentId'] ?? '0' );
+						$index     = ( $op_type === 'insert' && isset( $op['index'] ) ) ? (int) $op['index'] : PHP_INT_MAX;
+						$placed    = self::batch_place( $elements, $new_els, $parent_id, $index );
+						if ( is_wp_error( $placed ) ) {
+							return self::batch_error( $op_idx, $placed->get_error_message(), 404 );
+						}
+						$elements = $placed;
+					}
+					$results[] = array( 'op' => $op_type, 'added' => count( $new_els ) );
+					break;
+
+				case 'move':
+					$el_id  = sanitize_text_field( $op['id'] ?? '' );
+					$moving = null;
+					foreach ( $elements as $i => $el ) {
+						if ( isset( $el['id'] ) && $el['id'] === $el_id ) {
+							$moving = $el;
+							array_splice( $elements, $i, 1 );
+							break;
+						}
+					}
+					if ( ! $moving ) {
+						return self::batch_error( $op_idx, "element '$el_id' not found.", 404 );
+					}
+					foreach ( $elements as &$el ) {
+						if ( isset( $el['children'] ) && is_array( $el['children'] ) ) {
+							$el['children'] = array_values( array_diff( $el['children'], array( $el_id ) ) );
+						}
+					}
+					unset( $el );
+
+					$parent_id = sanitize_text_field( $op['parentId'] ?? '0' );
+					if ( self::batch_is_descendant( $elements, $parent_id, $el_id ) ) {
+						return self::batch_error( $op_idx, "cannot move '$el_id' into itself.", 400 );
+					}
+					$index  = isset( $op['index'] ) ? (int) $op['index'] : PHP_INT_MAX;
+					$placed = self::batch_place( $elements, array( $moving ), $parent_id, $index );
+					if ( is_wp_error( $placed ) ) {
+						return self::batch_error( $op_idx, $placed->get_error_message(), 404 );
+					}
+					$elements  = $placed;
+					$results[] = array( 'op' => 'move', 'moved' => 1 );
 					break;
 
 				case 'patch':
@@ -426,9 +490,7 @@ class ATB_Elements_API {
 					foreach ( $patches as $patch ) {
 						$el_id = $patch['id'] ?? null;
 						if ( ! $el_id || ! isset( $index[ $el_id ] ) ) {
-							return new WP_REST_Response( array(
-								'error' => "Batch op $op_idx: element '$el_id' not found.",
-							), 404 );
+							return self::batch_error( $op_idx, "element '$el_id' not found.", 404 );
 						}
 						$idx = $index[ $el_id ];
 						foreach ( $patch as $key => $value ) {
@@ -479,9 +541,7 @@ class ATB_Elements_API {
 					break;
 
 				default:
-					return new WP_REST_Response( array(
-						'error' => "Unknown operation '$op_type' at index $op_idx.",
-					), 400 );
+					return self::batch_error( $op_idx, "unknown operation '$op_type'.", 400 );
 			}
 		}
 
@@ -502,6 +562,95 @@ class ATB_Elements_API {
 		), 200 );
 	}
 
+	/**
+	 * Error response for a failed batch operation.
+	 */
+	private static function batch_error( $op_idx, $message, $status ) {
+		return new WP_REST_Response( array(
+			'error'    => "Batch op $op_idx: $message",
+			'failedOp' => $op_idx,
+		), $status );
+	}
+
+	/**
+	 * Whether $id is $ancestor_id or sits below it, following parent links.
+	 */
+	private static function batch_is_descendant( array $elements, $id, $ancestor_id ) {
+		$parents = array();
+		foreach ( $elements as $el ) {
+			if ( isset( $el['id'] ) ) {
+				$parents[ $el['id'] ] = $el['parent'] ?? '0';
+			}
+		}
+		$seen = array();
+		while ( $id !== '' && $id !== '0' && ! isset( $seen[ $id ] ) ) {
+			if ( $id === $ancestor_id ) {
+				return true;
+			}
+			$seen[ $id ] = true;
+			$id          = isset( $parents[ $id ] ) ? (string) $parents[ $id ] : '0';
+		}
+		return false;
+	}
+
+	/**
+	 * Place new elements under a parent at a child index.
+	 *
+	 * Elements whose parent is not among $new_els are the top of the inserted
+	 * subtree: they get $parent_id as parent and are spliced into the parent's
+	 * children. For the page root ("0") the flat order is what orders root
+	 * elements, so they are spliced before the $index-th root element instead.
+	 */
+	private static function batch_place( array $elements, array $new_els, $parent_id, $index ) {
+		$new_ids = array();
+		foreach ( $new_els as $el ) {
+			$new_ids[ $el['id'] ] = true;
+		}
+		$top_ids = array();
+		foreach ( $new_els as &$el ) {
+			if ( ! isset( $el['parent'] ) || ! isset( $new_ids[ $el['parent'] ] ) ) {
+				$el['parent'] = $parent_id;
+				$top_ids[]    = $el['id'];
+			}
+		}
+		unset( $el );
+
+		$index = max( 0, $index );
+
+		if ( $parent_id === '' || $parent_id === '0' ) {
+			$pos   = count( $elements );
+			$roots = 0;
+			foreach ( $elements as $i => $el ) {
+				if ( empty( $el['parent'] ) ) {
+					if ( $roots === $index ) {
+						$pos = $i;
+						break;
+					}
+					$roots++;
+				}
+			}
+			array_splice( $elements, $pos, 0, $new_els );
+			return $elements;
+		}
+
+		$found = false;
+		foreach ( $elements as &$el ) {
+			if ( isset( $el['id'] ) && $el['id'] === $parent_id ) {
+				$children = isset( $el['children'] ) && is_array( $el['children'] ) ? $el['children'] : array();
+				array_splice( $children, min( $index, count( $children ) ), 0, $top_ids );
+				$el['children'] = $children;
+				$found          = true;
+				break;
+			}
+		}
+		unset( $el );
+		if ( ! $found ) {
+			return new WP_Error( 'atb_parent_not_found', "parent element '$parent_id' not found." );
+		}
+
+		return array_merge( $elements, $new_els );
+	}
+
 	/**
 	 * Sanitize elements before writing to post meta.
 	 * Validates structure and applies wp_kses_post to text content.
@@ -137,11 +137,21 @@ class ATB_Site_API {
 					'sanitize_callback' => 'sanitize_text_field',
 					'default'           => '',
 				),
+				'slug'     => array(
+					'type'              => 'string',
+					'sanitize_callback' => 'sanitize_text_field',
+					'default'           => '',
+				),
 				'per_page' => array(
 					'type'              => 'integer',
 					'sanitize_callback' => 'absint',
 					'default'           => 20,
 				),
+				'page'     => array(
+					'type'              => 'integer',
+					'sanitize_callback' => 'absint',
+					'default'           => 1,
+				),
 			),
 		) );
 	}
@@ -320,6 +330,7 @@ class ATB_Site_API {
 			'post_type'      => 'page',
 			'post_status'    => array( 'publish', 'draft', 'private' ),
 			'posts_per_page' => $per_page,
+			'paged'          => max( 1, (int) $request->get_param( 'page' ) ),
 			'orderby'        => 'title',
 			'order'          => 'ASC',
 		);
@@ -328,6 +339,18 @@ class ATB_Site_API {
 			$args['s'] = $search;
 		}
 
+		// slug matches post_name; a slash-separated value is a full page path
+		// such as "services/web-design".
+		$slug = trim( (string) $request->get_param( 'slug' ), '/' );
+		if ( '' !== $slug ) {
+			if ( false !== strpos( $slug, '/' ) ) {
+				$found            = get_page_by_path( $slug, OBJECT, 'page' );
+				$args['post__in'] = $found ? array( $found->ID ) : array( 0 );
+			} else {
+				$args['name'] = sanitize_title( $slug );
+			}
+		}
+
 		$query = new WP_Query( $args );
 		$pages = array();
 
@@ -341,10 +364,15 @@ class ATB_Site_API {
 				'slug'     => $post->post_name,
 				'status'   => $post->post_status,
 				'modified' => $post->post_modified,
+				'path'     => get_page_uri( $post ),
+				'link'     => get_permalink( $post ),
 			);
 		}
 
-		return new WP_REST_Response( $pages, 200 );
+		$response = new WP_REST_Response( $pages, 200 );
+		$response->header( 'X-WP-Total', (int) $query->found_posts );
+		$response->header( 'X-WP-TotalPages', (int) $query->max_num_pages );
+		return $response;
 	}
 
 	private static function get_info_data(): array {
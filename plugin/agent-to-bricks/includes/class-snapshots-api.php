@@ -31,6 +31,20 @@ class ATB_Snapshots_API {
 			),
 		) );
 
+		// GET + DELETE /pages/{id}/snapshots/{snapshot_id}
+		register_rest_route( 'agent-bricks/v1', '/pages/(?P<id>\d+)/snapshots/(?P<snapshot_id>[a-zA-Z0-9_-]+)', array(
+			array(
+				'methods'             => 'GET',
+				'callback'            => array( __CLASS__, 'get_snapshot' ),
+				'permission_callback' => array( __CLASS__, 'check_permission' ),
+			),
+			array(
+				'methods'             => 'DELETE',
+				'callback'            => array( __CLASS__, 'delete_snapshot' ),
+				'permission_callback' => array( __CLASS__, 'check_permission' ),
+			),
+		) );
+
 		// POST /pages/{id}/snapshots/{snapshot_id}/rollback
 		register_rest_route( 'agent-bricks/v1', '/pages/(?P<id>\d+)/snapshots/(?P<snapshot_id>[a-zA-Z0-9_-]+)/rollback', array(
 			array(
@@ -72,6 +86,7 @@ class ATB_Snapshots_API {
 				'contentHash' => $s['contentHash'],
 				'elementCount'=> $s['elementCount'],
 				'timestamp'   => $s['timestamp'],
+				'timestampGmt'=> $s['timestampGmt'] ?? '',
 				'label'       => $s['label'] ?? '',
 			);
 		}, $snapshots );
@@ -79,6 +94,61 @@ class ATB_Snapshots_API {
 		return new WP_REST_Response( array( 'snapshots' => $listing ), 200 );
 	}
 
+	/**
+	 * Get a single snapshot including its elements.
+	 */
+	public static function get_snapshot( $request ) {
+		$post_id     = (int) $request->get_param( 'id' );
+		$snapshot_id = sanitize_text_field( $request->get_param( 'snapshot_id' ) );
+
+		if ( ! get_post( $post_id ) ) {
+			return new WP_REST_Response( array( 'error' => 'Post not found.' ), 404 );
+		}
+
+		$target = self::find_snapshot( $post_id, $snapshot_id );
+		if ( ! $target ) {
+			return new WP_REST_Response( array( 'error' => 'Snapshot not found.' ), 404 );
+		}
+
+		return new WP_REST_Response( array(
+			'snapshotId'   => $target['snapshotId'],
+			'contentHash'  => $target['contentHash'],
+			'elementCount' => $target['elementCount'],
+			'timestamp'    => $target['timestamp'],
+			'timestampGmt' => $target['timestampGmt'] ?? '',
+			'label'        => $target['label'] ?? '',
+			'elements'     => $target['elements'],
+		), 200 );
+	}
+
+	/**
+	 * Delete a single snapshot.
+	 */
+	public static function delete_snapshot( $request ) {
+		$post_id     = (int) $request->get_param( 'id' );
+		$snapshot_id = sanitize_text_field( $request->get_param( 'snapshot_id' ) );
+
+		if ( ! get_post( $post_id ) ) {
+			return new WP_REST_Response( array( 'error' => 'Post not found.' ), 404 );
+		}
+
+		$snapshots = self::get_snapshots( $post_id );
+		$remaining = array_values( array_filter( $snapshots, function( $s ) use ( $snapshot_id ) {
+			return $s['snapshotId'] !== $snapshot_id;
+		} ) );
+
+		if ( count( $remaining ) === count( $snapshots ) ) {
+			return new WP_REST_Response( array( 'error' => 'Snapshot not found.' ), 404 );
+		}
+
+		update_post_meta( $post_id, self::META_KEY, $remaining );
+
+		return new WP_REST_Response( array(
+			'deleted'   => $snapshot_id,
+			'remaining' => count( $remaining ),
+		), 200 );
+	}
+
 	/**
 	 * Create a snapshot of the current page content.
 	 */
@@ -113,15 +183,7 @@ class ATB_Snapshots_API {
 			return new WP_REST_Response( array( 'error' => 'Post not found.' ), 404 );
 		}
 
-		$snapshots = self::get_snapshots( $post_id );
-		$target    = null;
-
-		foreach ( $snapshots as $s ) {
-			if ( $s['snapshotId'] === $snapshot_id ) {
-				$target = $s;
-				break;
-			}
-		}
+		$target = self::find_snapshot( $post_id, $snapshot_id );
 
 		if ( ! $target ) {
 			return new WP_REST_Response( array( 'error' => 'Snapshot not found.' ), 404 );
@@ -144,6 +206,7 @@ class ATB_Snapshots_API {
 		$data = ATB_Bricks_Lifecycle::read_elements( $post_id );
 
 		return new WP_REST_Response( array(
+			'success'      => true,
 			'contentHash'  => $data['contentHash'],
 			'count'        => count( $data['elements'] ),
 			'restoredFrom' => $snapshot_id,
@@ -162,6 +225,7 @@ class ATB_Snapshots_API {
 			'elementCount' => count( $data['elements'] ),
 			'elements'     => $data['elements'],
 			'timestamp'    => current_time( 'mysql' ),
+			'timestampGmt' => current_time( 'mysql', true ),
 			'label'        => $label,
 		);
 
@@ -178,6 +242,18 @@ class ATB_Snapshots_API {
 		return $snapshot;
 	}
 
+	/**
+	 * Find a snapshot by ID. Returns null if it does not exist.
+	 */
+	private static function find_snapshot( $post_id, $snapshot_id ) {
+		foreach ( self::get_snapshots( $post_id ) as $s ) {
+			if ( $s['snapshotId'] === $snapshot_id ) {
+				return $s;
+			}
+		}
+		return null;
+	}
+
 	/**
 	 * Get all snapshots for a page.
 	 */
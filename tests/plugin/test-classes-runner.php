@@ -137,5 +137,19 @@ if (isset($dup1['data']['id'])) {
     dispatch_rest('DELETE', "/agent-bricks/v1/classes/{$dup1['data']['id']}", ['id' => $dup1['data']['id']]);
 }
 
+// ===== Test 7: Conditional GET with the classes hash =====
+echo "TEST 7: If-None-Match revalidation... ";
+$first = dispatch_rest('GET', '/agent-bricks/v1/classes');
+$hash = $first['data']['hash'] ?? '';
+$same = dispatch_rest('GET', '/agent-bricks/v1/classes', [], ['if_none_match' => '"' . $hash . '"']);
+$other = dispatch_rest('GET', '/agent-bricks/v1/classes', [], ['if_none_match' => '"stale"']);
+if ($hash !== '' && $same['status'] === 304 && $other['status'] === 200 && ($other['data']['hash'] ?? '') === $hash) {
+    echo "PASS (hash=$hash)\n";
+    $pass++;
+} else {
+    echo "FAIL (hash=$hash, matching={$same['status']}, stale={$other['status']})\n";
+    $fail++;
+}
+
 echo "\nResults: $pass passed, $fail failed\n";
 exit($fail > 0 ? 1 : 0);
@@ -232,5 +232,80 @@ if ($batch_r['status'] === 200 && isset($batch_r['data']['contentHash'])) {
     $fail++;
 }
 
+// ===== Test 9: Batch insert + move =====
+echo "TEST 9: Batch insert and move... ";
+$get9 = dispatch_rest('GET', "/agent-bricks/v1/pages/$test_page/elements", ['id' => $test_page]);
+$batch9 = dispatch_rest('POST', "/agent-bricks/v1/pages/$test_page/elements/batch",
+    ['id' => $test_page],
+    ['if_match' => $get9['data']['contentHash']],
+    ['operations' => [
+        ['op' => 'insert', 'index' => 0, 'elements' => [['id' => 'bsec01', 'name' => 'section', 'children' => [], 'settings' => []]]],
+        ['op' => 'append', 'parentId' => 'bsec01', 'elements' => [['id' => 'bhead1', 'name' => 'heading', 'children' => [], 'settings' => ['text' => 'A']]]],
+        ['op' => 'insert', 'parentId' => 'bsec01', 'index' => 0, 'elements' => [['id' => 'bhead2', 'name' => 'heading', 'children' => [], 'settings' => ['text' => 'B']]]],
+        ['op' => 'move', 'id' => 'bhead1', 'parentId' => 'bsec01', 'index' => 0],
+    ]]
+);
+$get9b = dispatch_rest('GET', "/agent-bricks/v1/pages/$test_page/elements", ['id' => $test_page]);
+$bsec = null;
+foreach ($get9b['data']['elements'] as $el) {
+    if ($el['id'] === 'bsec01') $bsec = $el;
+}
+if ($batch9['status'] === 200 && $bsec && $bsec['children'] === ['bhead1', 'bhead2']
+    && $get9b['data']['elements'][0]['id'] === 'bsec01') {
+    echo "PASS\n";
+    $pass++;
+} else {
+    echo "FAIL (status={$batch9['status']})\n";
+    echo json_encode($batch9['data']) . "\n";
+    $fail++;
+}
+if ($batch9['status'] === 200) {
+    dispatch_rest('DELETE', "/agent-bricks/v1/pages/$test_page/elements",
+        ['id' => $test_page],
+        ['if_match' => $get9b['data']['contentHash']],
+        ['ids' => ['bsec01', 'bhead1', 'bhead2']]
+    );
+}
+
+// ===== Test 10: Batch error reports the failing op =====
+echo "TEST 10: Batch failedOp... ";
+$get10 = dispatch_rest('GET', "/agent-bricks/v1/pages/$test_page/elements", ['id' => $test_page]);
+$batch10 = dispatch_rest('POST', "/agent-bricks/v1/pages/$test_page/elements/batch",
+    ['id' => $test_page],
+    ['if_match' => $get10['data']['contentHash']],
+    ['operations' => [
+        ['op' => 'patch', 'patches' => []],
+        ['op' => 'move', 'id' => 'does_not_exist'],
+    ]]
+);
+if ($batch10['status'] === 404 && ($batch10['data']['failedOp'] ?? null) === 1) {
+    echo "PASS\n";
+    $pass++;
+} else {
+    echo "FAIL (status={$batch10['status']})\n";
+    echo json_encode($batch10['data']) . "\n";
+    $fail++;
+}
+
+// ===== Test 11: Batch rejects duplicate element IDs =====
+echo "TEST 11: Batch duplicate ID... ";
+$get11 = dispatch_rest('GET', "/agent-bricks/v1/pages/$test_page/elements", ['id' => $test_page]);
+$dup_id = $get11['data']['elements'][0]['id'] ?? '';
+$batch11 = dispatch_rest('POST', "/agent-bricks/v1/pages/$test_page/elements/batch",
+    ['id' => $test_page],
+    ['if_match' => $get11['data']['contentHash']],
+    ['operations' => [
+        ['op' => 'append', 'elements' => [['id' => $dup_id, 'name' => 'heading', 'parent' => 0, 'children' => [], 'settings' => []]]],
+    ]]
+);
+if ($batch11['status'] === 400 && ($batch11['data']['failedOp'] ?? null) === 0) {
+    echo "PASS\n";
+    $pass++;
+} else {
+    echo "FAIL (status={$batch11['status']})\n";
+    echo json_encode($batch11['data']) . "\n";
+    $fail++;
+}
+
 echo "\nResults: $pass passed, $fail failed\n";
 exit($fail > 0 ? 1 : 0);
@@ -17,7 +17,7 @@ function dispatch_rest($method, $route, $params = [], $headers = [], $body = nul
         $request->set_header('content_type', 'application/json');
     }
     $response = $server->dispatch($request);
-    return ['status' => $response->get_status(), 'data' => $response->get_data()];
+    return ['status' => $response->get_status(), 'data' => $response->get_data(), 'headers' => $response->get_headers()];
 }
 
 $pass = 0;
@@ -152,5 +152,33 @@ if (
     $fail++;
 }
 
+// ===== Test 10: GET /pages by slug with pagination headers =====
+echo "TEST 10: GET pages by slug... ";
+$atb_page_slug = 'atb-pages-test-' . wp_generate_password(6, false, false);
+$atb_page_id = wp_insert_post([
+    'post_type'   => 'page',
+    'post_status' => 'draft',
+    'post_title'  => 'ATB Pages Test',
+    'post_name'   => $atb_page_slug,
+]);
+$r = dispatch_rest('GET', '/agent-bricks/v1/pages', ['slug' => $atb_page_slug, 'per_page' => 5, 'page' => 1]);
+$total = $r['headers']['X-WP-Total'] ?? null;
+if (
+    $r['status'] === 200
+    && is_array($r['data'])
+    && count($r['data']) === 1
+    && $r['data'][0]['id'] === $atb_page_id
+    && isset($r['data'][0]['path'], $r['data'][0]['link'])
+    && (int) $total === 1
+) {
+    echo "PASS (id={$atb_page_id}, path={$r['data'][0]['path']})\n";
+    $pass++;
+} else {
+    echo "FAIL (status={$r['status']}, total=" . var_export($total, true) . ")\n";
+    echo json_encode($r['data']) . "\n";
+    $fail++;
+}
+wp_delete_post($atb_page_id, true);
+
 echo "\nResults: $pass passed, $fail failed\n";
 exit($fail > 0 ? 1 : 0);
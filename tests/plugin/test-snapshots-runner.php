@@ -55,6 +55,38 @@ if ($list_r['status'] === 200 && isset($list_r['data']['snapshots']) && count($l
     $fail++;
 }
 
+// ===== Test 2b: Get single snapshot with elements =====
+echo "TEST 2b: GET snapshot by id... ";
+if ($snapshot_id) {
+    $one_r = dispatch_rest('GET', "/agent-bricks/v1/pages/$test_page/snapshots/$snapshot_id",
+        ['id' => $test_page, 'snapshot_id' => $snapshot_id]
+    );
+    if ($one_r['status'] === 200 && ($one_r['data']['contentHash'] ?? '') === $hash_before
+        && count($one_r['data']['elements'] ?? []) === count($elements_before)) {
+        echo "PASS\n";
+        $pass++;
+    } else {
+        echo "FAIL (status={$one_r['status']})\n";
+        echo json_encode($one_r['data']) . "\n";
+        $fail++;
+    }
+} else {
+    echo "SKIP (no snapshot)\n";
+}
+
+// ===== Test 2c: Unknown snapshot =====
+echo "TEST 2c: GET unknown snapshot returns 404... ";
+$missing_r = dispatch_rest('GET', "/agent-bricks/v1/pages/$test_page/snapshots/snap_missing",
+    ['id' => $test_page, 'snapshot_id' => 'snap_missing']
+);
+if ($missing_r['status'] === 404) {
+    echo "PASS\n";
+    $pass++;
+} else {
+    echo "FAIL (status={$missing_r['status']})\n";
+    $fail++;
+}
+
 // ===== Test 3: Modify page, then rollback =====
 echo "TEST 3: Modify + rollback... ";
 if ($snapshot_id) {
@@ -144,6 +176,28 @@ if ($snap_count <= 10) {
     $fail++;
 }
 
+// ===== Test 6: Delete a snapshot =====
+echo "TEST 6: DELETE snapshot... ";
+$victim = $list_r3['data']['snapshots'][0]['snapshotId'] ?? null;
+if ($victim) {
+    $del_r = dispatch_rest('DELETE', "/agent-bricks/v1/pages/$test_page/snapshots/$victim",
+        ['id' => $test_page, 'snapshot_id' => $victim]
+    );
+    $again_r = dispatch_rest('DELETE', "/agent-bricks/v1/pages/$test_page/snapshots/$victim",
+        ['id' => $test_page, 'snapshot_id' => $victim]
+    );
+    if ($del_r['status'] === 200 && ($del_r['data']['remaining'] ?? -1) === $snap_count - 1 && $again_r['status'] === 404) {
+        echo "PASS (remaining={$del_r['data']['remaining']})\n";
+        $pass++;
+    } else {
+        echo "FAIL (status={$del_r['status']}, repeat={$again_r['status']})\n";
+        echo json_encode($del_r['data']) . "\n";
+        $fail++;
+    }
+} else {
+    echo "SKIP (no snapshots)\n";
+}
+
 // ===== Cleanup: delete all test snapshots =====
 delete_post_meta($test_page, '_agent_bricks_snapshots');
 
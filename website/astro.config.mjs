@@ -86,6 +86,7 @@ export default defineConfig({
           collapsed: true,
           items: [
             'cli/site-commands',
+            'cli/pages-commands',
             'cli/convert-commands',
             'cli/search-commands',
             'cli/template-commands',